@@ -0,0 +1,46 @@
+package dispatcher
+
+import (
+	"context"
+	"testing"
+
+	"github.com/v2fly/v2ray-core/v5/common/log"
+	"github.com/v2fly/v2ray-core/v5/common/log/logtest"
+	"github.com/v2fly/v2ray-core/v5/common/session"
+	"github.com/v2fly/v2ray-core/v5/transport"
+)
+
+type transportHandler struct {
+	tag       string
+	transport string
+}
+
+func (h *transportHandler) Start() error                                       { return nil }
+func (h *transportHandler) Close() error                                       { return nil }
+func (h *transportHandler) Tag() string                                        { return h.tag }
+func (h *transportHandler) Dispatch(ctx context.Context, link *transport.Link) {}
+func (h *transportHandler) Transport() string                                  { return h.transport }
+
+func TestRecordAccessTransport(t *testing.T) {
+	recorder := logtest.Capture(t)
+
+	ctx := session.ContextWithInbound(context.Background(), &session.Inbound{Tag: "in", Transport: "websocket"})
+	ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{
+		From:   "127.0.0.1:1234",
+		To:     "tcp:example.com:443",
+		Status: log.AccessAccepted,
+	})
+	recordAccess(ctx, &transportHandler{tag: "out", transport: "grpc"})
+
+	messages := recorder.Messages()
+	if len(messages) != 1 {
+		t.Fatal("expected 1 access record, but actually ", messages)
+	}
+	access, ok := messages[0].(*log.AccessMessage)
+	if !ok {
+		t.Fatalf("expected an access record, but actually %T", messages[0])
+	}
+	if access.Detour != "out" || access.InboundTag != "in" || access.InboundTransport != "websocket" || access.OutboundTransport != "grpc" {
+		t.Errorf("unexpected access record: %+v", access)
+	}
+}
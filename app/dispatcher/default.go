@@ -373,7 +373,7 @@ func (d *DefaultDispatcher) routedDispatch(ctx context.Context, link *transport.
 	if forcedOutboundTag := session.GetForcedOutboundTagFromContext(ctx); forcedOutboundTag != "" {
 		ctx = session.SetForcedOutboundTagToContext(ctx, "")
 		if h := d.ohm.GetHandler(forcedOutboundTag); h != nil {
-			newError("taking platform initialized detour [", forcedOutboundTag, "] for [", destination, "]").WriteToLog(session.ExportIDToError(ctx))
+			newError("taking platform initialized detour [", forcedOutboundTag, "] for [", destination, "]").WithCategory("routing").WriteToLog(session.ExportIDToError(ctx))
 			handler = h
 		} else {
 			newError("non existing tag for platform initialized detour: ", forcedOutboundTag).AtError().WriteToLog(session.ExportIDToError(ctx))
@@ -385,13 +385,13 @@ func (d *DefaultDispatcher) routedDispatch(ctx context.Context, link *transport.
 		if route, err := d.router.PickRoute(routing_session.AsRoutingContext(ctx)); err == nil {
 			tag := route.GetOutboundTag()
 			if h := d.ohm.GetHandler(tag); h != nil {
-				newError("taking detour [", tag, "] for [", destination, "]").WriteToLog(session.ExportIDToError(ctx))
+				newError("taking detour [", tag, "] for [", destination, "]").WithCategory("routing").WriteToLog(session.ExportIDToError(ctx))
 				handler = h
 			} else {
 				newError("non existing tag: ", tag).AtWarning().WriteToLog(session.ExportIDToError(ctx))
 			}
 		} else {
-			newError("default route for ", destination).AtWarning().WriteToLog(session.ExportIDToError(ctx))
+			newError("default route for ", destination).WithCategory("routing").AtWarning().WriteToLog(session.ExportIDToError(ctx))
 		}
 	}
 
@@ -406,12 +406,32 @@ func (d *DefaultDispatcher) routedDispatch(ctx context.Context, link *transport.
 		return
 	}
 
-	if accessMessage := log.AccessMessageFromContext(ctx); accessMessage != nil {
-		if tag := handler.Tag(); tag != "" {
-			accessMessage.Detour = tag
-		}
-		log.Record(accessMessage)
-	}
+	recordAccess(ctx, handler)
 
 	handler.Dispatch(ctx, link)
 }
+
+// recordAccess completes the access record of the connection with the
+// outbound handler picked for it, and logs it.
+func recordAccess(ctx context.Context, handler outbound.Handler) {
+	accessMessage := log.AccessMessageFromContext(ctx)
+	if accessMessage == nil {
+		return
+	}
+	if tag := handler.Tag(); tag != "" {
+		accessMessage.Detour = tag
+	}
+	if inbound := session.InboundFromContext(ctx); inbound != nil {
+		accessMessage.InboundTag = inbound.Tag
+		accessMessage.InboundTransport = inbound.Transport
+	}
+	accessMessage.SessionID = uint32(session.IDFromContext(ctx))
+	if trace := session.TraceFromContext(ctx); trace != nil {
+		accessMessage.TraceID = trace.TraceID
+		accessMessage.SpanID = trace.SpanID
+	}
+	if transportHandler, ok := handler.(outbound.TransportHandler); ok {
+		accessMessage.OutboundTransport = transportHandler.Transport()
+	}
+	log.Record(accessMessage)
+}
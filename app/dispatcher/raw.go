@@ -9,7 +9,6 @@ import (
 	E "github.com/sagernet/sing/common/exceptions"
 	"github.com/v2fly/v2ray-core/v5/common"
 	"github.com/v2fly/v2ray-core/v5/common/buf"
-	"github.com/v2fly/v2ray-core/v5/common/log"
 	"github.com/v2fly/v2ray-core/v5/common/net"
 	"github.com/v2fly/v2ray-core/v5/common/session"
 	"github.com/v2fly/v2ray-core/v5/features/outbound"
@@ -113,7 +112,7 @@ func (d *DefaultDispatcher) routedDispatchConn0(ctx context.Context, conn net.Co
 	if forcedOutboundTag := session.GetForcedOutboundTagFromContext(ctx); forcedOutboundTag != "" {
 		ctx = session.SetForcedOutboundTagToContext(ctx, "")
 		if h := d.ohm.GetHandler(forcedOutboundTag); h != nil {
-			newError("taking platform initialized detour [", forcedOutboundTag, "] for [", destination, "]").WriteToLog(session.ExportIDToError(ctx))
+			newError("taking platform initialized detour [", forcedOutboundTag, "] for [", destination, "]").WithCategory("routing").WriteToLog(session.ExportIDToError(ctx))
 			handler = h
 		} else {
 			newError("non existing tag for platform initialized detour: ", forcedOutboundTag).AtError().WriteToLog(session.ExportIDToError(ctx))
@@ -124,13 +123,13 @@ func (d *DefaultDispatcher) routedDispatchConn0(ctx context.Context, conn net.Co
 		if route, err := d.router.PickRoute(routing_session.AsRoutingContext(ctx)); err == nil {
 			tag := route.GetOutboundTag()
 			if h := d.ohm.GetHandler(tag); h != nil {
-				newError("taking detour [", tag, "] for [", destination, "]").WriteToLog(session.ExportIDToError(ctx))
+				newError("taking detour [", tag, "] for [", destination, "]").WithCategory("routing").WriteToLog(session.ExportIDToError(ctx))
 				handler = h
 			} else {
 				newError("non existing tag: ", tag).AtWarning().WriteToLog(session.ExportIDToError(ctx))
 			}
 		} else {
-			newError("default route for ", destination).AtWarning().WriteToLog(session.ExportIDToError(ctx))
+			newError("default route for ", destination).WithCategory("routing").AtWarning().WriteToLog(session.ExportIDToError(ctx))
 		}
 	}
 
@@ -144,12 +143,7 @@ func (d *DefaultDispatcher) routedDispatchConn0(ctx context.Context, conn net.Co
 		return
 	}
 
-	if accessMessage := log.AccessMessageFromContext(ctx); accessMessage != nil {
-		if tag := handler.Tag(); tag != "" {
-			accessMessage.Detour = tag
-		}
-		log.Record(accessMessage)
-	}
+	recordAccess(ctx, handler)
 
 	if connHandler, ok := handler.(outbound.ConnHandler); ok && connHandler.IsConnDispatcher() {
 		connHandler.DispatchConn(ctx, conn)
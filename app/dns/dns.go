@@ -14,6 +14,7 @@ import (
 	"github.com/v2fly/v2ray-core/v5/common"
 	"github.com/v2fly/v2ray-core/v5/common/buf"
 	"github.com/v2fly/v2ray-core/v5/common/errors"
+	"github.com/v2fly/v2ray-core/v5/common/log"
 	"github.com/v2fly/v2ray-core/v5/common/net"
 	"github.com/v2fly/v2ray-core/v5/common/session"
 	"github.com/v2fly/v2ray-core/v5/common/strmatcher"
@@ -74,6 +75,7 @@ type queryCallback struct {
 	parseIPs bool
 	domain   string
 	strategy dns.QueryStrategy
+	start    time.Time
 
 	wg *sync.WaitGroup
 
@@ -106,6 +108,32 @@ type ipCacheEntire struct {
 	expire4, expire6 time.Time
 }
 
+// queryTypeName returns the record types queried for strategy, as logged.
+func queryTypeName(strategy dns.QueryStrategy) string {
+	switch strategy {
+	case dns.QueryStrategy_USE_IP4:
+		return "A"
+	case dns.QueryStrategy_USE_IP6:
+		return "AAAA"
+	default:
+		return "A,AAAA"
+	}
+}
+
+// recordDNS records msg for the connection of ctx, if any, so it is found
+// with the other messages of the connection.
+func recordDNS(ctx context.Context, msg *log.DNSMessage) {
+	msg.SessionID = uint32(session.IDFromContext(ctx))
+	if inbound := session.InboundFromContext(ctx); inbound != nil {
+		msg.InboundTag = inbound.Tag
+	}
+	if trace := session.TraceFromContext(ctx); trace != nil {
+		msg.TraceID = trace.TraceID
+		msg.SpanID = trace.SpanID
+	}
+	log.Record(msg)
+}
+
 func (c *Client) nextRequestId() uint16 {
 	requestId := atomic.AddInt32(&c.requestId, 1)
 	if requestId > 65535 {
@@ -127,6 +155,7 @@ func (c *Client) Lookup(ctx context.Context, domain string, strategy dns.QuerySt
 
 	var ips []net.IP
 	var cached4, cached6 bool
+	var expire time.Time
 	now := time.Now()
 
 	cacheI, cachedHit := c.cache.Load(domain)
@@ -137,20 +166,20 @@ func (c *Client) Lookup(ctx context.Context, domain string, strategy dns.QuerySt
 			if cache.cached4 && (c.disableExpire || now.Before(cache.expire4)) {
 				ips = append(ips, cache.cache4...)
 				cached4 = true
+				expire = cache.expire4
 			}
 		}
 		if strategy != dns.QueryStrategy_USE_IP4 {
 			if cache.cached6 && (c.disableExpire || now.Before(cache.expire6)) {
 				ips = append(ips, cache.cache6...)
 				cached6 = true
+				if expire.IsZero() || cache.expire6.Before(expire) {
+					expire = cache.expire6
+				}
 			}
 		}
 	}
 
-	if len(ips) > 0 {
-		newError("dns cache HIT ", domain, " -> ", ips).AtDebug().WriteToLog()
-	}
-
 	var query bool
 	switch strategy {
 	case dns.QueryStrategy_USE_IP4:
@@ -161,6 +190,21 @@ func (c *Client) Lookup(ctx context.Context, domain string, strategy dns.QuerySt
 		query = !cached4 || !cached6
 	}
 
+	// A partial hit is logged as the query of the missing records only.
+	if len(ips) > 0 && !query {
+		remaining := ttl
+		if !c.disableExpire && expire.After(now) {
+			remaining = uint32(expire.Sub(now) / time.Second)
+		}
+		recordDNS(ctx, &log.DNSMessage{
+			Domain:    domain,
+			QueryType: queryTypeName(strategy),
+			IPs:       ips,
+			Source:    log.DNSSourceCache,
+			TTL:       remaining,
+		})
+	}
+
 	newStrategy := strategy
 	if query {
 		if cached4 {
@@ -257,6 +301,7 @@ func (c *Client) lookup(ctx context.Context, domain string, strategy dns.QuerySt
 		parseIPs: true,
 		domain:   domain,
 		strategy: strategy,
+		start:    time.Now(),
 	}
 
 	q.wg.Add(len(servers))
@@ -345,7 +390,15 @@ func (c *Client) lookup(ctx context.Context, domain string, strategy dns.QuerySt
 						r.errors = append(r.errors, err)
 						return
 					}
-					newError(server.name, " got answer: ", r.domain, " -> ", ips).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+					recordDNS(ctx, &log.DNSMessage{
+						Server:    server.name,
+						Domain:    r.domain,
+						QueryType: queryTypeName(strategy),
+						IPs:       ips,
+						Source:    log.DNSSourceUpstream,
+						TTL:       r.ttl,
+						Latency:   time.Since(q.start),
+					})
 					r.ips = matched
 					q.response = r
 					q.cancel()
@@ -852,7 +905,15 @@ func (c *Client) writeBack(server *Server, message *dnsmessage.Message) {
 			return
 		}
 
-		newError(server.name, " got answer: ", d.domain, " -> ", queryType, " ", d.ips).AtDebug().WriteToLog(session.ExportIDToError(d.ctx))
+		recordDNS(d.ctx, &log.DNSMessage{
+			Server:    server.name,
+			Domain:    d.domain,
+			QueryType: queryTypeName(d.strategy),
+			IPs:       d.ips,
+			Source:    log.DNSSourceUpstream,
+			TTL:       d.ttl,
+			Latency:   time.Since(d.start),
+		})
 
 		d.queryCallback.response = d
 		d.queryCallback.cancel()
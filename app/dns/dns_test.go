@@ -0,0 +1,157 @@
+package dns
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/buf"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+	"github.com/v2fly/v2ray-core/v5/common/net"
+	"github.com/v2fly/v2ray-core/v5/common/strmatcher"
+	"github.com/v2fly/v2ray-core/v5/features/dns"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+type staticTransport struct {
+	ttl uint32
+}
+
+func (t *staticTransport) Type() dns.TransportType {
+	return dns.TransportTypeExchange
+}
+
+func (t *staticTransport) Write(context.Context, *dnsmessage.Message) error {
+	return common.ErrNoClue
+}
+
+func (t *staticTransport) Exchange(ctx context.Context, message *dnsmessage.Message) (*dnsmessage.Message, error) {
+	response := &dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: message.ID, Response: true},
+		Questions: message.Questions,
+	}
+	question := message.Questions[0]
+	if question.Type == dnsmessage.TypeA {
+		response.Answers = append(response.Answers, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: t.ttl},
+			Body:   &dnsmessage.AResource{A: [4]byte{1, 2, 3, 4}},
+		})
+	}
+	return response, nil
+}
+
+func (t *staticTransport) ExchangeRaw(context.Context, *buf.Buffer) (*buf.Buffer, error) {
+	return nil, common.ErrNoClue
+}
+
+func (t *staticTransport) Lookup(context.Context, string, dns.QueryStrategy) ([]net.IP, error) {
+	return nil, common.ErrNoClue
+}
+
+func (t *staticTransport) Close() error {
+	return nil
+}
+
+var _ dns.Transport = (*staticTransport)(nil)
+
+type dnsRecorder struct {
+	sync.Mutex
+	messages []*log.DNSMessage
+}
+
+func (r *dnsRecorder) Handle(msg log.Message) {
+	if msg, ok := msg.(*log.DNSMessage); ok {
+		r.Lock()
+		r.messages = append(r.messages, msg)
+		r.Unlock()
+	}
+}
+
+func TestLookupRecordsCacheHit(t *testing.T) {
+	recorder := &dnsRecorder{}
+	if previous := log.ReplaceHandler(recorder); previous != nil {
+		defer log.RegisterHandler(previous)
+	}
+
+	matcher := strmatcher.NewMixedIndexMatcher()
+	common.Must(matcher.Build())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &Client{
+		ctx:           ctx,
+		cancel:        cancel,
+		domainMatcher: matcher,
+		servers: []*Server{{
+			name:      "static",
+			transport: &staticTransport{ttl: 300},
+		}},
+	}
+
+	for i := 0; i < 2; i++ {
+		ips, _, err := client.Lookup(context.Background(), "example.com", dns.QueryStrategy_USE_IP4)
+		common.Must(err)
+		if len(ips) != 1 || !ips[0].Equal(net.IP{1, 2, 3, 4}) {
+			t.Fatal("unexpected answer: ", ips)
+		}
+	}
+
+	recorder.Lock()
+	defer recorder.Unlock()
+	if len(recorder.messages) != 2 {
+		t.Fatal("expected 2 dns records, but got ", len(recorder.messages))
+	}
+	if first := recorder.messages[0]; first.Source != log.DNSSourceUpstream || first.Server != "static" || first.TTL != 300 {
+		t.Error("unexpected upstream record: ", first)
+	}
+	second := recorder.messages[1]
+	if second.Source != log.DNSSourceCache {
+		t.Error("expected cache hit, but got ", second.Source)
+	}
+	if second.TTL == 0 || second.TTL > 300 {
+		t.Error("unexpected remaining ttl: ", second.TTL)
+	}
+}
+
+func TestLookupPartialCacheHit(t *testing.T) {
+	recorder := &dnsRecorder{}
+	if previous := log.ReplaceHandler(recorder); previous != nil {
+		defer log.RegisterHandler(previous)
+	}
+
+	matcher := strmatcher.NewMixedIndexMatcher()
+	common.Must(matcher.Build())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &Client{
+		ctx:           ctx,
+		cancel:        cancel,
+		domainMatcher: matcher,
+		servers: []*Server{{
+			name:      "static",
+			transport: &staticTransport{ttl: 300},
+		}},
+	}
+
+	// Caches the A records only.
+	_, _, err := client.Lookup(context.Background(), "example.com", dns.QueryStrategy_USE_IP4)
+	common.Must(err)
+	recorder.Lock()
+	recorder.messages = nil
+	recorder.Unlock()
+
+	// The server has no AAAA records, whether the lookup then fails is not
+	// the matter here.
+	client.Lookup(context.Background(), "example.com", dns.QueryStrategy_USE_IP)
+
+	recorder.Lock()
+	defer recorder.Unlock()
+	if len(recorder.messages) != 1 {
+		t.Fatal("expected 1 dns record, but got ", len(recorder.messages))
+	}
+	if record := recorder.messages[0]; record.Source != log.DNSSourceUpstream || record.QueryType != "AAAA" {
+		t.Error("expected the upstream query of the missing AAAA records only, but got ", record)
+	}
+}
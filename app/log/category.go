@@ -0,0 +1,117 @@
+package log
+
+import (
+	"path"
+	"strings"
+
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+)
+
+// origin is implemented by the errors of common/errors, telling where a
+// record comes from.
+type origin interface {
+	Package() string
+	Category() string
+}
+
+type categoryFilter struct {
+	pattern string
+	// recursive is set for patterns ending in /*, which match the packages
+	// below the rest of the pattern.
+	recursive bool
+	deny      bool
+	level     log.Severity
+}
+
+// compileCategoryFilters returns the filters of specs, nil if there are none.
+func compileCategoryFilters(specs []*CategoryFilter) ([]categoryFilter, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	filters := make([]categoryFilter, 0, len(specs))
+	for _, spec := range specs {
+		if _, err := path.Match(spec.Pattern, ""); err != nil {
+			return nil, newError("invalid category pattern ", spec.Pattern).Base(err)
+		}
+		filter := categoryFilter{pattern: spec.Pattern, deny: spec.Deny, level: spec.Level}
+		if strings.HasSuffix(spec.Pattern, "/*") {
+			filter.pattern = strings.TrimSuffix(spec.Pattern, "/*")
+			filter.recursive = true
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+// matches returns true if the filter applies to records of severity from pkg
+// or tagged with category.
+func (f *categoryFilter) matches(severity log.Severity, pkg, category string) bool {
+	if f.level != log.Severity_Unknown && severity < f.level {
+		return false
+	}
+	return f.match(pkg) || (category != "" && f.match(category))
+}
+
+func (f *categoryFilter) match(name string) bool {
+	if !f.recursive {
+		matched, _ := path.Match(f.pattern, name)
+		return matched
+	}
+	// Match the leading elements of name against the pattern, there must be
+	// more of them.
+	n := strings.Count(f.pattern, "/") + 1
+	elements := strings.SplitN(name, "/", n+1)
+	if len(elements) <= n {
+		return false
+	}
+	matched, _ := path.Match(f.pattern, strings.Join(elements[:n], "/"))
+	return matched
+}
+
+// categoryHandler drops the general messages its filters deny before they
+// are formatted.
+type categoryHandler struct {
+	handler log.Handler
+	filters []categoryFilter
+}
+
+// newCategoryHandler returns handler filtering messages with filters, or
+// handler as is if there are none.
+func newCategoryHandler(handler log.Handler, filters []categoryFilter) log.Handler {
+	if handler == nil || len(filters) == 0 {
+		return handler
+	}
+	return &categoryHandler{handler: handler, filters: filters}
+}
+
+func (h *categoryHandler) Handle(msg log.Message) {
+	if general, ok := unwrapMessage(msg).(*log.GeneralMessage); ok && !h.keep(general) {
+		return
+	}
+	h.handler.Handle(msg)
+}
+
+// keep returns true if msg is to be written, as decided by the first filter
+// matching it, or by none.
+func (h *categoryHandler) keep(msg *log.GeneralMessage) bool {
+	var pkg, category string
+	if o, ok := msg.Content.(origin); ok {
+		pkg, category = o.Package(), o.Category()
+	}
+	for i := range h.filters {
+		if h.filters[i].matches(msg.Severity, pkg, category) {
+			return !h.filters[i].deny
+		}
+	}
+	return true
+}
+
+func (h *categoryHandler) Close() error {
+	return common.Close(h.handler)
+}
+
+// Rotate implements log.Rotator.
+func (h *categoryHandler) Rotate() error {
+	return log.Rotate(h.handler)
+}
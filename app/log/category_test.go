@@ -0,0 +1,67 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	verrors "github.com/v2fly/v2ray-core/v5/common/errors"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+)
+
+type testOrigin struct {
+	pkg      string
+	category string
+}
+
+func (o testOrigin) Package() string  { return o.pkg }
+func (o testOrigin) Category() string { return o.category }
+func (o testOrigin) String() string   { return o.pkg }
+
+func TestCategoryFilters(t *testing.T) {
+	filters, err := compileCategoryFilters([]*CategoryFilter{
+		{Pattern: "proxy/vmess"},
+		{Pattern: "transport/internet/*", Deny: true, Level: log.Severity_Info},
+		{Pattern: "routing", Deny: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	collected := &collectingHandler{}
+	handler := newCategoryHandler(collected, filters)
+
+	for _, msg := range []*log.GeneralMessage{
+		{Severity: log.Severity_Info, Content: testOrigin{pkg: "proxy/vmess"}},
+		{Severity: log.Severity_Warning, Content: testOrigin{pkg: "transport/internet/tcp"}},
+		{Severity: log.Severity_Info, Content: testOrigin{pkg: "transport/internet/tcp"}},
+		{Severity: log.Severity_Debug, Content: testOrigin{pkg: "transport/internet/grpc/encoding"}},
+		{Severity: log.Severity_Info, Content: testOrigin{pkg: "transport/internet"}},
+		{Severity: log.Severity_Info, Content: testOrigin{pkg: "app/dispatcher", category: "routing"}},
+		{Severity: log.Severity_Info, Content: "plain"},
+	} {
+		handler.Handle(&labeledMessage{Message: msg})
+	}
+	handler.Handle(&log.AccessMessage{From: "127.0.0.1:1001", To: "tcp:example.com:443", Status: log.AccessAccepted})
+
+	expected := []string{
+		"[Info] proxy/vmess",
+		"[Warning] transport/internet/tcp",
+		"[Info] transport/internet",
+		"[Info] plain",
+		"127.0.0.1:1001 accepted tcp:example.com:443",
+	}
+	if r := cmp.Diff([]string(*collected), expected); r != "" {
+		t.Error(r)
+	}
+
+	if _, err := compileCategoryFilters([]*CategoryFilter{{Pattern: "["}}); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+	if h := newCategoryHandler(collected, nil); h != log.Handler(collected) {
+		t.Error("expected no filtering without filters")
+	}
+
+	tagged := verrors.New("taking detour").WithCategory("routing")
+	if f := filters[2]; !f.matches(log.Severity_Info, tagged.Package(), tagged.Category()) {
+		t.Error("expected the category of errors to be matched")
+	}
+}
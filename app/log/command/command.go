@@ -4,6 +4,7 @@ package command
 
 import (
 	"context"
+	"time"
 
 	core "github.com/v2fly/v2ray-core/v5"
 	"github.com/v2fly/v2ray-core/v5/app/log"
@@ -32,6 +33,53 @@ func (s *LoggerServer) RestartLogger(ctx context.Context, request *RestartLogger
 	return &RestartLoggerResponse{}, nil
 }
 
+// RotateLogs implements LoggerService.
+func (s *LoggerServer) RotateLogs(ctx context.Context, request *RotateLogsRequest) (*RotateLogsResponse, error) {
+	logger, ok := s.V.GetFeature((*log.Instance)(nil)).(*log.Instance)
+	if !ok {
+		return nil, newError("unable to get logger instance")
+	}
+	if err := logger.Rotate(); err != nil {
+		return nil, newError("failed to rotate logs").Base(err)
+	}
+	return &RotateLogsResponse{}, nil
+}
+
+// SetLogLevel implements LoggerService.
+func (s *LoggerServer) SetLogLevel(ctx context.Context, request *SetLogLevelRequest) (*SetLogLevelResponse, error) {
+	logger, ok := s.V.GetFeature((*log.Instance)(nil)).(*log.Instance)
+	if !ok {
+		return nil, newError("unable to get logger instance")
+	}
+	previous, err := logger.SetLevel(request.Channel, request.Level)
+	if err != nil {
+		return nil, newError("failed to set log level").Base(err)
+	}
+	return &SetLogLevelResponse{Previous: previous}, nil
+}
+
+// EnableDebug implements LoggerService.
+func (s *LoggerServer) EnableDebug(ctx context.Context, request *EnableDebugRequest) (*EnableDebugResponse, error) {
+	logger, ok := s.V.GetFeature((*log.Instance)(nil)).(*log.Instance)
+	if !ok {
+		return nil, newError("unable to get logger instance")
+	}
+	if request.SessionId == 0 && request.InboundTag == "" {
+		return nil, newError("either session id or inbound tag is required")
+	}
+	if request.Ttl == 0 {
+		return nil, newError("ttl is required")
+	}
+	ttl := time.Duration(request.Ttl) * time.Second
+	if request.SessionId != 0 {
+		logger.EnableSessionDebug(request.SessionId, ttl)
+	}
+	if request.InboundTag != "" {
+		logger.EnableInboundDebug(request.InboundTag, ttl)
+	}
+	return &EnableDebugResponse{}, nil
+}
+
 // FollowLog implements LoggerService.
 func (s *LoggerServer) FollowLog(_ *FollowLogRequest, stream LoggerService_FollowLogServer) error {
 	logger := s.V.GetFeature((*log.Instance)(nil))
@@ -2,7 +2,11 @@ package command_test
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	core "github.com/v2fly/v2ray-core/v5"
 	"github.com/v2fly/v2ray-core/v5/app/dispatcher"
@@ -12,6 +16,7 @@ import (
 	_ "github.com/v2fly/v2ray-core/v5/app/proxyman/inbound"
 	_ "github.com/v2fly/v2ray-core/v5/app/proxyman/outbound"
 	"github.com/v2fly/v2ray-core/v5/common"
+	clog "github.com/v2fly/v2ray-core/v5/common/log"
 	"github.com/v2fly/v2ray-core/v5/common/serial"
 	"google.golang.org/protobuf/types/known/anypb"
 )
@@ -33,3 +38,128 @@ func TestLoggerRestart(t *testing.T) {
 	}
 	common.Must2(server.RestartLogger(context.Background(), &RestartLoggerRequest{}))
 }
+
+func TestLoggerRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "error.log")
+	v, err := core.New(&core.Config{
+		App: []*anypb.Any{
+			serial.ToTypedMessage(&log.Config{
+				Error: &log.LogSpecification{Type: log.LogType_File, Level: clog.Severity_Info, Path: path},
+			}),
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.InboundConfig{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+		},
+	})
+	common.Must(err)
+	common.Must(v.Start())
+	defer v.Close()
+
+	waitContains := func(path, text string) {
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			content, _ := os.ReadFile(path)
+			if strings.Contains(string(content), text) {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("expected ", path, " to contain ", text, ", but actually: ", string(content))
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	clog.Record(&clog.GeneralMessage{Severity: clog.Severity_Info, Content: "before rotation"})
+	waitContains(path, "before rotation")
+	common.Must(os.Rename(path, path+".1"))
+
+	server := &LoggerServer{
+		V: v,
+	}
+	common.Must2(server.RotateLogs(context.Background(), &RotateLogsRequest{}))
+
+	clog.Record(&clog.GeneralMessage{Severity: clog.Severity_Info, Content: "after rotation"})
+	waitContains(path, "after rotation")
+	rotated, err := os.ReadFile(path + ".1")
+	common.Must(err)
+	if strings.Contains(string(rotated), "after rotation") {
+		t.Error("rotated file still written: ", string(rotated))
+	}
+}
+
+func TestLoggerSetLogLevel(t *testing.T) {
+	v, err := core.New(&core.Config{
+		App: []*anypb.Any{
+			serial.ToTypedMessage(&log.Config{
+				Error: &log.LogSpecification{Type: log.LogType_None, Level: clog.Severity_Warning},
+			}),
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.InboundConfig{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+		},
+	})
+	common.Must(err)
+	common.Must(v.Start())
+	defer v.Close()
+
+	server := &LoggerServer{
+		V: v,
+	}
+	response, err := server.SetLogLevel(context.Background(), &SetLogLevelRequest{Channel: "error", Level: clog.Severity_Debug})
+	common.Must(err)
+	if response.Previous != clog.Severity_Warning {
+		t.Error("expected previous level Warning, but actually ", response.Previous)
+	}
+	if _, err := server.SetLogLevel(context.Background(), &SetLogLevelRequest{Channel: "dns", Level: clog.Severity_Debug}); err == nil {
+		t.Error("expected an unknown channel to be rejected")
+	}
+}
+
+func TestLoggerEnableDebug(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "error.log")
+	v, err := core.New(&core.Config{
+		App: []*anypb.Any{
+			serial.ToTypedMessage(&log.Config{
+				Error: &log.LogSpecification{Type: log.LogType_File, Level: clog.Severity_Warning, Path: path},
+			}),
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.InboundConfig{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+		},
+	})
+	common.Must(err)
+	common.Must(v.Start())
+	defer v.Close()
+
+	server := &LoggerServer{
+		V: v,
+	}
+	if _, err := server.EnableDebug(context.Background(), &EnableDebugRequest{Ttl: 60}); err == nil {
+		t.Error("expected a request without session id or inbound tag to be rejected")
+	}
+	common.Must2(server.EnableDebug(context.Background(), &EnableDebugRequest{SessionId: 7, Ttl: 60}))
+	common.Must2(server.EnableDebug(context.Background(), &EnableDebugRequest{InboundTag: "socks", Ttl: 60}))
+
+	clog.Record(&clog.GeneralMessage{Severity: clog.Severity_Debug, Content: "other session", SessionID: 8})
+	clog.Record(&clog.GeneralMessage{Severity: clog.Severity_Debug, Content: "debugged session", SessionID: 7})
+	clog.Record(&clog.GeneralMessage{Severity: clog.Severity_Debug, Content: "debugged inbound", InboundTag: "socks"})
+	clog.Record(&clog.GeneralMessage{Severity: clog.Severity_Warning, Content: "last"})
+
+	deadline := time.Now().Add(5 * time.Second)
+	var content []byte
+	for {
+		content, _ = os.ReadFile(path)
+		if strings.Contains(string(content), "last") || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for _, text := range []string{"debugged session", "debugged inbound"} {
+		if !strings.Contains(string(content), text) {
+			t.Error("expected ", text, " in the error log, but actually: ", string(content))
+		}
+	}
+	if strings.Contains(string(content), "other session") {
+		t.Error("debug record of another session logged: ", string(content))
+	}
+}
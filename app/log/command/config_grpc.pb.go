@@ -23,8 +23,12 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type LoggerServiceClient interface {
 	RestartLogger(ctx context.Context, in *RestartLoggerRequest, opts ...grpc.CallOption) (*RestartLoggerResponse, error)
+	// Reopen the log files, after an external tool moved them away.
+	RotateLogs(ctx context.Context, in *RotateLogsRequest, opts ...grpc.CallOption) (*RotateLogsResponse, error)
+	SetLogLevel(ctx context.Context, in *SetLogLevelRequest, opts ...grpc.CallOption) (*SetLogLevelResponse, error)
 	//Unstable interface
 	FollowLog(ctx context.Context, in *FollowLogRequest, opts ...grpc.CallOption) (LoggerService_FollowLogClient, error)
+	EnableDebug(ctx context.Context, in *EnableDebugRequest, opts ...grpc.CallOption) (*EnableDebugResponse, error)
 }
 
 type loggerServiceClient struct {
@@ -44,6 +48,24 @@ func (c *loggerServiceClient) RestartLogger(ctx context.Context, in *RestartLogg
 	return out, nil
 }
 
+func (c *loggerServiceClient) RotateLogs(ctx context.Context, in *RotateLogsRequest, opts ...grpc.CallOption) (*RotateLogsResponse, error) {
+	out := new(RotateLogsResponse)
+	err := c.cc.Invoke(ctx, "/v2ray.core.app.log.command.LoggerService/RotateLogs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *loggerServiceClient) SetLogLevel(ctx context.Context, in *SetLogLevelRequest, opts ...grpc.CallOption) (*SetLogLevelResponse, error) {
+	out := new(SetLogLevelResponse)
+	err := c.cc.Invoke(ctx, "/v2ray.core.app.log.command.LoggerService/SetLogLevel", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *loggerServiceClient) FollowLog(ctx context.Context, in *FollowLogRequest, opts ...grpc.CallOption) (LoggerService_FollowLogClient, error) {
 	stream, err := c.cc.NewStream(ctx, &LoggerService_ServiceDesc.Streams[0], "/v2ray.core.app.log.command.LoggerService/FollowLog", opts...)
 	if err != nil {
@@ -76,13 +98,26 @@ func (x *loggerServiceFollowLogClient) Recv() (*FollowLogResponse, error) {
 	return m, nil
 }
 
+func (c *loggerServiceClient) EnableDebug(ctx context.Context, in *EnableDebugRequest, opts ...grpc.CallOption) (*EnableDebugResponse, error) {
+	out := new(EnableDebugResponse)
+	err := c.cc.Invoke(ctx, "/v2ray.core.app.log.command.LoggerService/EnableDebug", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // LoggerServiceServer is the server API for LoggerService service.
 // All implementations must embed UnimplementedLoggerServiceServer
 // for forward compatibility
 type LoggerServiceServer interface {
 	RestartLogger(context.Context, *RestartLoggerRequest) (*RestartLoggerResponse, error)
+	// Reopen the log files, after an external tool moved them away.
+	RotateLogs(context.Context, *RotateLogsRequest) (*RotateLogsResponse, error)
+	SetLogLevel(context.Context, *SetLogLevelRequest) (*SetLogLevelResponse, error)
 	//Unstable interface
 	FollowLog(*FollowLogRequest, LoggerService_FollowLogServer) error
+	EnableDebug(context.Context, *EnableDebugRequest) (*EnableDebugResponse, error)
 	mustEmbedUnimplementedLoggerServiceServer()
 }
 
@@ -93,9 +128,18 @@ type UnimplementedLoggerServiceServer struct {
 func (UnimplementedLoggerServiceServer) RestartLogger(context.Context, *RestartLoggerRequest) (*RestartLoggerResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RestartLogger not implemented")
 }
+func (UnimplementedLoggerServiceServer) RotateLogs(context.Context, *RotateLogsRequest) (*RotateLogsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RotateLogs not implemented")
+}
+func (UnimplementedLoggerServiceServer) SetLogLevel(context.Context, *SetLogLevelRequest) (*SetLogLevelResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLogLevel not implemented")
+}
 func (UnimplementedLoggerServiceServer) FollowLog(*FollowLogRequest, LoggerService_FollowLogServer) error {
 	return status.Errorf(codes.Unimplemented, "method FollowLog not implemented")
 }
+func (UnimplementedLoggerServiceServer) EnableDebug(context.Context, *EnableDebugRequest) (*EnableDebugResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EnableDebug not implemented")
+}
 func (UnimplementedLoggerServiceServer) mustEmbedUnimplementedLoggerServiceServer() {}
 
 // UnsafeLoggerServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -127,6 +171,42 @@ func _LoggerService_RestartLogger_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _LoggerService_RotateLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RotateLogsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LoggerServiceServer).RotateLogs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v2ray.core.app.log.command.LoggerService/RotateLogs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LoggerServiceServer).RotateLogs(ctx, req.(*RotateLogsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LoggerService_SetLogLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLogLevelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LoggerServiceServer).SetLogLevel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v2ray.core.app.log.command.LoggerService/SetLogLevel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LoggerServiceServer).SetLogLevel(ctx, req.(*SetLogLevelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _LoggerService_FollowLog_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(FollowLogRequest)
 	if err := stream.RecvMsg(m); err != nil {
@@ -148,6 +228,24 @@ func (x *loggerServiceFollowLogServer) Send(m *FollowLogResponse) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _LoggerService_EnableDebug_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EnableDebugRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LoggerServiceServer).EnableDebug(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v2ray.core.app.log.command.LoggerService/EnableDebug",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LoggerServiceServer).EnableDebug(ctx, req.(*EnableDebugRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // LoggerService_ServiceDesc is the grpc.ServiceDesc for LoggerService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -159,6 +257,18 @@ var LoggerService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "RestartLogger",
 			Handler:    _LoggerService_RestartLogger_Handler,
 		},
+		{
+			MethodName: "RotateLogs",
+			Handler:    _LoggerService_RotateLogs_Handler,
+		},
+		{
+			MethodName: "SetLogLevel",
+			Handler:    _LoggerService_SetLogLevel_Handler,
+		},
+		{
+			MethodName: "EnableDebug",
+			Handler:    _LoggerService_EnableDebug_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -29,6 +29,17 @@ const (
 	LogType_Console LogType = 1
 	LogType_File    LogType = 2
 	LogType_Event   LogType = 3
+	// Structured access records in an SQLite database at path. Only available
+	// in builds with the sqlite tag.
+	LogType_SQLite LogType = 4
+	// Access record counters in Prometheus textfile collector format at path,
+	// rewritten every textfile_interval.
+	LogType_PromTextfile LogType = 5
+	// Records POSTed in JSON arrays to http_endpoint. The format is always JSON.
+	LogType_HTTP LogType = 6
+	// Records sent to a syslog daemon at path, a URI such as udp://host:514,
+	// tcp://host:601 or unix:///dev/log, the latter if empty.
+	LogType_Syslog LogType = 7
 )
 
 // Enum value maps for LogType.
@@ -38,12 +49,20 @@ var (
 		1: "Console",
 		2: "File",
 		3: "Event",
+		4: "SQLite",
+		5: "PromTextfile",
+		6: "HTTP",
+		7: "Syslog",
 	}
 	LogType_value = map[string]int32{
-		"None":    0,
-		"Console": 1,
-		"File":    2,
-		"Event":   3,
+		"None":         0,
+		"Console":      1,
+		"File":         2,
+		"Event":        3,
+		"SQLite":       4,
+		"PromTextfile": 5,
+		"HTTP":         6,
+		"Syslog":       7,
 	}
 )
 
@@ -74,33 +93,1006 @@ func (LogType) EnumDescriptor() ([]byte, []int) {
 	return file_app_log_config_proto_rawDescGZIP(), []int{0}
 }
 
+type LogFormat int32
+
+const (
+	LogFormat_Plain  LogFormat = 0
+	LogFormat_Logfmt LogFormat = 1
+	// Newline-delimited JSON objects carrying a schema_version field.
+	LogFormat_JSON LogFormat = 2
+)
+
+// Enum value maps for LogFormat.
+var (
+	LogFormat_name = map[int32]string{
+		0: "Plain",
+		1: "Logfmt",
+		2: "JSON",
+	}
+	LogFormat_value = map[string]int32{
+		"Plain":  0,
+		"Logfmt": 1,
+		"JSON":   2,
+	}
+)
+
+func (x LogFormat) Enum() *LogFormat {
+	p := new(LogFormat)
+	*p = x
+	return p
+}
+
+func (x LogFormat) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (LogFormat) Descriptor() protoreflect.EnumDescriptor {
+	return file_app_log_config_proto_enumTypes[1].Descriptor()
+}
+
+func (LogFormat) Type() protoreflect.EnumType {
+	return &file_app_log_config_proto_enumTypes[1]
+}
+
+func (x LogFormat) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use LogFormat.Descriptor instead.
+func (LogFormat) EnumDescriptor() ([]byte, []int) {
+	return file_app_log_config_proto_rawDescGZIP(), []int{1}
+}
+
+type TimestampPrecision int32
+
+const (
+	TimestampPrecision_Seconds      TimestampPrecision = 0
+	TimestampPrecision_Milliseconds TimestampPrecision = 1
+	TimestampPrecision_Microseconds TimestampPrecision = 2
+	TimestampPrecision_Nanoseconds  TimestampPrecision = 3
+)
+
+// Enum value maps for TimestampPrecision.
+var (
+	TimestampPrecision_name = map[int32]string{
+		0: "Seconds",
+		1: "Milliseconds",
+		2: "Microseconds",
+		3: "Nanoseconds",
+	}
+	TimestampPrecision_value = map[string]int32{
+		"Seconds":      0,
+		"Milliseconds": 1,
+		"Microseconds": 2,
+		"Nanoseconds":  3,
+	}
+)
+
+func (x TimestampPrecision) Enum() *TimestampPrecision {
+	p := new(TimestampPrecision)
+	*p = x
+	return p
+}
+
+func (x TimestampPrecision) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TimestampPrecision) Descriptor() protoreflect.EnumDescriptor {
+	return file_app_log_config_proto_enumTypes[2].Descriptor()
+}
+
+func (TimestampPrecision) Type() protoreflect.EnumType {
+	return &file_app_log_config_proto_enumTypes[2]
+}
+
+func (x TimestampPrecision) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TimestampPrecision.Descriptor instead.
+func (TimestampPrecision) EnumDescriptor() ([]byte, []int) {
+	return file_app_log_config_proto_rawDescGZIP(), []int{2}
+}
+
+// What to do with logs written to a named pipe while no reader is attached.
+type FifoPolicy int32
+
+const (
+	FifoPolicy_FifoDrop   FifoPolicy = 0
+	FifoPolicy_FifoBuffer FifoPolicy = 1
+)
+
+// Enum value maps for FifoPolicy.
+var (
+	FifoPolicy_name = map[int32]string{
+		0: "FifoDrop",
+		1: "FifoBuffer",
+	}
+	FifoPolicy_value = map[string]int32{
+		"FifoDrop":   0,
+		"FifoBuffer": 1,
+	}
+)
+
+func (x FifoPolicy) Enum() *FifoPolicy {
+	p := new(FifoPolicy)
+	*p = x
+	return p
+}
+
+func (x FifoPolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FifoPolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_app_log_config_proto_enumTypes[3].Descriptor()
+}
+
+func (FifoPolicy) Type() protoreflect.EnumType {
+	return &file_app_log_config_proto_enumTypes[3]
+}
+
+func (x FifoPolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FifoPolicy.Descriptor instead.
+func (FifoPolicy) EnumDescriptor() ([]byte, []int) {
+	return file_app_log_config_proto_rawDescGZIP(), []int{3}
+}
+
+// How to render bytes of log content that are not valid UTF-8.
+type InvalidUtf8Policy int32
+
+const (
+	// Substitute the Unicode replacement character.
+	InvalidUtf8Policy_Utf8Replace InvalidUtf8Policy = 0
+	// Write the byte as \xNN.
+	InvalidUtf8Policy_Utf8Escape InvalidUtf8Policy = 1
+	InvalidUtf8Policy_Utf8Drop   InvalidUtf8Policy = 2
+)
+
+// Enum value maps for InvalidUtf8Policy.
+var (
+	InvalidUtf8Policy_name = map[int32]string{
+		0: "Utf8Replace",
+		1: "Utf8Escape",
+		2: "Utf8Drop",
+	}
+	InvalidUtf8Policy_value = map[string]int32{
+		"Utf8Replace": 0,
+		"Utf8Escape":  1,
+		"Utf8Drop":    2,
+	}
+)
+
+func (x InvalidUtf8Policy) Enum() *InvalidUtf8Policy {
+	p := new(InvalidUtf8Policy)
+	*p = x
+	return p
+}
+
+func (x InvalidUtf8Policy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (InvalidUtf8Policy) Descriptor() protoreflect.EnumDescriptor {
+	return file_app_log_config_proto_enumTypes[4].Descriptor()
+}
+
+func (InvalidUtf8Policy) Type() protoreflect.EnumType {
+	return &file_app_log_config_proto_enumTypes[4]
+}
+
+func (x InvalidUtf8Policy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use InvalidUtf8Policy.Descriptor instead.
+func (InvalidUtf8Policy) EnumDescriptor() ([]byte, []int) {
+	return file_app_log_config_proto_rawDescGZIP(), []int{4}
+}
+
+// How the Logfmt and JSON formats render fields with several values, such as
+// the IPs of a DNS answer.
+type RepeatedFieldMode int32
+
+const (
+	// A single string of the values separated by commas.
+	RepeatedFieldMode_RepeatedJoined RepeatedFieldMode = 0
+	// A JSON array of the values. Logfmt has no arrays and joins them.
+	RepeatedFieldMode_RepeatedArray RepeatedFieldMode = 1
+	// A record per value, each with the value in a field of the singular name,
+	// like ip for ips.
+	RepeatedFieldMode_RepeatedLines RepeatedFieldMode = 2
+)
+
+// Enum value maps for RepeatedFieldMode.
+var (
+	RepeatedFieldMode_name = map[int32]string{
+		0: "RepeatedJoined",
+		1: "RepeatedArray",
+		2: "RepeatedLines",
+	}
+	RepeatedFieldMode_value = map[string]int32{
+		"RepeatedJoined": 0,
+		"RepeatedArray":  1,
+		"RepeatedLines":  2,
+	}
+)
+
+func (x RepeatedFieldMode) Enum() *RepeatedFieldMode {
+	p := new(RepeatedFieldMode)
+	*p = x
+	return p
+}
+
+func (x RepeatedFieldMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RepeatedFieldMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_app_log_config_proto_enumTypes[5].Descriptor()
+}
+
+func (RepeatedFieldMode) Type() protoreflect.EnumType {
+	return &file_app_log_config_proto_enumTypes[5]
+}
+
+func (x RepeatedFieldMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RepeatedFieldMode.Descriptor instead.
+func (RepeatedFieldMode) EnumDescriptor() ([]byte, []int) {
+	return file_app_log_config_proto_rawDescGZIP(), []int{5}
+}
+
+// Where the value of the hostname label attached to records comes from.
+type HostnameSource int32
+
+const (
+	HostnameSource_NoHostname HostnameSource = 0
+	HostnameSource_OSHostname HostnameSource = 1
+	// The hostname field of Config.
+	HostnameSource_StaticHostname HostnameSource = 2
+	// The environment variable named by the hostname field of Config.
+	HostnameSource_EnvHostname HostnameSource = 3
+)
+
+// Enum value maps for HostnameSource.
+var (
+	HostnameSource_name = map[int32]string{
+		0: "NoHostname",
+		1: "OSHostname",
+		2: "StaticHostname",
+		3: "EnvHostname",
+	}
+	HostnameSource_value = map[string]int32{
+		"NoHostname":     0,
+		"OSHostname":     1,
+		"StaticHostname": 2,
+		"EnvHostname":    3,
+	}
+)
+
+func (x HostnameSource) Enum() *HostnameSource {
+	p := new(HostnameSource)
+	*p = x
+	return p
+}
+
+func (x HostnameSource) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (HostnameSource) Descriptor() protoreflect.EnumDescriptor {
+	return file_app_log_config_proto_enumTypes[6].Descriptor()
+}
+
+func (HostnameSource) Type() protoreflect.EnumType {
+	return &file_app_log_config_proto_enumTypes[6]
+}
+
+func (x HostnameSource) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use HostnameSource.Descriptor instead.
+func (HostnameSource) EnumDescriptor() ([]byte, []int) {
+	return file_app_log_config_proto_rawDescGZIP(), []int{6}
+}
+
+// What a File output does once a write fails as the disk is full.
+type DiskFullPolicy int32
+
+const (
+	// Drop records while the disk is full, retrying every second, and report
+	// how many were dropped once writes succeed again.
+	DiskFullPolicy_DiskFullDrop DiskFullPolicy = 0
+	// Stop writing to the file, after telling so once on stderr.
+	DiskFullPolicy_DiskFullStop DiskFullPolicy = 1
+	// Write the remaining records to the console instead.
+	DiskFullPolicy_DiskFullConsole DiskFullPolicy = 2
+)
+
+// Enum value maps for DiskFullPolicy.
+var (
+	DiskFullPolicy_name = map[int32]string{
+		0: "DiskFullDrop",
+		1: "DiskFullStop",
+		2: "DiskFullConsole",
+	}
+	DiskFullPolicy_value = map[string]int32{
+		"DiskFullDrop":    0,
+		"DiskFullStop":    1,
+		"DiskFullConsole": 2,
+	}
+)
+
+func (x DiskFullPolicy) Enum() *DiskFullPolicy {
+	p := new(DiskFullPolicy)
+	*p = x
+	return p
+}
+
+func (x DiskFullPolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DiskFullPolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_app_log_config_proto_enumTypes[7].Descriptor()
+}
+
+func (DiskFullPolicy) Type() protoreflect.EnumType {
+	return &file_app_log_config_proto_enumTypes[7]
+}
+
+func (x DiskFullPolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DiskFullPolicy.Descriptor instead.
+func (DiskFullPolicy) EnumDescriptor() ([]byte, []int) {
+	return file_app_log_config_proto_rawDescGZIP(), []int{7}
+}
+
+// Where the key of a tamper evident log comes from.
+type TamperEvident struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// File holding the key, surrounding whitespace is ignored.
+	KeyFile string `protobuf:"bytes,1,opt,name=key_file,json=keyFile,proto3" json:"key_file,omitempty"`
+	// Environment variable holding the key, if key_file is empty.
+	KeyEnv string `protobuf:"bytes,2,opt,name=key_env,json=keyEnv,proto3" json:"key_env,omitempty"`
+}
+
+func (x *TamperEvident) Reset() {
+	*x = TamperEvident{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_app_log_config_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TamperEvident) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TamperEvident) ProtoMessage() {}
+
+func (x *TamperEvident) ProtoReflect() protoreflect.Message {
+	mi := &file_app_log_config_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TamperEvident.ProtoReflect.Descriptor instead.
+func (*TamperEvident) Descriptor() ([]byte, []int) {
+	return file_app_log_config_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *TamperEvident) GetKeyFile() string {
+	if x != nil {
+		return x.KeyFile
+	}
+	return ""
+}
+
+func (x *TamperEvident) GetKeyEnv() string {
+	if x != nil {
+		return x.KeyEnv
+	}
+	return ""
+}
+
 type LogSpecification struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Type  LogType      `protobuf:"varint,1,opt,name=type,proto3,enum=v2ray.core.app.log.LogType" json:"type,omitempty"`
-	Level log.Severity `protobuf:"varint,2,opt,name=level,proto3,enum=v2ray.core.common.log.Severity" json:"level,omitempty"`
-	Path  string       `protobuf:"bytes,3,opt,name=path,proto3" json:"path,omitempty"`
+	Type  LogType      `protobuf:"varint,1,opt,name=type,proto3,enum=v2ray.core.app.log.LogType" json:"type,omitempty"`
+	Level log.Severity `protobuf:"varint,2,opt,name=level,proto3,enum=v2ray.core.common.log.Severity" json:"level,omitempty"`
+	// For File, {inbound} and {outbound} in the path are replaced by the tags of
+	// each access record, writing a file per tag.
+	Path               string             `protobuf:"bytes,3,opt,name=path,proto3" json:"path,omitempty"`
+	Format             LogFormat          `protobuf:"varint,4,opt,name=format,proto3,enum=v2ray.core.app.log.LogFormat" json:"format,omitempty"`
+	TimestampPrecision TimestampPrecision `protobuf:"varint,5,opt,name=timestamp_precision,json=timestampPrecision,proto3,enum=v2ray.core.app.log.TimestampPrecision" json:"timestamp_precision,omitempty"`
+	// Maximum number of records kept by the SQLite sink, 0 for unlimited.
+	MaxRecords  uint32            `protobuf:"varint,6,opt,name=max_records,json=maxRecords,proto3" json:"max_records,omitempty"`
+	FifoPolicy  FifoPolicy        `protobuf:"varint,7,opt,name=fifo_policy,json=fifoPolicy,proto3,enum=v2ray.core.app.log.FifoPolicy" json:"fifo_policy,omitempty"`
+	InvalidUtf8 InvalidUtf8Policy `protobuf:"varint,8,opt,name=invalid_utf8,json=invalidUtf8,proto3,enum=v2ray.core.app.log.InvalidUtf8Policy" json:"invalid_utf8,omitempty"`
+	// Seconds between writes of the PromTextfile sink, 0 for the default of 15.
+	TextfileInterval uint32 `protobuf:"varint,9,opt,name=textfile_interval,json=textfileInterval,proto3" json:"textfile_interval,omitempty"`
+	// Milliseconds between flushes of output buffered by the FifoBuffer policy,
+	// 0 for the default of 1000.
+	FlushInterval uint32 `protobuf:"varint,10,opt,name=flush_interval,json=flushInterval,proto3" json:"flush_interval,omitempty"`
+	// More outputs of the channel, each with its own type, path and format.
+	// Their level is ignored, the channel's level applies.
+	Outputs []*LogSpecification `protobuf:"bytes,11,rep,name=outputs,proto3" json:"outputs,omitempty"`
+	// Access records of connections through inbounds or outbounds with these
+	// tags are not logged.
+	ExcludeTags []string `protobuf:"bytes,12,rep,name=exclude_tags,json=excludeTags,proto3" json:"exclude_tags,omitempty"`
+	// For Console and File, records are written in batches of up to this many,
+	// 0 or 1 to write each on its own.
+	BatchSize uint32 `protobuf:"varint,13,opt,name=batch_size,json=batchSize,proto3" json:"batch_size,omitempty"`
+	// Milliseconds a partial batch waits to be written, 0 for the default of
+	// 1000.
+	BatchInterval  uint32            `protobuf:"varint,14,opt,name=batch_interval,json=batchInterval,proto3" json:"batch_interval,omitempty"`
+	RepeatedFields RepeatedFieldMode `protobuf:"varint,15,opt,name=repeated_fields,json=repeatedFields,proto3,enum=v2ray.core.app.log.RepeatedFieldMode" json:"repeated_fields,omitempty"`
+	// For HTTP, the URL records are posted to and the headers sent with them,
+	// such as Authorization.
+	HttpEndpoint string            `protobuf:"bytes,16,opt,name=http_endpoint,json=httpEndpoint,proto3" json:"http_endpoint,omitempty"`
+	HttpHeaders  map[string]string `protobuf:"bytes,17,rep,name=http_headers,json=httpHeaders,proto3" json:"http_headers,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// For HTTP, records per request, 0 for the default of 64. Partial batches
+	// are posted after batch_interval.
+	HttpBatchSize uint32 `protobuf:"varint,18,opt,name=http_batch_size,json=httpBatchSize,proto3" json:"http_batch_size,omitempty"`
+	// For Console and File, end every line with an HMAC-SHA256 chained to the
+	// one of the line before, so altering, removing or reordering lines is
+	// detected. Verify a log with VerifyChain of common/log.
+	TamperEvident *TamperEvident `protobuf:"bytes,19,opt,name=tamper_evident,json=tamperEvident,proto3" json:"tamper_evident,omitempty"`
+	// Records of the severity named by a key, error, warning, info or debug, are
+	// written to the outputs of its route instead of those of the channel. The
+	// channel's level still applies, the level and severity_routes of routes
+	// are ignored.
+	SeverityRoutes map[string]*LogSpecification `protobuf:"bytes,20,rep,name=severity_routes,json=severityRoutes,proto3" json:"severity_routes,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// File: rename the file to a backup with a timestamp and start a new one
+	// when it would grow beyond max_size_mb. Keep max_backups backups, or all
+	// for 0, none older than max_age_days, or any age for 0, and gzip them if
+	// compress is set. 0 for no rotation.
+	MaxSizeMb  uint32 `protobuf:"varint,21,opt,name=max_size_mb,json=maxSizeMb,proto3" json:"max_size_mb,omitempty"`
+	MaxBackups uint32 `protobuf:"varint,22,opt,name=max_backups,json=maxBackups,proto3" json:"max_backups,omitempty"`
+	MaxAgeDays uint32 `protobuf:"varint,23,opt,name=max_age_days,json=maxAgeDays,proto3" json:"max_age_days,omitempty"`
+	Compress   bool   `protobuf:"varint,24,opt,name=compress,proto3" json:"compress,omitempty"`
+	// For migrating log pipelines, mark the channel or one of its outputs as
+	// the legacy side, written alongside the others until drop_legacy is set
+	// on the channel.
+	Legacy     bool `protobuf:"varint,25,opt,name=legacy,proto3" json:"legacy,omitempty"`
+	DropLegacy bool `protobuf:"varint,26,opt,name=drop_legacy,json=dropLegacy,proto3" json:"drop_legacy,omitempty"`
+	// For Syslog, the facility, such as daemon or local0, daemon if empty, and
+	// the tag records are sent with, v2ray if empty.
+	SyslogFacility string `protobuf:"bytes,27,opt,name=syslog_facility,json=syslogFacility,proto3" json:"syslog_facility,omitempty"`
+	SyslogTag      string `protobuf:"bytes,28,opt,name=syslog_tag,json=syslogTag,proto3" json:"syslog_tag,omitempty"`
+	// For File, seconds to keep retrying to open a file that cannot be opened
+	// at start, such as on a network mount not ready yet, instead of failing.
+	// Records are kept in memory meanwhile, and written once it opens.
+	OpenRetryTimeout uint32 `protobuf:"varint,29,opt,name=open_retry_timeout,json=openRetryTimeout,proto3" json:"open_retry_timeout,omitempty"`
+	// For the JSON format, write each record indented over several lines, for
+	// reading on a console. Sinks expecting a record per line break on them.
+	IndentJson bool `protobuf:"varint,30,opt,name=indent_json,json=indentJson,proto3" json:"indent_json,omitempty"`
+	// Sample records of the channel with the same text: within each window of
+	// sample_window seconds, 0 for 1, write the first sample_initial of them,
+	// then every sample_thereafter-th, or none for 0. 0 sample_initial writes
+	// all records.
+	SampleInitial    uint32 `protobuf:"varint,31,opt,name=sample_initial,json=sampleInitial,proto3" json:"sample_initial,omitempty"`
+	SampleThereafter uint32 `protobuf:"varint,32,opt,name=sample_thereafter,json=sampleThereafter,proto3" json:"sample_thereafter,omitempty"`
+	SampleWindow     uint32 `protobuf:"varint,33,opt,name=sample_window,json=sampleWindow,proto3" json:"sample_window,omitempty"`
+	// Mask sensitive data, such as credentials, in the text of records before
+	// they are written. Outputs storing fields, like SQLite, are not masked.
+	Masking *Masking `protobuf:"bytes,34,opt,name=masking,proto3" json:"masking,omitempty"`
+	// Layout of the timestamps of records, a Go reference layout such as
+	// "2006-01-02 15:04:05.000", or rfc3339, unix or unixnano. Empty for the
+	// default of the format. Layouts other than rfc3339 ignore
+	// timestamp_precision.
+	TimeFormat string `protobuf:"bytes,35,opt,name=time_format,json=timeFormat,proto3" json:"time_format,omitempty"`
+	// IANA name of the time zone of timestamps, such as UTC or Europe/Berlin,
+	// empty for the local time zone.
+	Timezone string `protobuf:"bytes,36,opt,name=timezone,proto3" json:"timezone,omitempty"`
+	// For File, what to do once a write fails as the disk is full.
+	DiskFull DiskFullPolicy `protobuf:"varint,37,opt,name=disk_full,json=diskFull,proto3,enum=v2ray.core.app.log.DiskFullPolicy" json:"disk_full,omitempty"`
+	// Records of the error log are checked against the filters in order, the
+	// first matching one keeps or drops them. Records matching none are kept.
+	CategoryFilters []*CategoryFilter `protobuf:"bytes,38,rep,name=category_filters,json=categoryFilters,proto3" json:"category_filters,omitempty"`
+}
+
+func (x *LogSpecification) Reset() {
+	*x = LogSpecification{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_app_log_config_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LogSpecification) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogSpecification) ProtoMessage() {}
+
+func (x *LogSpecification) ProtoReflect() protoreflect.Message {
+	mi := &file_app_log_config_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogSpecification.ProtoReflect.Descriptor instead.
+func (*LogSpecification) Descriptor() ([]byte, []int) {
+	return file_app_log_config_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *LogSpecification) GetType() LogType {
+	if x != nil {
+		return x.Type
+	}
+	return LogType_None
+}
+
+func (x *LogSpecification) GetLevel() log.Severity {
+	if x != nil {
+		return x.Level
+	}
+	return log.Severity(0)
+}
+
+func (x *LogSpecification) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *LogSpecification) GetFormat() LogFormat {
+	if x != nil {
+		return x.Format
+	}
+	return LogFormat_Plain
+}
+
+func (x *LogSpecification) GetTimestampPrecision() TimestampPrecision {
+	if x != nil {
+		return x.TimestampPrecision
+	}
+	return TimestampPrecision_Seconds
+}
+
+func (x *LogSpecification) GetMaxRecords() uint32 {
+	if x != nil {
+		return x.MaxRecords
+	}
+	return 0
+}
+
+func (x *LogSpecification) GetFifoPolicy() FifoPolicy {
+	if x != nil {
+		return x.FifoPolicy
+	}
+	return FifoPolicy_FifoDrop
+}
+
+func (x *LogSpecification) GetInvalidUtf8() InvalidUtf8Policy {
+	if x != nil {
+		return x.InvalidUtf8
+	}
+	return InvalidUtf8Policy_Utf8Replace
+}
+
+func (x *LogSpecification) GetTextfileInterval() uint32 {
+	if x != nil {
+		return x.TextfileInterval
+	}
+	return 0
+}
+
+func (x *LogSpecification) GetFlushInterval() uint32 {
+	if x != nil {
+		return x.FlushInterval
+	}
+	return 0
+}
+
+func (x *LogSpecification) GetOutputs() []*LogSpecification {
+	if x != nil {
+		return x.Outputs
+	}
+	return nil
+}
+
+func (x *LogSpecification) GetExcludeTags() []string {
+	if x != nil {
+		return x.ExcludeTags
+	}
+	return nil
+}
+
+func (x *LogSpecification) GetBatchSize() uint32 {
+	if x != nil {
+		return x.BatchSize
+	}
+	return 0
+}
+
+func (x *LogSpecification) GetBatchInterval() uint32 {
+	if x != nil {
+		return x.BatchInterval
+	}
+	return 0
+}
+
+func (x *LogSpecification) GetRepeatedFields() RepeatedFieldMode {
+	if x != nil {
+		return x.RepeatedFields
+	}
+	return RepeatedFieldMode_RepeatedJoined
+}
+
+func (x *LogSpecification) GetHttpEndpoint() string {
+	if x != nil {
+		return x.HttpEndpoint
+	}
+	return ""
+}
+
+func (x *LogSpecification) GetHttpHeaders() map[string]string {
+	if x != nil {
+		return x.HttpHeaders
+	}
+	return nil
+}
+
+func (x *LogSpecification) GetHttpBatchSize() uint32 {
+	if x != nil {
+		return x.HttpBatchSize
+	}
+	return 0
+}
+
+func (x *LogSpecification) GetTamperEvident() *TamperEvident {
+	if x != nil {
+		return x.TamperEvident
+	}
+	return nil
+}
+
+func (x *LogSpecification) GetSeverityRoutes() map[string]*LogSpecification {
+	if x != nil {
+		return x.SeverityRoutes
+	}
+	return nil
+}
+
+func (x *LogSpecification) GetMaxSizeMb() uint32 {
+	if x != nil {
+		return x.MaxSizeMb
+	}
+	return 0
+}
+
+func (x *LogSpecification) GetMaxBackups() uint32 {
+	if x != nil {
+		return x.MaxBackups
+	}
+	return 0
+}
+
+func (x *LogSpecification) GetMaxAgeDays() uint32 {
+	if x != nil {
+		return x.MaxAgeDays
+	}
+	return 0
+}
+
+func (x *LogSpecification) GetCompress() bool {
+	if x != nil {
+		return x.Compress
+	}
+	return false
+}
+
+func (x *LogSpecification) GetLegacy() bool {
+	if x != nil {
+		return x.Legacy
+	}
+	return false
+}
+
+func (x *LogSpecification) GetDropLegacy() bool {
+	if x != nil {
+		return x.DropLegacy
+	}
+	return false
+}
+
+func (x *LogSpecification) GetSyslogFacility() string {
+	if x != nil {
+		return x.SyslogFacility
+	}
+	return ""
+}
+
+func (x *LogSpecification) GetSyslogTag() string {
+	if x != nil {
+		return x.SyslogTag
+	}
+	return ""
+}
+
+func (x *LogSpecification) GetOpenRetryTimeout() uint32 {
+	if x != nil {
+		return x.OpenRetryTimeout
+	}
+	return 0
+}
+
+func (x *LogSpecification) GetIndentJson() bool {
+	if x != nil {
+		return x.IndentJson
+	}
+	return false
+}
+
+func (x *LogSpecification) GetSampleInitial() uint32 {
+	if x != nil {
+		return x.SampleInitial
+	}
+	return 0
+}
+
+func (x *LogSpecification) GetSampleThereafter() uint32 {
+	if x != nil {
+		return x.SampleThereafter
+	}
+	return 0
+}
+
+func (x *LogSpecification) GetSampleWindow() uint32 {
+	if x != nil {
+		return x.SampleWindow
+	}
+	return 0
+}
+
+func (x *LogSpecification) GetMasking() *Masking {
+	if x != nil {
+		return x.Masking
+	}
+	return nil
+}
+
+func (x *LogSpecification) GetTimeFormat() string {
+	if x != nil {
+		return x.TimeFormat
+	}
+	return ""
+}
+
+func (x *LogSpecification) GetTimezone() string {
+	if x != nil {
+		return x.Timezone
+	}
+	return ""
+}
+
+func (x *LogSpecification) GetDiskFull() DiskFullPolicy {
+	if x != nil {
+		return x.DiskFull
+	}
+	return DiskFullPolicy_DiskFullDrop
+}
+
+func (x *LogSpecification) GetCategoryFilters() []*CategoryFilter {
+	if x != nil {
+		return x.CategoryFilters
+	}
+	return nil
+}
+
+type Config struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Error  *LogSpecification `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	Access *LogSpecification `protobuf:"bytes,7,opt,name=access,proto3" json:"access,omitempty"`
+	// Labels attached to every log record.
+	StaticLabels map[string]string `protobuf:"bytes,8,rep,name=static_labels,json=staticLabels,proto3" json:"static_labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Attach a seq label, increasing across all channels, to every record.
+	SequenceNumbers bool `protobuf:"varint,9,opt,name=sequence_numbers,json=sequenceNumbers,proto3" json:"sequence_numbers,omitempty"`
+	// Connections closed or throttled by policy enforcement.
+	Policy *LogSpecification `protobuf:"bytes,10,opt,name=policy,proto3" json:"policy,omitempty"`
+	// Attach a hostname label, resolved once at start, to every record.
+	HostnameSource HostnameSource `protobuf:"varint,11,opt,name=hostname_source,json=hostnameSource,proto3,enum=v2ray.core.app.log.HostnameSource" json:"hostname_source,omitempty"`
+	Hostname       string         `protobuf:"bytes,12,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	// Time records from the wall clock at start plus the monotonic time elapsed
+	// since, so adjustments of the system clock do not make timestamps jump,
+	// notably backwards, within a run. They drift from the wall clock instead.
+	MonotonicTimestamps bool `protobuf:"varint,13,opt,name=monotonic_timestamps,json=monotonicTimestamps,proto3" json:"monotonic_timestamps,omitempty"`
+	// Log access records of rejected and failed connections only.
+	AccessLogOnlyFailures bool `protobuf:"varint,14,opt,name=access_log_only_failures,json=accessLogOnlyFailures,proto3" json:"access_log_only_failures,omitempty"`
+	// DNS queries resolved by the DNS app, from the cache or an upstream. If
+	// not set, they are logged to the error log at Debug level. The level of
+	// the channel is ignored, all queries are logged.
+	Dns *LogSpecification `protobuf:"bytes,15,opt,name=dns,proto3" json:"dns,omitempty"`
+	// Trace and span ids attached to the records of connections.
+	TraceContext *TraceContext `protobuf:"bytes,16,opt,name=trace_context,json=traceContext,proto3" json:"trace_context,omitempty"`
+	// Drop access records while the level of the access log is set below Info,
+	// also when lowered at runtime. Access records have no severity, so without
+	// it the level of the access log is ignored.
+	AccessLevelFilter bool `protobuf:"varint,17,opt,name=access_level_filter,json=accessLevelFilter,proto3" json:"access_level_filter,omitempty"`
+}
+
+func (x *Config) Reset() {
+	*x = Config{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_app_log_config_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Config) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Config) ProtoMessage() {}
+
+func (x *Config) ProtoReflect() protoreflect.Message {
+	mi := &file_app_log_config_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Config.ProtoReflect.Descriptor instead.
+func (*Config) Descriptor() ([]byte, []int) {
+	return file_app_log_config_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Config) GetError() *LogSpecification {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+func (x *Config) GetAccess() *LogSpecification {
+	if x != nil {
+		return x.Access
+	}
+	return nil
+}
+
+func (x *Config) GetStaticLabels() map[string]string {
+	if x != nil {
+		return x.StaticLabels
+	}
+	return nil
+}
+
+func (x *Config) GetSequenceNumbers() bool {
+	if x != nil {
+		return x.SequenceNumbers
+	}
+	return false
+}
+
+func (x *Config) GetPolicy() *LogSpecification {
+	if x != nil {
+		return x.Policy
+	}
+	return nil
+}
+
+func (x *Config) GetHostnameSource() HostnameSource {
+	if x != nil {
+		return x.HostnameSource
+	}
+	return HostnameSource_NoHostname
+}
+
+func (x *Config) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *Config) GetMonotonicTimestamps() bool {
+	if x != nil {
+		return x.MonotonicTimestamps
+	}
+	return false
+}
+
+func (x *Config) GetAccessLogOnlyFailures() bool {
+	if x != nil {
+		return x.AccessLogOnlyFailures
+	}
+	return false
+}
+
+func (x *Config) GetDns() *LogSpecification {
+	if x != nil {
+		return x.Dns
+	}
+	return nil
+}
+
+func (x *Config) GetTraceContext() *TraceContext {
+	if x != nil {
+		return x.TraceContext
+	}
+	return nil
+}
+
+func (x *Config) GetAccessLevelFilter() bool {
+	if x != nil {
+		return x.AccessLevelFilter
+	}
+	return false
+}
+
+// Trace and span ids of a connection, for correlating its records with
+// tracing upstream. The ids are taken from the inbound, such as from the
+// traceparent header of HTTP requests, when it carries them.
+type TraceContext struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Derive ids for connections carrying none from their session id, so the
+	// records of a connection share them.
+	Generate bool `protobuf:"varint,1,opt,name=generate,proto3" json:"generate,omitempty"`
 }
 
-func (x *LogSpecification) Reset() {
-	*x = LogSpecification{}
+func (x *TraceContext) Reset() {
+	*x = TraceContext{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_app_log_config_proto_msgTypes[0]
+		mi := &file_app_log_config_proto_msgTypes[3]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *LogSpecification) String() string {
+func (x *TraceContext) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LogSpecification) ProtoMessage() {}
+func (*TraceContext) ProtoMessage() {}
 
-func (x *LogSpecification) ProtoReflect() protoreflect.Message {
-	mi := &file_app_log_config_proto_msgTypes[0]
+func (x *TraceContext) ProtoReflect() protoreflect.Message {
+	mi := &file_app_log_config_proto_msgTypes[3]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -111,58 +1103,180 @@ func (x *LogSpecification) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LogSpecification.ProtoReflect.Descriptor instead.
-func (*LogSpecification) Descriptor() ([]byte, []int) {
-	return file_app_log_config_proto_rawDescGZIP(), []int{0}
+// Deprecated: Use TraceContext.ProtoReflect.Descriptor instead.
+func (*TraceContext) Descriptor() ([]byte, []int) {
+	return file_app_log_config_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *LogSpecification) GetType() LogType {
+func (x *TraceContext) GetGenerate() bool {
 	if x != nil {
-		return x.Type
+		return x.Generate
 	}
-	return LogType_None
+	return false
 }
 
-func (x *LogSpecification) GetLevel() log.Severity {
+// Masks applied to the text of records.
+type Masking struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Apply the built-in masks, for the userinfo of URLs, authorization
+	// headers, password and token parameters, and UUIDs, which are user ids of
+	// VMess and VLESS.
+	Builtin bool `protobuf:"varint,1,opt,name=builtin,proto3" json:"builtin,omitempty"`
+	// Applied in order, after the built-in masks.
+	Rules []*MaskRule `protobuf:"bytes,2,rep,name=rules,proto3" json:"rules,omitempty"`
+}
+
+func (x *Masking) Reset() {
+	*x = Masking{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_app_log_config_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Masking) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Masking) ProtoMessage() {}
+
+func (x *Masking) ProtoReflect() protoreflect.Message {
+	mi := &file_app_log_config_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Masking.ProtoReflect.Descriptor instead.
+func (*Masking) Descriptor() ([]byte, []int) {
+	return file_app_log_config_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Masking) GetBuiltin() bool {
 	if x != nil {
-		return x.Level
+		return x.Builtin
 	}
-	return log.Severity(0)
+	return false
 }
 
-func (x *LogSpecification) GetPath() string {
+func (x *Masking) GetRules() []*MaskRule {
 	if x != nil {
-		return x.Path
+		return x.Rules
+	}
+	return nil
+}
+
+type MaskRule struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// RE2 regular expression of the text to mask.
+	Pattern string `protobuf:"bytes,1,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	// Replacement of the matches, which may refer to groups of the pattern as
+	// $1 or ${name}.
+	Replacement string `protobuf:"bytes,2,opt,name=replacement,proto3" json:"replacement,omitempty"`
+	// Replace the matches with a hash of them instead, so records of the same
+	// value can still be correlated.
+	Hash bool `protobuf:"varint,3,opt,name=hash,proto3" json:"hash,omitempty"`
+}
+
+func (x *MaskRule) Reset() {
+	*x = MaskRule{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_app_log_config_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MaskRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MaskRule) ProtoMessage() {}
+
+func (x *MaskRule) ProtoReflect() protoreflect.Message {
+	mi := &file_app_log_config_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MaskRule.ProtoReflect.Descriptor instead.
+func (*MaskRule) Descriptor() ([]byte, []int) {
+	return file_app_log_config_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *MaskRule) GetPattern() string {
+	if x != nil {
+		return x.Pattern
 	}
 	return ""
 }
 
-type Config struct {
+func (x *MaskRule) GetReplacement() string {
+	if x != nil {
+		return x.Replacement
+	}
+	return ""
+}
+
+func (x *MaskRule) GetHash() bool {
+	if x != nil {
+		return x.Hash
+	}
+	return false
+}
+
+// Filters records of the error log by where they come from.
+type CategoryFilter struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Error  *LogSpecification `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
-	Access *LogSpecification `protobuf:"bytes,7,opt,name=access,proto3" json:"access,omitempty"`
+	// Package path of the records relative to the module, such as proxy/vmess,
+	// or category they are tagged with, such as routing. * matches any
+	// characters but /, a trailing /* any packages below the path.
+	Pattern string `protobuf:"bytes,1,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	// Drop the matching records instead of keeping them.
+	Deny bool `protobuf:"varint,2,opt,name=deny,proto3" json:"deny,omitempty"`
+	// Apply the filter to records of this severity and less severe ones only,
+	// such as info for info and debug, all records if unset.
+	Level log.Severity `protobuf:"varint,3,opt,name=level,proto3,enum=v2ray.core.common.log.Severity" json:"level,omitempty"`
 }
 
-func (x *Config) Reset() {
-	*x = Config{}
+func (x *CategoryFilter) Reset() {
+	*x = CategoryFilter{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_app_log_config_proto_msgTypes[1]
+		mi := &file_app_log_config_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Config) String() string {
+func (x *CategoryFilter) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Config) ProtoMessage() {}
+func (*CategoryFilter) ProtoMessage() {}
 
-func (x *Config) ProtoReflect() protoreflect.Message {
-	mi := &file_app_log_config_proto_msgTypes[1]
+func (x *CategoryFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_app_log_config_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -173,23 +1287,30 @@ func (x *Config) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Config.ProtoReflect.Descriptor instead.
-func (*Config) Descriptor() ([]byte, []int) {
-	return file_app_log_config_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use CategoryFilter.ProtoReflect.Descriptor instead.
+func (*CategoryFilter) Descriptor() ([]byte, []int) {
+	return file_app_log_config_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *Config) GetError() *LogSpecification {
+func (x *CategoryFilter) GetPattern() string {
 	if x != nil {
-		return x.Error
+		return x.Pattern
 	}
-	return nil
+	return ""
 }
 
-func (x *Config) GetAccess() *LogSpecification {
+func (x *CategoryFilter) GetDeny() bool {
 	if x != nil {
-		return x.Access
+		return x.Deny
 	}
-	return nil
+	return false
+}
+
+func (x *CategoryFilter) GetLevel() log.Severity {
+	if x != nil {
+		return x.Level
+	}
+	return log.Severity(0)
 }
 
 var File_app_log_config_proto protoreflect.FileDescriptor
@@ -201,37 +1322,254 @@ var file_app_log_config_proto_rawDesc = []byte{
 	0x6f, 0x6e, 0x2f, 0x6c, 0x6f, 0x67, 0x2f, 0x6c, 0x6f, 0x67, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
 	0x1a, 0x20, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x65, 0x78,
 	0x74, 0x2f, 0x65, 0x78, 0x74, 0x65, 0x6e, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x22, 0x8e, 0x01, 0x0a, 0x10, 0x4c, 0x6f, 0x67, 0x53, 0x70, 0x65, 0x63, 0x69, 0x66,
-	0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x2f, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1b, 0x2e, 0x76, 0x32, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f,
-	0x72, 0x65, 0x2e, 0x61, 0x70, 0x70, 0x2e, 0x6c, 0x6f, 0x67, 0x2e, 0x4c, 0x6f, 0x67, 0x54, 0x79,
-	0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x35, 0x0a, 0x05, 0x6c, 0x65, 0x76, 0x65,
-	0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1f, 0x2e, 0x76, 0x32, 0x72, 0x61, 0x79, 0x2e,
-	0x63, 0x6f, 0x72, 0x65, 0x2e, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x2e, 0x6c, 0x6f, 0x67, 0x2e,
-	0x53, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x52, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x12,
-	0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70,
-	0x61, 0x74, 0x68, 0x22, 0xb8, 0x01, 0x0a, 0x06, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x3a,
-	0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x24, 0x2e,
+	0x74, 0x6f, 0x22, 0x43, 0x0a, 0x0d, 0x54, 0x61, 0x6d, 0x70, 0x65, 0x72, 0x45, 0x76, 0x69, 0x64,
+	0x65, 0x6e, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x6b, 0x65, 0x79, 0x5f, 0x66, 0x69, 0x6c, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6b, 0x65, 0x79, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x17,
+	0x0a, 0x07, 0x6b, 0x65, 0x79, 0x5f, 0x65, 0x6e, 0x76, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x6b, 0x65, 0x79, 0x45, 0x6e, 0x76, 0x22, 0xe0, 0x0f, 0x0a, 0x10, 0x4c, 0x6f, 0x67, 0x53,
+	0x70, 0x65, 0x63, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x2f, 0x0a, 0x04,
+	0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1b, 0x2e, 0x76, 0x32, 0x72,
+	0x61, 0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x61, 0x70, 0x70, 0x2e, 0x6c, 0x6f, 0x67, 0x2e,
+	0x4c, 0x6f, 0x67, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x35, 0x0a,
+	0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1f, 0x2e, 0x76,
+	0x32, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e,
+	0x2e, 0x6c, 0x6f, 0x67, 0x2e, 0x53, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x52, 0x05, 0x6c,
+	0x65, 0x76, 0x65, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x35, 0x0a, 0x06, 0x66, 0x6f, 0x72, 0x6d,
+	0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1d, 0x2e, 0x76, 0x32, 0x72, 0x61, 0x79,
+	0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x61, 0x70, 0x70, 0x2e, 0x6c, 0x6f, 0x67, 0x2e, 0x4c, 0x6f,
+	0x67, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x52, 0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12,
+	0x57, 0x0a, 0x13, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x5f, 0x70, 0x72, 0x65,
+	0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x26, 0x2e, 0x76,
+	0x32, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x61, 0x70, 0x70, 0x2e, 0x6c, 0x6f,
+	0x67, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x50, 0x72, 0x65, 0x63, 0x69,
+	0x73, 0x69, 0x6f, 0x6e, 0x52, 0x12, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x50,
+	0x72, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1f, 0x0a, 0x0b, 0x6d, 0x61, 0x78, 0x5f,
+	0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x6d,
+	0x61, 0x78, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x12, 0x3f, 0x0a, 0x0b, 0x66, 0x69, 0x66,
+	0x6f, 0x5f, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1e,
+	0x2e, 0x76, 0x32, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x61, 0x70, 0x70, 0x2e,
+	0x6c, 0x6f, 0x67, 0x2e, 0x46, 0x69, 0x66, 0x6f, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x52, 0x0a,
+	0x66, 0x69, 0x66, 0x6f, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x48, 0x0a, 0x0c, 0x69, 0x6e,
+	0x76, 0x61, 0x6c, 0x69, 0x64, 0x5f, 0x75, 0x74, 0x66, 0x38, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x25, 0x2e, 0x76, 0x32, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x61, 0x70,
+	0x70, 0x2e, 0x6c, 0x6f, 0x67, 0x2e, 0x49, 0x6e, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x55, 0x74, 0x66,
+	0x38, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x52, 0x0b, 0x69, 0x6e, 0x76, 0x61, 0x6c, 0x69, 0x64,
+	0x55, 0x74, 0x66, 0x38, 0x12, 0x2b, 0x0a, 0x11, 0x74, 0x65, 0x78, 0x74, 0x66, 0x69, 0x6c, 0x65,
+	0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x10, 0x74, 0x65, 0x78, 0x74, 0x66, 0x69, 0x6c, 0x65, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61,
+	0x6c, 0x12, 0x25, 0x0a, 0x0e, 0x66, 0x6c, 0x75, 0x73, 0x68, 0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72,
+	0x76, 0x61, 0x6c, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0d, 0x66, 0x6c, 0x75, 0x73, 0x68,
+	0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x12, 0x3e, 0x0a, 0x07, 0x6f, 0x75, 0x74, 0x70,
+	0x75, 0x74, 0x73, 0x18, 0x0b, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x24, 0x2e, 0x76, 0x32, 0x72, 0x61,
+	0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x61, 0x70, 0x70, 0x2e, 0x6c, 0x6f, 0x67, 0x2e, 0x4c,
+	0x6f, 0x67, 0x53, 0x70, 0x65, 0x63, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52,
+	0x07, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x65, 0x78, 0x63, 0x6c,
+	0x75, 0x64, 0x65, 0x5f, 0x74, 0x61, 0x67, 0x73, 0x18, 0x0c, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0b,
+	0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x54, 0x61, 0x67, 0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x62,
+	0x61, 0x74, 0x63, 0x68, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x09, 0x62, 0x61, 0x74, 0x63, 0x68, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x25, 0x0a, 0x0e, 0x62, 0x61,
+	0x74, 0x63, 0x68, 0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x18, 0x0e, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x0d, 0x62, 0x61, 0x74, 0x63, 0x68, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61,
+	0x6c, 0x12, 0x4e, 0x0a, 0x0f, 0x72, 0x65, 0x70, 0x65, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x66, 0x69,
+	0x65, 0x6c, 0x64, 0x73, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x25, 0x2e, 0x76, 0x32, 0x72,
+	0x61, 0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x61, 0x70, 0x70, 0x2e, 0x6c, 0x6f, 0x67, 0x2e,
+	0x52, 0x65, 0x70, 0x65, 0x61, 0x74, 0x65, 0x64, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4d, 0x6f, 0x64,
+	0x65, 0x52, 0x0e, 0x72, 0x65, 0x70, 0x65, 0x61, 0x74, 0x65, 0x64, 0x46, 0x69, 0x65, 0x6c, 0x64,
+	0x73, 0x12, 0x23, 0x0a, 0x0d, 0x68, 0x74, 0x74, 0x70, 0x5f, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69,
+	0x6e, 0x74, 0x18, 0x10, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x68, 0x74, 0x74, 0x70, 0x45, 0x6e,
+	0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x58, 0x0a, 0x0c, 0x68, 0x74, 0x74, 0x70, 0x5f, 0x68,
+	0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x18, 0x11, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x35, 0x2e, 0x76,
+	0x32, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x61, 0x70, 0x70, 0x2e, 0x6c, 0x6f,
+	0x67, 0x2e, 0x4c, 0x6f, 0x67, 0x53, 0x70, 0x65, 0x63, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x2e, 0x48, 0x74, 0x74, 0x70, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x52, 0x0b, 0x68, 0x74, 0x74, 0x70, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73,
+	0x12, 0x26, 0x0a, 0x0f, 0x68, 0x74, 0x74, 0x70, 0x5f, 0x62, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x73,
+	0x69, 0x7a, 0x65, 0x18, 0x12, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0d, 0x68, 0x74, 0x74, 0x70, 0x42,
+	0x61, 0x74, 0x63, 0x68, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x48, 0x0a, 0x0e, 0x74, 0x61, 0x6d, 0x70,
+	0x65, 0x72, 0x5f, 0x65, 0x76, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x18, 0x13, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x21, 0x2e, 0x76, 0x32, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x61, 0x70,
+	0x70, 0x2e, 0x6c, 0x6f, 0x67, 0x2e, 0x54, 0x61, 0x6d, 0x70, 0x65, 0x72, 0x45, 0x76, 0x69, 0x64,
+	0x65, 0x6e, 0x74, 0x52, 0x0d, 0x74, 0x61, 0x6d, 0x70, 0x65, 0x72, 0x45, 0x76, 0x69, 0x64, 0x65,
+	0x6e, 0x74, 0x12, 0x61, 0x0a, 0x0f, 0x73, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x5f, 0x72,
+	0x6f, 0x75, 0x74, 0x65, 0x73, 0x18, 0x14, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x38, 0x2e, 0x76, 0x32,
+	0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x61, 0x70, 0x70, 0x2e, 0x6c, 0x6f, 0x67,
+	0x2e, 0x4c, 0x6f, 0x67, 0x53, 0x70, 0x65, 0x63, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x2e, 0x53, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x73,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0e, 0x73, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x52,
+	0x6f, 0x75, 0x74, 0x65, 0x73, 0x12, 0x1e, 0x0a, 0x0b, 0x6d, 0x61, 0x78, 0x5f, 0x73, 0x69, 0x7a,
+	0x65, 0x5f, 0x6d, 0x62, 0x18, 0x15, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x6d, 0x61, 0x78, 0x53,
+	0x69, 0x7a, 0x65, 0x4d, 0x62, 0x12, 0x1f, 0x0a, 0x0b, 0x6d, 0x61, 0x78, 0x5f, 0x62, 0x61, 0x63,
+	0x6b, 0x75, 0x70, 0x73, 0x18, 0x16, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x6d, 0x61, 0x78, 0x42,
+	0x61, 0x63, 0x6b, 0x75, 0x70, 0x73, 0x12, 0x20, 0x0a, 0x0c, 0x6d, 0x61, 0x78, 0x5f, 0x61, 0x67,
+	0x65, 0x5f, 0x64, 0x61, 0x79, 0x73, 0x18, 0x17, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x6d, 0x61,
+	0x78, 0x41, 0x67, 0x65, 0x44, 0x61, 0x79, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x6f, 0x6d, 0x70,
+	0x72, 0x65, 0x73, 0x73, 0x18, 0x18, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x63, 0x6f, 0x6d, 0x70,
+	0x72, 0x65, 0x73, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x6c, 0x65, 0x67, 0x61, 0x63, 0x79, 0x18, 0x19,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6c, 0x65, 0x67, 0x61, 0x63, 0x79, 0x12, 0x1f, 0x0a, 0x0b,
+	0x64, 0x72, 0x6f, 0x70, 0x5f, 0x6c, 0x65, 0x67, 0x61, 0x63, 0x79, 0x18, 0x1a, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x0a, 0x64, 0x72, 0x6f, 0x70, 0x4c, 0x65, 0x67, 0x61, 0x63, 0x79, 0x12, 0x27, 0x0a,
+	0x0f, 0x73, 0x79, 0x73, 0x6c, 0x6f, 0x67, 0x5f, 0x66, 0x61, 0x63, 0x69, 0x6c, 0x69, 0x74, 0x79,
+	0x18, 0x1b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x73, 0x79, 0x73, 0x6c, 0x6f, 0x67, 0x46, 0x61,
+	0x63, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x79, 0x73, 0x6c, 0x6f, 0x67,
+	0x5f, 0x74, 0x61, 0x67, 0x18, 0x1c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x79, 0x73, 0x6c,
+	0x6f, 0x67, 0x54, 0x61, 0x67, 0x12, 0x2c, 0x0a, 0x12, 0x6f, 0x70, 0x65, 0x6e, 0x5f, 0x72, 0x65,
+	0x74, 0x72, 0x79, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x18, 0x1d, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x10, 0x6f, 0x70, 0x65, 0x6e, 0x52, 0x65, 0x74, 0x72, 0x79, 0x54, 0x69, 0x6d, 0x65,
+	0x6f, 0x75, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x69, 0x6e, 0x64, 0x65, 0x6e, 0x74, 0x5f, 0x6a, 0x73,
+	0x6f, 0x6e, 0x18, 0x1e, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x69, 0x6e, 0x64, 0x65, 0x6e, 0x74,
+	0x4a, 0x73, 0x6f, 0x6e, 0x12, 0x25, 0x0a, 0x0e, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x5f, 0x69,
+	0x6e, 0x69, 0x74, 0x69, 0x61, 0x6c, 0x18, 0x1f, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0d, 0x73, 0x61,
+	0x6d, 0x70, 0x6c, 0x65, 0x49, 0x6e, 0x69, 0x74, 0x69, 0x61, 0x6c, 0x12, 0x2b, 0x0a, 0x11, 0x73,
+	0x61, 0x6d, 0x70, 0x6c, 0x65, 0x5f, 0x74, 0x68, 0x65, 0x72, 0x65, 0x61, 0x66, 0x74, 0x65, 0x72,
+	0x18, 0x20, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x10, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x54, 0x68,
+	0x65, 0x72, 0x65, 0x61, 0x66, 0x74, 0x65, 0x72, 0x12, 0x23, 0x0a, 0x0d, 0x73, 0x61, 0x6d, 0x70,
+	0x6c, 0x65, 0x5f, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x18, 0x21, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x0c, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x12, 0x35, 0x0a,
+	0x07, 0x6d, 0x61, 0x73, 0x6b, 0x69, 0x6e, 0x67, 0x18, 0x22, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b,
+	0x2e, 0x76, 0x32, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x61, 0x70, 0x70, 0x2e,
+	0x6c, 0x6f, 0x67, 0x2e, 0x4d, 0x61, 0x73, 0x6b, 0x69, 0x6e, 0x67, 0x52, 0x07, 0x6d, 0x61, 0x73,
+	0x6b, 0x69, 0x6e, 0x67, 0x12, 0x1f, 0x0a, 0x0b, 0x74, 0x69, 0x6d, 0x65, 0x5f, 0x66, 0x6f, 0x72,
+	0x6d, 0x61, 0x74, 0x18, 0x23, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x74, 0x69, 0x6d, 0x65, 0x46,
+	0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x74, 0x69, 0x6d, 0x65, 0x7a, 0x6f, 0x6e,
+	0x65, 0x18, 0x24, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x74, 0x69, 0x6d, 0x65, 0x7a, 0x6f, 0x6e,
+	0x65, 0x12, 0x3f, 0x0a, 0x09, 0x64, 0x69, 0x73, 0x6b, 0x5f, 0x66, 0x75, 0x6c, 0x6c, 0x18, 0x25,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x22, 0x2e, 0x76, 0x32, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x72,
+	0x65, 0x2e, 0x61, 0x70, 0x70, 0x2e, 0x6c, 0x6f, 0x67, 0x2e, 0x44, 0x69, 0x73, 0x6b, 0x46, 0x75,
+	0x6c, 0x6c, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x52, 0x08, 0x64, 0x69, 0x73, 0x6b, 0x46, 0x75,
+	0x6c, 0x6c, 0x12, 0x4d, 0x0a, 0x10, 0x63, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x79, 0x5f, 0x66,
+	0x69, 0x6c, 0x74, 0x65, 0x72, 0x73, 0x18, 0x26, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x22, 0x2e, 0x76,
+	0x32, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x61, 0x70, 0x70, 0x2e, 0x6c, 0x6f,
+	0x67, 0x2e, 0x43, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x79, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72,
+	0x52, 0x0f, 0x63, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x79, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72,
+	0x73, 0x1a, 0x3e, 0x0a, 0x10, 0x48, 0x74, 0x74, 0x70, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38,
+	0x01, 0x1a, 0x67, 0x0a, 0x13, 0x53, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x52, 0x6f, 0x75,
+	0x74, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x3a, 0x0a, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x24, 0x2e, 0x76, 0x32, 0x72, 0x61,
+	0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x61, 0x70, 0x70, 0x2e, 0x6c, 0x6f, 0x67, 0x2e, 0x4c,
+	0x6f, 0x67, 0x53, 0x70, 0x65, 0x63, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xb9, 0x06, 0x0a, 0x06, 0x43,
+	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x3a, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x24, 0x2e, 0x76, 0x32, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x72,
+	0x65, 0x2e, 0x61, 0x70, 0x70, 0x2e, 0x6c, 0x6f, 0x67, 0x2e, 0x4c, 0x6f, 0x67, 0x53, 0x70, 0x65,
+	0x63, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f,
+	0x72, 0x12, 0x3c, 0x0a, 0x06, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x24, 0x2e, 0x76, 0x32, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x61,
+	0x70, 0x70, 0x2e, 0x6c, 0x6f, 0x67, 0x2e, 0x4c, 0x6f, 0x67, 0x53, 0x70, 0x65, 0x63, 0x69, 0x66,
+	0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x06, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x12,
+	0x51, 0x0a, 0x0d, 0x73, 0x74, 0x61, 0x74, 0x69, 0x63, 0x5f, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73,
+	0x18, 0x08, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2c, 0x2e, 0x76, 0x32, 0x72, 0x61, 0x79, 0x2e, 0x63,
+	0x6f, 0x72, 0x65, 0x2e, 0x61, 0x70, 0x70, 0x2e, 0x6c, 0x6f, 0x67, 0x2e, 0x43, 0x6f, 0x6e, 0x66,
+	0x69, 0x67, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x69, 0x63, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x52, 0x0c, 0x73, 0x74, 0x61, 0x74, 0x69, 0x63, 0x4c, 0x61, 0x62, 0x65,
+	0x6c, 0x73, 0x12, 0x29, 0x0a, 0x10, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x5f, 0x6e,
+	0x75, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0f, 0x73, 0x65,
+	0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x12, 0x3c, 0x0a,
+	0x06, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x24, 0x2e,
 	0x76, 0x32, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x61, 0x70, 0x70, 0x2e, 0x6c,
 	0x6f, 0x67, 0x2e, 0x4c, 0x6f, 0x67, 0x53, 0x70, 0x65, 0x63, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74,
-	0x69, 0x6f, 0x6e, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x3c, 0x0a, 0x06, 0x61, 0x63,
-	0x63, 0x65, 0x73, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x24, 0x2e, 0x76, 0x32, 0x72,
-	0x61, 0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x61, 0x70, 0x70, 0x2e, 0x6c, 0x6f, 0x67, 0x2e,
-	0x4c, 0x6f, 0x67, 0x53, 0x70, 0x65, 0x63, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e,
-	0x52, 0x06, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x3a, 0x16, 0x82, 0xb5, 0x18, 0x09, 0x0a, 0x07,
-	0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x82, 0xb5, 0x18, 0x05, 0x12, 0x03, 0x6c, 0x6f, 0x67,
-	0x4a, 0x04, 0x08, 0x01, 0x10, 0x02, 0x4a, 0x04, 0x08, 0x02, 0x10, 0x03, 0x4a, 0x04, 0x08, 0x03,
-	0x10, 0x04, 0x4a, 0x04, 0x08, 0x04, 0x10, 0x05, 0x4a, 0x04, 0x08, 0x05, 0x10, 0x06, 0x2a, 0x35,
+	0x69, 0x6f, 0x6e, 0x52, 0x06, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x4b, 0x0a, 0x0f, 0x68,
+	0x6f, 0x73, 0x74, 0x6e, 0x61, 0x6d, 0x65, 0x5f, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x0b,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x22, 0x2e, 0x76, 0x32, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x72,
+	0x65, 0x2e, 0x61, 0x70, 0x70, 0x2e, 0x6c, 0x6f, 0x67, 0x2e, 0x48, 0x6f, 0x73, 0x74, 0x6e, 0x61,
+	0x6d, 0x65, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x52, 0x0e, 0x68, 0x6f, 0x73, 0x74, 0x6e, 0x61,
+	0x6d, 0x65, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x68, 0x6f, 0x73, 0x74,
+	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x68, 0x6f, 0x73, 0x74,
+	0x6e, 0x61, 0x6d, 0x65, 0x12, 0x31, 0x0a, 0x14, 0x6d, 0x6f, 0x6e, 0x6f, 0x74, 0x6f, 0x6e, 0x69,
+	0x63, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x73, 0x18, 0x0d, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x13, 0x6d, 0x6f, 0x6e, 0x6f, 0x74, 0x6f, 0x6e, 0x69, 0x63, 0x54, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x73, 0x12, 0x37, 0x0a, 0x18, 0x61, 0x63, 0x63, 0x65, 0x73,
+	0x73, 0x5f, 0x6c, 0x6f, 0x67, 0x5f, 0x6f, 0x6e, 0x6c, 0x79, 0x5f, 0x66, 0x61, 0x69, 0x6c, 0x75,
+	0x72, 0x65, 0x73, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x08, 0x52, 0x15, 0x61, 0x63, 0x63, 0x65, 0x73,
+	0x73, 0x4c, 0x6f, 0x67, 0x4f, 0x6e, 0x6c, 0x79, 0x46, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x73,
+	0x12, 0x36, 0x0a, 0x03, 0x64, 0x6e, 0x73, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x24, 0x2e,
+	0x76, 0x32, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x61, 0x70, 0x70, 0x2e, 0x6c,
+	0x6f, 0x67, 0x2e, 0x4c, 0x6f, 0x67, 0x53, 0x70, 0x65, 0x63, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x52, 0x03, 0x64, 0x6e, 0x73, 0x12, 0x45, 0x0a, 0x0d, 0x74, 0x72, 0x61, 0x63,
+	0x65, 0x5f, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x18, 0x10, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x20, 0x2e, 0x76, 0x32, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x61, 0x70, 0x70,
+	0x2e, 0x6c, 0x6f, 0x67, 0x2e, 0x54, 0x72, 0x61, 0x63, 0x65, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x78,
+	0x74, 0x52, 0x0c, 0x74, 0x72, 0x61, 0x63, 0x65, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x12,
+	0x2e, 0x0a, 0x13, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x5f,
+	0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x18, 0x11, 0x20, 0x01, 0x28, 0x08, 0x52, 0x11, 0x61, 0x63,
+	0x63, 0x65, 0x73, 0x73, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x1a,
+	0x3f, 0x0a, 0x11, 0x53, 0x74, 0x61, 0x74, 0x69, 0x63, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01,
+	0x3a, 0x16, 0x82, 0xb5, 0x18, 0x09, 0x0a, 0x07, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x82,
+	0xb5, 0x18, 0x05, 0x12, 0x03, 0x6c, 0x6f, 0x67, 0x4a, 0x04, 0x08, 0x01, 0x10, 0x02, 0x4a, 0x04,
+	0x08, 0x02, 0x10, 0x03, 0x4a, 0x04, 0x08, 0x03, 0x10, 0x04, 0x4a, 0x04, 0x08, 0x04, 0x10, 0x05,
+	0x4a, 0x04, 0x08, 0x05, 0x10, 0x06, 0x22, 0x2a, 0x0a, 0x0c, 0x54, 0x72, 0x61, 0x63, 0x65, 0x43,
+	0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61,
+	0x74, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61,
+	0x74, 0x65, 0x22, 0x57, 0x0a, 0x07, 0x4d, 0x61, 0x73, 0x6b, 0x69, 0x6e, 0x67, 0x12, 0x18, 0x0a,
+	0x07, 0x62, 0x75, 0x69, 0x6c, 0x74, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07,
+	0x62, 0x75, 0x69, 0x6c, 0x74, 0x69, 0x6e, 0x12, 0x32, 0x0a, 0x05, 0x72, 0x75, 0x6c, 0x65, 0x73,
+	0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x76, 0x32, 0x72, 0x61, 0x79, 0x2e, 0x63,
+	0x6f, 0x72, 0x65, 0x2e, 0x61, 0x70, 0x70, 0x2e, 0x6c, 0x6f, 0x67, 0x2e, 0x4d, 0x61, 0x73, 0x6b,
+	0x52, 0x75, 0x6c, 0x65, 0x52, 0x05, 0x72, 0x75, 0x6c, 0x65, 0x73, 0x22, 0x5a, 0x0a, 0x08, 0x4d,
+	0x61, 0x73, 0x6b, 0x52, 0x75, 0x6c, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61, 0x74, 0x74, 0x65,
+	0x72, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x70, 0x61, 0x74, 0x74, 0x65, 0x72,
+	0x6e, 0x12, 0x20, 0x0a, 0x0b, 0x72, 0x65, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x6d, 0x65, 0x6e, 0x74,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x72, 0x65, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x6d,
+	0x65, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x61, 0x73, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x04, 0x68, 0x61, 0x73, 0x68, 0x22, 0x75, 0x0a, 0x0e, 0x43, 0x61, 0x74, 0x65, 0x67,
+	0x6f, 0x72, 0x79, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61, 0x74,
+	0x74, 0x65, 0x72, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x70, 0x61, 0x74, 0x74,
+	0x65, 0x72, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x65, 0x6e, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x04, 0x64, 0x65, 0x6e, 0x79, 0x12, 0x35, 0x0a, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1f, 0x2e, 0x76, 0x32, 0x72, 0x61, 0x79, 0x2e, 0x63,
+	0x6f, 0x72, 0x65, 0x2e, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x2e, 0x6c, 0x6f, 0x67, 0x2e, 0x53,
+	0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x52, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x2a, 0x69,
 	0x0a, 0x07, 0x4c, 0x6f, 0x67, 0x54, 0x79, 0x70, 0x65, 0x12, 0x08, 0x0a, 0x04, 0x4e, 0x6f, 0x6e,
 	0x65, 0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07, 0x43, 0x6f, 0x6e, 0x73, 0x6f, 0x6c, 0x65, 0x10, 0x01,
 	0x12, 0x08, 0x0a, 0x04, 0x46, 0x69, 0x6c, 0x65, 0x10, 0x02, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x76,
-	0x65, 0x6e, 0x74, 0x10, 0x03, 0x42, 0x57, 0x0a, 0x16, 0x63, 0x6f, 0x6d, 0x2e, 0x76, 0x32, 0x72,
-	0x61, 0x79, 0x2e, 0x63, 0x6f, 0x72, 0x65, 0x2e, 0x61, 0x70, 0x70, 0x2e, 0x6c, 0x6f, 0x67, 0x50,
-	0x01, 0x5a, 0x26, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x76, 0x32,
-	0x66, 0x6c, 0x79, 0x2f, 0x76, 0x32, 0x72, 0x61, 0x79, 0x2d, 0x63, 0x6f, 0x72, 0x65, 0x2f, 0x76,
-	0x35, 0x2f, 0x61, 0x70, 0x70, 0x2f, 0x6c, 0x6f, 0x67, 0xaa, 0x02, 0x12, 0x56, 0x32, 0x52, 0x61,
-	0x79, 0x2e, 0x43, 0x6f, 0x72, 0x65, 0x2e, 0x41, 0x70, 0x70, 0x2e, 0x4c, 0x6f, 0x67, 0x62, 0x06,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x65, 0x6e, 0x74, 0x10, 0x03, 0x12, 0x0a, 0x0a, 0x06, 0x53, 0x51, 0x4c, 0x69, 0x74, 0x65, 0x10,
+	0x04, 0x12, 0x10, 0x0a, 0x0c, 0x50, 0x72, 0x6f, 0x6d, 0x54, 0x65, 0x78, 0x74, 0x66, 0x69, 0x6c,
+	0x65, 0x10, 0x05, 0x12, 0x08, 0x0a, 0x04, 0x48, 0x54, 0x54, 0x50, 0x10, 0x06, 0x12, 0x0a, 0x0a,
+	0x06, 0x53, 0x79, 0x73, 0x6c, 0x6f, 0x67, 0x10, 0x07, 0x2a, 0x2c, 0x0a, 0x09, 0x4c, 0x6f, 0x67,
+	0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x09, 0x0a, 0x05, 0x50, 0x6c, 0x61, 0x69, 0x6e, 0x10,
+	0x00, 0x12, 0x0a, 0x0a, 0x06, 0x4c, 0x6f, 0x67, 0x66, 0x6d, 0x74, 0x10, 0x01, 0x12, 0x08, 0x0a,
+	0x04, 0x4a, 0x53, 0x4f, 0x4e, 0x10, 0x02, 0x2a, 0x56, 0x0a, 0x12, 0x54, 0x69, 0x6d, 0x65, 0x73,
+	0x74, 0x61, 0x6d, 0x70, 0x50, 0x72, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x0b, 0x0a,
+	0x07, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x10, 0x00, 0x12, 0x10, 0x0a, 0x0c, 0x4d, 0x69,
+	0x6c, 0x6c, 0x69, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x10, 0x01, 0x12, 0x10, 0x0a, 0x0c,
+	0x4d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x10, 0x02, 0x12, 0x0f,
+	0x0a, 0x0b, 0x4e, 0x61, 0x6e, 0x6f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x10, 0x03, 0x2a,
+	0x2a, 0x0a, 0x0a, 0x46, 0x69, 0x66, 0x6f, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x0c, 0x0a,
+	0x08, 0x46, 0x69, 0x66, 0x6f, 0x44, 0x72, 0x6f, 0x70, 0x10, 0x00, 0x12, 0x0e, 0x0a, 0x0a, 0x46,
+	0x69, 0x66, 0x6f, 0x42, 0x75, 0x66, 0x66, 0x65, 0x72, 0x10, 0x01, 0x2a, 0x42, 0x0a, 0x11, 0x49,
+	0x6e, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x55, 0x74, 0x66, 0x38, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79,
+	0x12, 0x0f, 0x0a, 0x0b, 0x55, 0x74, 0x66, 0x38, 0x52, 0x65, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x10,
+	0x00, 0x12, 0x0e, 0x0a, 0x0a, 0x55, 0x74, 0x66, 0x38, 0x45, 0x73, 0x63, 0x61, 0x70, 0x65, 0x10,
+	0x01, 0x12, 0x0c, 0x0a, 0x08, 0x55, 0x74, 0x66, 0x38, 0x44, 0x72, 0x6f, 0x70, 0x10, 0x02, 0x2a,
+	0x4d, 0x0a, 0x11, 0x52, 0x65, 0x70, 0x65, 0x61, 0x74, 0x65, 0x64, 0x46, 0x69, 0x65, 0x6c, 0x64,
+	0x4d, 0x6f, 0x64, 0x65, 0x12, 0x12, 0x0a, 0x0e, 0x52, 0x65, 0x70, 0x65, 0x61, 0x74, 0x65, 0x64,
+	0x4a, 0x6f, 0x69, 0x6e, 0x65, 0x64, 0x10, 0x00, 0x12, 0x11, 0x0a, 0x0d, 0x52, 0x65, 0x70, 0x65,
+	0x61, 0x74, 0x65, 0x64, 0x41, 0x72, 0x72, 0x61, 0x79, 0x10, 0x01, 0x12, 0x11, 0x0a, 0x0d, 0x52,
+	0x65, 0x70, 0x65, 0x61, 0x74, 0x65, 0x64, 0x4c, 0x69, 0x6e, 0x65, 0x73, 0x10, 0x02, 0x2a, 0x55,
+	0x0a, 0x0e, 0x48, 0x6f, 0x73, 0x74, 0x6e, 0x61, 0x6d, 0x65, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65,
+	0x12, 0x0e, 0x0a, 0x0a, 0x4e, 0x6f, 0x48, 0x6f, 0x73, 0x74, 0x6e, 0x61, 0x6d, 0x65, 0x10, 0x00,
+	0x12, 0x0e, 0x0a, 0x0a, 0x4f, 0x53, 0x48, 0x6f, 0x73, 0x74, 0x6e, 0x61, 0x6d, 0x65, 0x10, 0x01,
+	0x12, 0x12, 0x0a, 0x0e, 0x53, 0x74, 0x61, 0x74, 0x69, 0x63, 0x48, 0x6f, 0x73, 0x74, 0x6e, 0x61,
+	0x6d, 0x65, 0x10, 0x02, 0x12, 0x0f, 0x0a, 0x0b, 0x45, 0x6e, 0x76, 0x48, 0x6f, 0x73, 0x74, 0x6e,
+	0x61, 0x6d, 0x65, 0x10, 0x03, 0x2a, 0x49, 0x0a, 0x0e, 0x44, 0x69, 0x73, 0x6b, 0x46, 0x75, 0x6c,
+	0x6c, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x10, 0x0a, 0x0c, 0x44, 0x69, 0x73, 0x6b, 0x46,
+	0x75, 0x6c, 0x6c, 0x44, 0x72, 0x6f, 0x70, 0x10, 0x00, 0x12, 0x10, 0x0a, 0x0c, 0x44, 0x69, 0x73,
+	0x6b, 0x46, 0x75, 0x6c, 0x6c, 0x53, 0x74, 0x6f, 0x70, 0x10, 0x01, 0x12, 0x13, 0x0a, 0x0f, 0x44,
+	0x69, 0x73, 0x6b, 0x46, 0x75, 0x6c, 0x6c, 0x43, 0x6f, 0x6e, 0x73, 0x6f, 0x6c, 0x65, 0x10, 0x02,
+	0x42, 0x57, 0x0a, 0x16, 0x63, 0x6f, 0x6d, 0x2e, 0x76, 0x32, 0x72, 0x61, 0x79, 0x2e, 0x63, 0x6f,
+	0x72, 0x65, 0x2e, 0x61, 0x70, 0x70, 0x2e, 0x6c, 0x6f, 0x67, 0x50, 0x01, 0x5a, 0x26, 0x67, 0x69,
+	0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x76, 0x32, 0x66, 0x6c, 0x79, 0x2f, 0x76,
+	0x32, 0x72, 0x61, 0x79, 0x2d, 0x63, 0x6f, 0x72, 0x65, 0x2f, 0x76, 0x35, 0x2f, 0x61, 0x70, 0x70,
+	0x2f, 0x6c, 0x6f, 0x67, 0xaa, 0x02, 0x12, 0x56, 0x32, 0x52, 0x61, 0x79, 0x2e, 0x43, 0x6f, 0x72,
+	0x65, 0x2e, 0x41, 0x70, 0x70, 0x2e, 0x4c, 0x6f, 0x67, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
 }
 
 var (
@@ -246,24 +1584,59 @@ func file_app_log_config_proto_rawDescGZIP() []byte {
 	return file_app_log_config_proto_rawDescData
 }
 
-var file_app_log_config_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_app_log_config_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_app_log_config_proto_enumTypes = make([]protoimpl.EnumInfo, 8)
+var file_app_log_config_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
 var file_app_log_config_proto_goTypes = []interface{}{
 	(LogType)(0),             // 0: v2ray.core.app.log.LogType
-	(*LogSpecification)(nil), // 1: v2ray.core.app.log.LogSpecification
-	(*Config)(nil),           // 2: v2ray.core.app.log.Config
-	(log.Severity)(0),        // 3: v2ray.core.common.log.Severity
+	(LogFormat)(0),           // 1: v2ray.core.app.log.LogFormat
+	(TimestampPrecision)(0),  // 2: v2ray.core.app.log.TimestampPrecision
+	(FifoPolicy)(0),          // 3: v2ray.core.app.log.FifoPolicy
+	(InvalidUtf8Policy)(0),   // 4: v2ray.core.app.log.InvalidUtf8Policy
+	(RepeatedFieldMode)(0),   // 5: v2ray.core.app.log.RepeatedFieldMode
+	(HostnameSource)(0),      // 6: v2ray.core.app.log.HostnameSource
+	(DiskFullPolicy)(0),      // 7: v2ray.core.app.log.DiskFullPolicy
+	(*TamperEvident)(nil),    // 8: v2ray.core.app.log.TamperEvident
+	(*LogSpecification)(nil), // 9: v2ray.core.app.log.LogSpecification
+	(*Config)(nil),           // 10: v2ray.core.app.log.Config
+	(*TraceContext)(nil),     // 11: v2ray.core.app.log.TraceContext
+	(*Masking)(nil),          // 12: v2ray.core.app.log.Masking
+	(*MaskRule)(nil),         // 13: v2ray.core.app.log.MaskRule
+	(*CategoryFilter)(nil),   // 14: v2ray.core.app.log.CategoryFilter
+	nil,                      // 15: v2ray.core.app.log.LogSpecification.HttpHeadersEntry
+	nil,                      // 16: v2ray.core.app.log.LogSpecification.SeverityRoutesEntry
+	nil,                      // 17: v2ray.core.app.log.Config.StaticLabelsEntry
+	(log.Severity)(0),        // 18: v2ray.core.common.log.Severity
 }
 var file_app_log_config_proto_depIdxs = []int32{
-	0, // 0: v2ray.core.app.log.LogSpecification.type:type_name -> v2ray.core.app.log.LogType
-	3, // 1: v2ray.core.app.log.LogSpecification.level:type_name -> v2ray.core.common.log.Severity
-	1, // 2: v2ray.core.app.log.Config.error:type_name -> v2ray.core.app.log.LogSpecification
-	1, // 3: v2ray.core.app.log.Config.access:type_name -> v2ray.core.app.log.LogSpecification
-	4, // [4:4] is the sub-list for method output_type
-	4, // [4:4] is the sub-list for method input_type
-	4, // [4:4] is the sub-list for extension type_name
-	4, // [4:4] is the sub-list for extension extendee
-	0, // [0:4] is the sub-list for field type_name
+	0,  // 0: v2ray.core.app.log.LogSpecification.type:type_name -> v2ray.core.app.log.LogType
+	18, // 1: v2ray.core.app.log.LogSpecification.level:type_name -> v2ray.core.common.log.Severity
+	1,  // 2: v2ray.core.app.log.LogSpecification.format:type_name -> v2ray.core.app.log.LogFormat
+	2,  // 3: v2ray.core.app.log.LogSpecification.timestamp_precision:type_name -> v2ray.core.app.log.TimestampPrecision
+	3,  // 4: v2ray.core.app.log.LogSpecification.fifo_policy:type_name -> v2ray.core.app.log.FifoPolicy
+	4,  // 5: v2ray.core.app.log.LogSpecification.invalid_utf8:type_name -> v2ray.core.app.log.InvalidUtf8Policy
+	9,  // 6: v2ray.core.app.log.LogSpecification.outputs:type_name -> v2ray.core.app.log.LogSpecification
+	5,  // 7: v2ray.core.app.log.LogSpecification.repeated_fields:type_name -> v2ray.core.app.log.RepeatedFieldMode
+	15, // 8: v2ray.core.app.log.LogSpecification.http_headers:type_name -> v2ray.core.app.log.LogSpecification.HttpHeadersEntry
+	8,  // 9: v2ray.core.app.log.LogSpecification.tamper_evident:type_name -> v2ray.core.app.log.TamperEvident
+	16, // 10: v2ray.core.app.log.LogSpecification.severity_routes:type_name -> v2ray.core.app.log.LogSpecification.SeverityRoutesEntry
+	12, // 11: v2ray.core.app.log.LogSpecification.masking:type_name -> v2ray.core.app.log.Masking
+	7,  // 12: v2ray.core.app.log.LogSpecification.disk_full:type_name -> v2ray.core.app.log.DiskFullPolicy
+	14, // 13: v2ray.core.app.log.LogSpecification.category_filters:type_name -> v2ray.core.app.log.CategoryFilter
+	9,  // 14: v2ray.core.app.log.Config.error:type_name -> v2ray.core.app.log.LogSpecification
+	9,  // 15: v2ray.core.app.log.Config.access:type_name -> v2ray.core.app.log.LogSpecification
+	17, // 16: v2ray.core.app.log.Config.static_labels:type_name -> v2ray.core.app.log.Config.StaticLabelsEntry
+	9,  // 17: v2ray.core.app.log.Config.policy:type_name -> v2ray.core.app.log.LogSpecification
+	6,  // 18: v2ray.core.app.log.Config.hostname_source:type_name -> v2ray.core.app.log.HostnameSource
+	9,  // 19: v2ray.core.app.log.Config.dns:type_name -> v2ray.core.app.log.LogSpecification
+	11, // 20: v2ray.core.app.log.Config.trace_context:type_name -> v2ray.core.app.log.TraceContext
+	13, // 21: v2ray.core.app.log.Masking.rules:type_name -> v2ray.core.app.log.MaskRule
+	18, // 22: v2ray.core.app.log.CategoryFilter.level:type_name -> v2ray.core.common.log.Severity
+	9,  // 23: v2ray.core.app.log.LogSpecification.SeverityRoutesEntry.value:type_name -> v2ray.core.app.log.LogSpecification
+	24, // [24:24] is the sub-list for method output_type
+	24, // [24:24] is the sub-list for method input_type
+	24, // [24:24] is the sub-list for extension type_name
+	24, // [24:24] is the sub-list for extension extendee
+	0,  // [0:24] is the sub-list for field type_name
 }
 
 func init() { file_app_log_config_proto_init() }
@@ -273,7 +1646,7 @@ func file_app_log_config_proto_init() {
 	}
 	if !protoimpl.UnsafeEnabled {
 		file_app_log_config_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*LogSpecification); i {
+			switch v := v.(*TamperEvident); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -285,6 +1658,18 @@ func file_app_log_config_proto_init() {
 			}
 		}
 		file_app_log_config_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LogSpecification); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_app_log_config_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Config); i {
 			case 0:
 				return &v.state
@@ -296,14 +1681,62 @@ func file_app_log_config_proto_init() {
 				return nil
 			}
 		}
+		file_app_log_config_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TraceContext); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_app_log_config_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Masking); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_app_log_config_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MaskRule); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_app_log_config_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CategoryFilter); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_app_log_config_proto_rawDesc,
-			NumEnums:      1,
-			NumMessages:   2,
+			NumEnums:      8,
+			NumMessages:   10,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,511 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+	"github.com/v2fly/v2ray-core/v5/common/serial"
+)
+
+type formatOptions struct {
+	precision      TimestampPrecision
+	invalidUTF8    InvalidUtf8Policy
+	repeatedFields RepeatedFieldMode
+	indent         bool
+	// timeFormat is the time_format of the spec, location its timezone, nil
+	// for the local time zone.
+	timeFormat string
+	location   *time.Location
+}
+
+type formatter func(msg log.Message, t time.Time, options formatOptions) string
+
+// formattedHandler renders messages with a formatter before passing them on.
+type formattedHandler struct {
+	handler log.Handler
+	format  formatter
+	options formatOptions
+	now     func() time.Time
+}
+
+// newFormattedHandler returns a handler timing messages with now, or with
+// time.Now if it is nil.
+func newFormattedHandler(handler log.Handler, spec *LogSpecification, now func() time.Time) log.Handler {
+	if handler == nil {
+		return nil
+	}
+	// The timezone was validated by createOutputsHandler.
+	location, _ := loadTimezone(spec.Timezone)
+	h := &formattedHandler{
+		handler: handler,
+		now:     now,
+		options: formatOptions{
+			precision:      spec.TimestampPrecision,
+			invalidUTF8:    spec.InvalidUtf8,
+			repeatedFields: spec.RepeatedFields,
+			// HTTP posts a record per line in arrays.
+			indent:     spec.IndentJson && spec.Type != LogType_HTTP,
+			timeFormat: spec.TimeFormat,
+			location:   location,
+		},
+	}
+	format := spec.Format
+	if spec.Type == LogType_HTTP {
+		// Records are posted in JSON arrays.
+		format = LogFormat_JSON
+	}
+	switch format {
+	case LogFormat_Logfmt:
+		h.format = formatLogfmt
+	case LogFormat_JSON:
+		h.format = formatJSON
+	default:
+		h.format = formatPlain
+		if spec.TimestampPrecision == TimestampPrecision_Seconds && spec.BatchSize <= 1 && spec.TamperEvident == nil && now == nil && spec.TimeFormat == "" && location == nil {
+			// The writer prefixes the timestamp itself.
+			h.format = formatUntimed
+		}
+	}
+	if h.now == nil {
+		h.now = time.Now
+	}
+	return h
+}
+
+func (h *formattedHandler) Handle(msg log.Message) {
+	h.handler.Handle(&formattedMessage{
+		Message: msg,
+		time:    h.now(),
+		format:  h.format,
+		options: h.options,
+	})
+}
+
+func (h *formattedHandler) Close() error {
+	return common.Close(h.handler)
+}
+
+// Rotate implements log.Rotator.
+func (h *formattedHandler) Rotate() error {
+	return log.Rotate(h.handler)
+}
+
+type formattedMessage struct {
+	log.Message
+	time    time.Time
+	format  formatter
+	options formatOptions
+}
+
+func (m *formattedMessage) String() string {
+	return m.format(m.Message, m.time, m.options)
+}
+
+// monotonicClock returns a clock reading the wall time of start plus elapsed,
+// the monotonic time since start.
+func monotonicClock(start time.Time, elapsed func() time.Duration) func() time.Time {
+	wall := start.Round(0)
+	return func() time.Time {
+		return wall.Add(elapsed())
+	}
+}
+
+// unwrapMessage strips the wrappers added by Instance, formattedHandler and
+// maskingHandler.
+func unwrapMessage(msg log.Message) log.Message {
+	for {
+		switch m := msg.(type) {
+		case *labeledMessage:
+			msg = m.Message
+		case *formattedMessage:
+			msg = m.Message
+		case *maskedMessage:
+			msg = m.Message
+		default:
+			return msg
+		}
+	}
+}
+
+// fractionLayout returns the layout of the fractional seconds to render at
+// the given precision.
+func fractionLayout(precision TimestampPrecision) string {
+	switch precision {
+	case TimestampPrecision_Milliseconds:
+		return ".000"
+	case TimestampPrecision_Microseconds:
+		return ".000000"
+	case TimestampPrecision_Nanoseconds:
+		return ".000000000"
+	default:
+		return ""
+	}
+}
+
+// rfc3339Layout returns the layout of RFC 3339 timestamps at the given
+// precision.
+func rfc3339Layout(precision TimestampPrecision) string {
+	return "2006-01-02T15:04:05" + fractionLayout(precision) + "Z07:00"
+}
+
+// loadTimezone returns the location named by timezone, nil for the local time
+// zone if it is empty.
+func loadTimezone(timezone string) (*time.Location, error) {
+	if timezone == "" {
+		return nil, nil
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, newError("unknown timezone ", timezone).Base(err)
+	}
+	return location, nil
+}
+
+// formatTimestamp renders t in the time zone and with the time format of
+// options, or with layout if there is no time format.
+func formatTimestamp(t time.Time, layout string, options formatOptions) string {
+	if options.location != nil {
+		t = t.In(options.location)
+	}
+	switch options.timeFormat {
+	case "":
+		return t.Format(layout)
+	case "rfc3339":
+		return t.Format(rfc3339Layout(options.precision))
+	case "unix":
+		return strconv.FormatInt(t.Unix(), 10)
+	case "unixnano":
+		return strconv.FormatInt(t.UnixNano(), 10)
+	default:
+		return t.Format(options.timeFormat)
+	}
+}
+
+// sanitizeUTF8 applies policy to the bytes of s that are not valid UTF-8.
+func sanitizeUTF8(s string, policy InvalidUtf8Policy) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+	builder := strings.Builder{}
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		if r != utf8.RuneError || size != 1 {
+			builder.WriteString(s[:size])
+		} else {
+			switch policy {
+			case InvalidUtf8Policy_Utf8Escape:
+				builder.WriteString(`\x`)
+				builder.WriteByte("0123456789abcdef"[s[0]>>4])
+				builder.WriteByte("0123456789abcdef"[s[0]&0xf])
+			case InvalidUtf8Policy_Utf8Drop:
+			default:
+				builder.WriteRune(utf8.RuneError)
+			}
+		}
+		s = s[size:]
+	}
+	return builder.String()
+}
+
+// formatUntimed renders msg for writers adding the timestamp themselves.
+func formatUntimed(msg log.Message, t time.Time, options formatOptions) string {
+	return sanitizeUTF8(msg.String(), options.invalidUTF8)
+}
+
+// formatPlain renders msg the way the standard logger does, with the
+// timestamp at the given precision.
+func formatPlain(msg log.Message, t time.Time, options formatOptions) string {
+	return formatTimestamp(t, "2006/01/02 15:04:05"+fractionLayout(options.precision), options) + " " + sanitizeUTF8(msg.String(), options.invalidUTF8)
+}
+
+// recordField is a key and value of a structured record. Fields with several
+// values also have them in values, and the singular key of one in itemKey.
+type recordField struct {
+	key     string
+	value   string
+	values  []string
+	itemKey string
+}
+
+// recordFields returns the fields of msg rendered by the structured formats.
+func recordFields(msg log.Message, t time.Time, options formatOptions) []recordField {
+	var labels []label
+	if labeled, ok := msg.(*labeledMessage); ok {
+		msg = labeled.Message
+		labels = labeled.labels
+	}
+
+	fields := []recordField{{key: "time", value: formatTimestamp(t, rfc3339Layout(options.precision), options)}}
+	field := func(key, value string) {
+		fields = append(fields, recordField{key: key, value: sanitizeUTF8(value, options.invalidUTF8)})
+	}
+	switch msg := msg.(type) {
+	case *log.GeneralMessage:
+		field("level", strings.ToLower(msg.Severity.String()))
+		field("msg", serial.ToString(msg.Content))
+		if len(msg.TraceID) > 0 {
+			field("trace_id", msg.TraceID)
+			field("span_id", msg.SpanID)
+		}
+	case *log.AccessMessage:
+		field("type", "access")
+		field("from", serial.ToString(msg.From))
+		field("to", serial.ToString(msg.To))
+		field("status", string(msg.Status))
+		if len(msg.Detour) > 0 {
+			field("detour", msg.Detour)
+		}
+		if len(msg.InboundTag) > 0 {
+			field("inbound", msg.InboundTag)
+		}
+		if len(msg.InboundTransport) > 0 {
+			field("inbound_transport", msg.InboundTransport)
+		}
+		if len(msg.OutboundTransport) > 0 {
+			field("outbound_transport", msg.OutboundTransport)
+		}
+		if reason := serial.ToString(msg.Reason); len(reason) > 0 {
+			field("reason", reason)
+		}
+		if len(msg.Email) > 0 {
+			field("email", msg.Email)
+		}
+		if len(msg.Outcome) > 0 {
+			field("outcome", string(msg.Outcome))
+		}
+		if msg.Bytes > 0 {
+			field("bytes", strconv.FormatUint(msg.Bytes, 10))
+		}
+		if len(msg.TraceID) > 0 {
+			field("trace_id", msg.TraceID)
+			field("span_id", msg.SpanID)
+		}
+		for _, key := range msg.AttributeKeys() {
+			field(key, msg.Attributes[key])
+		}
+	case *log.DNSMessage:
+		field("type", "dns")
+		field("source", string(msg.Source))
+		if len(msg.Server) > 0 {
+			field("server", msg.Server)
+		}
+		field("domain", msg.Domain)
+		ips := make([]string, 0, len(msg.IPs))
+		for _, ip := range msg.IPs {
+			ips = append(ips, ip.String())
+		}
+		fields = append(fields, recordField{key: "ips", value: strings.Join(ips, ","), values: ips, itemKey: "ip"})
+		field("ttl", strconv.FormatUint(uint64(msg.TTL), 10))
+		if len(msg.QueryType) > 0 {
+			field("qtype", msg.QueryType)
+		}
+		if msg.Latency > 0 {
+			field("latency_ms", strconv.FormatInt(msg.Latency.Milliseconds(), 10))
+		}
+		if msg.SessionID > 0 {
+			field("session", strconv.FormatUint(uint64(msg.SessionID), 10))
+		}
+		if len(msg.InboundTag) > 0 {
+			field("inbound", msg.InboundTag)
+		}
+		if len(msg.TraceID) > 0 {
+			field("trace_id", msg.TraceID)
+			field("span_id", msg.SpanID)
+		}
+	case *log.PolicyMessage:
+		field("type", "policy")
+		if msg.SessionID > 0 {
+			field("session", strconv.FormatUint(uint64(msg.SessionID), 10))
+		}
+		field("action", string(msg.Action))
+		if reason := serial.ToString(msg.Reason); len(reason) > 0 {
+			field("reason", reason)
+		}
+	case *log.ConfigMessage:
+		field("type", "config")
+		field("level", strings.ToLower(msg.Severity().String()))
+		field("section", msg.Section)
+		field("status", string(msg.Status))
+		if msg.Reason != nil {
+			field("reason", msg.Reason.Error())
+		}
+	default:
+		field("msg", msg.String())
+	}
+	for _, l := range labels {
+		field(l.key, l.value)
+	}
+	return fields
+}
+
+// expandRepeated returns the records to render for fields, one for each value
+// of the field with several values in RepeatedLines mode.
+func expandRepeated(fields []recordField, mode RepeatedFieldMode) [][]recordField {
+	if mode != RepeatedFieldMode_RepeatedLines {
+		return [][]recordField{fields}
+	}
+	for i, f := range fields {
+		if f.itemKey == "" || len(f.values) < 2 {
+			continue
+		}
+		records := make([][]recordField, 0, len(f.values))
+		for _, value := range f.values {
+			record := append([]recordField(nil), fields...)
+			record[i] = recordField{key: f.itemKey, value: value}
+			records = append(records, record)
+		}
+		return records
+	}
+	return [][]recordField{fields}
+}
+
+func formatLogfmt(msg log.Message, t time.Time, options formatOptions) string {
+	var lines []string
+	for _, record := range expandRepeated(recordFields(msg, t, options), options.repeatedFields) {
+		encoder := &logfmtEncoder{}
+		for _, f := range record {
+			encoder.field(f.key, f.value)
+		}
+		lines = append(lines, encoder.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// jsonSchemaVersion is the schema_version of JSON records. Bump it when fields
+// are renamed or removed, or change their meaning.
+const jsonSchemaVersion = 1
+
+// formatJSON renders msg as a single line JSON object.
+func formatJSON(msg log.Message, t time.Time, options formatOptions) string {
+	builder := &strings.Builder{}
+	for i, record := range expandRepeated(recordFields(msg, t, options), options.repeatedFields) {
+		if i > 0 {
+			builder.WriteByte('\n')
+		}
+		builder.WriteString(`{"schema_version":`)
+		builder.WriteString(strconv.Itoa(jsonSchemaVersion))
+		for _, f := range record {
+			builder.WriteByte(',')
+			writeJSONString(builder, f.key)
+			builder.WriteByte(':')
+			if f.itemKey != "" && options.repeatedFields == RepeatedFieldMode_RepeatedArray {
+				writeJSONArray(builder, f.values)
+			} else {
+				writeJSONString(builder, f.value)
+			}
+		}
+		builder.WriteByte('}')
+	}
+	if options.indent {
+		return indentJSON(builder.String())
+	}
+	return builder.String()
+}
+
+// indentJSON indents each of the records of s, one per line.
+func indentJSON(s string) string {
+	records := strings.Split(s, "\n")
+	for i, record := range records {
+		indented := &bytes.Buffer{}
+		if err := json.Indent(indented, []byte(record), "", "  "); err == nil {
+			records[i] = indented.String()
+		}
+	}
+	return strings.Join(records, "\n")
+}
+
+func writeJSONArray(builder *strings.Builder, values []string) {
+	builder.WriteByte('[')
+	for i, value := range values {
+		if i > 0 {
+			builder.WriteByte(',')
+		}
+		writeJSONString(builder, value)
+	}
+	builder.WriteByte(']')
+}
+
+func writeJSONString(builder *strings.Builder, s string) {
+	builder.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			builder.WriteByte('\\')
+			builder.WriteRune(r)
+		case '\n':
+			builder.WriteString(`\n`)
+		case '\r':
+			builder.WriteString(`\r`)
+		case '\t':
+			builder.WriteString(`\t`)
+		default:
+			if r < ' ' {
+				builder.WriteString(`\u00`)
+				builder.WriteByte("0123456789abcdef"[r>>4])
+				builder.WriteByte("0123456789abcdef"[r&0xf])
+			} else {
+				builder.WriteRune(r)
+			}
+		}
+	}
+	builder.WriteByte('"')
+}
+
+// logfmtEncoder writes space separated key=value pairs, quoting values where
+// needed.
+type logfmtEncoder struct {
+	strings.Builder
+}
+
+func (e *logfmtEncoder) field(key, value string) {
+	if e.Len() > 0 {
+		e.WriteByte(' ')
+	}
+	e.WriteString(key)
+	e.WriteByte('=')
+	if !logfmtNeedsQuote(value) {
+		e.WriteString(value)
+		return
+	}
+	e.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '"', '\\':
+			e.WriteByte('\\')
+			e.WriteRune(r)
+		case '\n':
+			e.WriteString(`\n`)
+		case '\r':
+			e.WriteString(`\r`)
+		case '\t':
+			e.WriteString(`\t`)
+		default:
+			if r < ' ' {
+				e.WriteString(`\u00`)
+				e.WriteByte("0123456789abcdef"[r>>4])
+				e.WriteByte("0123456789abcdef"[r&0xf])
+			} else {
+				e.WriteRune(r)
+			}
+		}
+	}
+	e.WriteByte('"')
+}
+
+func logfmtNeedsQuote(value string) bool {
+	if value == "" {
+		return true
+	}
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError {
+			return true
+		}
+	}
+	return false
+}
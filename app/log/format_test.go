@@ -0,0 +1,300 @@
+package log
+
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/v2fly/v2ray-core/v5/common/log"
+)
+
+func TestTimestampPrecision(t *testing.T) {
+	instant := time.Date(2022, 8, 1, 12, 34, 56, 123456789, time.UTC)
+	msg := &log.GeneralMessage{Severity: log.Severity_Info, Content: "test"}
+
+	cases := []struct {
+		precision TimestampPrecision
+		plain     string
+		logfmt    string
+	}{
+		{TimestampPrecision_Seconds, "2022/08/01 12:34:56", "2022-08-01T12:34:56Z"},
+		{TimestampPrecision_Milliseconds, "2022/08/01 12:34:56.123", "2022-08-01T12:34:56.123Z"},
+		{TimestampPrecision_Microseconds, "2022/08/01 12:34:56.123456", "2022-08-01T12:34:56.123456Z"},
+		{TimestampPrecision_Nanoseconds, "2022/08/01 12:34:56.123456789", "2022-08-01T12:34:56.123456789Z"},
+	}
+	for _, c := range cases {
+		if s := formatPlain(msg, instant, formatOptions{precision: c.precision}); s != c.plain+" [Info] test" {
+			t.Error(c.precision, ": unexpected plain record: ", s)
+		}
+		if s := formatLogfmt(msg, instant, formatOptions{precision: c.precision}); !strings.HasPrefix(s, "time="+c.logfmt+" ") {
+			t.Error(c.precision, ": unexpected logfmt record: ", s)
+		}
+	}
+}
+
+func TestTimeFormat(t *testing.T) {
+	instant := time.Date(2022, 8, 1, 14, 34, 56, 123456789, time.FixedZone("CEST", 2*60*60))
+	msg := &log.GeneralMessage{Severity: log.Severity_Info, Content: "test"}
+
+	cases := []struct {
+		timeFormat string
+		timezone   string
+		plain      string
+		logfmt     string
+	}{
+		{"", "", "2022/08/01 14:34:56", "2022-08-01T14:34:56+02:00"},
+		{"", "UTC", "2022/08/01 12:34:56", "2022-08-01T12:34:56Z"},
+		{"rfc3339", "UTC", "2022-08-01T12:34:56.123Z", "2022-08-01T12:34:56.123Z"},
+		{"rfc3339", "", "2022-08-01T14:34:56.123+02:00", "2022-08-01T14:34:56.123+02:00"},
+		{"unix", "", "1659357296", "1659357296"},
+		{"unixnano", "UTC", "1659357296123456789", "1659357296123456789"},
+		{"Jan _2 15:04:05.000000", "UTC", "Aug  1 12:34:56.123456", `"Aug  1 12:34:56.123456"`},
+	}
+	for _, c := range cases {
+		location, err := loadTimezone(c.timezone)
+		if err != nil {
+			t.Fatal(err)
+		}
+		options := formatOptions{precision: TimestampPrecision_Milliseconds, timeFormat: c.timeFormat, location: location}
+		if c.timeFormat == "" {
+			options.precision = TimestampPrecision_Seconds
+		}
+		if s := formatPlain(msg, instant, options); s != c.plain+" [Info] test" {
+			t.Error(c.timeFormat, " ", c.timezone, ": unexpected plain record: ", s)
+		}
+		if s := formatLogfmt(msg, instant, options); !strings.HasPrefix(s, "time="+c.logfmt+" ") {
+			t.Error(c.timeFormat, " ", c.timezone, ": unexpected logfmt record: ", s)
+		}
+	}
+
+	// The writer does not prefix its own timestamp in local time.
+	collected := &collectingHandler{}
+	newFormattedHandler(collected, &LogSpecification{TimeFormat: "unix"}, nil).Handle(msg)
+	if s := (*collected)[0]; !strings.HasSuffix(s, " [Info] test") || strings.HasPrefix(s, "[Info]") {
+		t.Error("expected a timestamp, but actually ", s)
+	}
+	if !(HandlerCreatorOptions{Timezone: "UTC"}).selfTimestamped() {
+		t.Error("a writer prefixing timestamps would prefix them in local time")
+	}
+
+	if _, err := loadTimezone("Nowhere/Special"); err == nil {
+		t.Error("expected error for unknown timezone")
+	}
+}
+
+func TestJSONSchemaVersion(t *testing.T) {
+	msg := &labeledMessage{
+		Message: &log.GeneralMessage{Severity: log.Severity_Warning, Content: "say \"hi\"\n"},
+		labels:  []label{{key: "region", value: "eu west"}},
+	}
+	s := formatJSON(msg, time.Now(), formatOptions{})
+
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &record); err != nil {
+		t.Fatal("invalid JSON record ", s, ": ", err)
+	}
+	if version, ok := record["schema_version"].(float64); !ok || version != jsonSchemaVersion {
+		t.Error("expected schema_version ", jsonSchemaVersion, ", but actually ", record["schema_version"])
+	}
+	if record["level"] != "warning" || record["msg"] != "say \"hi\"\n" || record["region"] != "eu west" {
+		t.Error("unexpected JSON record: ", s)
+	}
+}
+
+func TestJSONAccessRecord(t *testing.T) {
+	msg := &log.AccessMessage{
+		From:       "127.0.0.1:1001",
+		To:         "tcp:example.com:443",
+		Status:     log.AccessAccepted,
+		InboundTag: "socks",
+		Detour:     "proxy",
+		Email:      "user@example.com",
+	}
+	s := formatJSON(msg, time.Now(), formatOptions{})
+
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &record); err != nil {
+		t.Fatal("invalid JSON record ", s, ": ", err)
+	}
+	expected := map[string]string{
+		"type":    "access",
+		"from":    "127.0.0.1:1001",
+		"to":      "tcp:example.com:443",
+		"status":  "accepted",
+		"inbound": "socks",
+		"detour":  "proxy",
+		"email":   "user@example.com",
+	}
+	for key, value := range expected {
+		if record[key] != value {
+			t.Error("expected ", key, " ", value, ", but actually ", record[key])
+		}
+	}
+	if _, ok := record["time"].(string); !ok {
+		t.Error("expected a time in the JSON record: ", s)
+	}
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	msg := &log.GeneralMessage{Severity: log.Severity_Info, Content: "a\xffb\xe2\x82c \u00e9\ufffd"}
+
+	cases := []struct {
+		policy  InvalidUtf8Policy
+		content string
+	}{
+		{InvalidUtf8Policy_Utf8Replace, "a\ufffdb\ufffd\ufffdc \u00e9\ufffd"},
+		{InvalidUtf8Policy_Utf8Escape, `a\xffb\xe2\x82c ` + "\u00e9\ufffd"},
+		{InvalidUtf8Policy_Utf8Drop, "abc \u00e9\ufffd"},
+	}
+	for _, c := range cases {
+		options := formatOptions{invalidUTF8: c.policy}
+		if s := formatUntimed(msg, time.Now(), options); s != "[Info] "+c.content {
+			t.Error(c.policy, ": unexpected plain record: ", s)
+		}
+		if s := formatLogfmt(msg, time.Now(), options); !strings.HasSuffix(s, ` msg="`+strings.ReplaceAll(c.content, `\`, `\\`)+`"`) {
+			t.Error(c.policy, ": unexpected logfmt record: ", s)
+		}
+
+		s := formatJSON(msg, time.Now(), options)
+		if !utf8.ValidString(s) {
+			t.Error(c.policy, ": invalid UTF-8 in JSON record: ", s)
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(s), &record); err != nil {
+			t.Error(c.policy, ": invalid JSON record ", s, ": ", err)
+		} else if record["msg"] != c.content {
+			t.Error(c.policy, ": unexpected JSON msg: ", record["msg"])
+		}
+	}
+}
+
+func TestRepeatedFieldMode(t *testing.T) {
+	msg := &log.DNSMessage{
+		Server: "1.1.1.1",
+		Domain: "example.com",
+		IPs:    []net.IP{net.ParseIP("93.184.216.34"), net.ParseIP("2606:2800:220:1::")},
+		Source: log.DNSSourceUpstream,
+		TTL:    300,
+	}
+
+	joined := formatJSON(msg, time.Now(), formatOptions{})
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(joined), &record); err != nil {
+		t.Fatal("invalid JSON record ", joined, ": ", err)
+	}
+	if record["ips"] != "93.184.216.34,2606:2800:220:1::" {
+		t.Error("unexpected joined record: ", joined)
+	}
+
+	array := formatJSON(msg, time.Now(), formatOptions{repeatedFields: RepeatedFieldMode_RepeatedArray})
+	var arrayRecord struct {
+		Domain string   `json:"domain"`
+		IPs    []string `json:"ips"`
+	}
+	if err := json.Unmarshal([]byte(array), &arrayRecord); err != nil {
+		t.Fatal("invalid JSON record ", array, ": ", err)
+	}
+	if arrayRecord.Domain != "example.com" || len(arrayRecord.IPs) != 2 || arrayRecord.IPs[0] != "93.184.216.34" || arrayRecord.IPs[1] != "2606:2800:220:1::" {
+		t.Error("unexpected array record: ", array)
+	}
+
+	lines := strings.Split(formatJSON(msg, time.Now(), formatOptions{repeatedFields: RepeatedFieldMode_RepeatedLines}), "\n")
+	if len(lines) != 2 {
+		t.Fatal("expected a record per IP, but actually ", lines)
+	}
+	for i, ip := range []string{"93.184.216.34", "2606:2800:220:1::"} {
+		var lineRecord map[string]interface{}
+		if err := json.Unmarshal([]byte(lines[i]), &lineRecord); err != nil {
+			t.Fatal("invalid JSON record ", lines[i], ": ", err)
+		}
+		if lineRecord["ip"] != ip || lineRecord["domain"] != "example.com" || lineRecord["ips"] != nil {
+			t.Error("unexpected record for ", ip, ": ", lines[i])
+		}
+	}
+
+	logfmtLines := strings.Split(formatLogfmt(msg, time.Now(), formatOptions{repeatedFields: RepeatedFieldMode_RepeatedLines}), "\n")
+	if len(logfmtLines) != 2 || !strings.Contains(logfmtLines[0], " ip=93.184.216.34 ") || !strings.Contains(logfmtLines[1], " ip=2606:2800:220:1:: ") {
+		t.Error("unexpected logfmt records: ", logfmtLines)
+	}
+}
+
+// steppedClock stands for a system clock whose wall time can be set back,
+// while its monotonic time only moves forward.
+type steppedClock struct {
+	wall    time.Time
+	elapsed time.Duration
+}
+
+func (c *steppedClock) advance(d time.Duration) {
+	c.wall = c.wall.Add(d)
+	c.elapsed += d
+}
+
+type timeRecorder []time.Time
+
+func (r *timeRecorder) Handle(msg log.Message) {
+	*r = append(*r, msg.(*formattedMessage).time)
+}
+
+func TestMonotonicTimestamps(t *testing.T) {
+	record := func(now func(*steppedClock) func() time.Time) timeRecorder {
+		clock := &steppedClock{wall: time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC)}
+		recorder := timeRecorder{}
+		handler := newFormattedHandler(&recorder, &LogSpecification{}, now(clock))
+		msg := &log.GeneralMessage{Severity: log.Severity_Info, Content: "test"}
+		handler.Handle(msg)
+		clock.advance(time.Second)
+		handler.Handle(msg)
+		// An NTP correction sets the clock back by a minute.
+		clock.wall = clock.wall.Add(-time.Minute)
+		clock.advance(time.Second)
+		handler.Handle(msg)
+		return recorder
+	}
+
+	wall := record(func(c *steppedClock) func() time.Time {
+		return func() time.Time { return c.wall }
+	})
+	if !wall[2].Before(wall[1]) {
+		t.Fatal("expected wall clock timestamps to go back, but actually ", wall)
+	}
+
+	monotonic := record(func(c *steppedClock) func() time.Time {
+		return monotonicClock(c.wall, func() time.Duration { return c.elapsed })
+	})
+	for i := 1; i < len(monotonic); i++ {
+		if monotonic[i].Before(monotonic[i-1]) {
+			t.Error("timestamp ", i, " went back from ", monotonic[i-1], " to ", monotonic[i])
+		}
+	}
+	if expected := time.Date(2022, 8, 1, 12, 0, 2, 0, time.UTC); !monotonic[2].Equal(expected) {
+		t.Error("expected ", expected, ", but actually ", monotonic[2])
+	}
+}
+
+func TestIndentJSON(t *testing.T) {
+	msg := &log.GeneralMessage{Severity: log.Severity_Warning, Content: "indented"}
+	now := time.Now()
+
+	compact := formatJSON(msg, now, formatOptions{})
+	if strings.Contains(compact, "\n") {
+		t.Error("expected a record on a single line, but actually ", compact)
+	}
+	indented := formatJSON(msg, now, formatOptions{indent: true})
+	if !strings.HasPrefix(indented, "{\n  \"schema_version\": ") || !strings.HasSuffix(indented, "\n}") {
+		t.Error("expected an indented record, but actually ", indented)
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(indented), &record); err != nil || record["msg"] != "indented" {
+		t.Error("invalid indented record ", indented, ": ", err)
+	}
+
+	// HTTP posts records a line each, they are never indented.
+	handler := newFormattedHandler(log.NewLogger(nil), &LogSpecification{Type: LogType_HTTP, IndentJson: true}, nil).(*formattedHandler)
+	if handler.options.indent {
+		t.Error("expected records posted over HTTP not to be indented")
+	}
+}
@@ -0,0 +1,180 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+	"github.com/v2fly/v2ray-core/v5/common/retry"
+	"github.com/v2fly/v2ray-core/v5/common/signal/done"
+)
+
+const (
+	defaultHTTPBatchSize = 64
+	httpAttempts         = 3
+	httpRetryDelay       = 500 // milliseconds, growing linearly
+	httpRequestTimeout   = 10 * time.Second
+)
+
+// httpHandler posts records as JSON arrays to an HTTP endpoint, in batches of
+// up to batchSize or what arrived within interval. Failed posts are retried
+// on network errors and 5xx responses, then the batch is dropped.
+type httpHandler struct {
+	endpoint  string
+	headers   map[string]string
+	client    *http.Client
+	batchSize int
+	records   chan string
+	dropped   uint64 // accessed atomically
+	done      *done.Instance
+	finished  chan struct{}
+}
+
+func newHTTPHandler(endpoint string, headers map[string]string, batchSize uint32, interval time.Duration) (*httpHandler, error) {
+	if endpoint == "" {
+		return nil, newError("no endpoint for http log")
+	}
+	if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, newError("invalid endpoint for http log: ", endpoint)
+	}
+	if batchSize == 0 {
+		batchSize = defaultHTTPBatchSize
+	}
+	if interval <= 0 {
+		interval = defaultFlushInterval
+	}
+	h := &httpHandler{
+		endpoint:  endpoint,
+		headers:   headers,
+		client:    &http.Client{Timeout: httpRequestTimeout},
+		batchSize: int(batchSize),
+		records:   make(chan string, batchSize*16),
+		done:      done.New(),
+		finished:  make(chan struct{}),
+	}
+	go h.run(interval)
+	return h, nil
+}
+
+// Handle implements log.Handler. Records are dropped and counted if posting
+// falls behind.
+func (h *httpHandler) Handle(msg log.Message) {
+	for _, record := range strings.Split(msg.String(), "\n") {
+		if record == "" {
+			continue
+		}
+		select {
+		case h.records <- record:
+		default:
+			atomic.AddUint64(&h.dropped, 1)
+		}
+	}
+}
+
+// Dropped returns the number of records dropped so far.
+func (h *httpHandler) Dropped() uint64 {
+	return atomic.LoadUint64(&h.dropped)
+}
+
+func (h *httpHandler) run(interval time.Duration) {
+	defer close(h.finished)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	batch := make([]string, 0, h.batchSize)
+	for {
+		select {
+		case record := <-h.records:
+			batch = append(batch, record)
+			if len(batch) < h.batchSize {
+				continue
+			}
+		case <-ticker.C:
+		case <-h.done.Wait():
+			for {
+				select {
+				case record := <-h.records:
+					batch = append(batch, record)
+					if len(batch) >= h.batchSize {
+						h.flush(batch)
+						batch = batch[:0]
+					}
+				default:
+					h.flush(batch)
+					return
+				}
+			}
+		}
+		h.flush(batch)
+		batch = batch[:0]
+	}
+}
+
+func (h *httpHandler) flush(batch []string) {
+	if len(batch) == 0 {
+		return
+	}
+	body := []byte("[" + strings.Join(batch, ",") + "]")
+	var permanent error
+	err := retry.ExponentialBackoff(httpAttempts, httpRetryDelay).On(func() error {
+		retriable, err := h.post(body)
+		if err != nil && !retriable {
+			permanent = err
+			return nil
+		}
+		return err
+	})
+	if err == nil {
+		err = permanent
+	}
+	if err != nil {
+		dropped := atomic.AddUint64(&h.dropped, uint64(len(batch)))
+		newError("failed to post ", len(batch), " log records to ", h.endpoint, ", ", dropped, " dropped so far").Base(err).AtWarning().WriteToLog()
+	}
+}
+
+// post sends body once, returning whether a failure is worth retrying.
+func (h *httpHandler) post(body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, h.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range h.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return true, newError("http log endpoint returned ", resp.Status)
+	}
+	if resp.StatusCode >= 300 {
+		return false, newError("http log endpoint returned ", resp.Status)
+	}
+	return false, nil
+}
+
+// Close implements common.Closable. Pending records are posted first.
+func (h *httpHandler) Close() error {
+	h.done.Close()
+	<-h.finished
+	h.client.CloseIdleConnections()
+	return nil
+}
+
+func init() {
+	common.Must(RegisterHandlerCreator(LogType_HTTP, func(lt LogType, options HandlerCreatorOptions) (log.Handler, error) {
+		return newHTTPHandler(options.HTTPEndpoint, options.HTTPHeaders, options.HTTPBatchSize, options.BatchInterval)
+	}))
+}
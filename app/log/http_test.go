@@ -0,0 +1,113 @@
+package log
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+)
+
+// httpRecorder serves a log endpoint answering with the given statuses in
+// turn, then 200, and keeps the bodies it received.
+type httpRecorder struct {
+	sync.Mutex
+	statuses []int
+	bodies   []string
+	auth     []string
+}
+
+func (r *httpRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.Lock()
+	defer r.Unlock()
+	r.bodies = append(r.bodies, string(body))
+	r.auth = append(r.auth, req.Header.Get("Authorization"))
+	if len(r.statuses) > 0 {
+		w.WriteHeader(r.statuses[0])
+		r.statuses = r.statuses[1:]
+	}
+}
+
+func TestHTTPLog(t *testing.T) {
+	recorder := &httpRecorder{statuses: []int{http.StatusServiceUnavailable}}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	handler, err := createSpecHandler(&LogSpecification{
+		Type:          LogType_HTTP,
+		HttpEndpoint:  server.URL,
+		HttpHeaders:   map[string]string{"Authorization": "Bearer token"},
+		HttpBatchSize: 2,
+		BatchInterval: uint32(time.Hour / time.Millisecond),
+	}, nil)
+	common.Must(err)
+	for _, content := range []string{"one", "two", "three"} {
+		handler.Handle(&log.GeneralMessage{Severity: log.Severity_Warning, Content: content})
+	}
+	// The first batch is sent when full, the partial one on close.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		recorder.Lock()
+		n := len(recorder.bodies)
+		recorder.Unlock()
+		if n == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("first batch was not retried")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	common.Must(common.Close(handler))
+
+	recorder.Lock()
+	defer recorder.Unlock()
+	if len(recorder.bodies) != 3 {
+		t.Fatal("expected 3 requests, but actually ", len(recorder.bodies))
+	}
+	if recorder.bodies[0] != recorder.bodies[1] {
+		t.Error("retry sent a different batch: ", recorder.bodies[1])
+	}
+	expected := [][]string{{"one", "two"}, {"one", "two"}, {"three"}}
+	for i, body := range recorder.bodies {
+		var records []map[string]interface{}
+		if err := json.Unmarshal([]byte(body), &records); err != nil {
+			t.Fatal("invalid payload ", body, ": ", err)
+		}
+		if len(records) != len(expected[i]) {
+			t.Fatal("expected ", len(expected[i]), " records, but actually ", body)
+		}
+		for j, record := range records {
+			if record["msg"] != expected[i][j] {
+				t.Error("expected msg ", expected[i][j], ", but actually ", record["msg"])
+			}
+		}
+		if recorder.auth[i] != "Bearer token" {
+			t.Error("expected authorization header, but actually ", recorder.auth[i])
+		}
+	}
+}
+
+func TestHTTPLogDropped(t *testing.T) {
+	recorder := &httpRecorder{statuses: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	handler, err := newHTTPHandler(server.URL, nil, 1, time.Hour)
+	common.Must(err)
+	handler.Handle(&log.GeneralMessage{Severity: log.Severity_Warning, Content: "lost"})
+	common.Must(handler.Close())
+
+	if dropped := handler.Dropped(); dropped != 1 {
+		t.Error("expected 1 dropped record, but actually ", dropped)
+	}
+	if _, err := newHTTPHandler("ftp://example.com", nil, 0, 0); err == nil {
+		t.Error("expected an error for a non-http endpoint")
+	}
+}
@@ -0,0 +1,97 @@
+package log
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/v2fly/v2ray-core/v5/common/log"
+)
+
+var labelKeyRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reservedLabels are the names of the fields of structured records, which a
+// static label would collide with.
+var reservedLabels = map[string]bool{
+	"time":           true,
+	"level":          true,
+	"msg":            true,
+	"type":           true,
+	"seq":            true,
+	"schema_version": true,
+}
+
+type label struct {
+	key   string
+	value string
+}
+
+func parseStaticLabels(labels map[string]string) ([]label, error) {
+	if len(labels) == 0 {
+		return nil, nil
+	}
+	parsed := make([]label, 0, len(labels))
+	for key, value := range labels {
+		if !labelKeyRegexp.MatchString(key) {
+			return nil, newError("invalid static label name: ", key)
+		}
+		if reservedLabels[key] {
+			return nil, newError("reserved static label name: ", key)
+		}
+		parsed = append(parsed, label{key: key, value: value})
+	}
+	sort.Slice(parsed, func(i, j int) bool {
+		return parsed[i].key < parsed[j].key
+	})
+	return parsed, nil
+}
+
+// hostnameLabel returns the hostname label for source, and false if there is
+// none.
+func hostnameLabel(source HostnameSource, value string) (label, bool, error) {
+	var hostname string
+	switch source {
+	case HostnameSource_NoHostname:
+		return label{}, false, nil
+	case HostnameSource_OSHostname:
+		name, err := os.Hostname()
+		if err != nil {
+			return label{}, false, newError("failed to get hostname").Base(err)
+		}
+		hostname = name
+	case HostnameSource_StaticHostname:
+		hostname = value
+	case HostnameSource_EnvHostname:
+		hostname = os.Getenv(value)
+		if hostname == "" {
+			return label{}, false, newError("environment variable ", value, " for the hostname is not set")
+		}
+	default:
+		return label{}, false, newError("unknown hostname source ", source)
+	}
+	return label{key: "hostname", value: hostname}, true, nil
+}
+
+// labeledMessage is a log.Message with static labels attached.
+type labeledMessage struct {
+	log.Message
+	labels []label
+}
+
+func (m *labeledMessage) String() string {
+	builder := strings.Builder{}
+	builder.WriteString(m.Message.String())
+	for _, l := range m.labels {
+		builder.WriteByte(' ')
+		builder.WriteString(l.key)
+		builder.WriteByte('=')
+		if l.value == "" || strings.ContainsAny(l.value, " \"=") {
+			builder.WriteString(strconv.Quote(l.value))
+		} else {
+			builder.WriteString(l.value)
+		}
+	}
+	return builder.String()
+}
@@ -4,20 +4,41 @@ package log
 
 import (
 	"context"
+	"os"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/errors"
 	"github.com/v2fly/v2ray-core/v5/common/log"
+	"github.com/v2fly/v2ray-core/v5/common/platform"
 )
 
+// levelEnvFlag overrides the configured error log level, e.g.
+// V2RAY_LOG_LEVEL=debug.
+var levelEnvFlag = platform.NewEnvFlag("v2ray.log.level")
+
 // Instance is a log.Handler that handles logs.
 type Instance struct {
 	sync.RWMutex
 	config       *Config
 	accessLogger log.Handler
 	errorLogger  log.Handler
+	policyLogger log.Handler
+	dnsLogger    log.Handler
 	followers    map[reflect.Value]func(msg log.Message)
+	accessSinks  []AccessHandler
+	labels       []label
+	excludedTags map[string]bool
+	overrides    debugOverrides
+	traces       *traceGenerator
+	seq          uint64
+	now          func() time.Time
 	active       bool
 }
 
@@ -31,10 +52,55 @@ func New(ctx context.Context, config *Config) (*Instance, error) {
 		config.Access = &LogSpecification{Type: LogType_None}
 	}
 
+	if config.Policy == nil {
+		config.Policy = &LogSpecification{Type: LogType_None}
+	}
+
+	levelOverride := levelEnvFlag.GetValue(func() string { return "" })
+	overridden := false
+	if levelOverride != "" {
+		if level, ok := parseSeverity(levelOverride); ok {
+			config.Error.Level = level
+			overridden = true
+		}
+	}
+
+	labels, err := parseStaticLabels(config.StaticLabels)
+	if err != nil {
+		return nil, err
+	}
+	hostname, ok, err := hostnameLabel(config.HostnameSource, config.Hostname)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if _, found := config.StaticLabels[hostname.key]; found {
+			return nil, newError("static label ", hostname.key, " conflicts with the hostname source")
+		}
+		labels = append(labels, hostname)
+		sort.Slice(labels, func(i, j int) bool {
+			return labels[i].key < labels[j].key
+		})
+	}
+
 	g := &Instance{
 		config: config,
+		labels: labels,
 		active: false,
 	}
+	if config.MonotonicTimestamps {
+		start := time.Now()
+		g.now = monotonicClock(start, func() time.Duration { return time.Since(start) })
+	}
+	if config.TraceContext.GetGenerate() {
+		g.traces = newTraceGenerator()
+	}
+	if len(config.Access.ExcludeTags) > 0 {
+		g.excludedTags = make(map[string]bool, len(config.Access.ExcludeTags))
+		for _, tag := range config.Access.ExcludeTags {
+			g.excludedTags[tag] = true
+		}
+	}
 	log.RegisterHandler(g)
 
 	// start logger instantly on inited
@@ -44,13 +110,53 @@ func New(ctx context.Context, config *Config) (*Instance, error) {
 	}
 
 	newError("Logger started").AtDebug().WriteToLog()
+	log.FlushConfig()
+	// Logged once started, handlers are created with the instance locked.
+	for _, spec := range []*LogSpecification{config.Error, config.Access, config.Policy, config.Dns} {
+		warnIndentJSON(spec)
+	}
+	if overridden {
+		newError("error log level set to ", config.Error.Level, " by ", levelEnvFlag.AltName).AtInfo().WriteToLog()
+	} else if levelOverride != "" {
+		newError("ignoring invalid log level ", levelOverride, " in ", levelEnvFlag.AltName).AtWarning().WriteToLog()
+	}
 	return g, nil
 }
 
+// warnIndentJSON warns of indent_json set on spec or its outputs and routes
+// other than the console, likely read by tools expecting a record per line.
+func warnIndentJSON(spec *LogSpecification) {
+	if spec == nil {
+		return
+	}
+	if spec.IndentJson && spec.Format == LogFormat_JSON && spec.Type != LogType_Console && spec.Type != LogType_None {
+		newError("indent_json set for a ", spec.Type, " log, records span several lines instead of one").AtWarning().WriteToLog()
+	}
+	for _, output := range spec.Outputs {
+		warnIndentJSON(output)
+	}
+	for _, route := range spec.SeverityRoutes {
+		warnIndentJSON(route)
+	}
+}
+
+func parseSeverity(s string) (log.Severity, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return log.Severity_Debug, true
+	case "info":
+		return log.Severity_Info, true
+	case "warning":
+		return log.Severity_Warning, true
+	case "error":
+		return log.Severity_Error, true
+	default:
+		return log.Severity_Unknown, false
+	}
+}
+
 func (g *Instance) initAccessLogger() error {
-	handler, err := createHandler(g.config.Access.Type, HandlerCreatorOptions{
-		Path: g.config.Access.Path,
-	})
+	handler, err := createSpecHandler(g.config.Access, g.now)
 	if err != nil {
 		return err
 	}
@@ -59,9 +165,7 @@ func (g *Instance) initAccessLogger() error {
 }
 
 func (g *Instance) initErrorLogger() error {
-	handler, err := createHandler(g.config.Error.Type, HandlerCreatorOptions{
-		Path: g.config.Error.Path,
-	})
+	handler, err := createSpecHandler(g.config.Error, g.now)
 	if err != nil {
 		return err
 	}
@@ -69,6 +173,173 @@ func (g *Instance) initErrorLogger() error {
 	return nil
 }
 
+// initDNSLogger creates the DNS log, if configured, or leaves DNS queries to
+// the error log.
+func (g *Instance) initDNSLogger() error {
+	if g.config.Dns == nil {
+		return nil
+	}
+	handler, err := createSpecHandler(g.config.Dns, g.now)
+	if err != nil {
+		return err
+	}
+	g.dnsLogger = handler
+	return nil
+}
+
+func (g *Instance) initPolicyLogger() error {
+	handler, err := createSpecHandler(g.config.Policy, g.now)
+	if err != nil {
+		return err
+	}
+	g.policyLogger = handler
+	return nil
+}
+
+// createSpecHandler creates the handler of a channel, writing to the outputs
+// of spec, each in its own format, with timestamps from now if it is not nil.
+func createSpecHandler(spec *LogSpecification, now func() time.Time) (log.Handler, error) {
+	filters, err := compileCategoryFilters(spec.CategoryFilters)
+	if err != nil {
+		return nil, err
+	}
+	handler, err := createOutputsHandler(spec, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(spec.SeverityRoutes) > 0 {
+		router, err := newSeverityRouter(spec.SeverityRoutes, handler, now)
+		if err != nil {
+			common.Close(handler)
+			return nil, err
+		}
+		handler = router
+	}
+	// Filtered first, so denied records are not formatted for sampling.
+	return newCategoryHandler(newSamplingHandler(handler, spec), filters), nil
+}
+
+// createOutputsHandler creates the handler writing to spec and its outputs.
+func createOutputsHandler(spec *LogSpecification, now func() time.Time) (log.Handler, error) {
+	var handlers multiHandler
+	for _, output := range append([]*LogSpecification{spec}, spec.Outputs...) {
+		if output.Legacy && spec.DropLegacy {
+			continue
+		}
+		chainKey, err := loadChainKey(output)
+		if err != nil {
+			handlers.Close()
+			return nil, err
+		}
+		masks, err := compileMasks(output.Masking)
+		if err != nil {
+			handlers.Close()
+			return nil, err
+		}
+		if _, err := loadTimezone(output.Timezone); err != nil {
+			handlers.Close()
+			return nil, err
+		}
+		handler, err := createHandler(output.Type, HandlerCreatorOptions{
+			Path:               output.Path,
+			Format:             output.Format,
+			TimestampPrecision: output.TimestampPrecision,
+			MaxRecords:         output.MaxRecords,
+			FifoPolicy:         output.FifoPolicy,
+			Interval:           time.Duration(output.TextfileInterval) * time.Second,
+			FlushInterval:      time.Duration(output.FlushInterval) * time.Millisecond,
+			BatchSize:          output.BatchSize,
+			BatchInterval:      time.Duration(output.BatchInterval) * time.Millisecond,
+			HTTPEndpoint:       output.HttpEndpoint,
+			HTTPHeaders:        output.HttpHeaders,
+			HTTPBatchSize:      output.HttpBatchSize,
+			ChainKey:           chainKey,
+			Rotation: log.RotationOptions{
+				MaxSize:    int64(output.MaxSizeMb) << 20,
+				MaxBackups: int(output.MaxBackups),
+				MaxAge:     time.Duration(output.MaxAgeDays) * 24 * time.Hour,
+				Compress:   output.Compress,
+			},
+			SyslogFacility:   output.SyslogFacility,
+			SyslogTag:        output.SyslogTag,
+			OpenRetryTimeout: time.Duration(output.OpenRetryTimeout) * time.Second,
+			TimeFormat:       output.TimeFormat,
+			Timezone:         output.Timezone,
+			DiskFull:         output.DiskFull,
+		})
+		if err != nil {
+			handlers.Close()
+			return nil, err
+		}
+		if handler := newFormattedHandler(newMaskingHandler(handler, masks), output, now); handler != nil {
+			handlers = append(handlers, handler)
+		}
+	}
+	switch len(handlers) {
+	case 0:
+		return nil, nil
+	case 1:
+		return handlers[0], nil
+	default:
+		return handlers, nil
+	}
+}
+
+// loadChainKey returns the key of a tamper evident output, nil for others.
+func loadChainKey(spec *LogSpecification) ([]byte, error) {
+	tamperEvident := spec.TamperEvident
+	if tamperEvident == nil {
+		return nil, nil
+	}
+	if spec.Type != LogType_Console && spec.Type != LogType_File {
+		return nil, newError("tamper evident logs are only supported for Console and File")
+	}
+	var key string
+	if tamperEvident.KeyFile != "" {
+		content, err := os.ReadFile(tamperEvident.KeyFile)
+		if err != nil {
+			return nil, newError("failed to read tamper evident log key").Base(err)
+		}
+		key = strings.TrimSpace(string(content))
+	} else if tamperEvident.KeyEnv != "" {
+		key = os.Getenv(tamperEvident.KeyEnv)
+	}
+	if key == "" {
+		return nil, newError("no key for tamper evident log")
+	}
+	return []byte(key), nil
+}
+
+// multiHandler passes messages to each of its handlers.
+type multiHandler []log.Handler
+
+func (h multiHandler) Handle(msg log.Message) {
+	for _, handler := range h {
+		handler.Handle(msg)
+	}
+}
+
+func (h multiHandler) Close() error {
+	var errs []error
+	for _, handler := range h {
+		if err := common.Close(handler); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Combine(errs...)
+}
+
+// Rotate implements log.Rotator.
+func (h multiHandler) Rotate() error {
+	var errs []error
+	for _, handler := range h {
+		if err := log.Rotate(handler); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Combine(errs...)
+}
+
 // Type implements common.HasType.
 func (*Instance) Type() interface{} {
 	return (*Instance)(nil)
@@ -90,6 +361,12 @@ func (g *Instance) startInternal() error {
 	if err := g.initErrorLogger(); err != nil {
 		return newError("failed to initialize error logger").Base(err).AtWarning()
 	}
+	if err := g.initPolicyLogger(); err != nil {
+		return newError("failed to initialize policy logger").Base(err).AtWarning()
+	}
+	if err := g.initDNSLogger(); err != nil {
+		return newError("failed to initialize dns logger").Base(err).AtWarning()
+	}
 
 	return nil
 }
@@ -116,6 +393,58 @@ func (g *Instance) RemoveFollower(f func(msg log.Message)) {
 	delete(g.followers, reflect.ValueOf(f))
 }
 
+// SetLevel sets the level of the error or access log, named by channel, at
+// runtime, returning the previous one. Access records have no severity and
+// are logged at the default level Unknown, or at Info and Debug. The level of
+// the access log can only be set with AccessLevelFilter.
+func (g *Instance) SetLevel(channel string, level log.Severity) (log.Severity, error) {
+	g.Lock()
+	defer g.Unlock()
+
+	var spec *LogSpecification
+	switch channel {
+	case "error":
+		spec = g.config.Error
+	case "access":
+		if !g.config.AccessLevelFilter {
+			return log.Severity_Unknown, newError("the level of the access log has no effect without access_level_filter")
+		}
+		spec = g.config.Access
+	default:
+		return log.Severity_Unknown, newError("unknown log channel ", channel)
+	}
+	previous := spec.Level
+	spec.Level = level
+	return previous, nil
+}
+
+// AccessHandler consumes access records as they are, rather than rendered
+// by a format, e.g. to count them or store them in a database.
+type AccessHandler interface {
+	HandleAccess(msg *log.AccessMessage)
+}
+
+// AddAccessHandler attaches h to the access log. It is passed the records
+// the access log writes, that is those not excluded by exclude_tags or
+// access_log_only_failures, whatever the type of the access log.
+func (g *Instance) AddAccessHandler(h AccessHandler) {
+	g.Lock()
+	defer g.Unlock()
+	g.accessSinks = append(g.accessSinks, h)
+}
+
+// RemoveAccessHandler detaches h from the access log.
+func (g *Instance) RemoveAccessHandler(h AccessHandler) {
+	g.Lock()
+	defer g.Unlock()
+	for i, sink := range g.accessSinks {
+		if sink == h {
+			g.accessSinks = append(g.accessSinks[:i:i], g.accessSinks[i+1:]...)
+			return
+		}
+	}
+}
+
 // Handle implements log.Handler.
 func (g *Instance) Handle(msg log.Message) {
 	g.RLock()
@@ -125,24 +454,104 @@ func (g *Instance) Handle(msg log.Message) {
 		return
 	}
 
+	if g.traces != nil {
+		msg = g.traces.withTrace(msg)
+	}
+	labeled := g.withLabels(msg)
 	for _, f := range g.followers {
-		f(msg)
+		f(labeled)
 	}
 
 	switch msg := msg.(type) {
 	case *log.AccessMessage:
+		if level := g.config.Access.Level; g.config.AccessLevelFilter && level != log.Severity_Unknown && level < log.Severity_Info {
+			break
+		}
+		if g.excluded(msg) {
+			break
+		}
+		for _, sink := range g.accessSinks {
+			sink.HandleAccess(msg)
+		}
 		if g.accessLogger != nil {
-			g.accessLogger.Handle(msg)
+			g.accessLogger.Handle(labeled)
 		}
 	case *log.GeneralMessage:
-		if g.errorLogger != nil && msg.Severity <= g.config.Error.Level {
-			g.errorLogger.Handle(msg)
+		if g.errorLogger != nil && (msg.Severity <= g.config.Error.Level || g.overrides.match(msg.SessionID, msg.InboundTag, time.Now())) {
+			g.errorLogger.Handle(labeled)
+		}
+	case *log.DNSMessage:
+		// DNS records are at Debug level, like the messages they replace.
+		if g.config.Dns != nil {
+			if level := g.config.Dns.Level; g.dnsLogger != nil && (level == log.Severity_Unknown || log.Severity_Debug <= level || g.overrides.match(msg.SessionID, msg.InboundTag, time.Now())) {
+				g.dnsLogger.Handle(labeled)
+			}
+		} else if g.errorLogger != nil && (log.Severity_Debug <= g.config.Error.Level || g.overrides.match(msg.SessionID, msg.InboundTag, time.Now())) {
+			g.errorLogger.Handle(labeled)
+		}
+	case *log.ConfigMessage:
+		if g.errorLogger != nil && msg.Severity() <= g.config.Error.Level {
+			g.errorLogger.Handle(labeled)
+		}
+	case *log.PolicyMessage:
+		if g.policyLogger != nil {
+			g.policyLogger.Handle(labeled)
 		}
 	default:
 		// Swallow
 	}
 }
 
+// excluded returns true if msg is for a connection through an inbound or
+// outbound whose access records are not logged, or is a success while only
+// failures are.
+func (g *Instance) excluded(msg *log.AccessMessage) bool {
+	if g.config.AccessLogOnlyFailures && !msg.Failed() {
+		return true
+	}
+	return g.excludedTags[msg.InboundTag] || g.excludedTags[msg.Detour]
+}
+
+// EnableSessionDebug logs all records of the connection with the given session
+// id at Debug level for the duration of ttl, without changing the global level.
+func (g *Instance) EnableSessionDebug(id uint32, ttl time.Duration) {
+	g.overrides.enableSession(id, time.Now().Add(ttl))
+}
+
+// EnableInboundDebug logs all records of connections accepted by the inbound
+// with the given tag at Debug level for the duration of ttl.
+func (g *Instance) EnableInboundDebug(tag string, ttl time.Duration) {
+	g.overrides.enableInbound(tag, time.Now().Add(ttl))
+}
+
+func (g *Instance) withLabels(msg log.Message) log.Message {
+	labels := g.labels
+	if g.config.SequenceNumbers {
+		// Numbered here, before the message is passed to any handler, so all
+		// of them see the same number.
+		seq := atomic.AddUint64(&g.seq, 1)
+		labels = make([]label, 0, len(g.labels)+1)
+		labels = append(labels, label{key: "seq", value: strconv.FormatUint(seq, 10)})
+		labels = append(labels, g.labels...)
+	}
+	if len(labels) == 0 {
+		return msg
+	}
+	return &labeledMessage{Message: msg, labels: labels}
+}
+
+// Rotate starts writing the log files anew, for files moved away by an
+// external rotation.
+func (g *Instance) Rotate() error {
+	g.RLock()
+	defer g.RUnlock()
+
+	if !g.active {
+		return nil
+	}
+	return errors.Combine(log.Rotate(g.accessLogger), log.Rotate(g.errorLogger), log.Rotate(g.policyLogger), log.Rotate(g.dnsLogger))
+}
+
 // Close implements common.Closable.Close().
 func (g *Instance) Close() error {
 	newError("Logger closing").AtDebug().WriteToLog()
@@ -162,6 +571,12 @@ func (g *Instance) Close() error {
 	common.Close(g.errorLogger)
 	g.errorLogger = nil
 
+	common.Close(g.policyLogger)
+	g.policyLogger = nil
+
+	common.Close(g.dnsLogger)
+	g.dnsLogger = nil
+
 	return nil
 }
 
@@ -1,12 +1,80 @@
 package log
 
 import (
+	"time"
+
 	"github.com/v2fly/v2ray-core/v5/common"
 	"github.com/v2fly/v2ray-core/v5/common/log"
 )
 
 type HandlerCreatorOptions struct {
-	Path string
+	Path               string
+	Format             LogFormat
+	TimestampPrecision TimestampPrecision
+	MaxRecords         uint32
+	FifoPolicy         FifoPolicy
+	Interval           time.Duration
+	FlushInterval      time.Duration
+	BatchSize          uint32
+	BatchInterval      time.Duration
+	HTTPEndpoint       string
+	HTTPHeaders        map[string]string
+	HTTPBatchSize      uint32
+	ChainKey           []byte
+	Rotation           log.RotationOptions
+	SyslogFacility     string
+	SyslogTag          string
+	OpenRetryTimeout   time.Duration
+	TimeFormat         string
+	Timezone           string
+	DiskFull           DiskFullPolicy
+}
+
+const defaultFlushInterval = time.Second
+
+// selfTimestamped returns true if messages are rendered with their own
+// timestamp, so the writer should not prefix one.
+func (o HandlerCreatorOptions) selfTimestamped() bool {
+	return o.Format != LogFormat_Plain || o.TimestampPrecision != TimestampPrecision_Seconds || o.batched() || o.ChainKey != nil || o.TimeFormat != "" || o.Timezone != ""
+}
+
+// diskFullWriter returns creator applying the disk full policy, with the
+// console it writes to.
+func (o HandlerCreatorOptions) diskFullWriter(creator log.WriterCreator) log.WriterCreator {
+	switch o.DiskFull {
+	case DiskFullPolicy_DiskFullStop:
+		return log.CreateDiskFullWriter(o.Path, creator, log.DiskFullStop, log.CreateStderrLogWriter())
+	case DiskFullPolicy_DiskFullConsole:
+		console := log.CreateStdoutLogWriter()
+		if o.selfTimestamped() {
+			console = log.CreateRawStdoutLogWriter()
+		}
+		return log.CreateDiskFullWriter(o.Path, creator, log.DiskFullConsole, console)
+	default:
+		return log.CreateDiskFullWriter(o.Path, creator, log.DiskFullDrop, nil)
+	}
+}
+
+// batched returns true if records are written in batches. A writer prefixing
+// timestamps would prefix only the first record of each.
+func (o HandlerCreatorOptions) batched() bool {
+	return o.BatchSize > 1
+}
+
+// newLogger returns a logger writing with creator, in batches and with an
+// HMAC chain if configured.
+func (o HandlerCreatorOptions) newLogger(name string, creator log.WriterCreator) log.Handler {
+	if o.ChainKey != nil {
+		creator = log.CreateChainWriter(creator, o.ChainKey)
+	}
+	if !o.batched() {
+		return log.NewNamedLogger(name, creator)
+	}
+	interval := o.BatchInterval
+	if interval <= 0 {
+		interval = defaultFlushInterval
+	}
+	return log.NewBufferedLogger(name, log.CreateBatchWriter(creator, int(o.BatchSize)), interval)
 }
 
 type HandlerCreator func(LogType, HandlerCreatorOptions) (log.Handler, error)
@@ -32,15 +100,59 @@ func createHandler(logType LogType, options HandlerCreatorOptions) (log.Handler,
 
 func init() {
 	common.Must(RegisterHandlerCreator(LogType_Console, func(lt LogType, options HandlerCreatorOptions) (log.Handler, error) {
-		return log.NewLogger(log.CreateStdoutLogWriter()), nil
+		if options.selfTimestamped() {
+			return options.newLogger("stdout", log.CreateRawStdoutLogWriter()), nil
+		}
+		return options.newLogger("stdout", log.CreateStdoutLogWriter()), nil
 	}))
 
 	common.Must(RegisterHandlerCreator(LogType_File, func(lt LogType, options HandlerCreatorOptions) (log.Handler, error) {
-		creator, err := log.CreateFileLogWriter(options.Path)
+		if options.ChainKey != nil && (isPathTemplate(options.Path) || log.IsNamedPipe(options.Path)) {
+			return nil, newError("tamper evident logs are not supported for path templates and named pipes")
+		}
+		if options.Rotation.MaxSize > 0 && (isPathTemplate(options.Path) || log.IsNamedPipe(options.Path)) {
+			return nil, newError("rotation is not supported for path templates and named pipes")
+		}
+		if isPathTemplate(options.Path) {
+			if options.selfTimestamped() {
+				return newTemplateFileHandler(options.Path, log.CreateRawFileLogWriter), nil
+			}
+			return newTemplateFileHandler(options.Path, log.CreateFileLogWriter), nil
+		}
+		if log.IsNamedPipe(options.Path) {
+			createWriter := log.CreateFIFOLogWriter
+			if options.selfTimestamped() {
+				createWriter = log.CreateRawFIFOLogWriter
+			}
+			if options.FifoPolicy != FifoPolicy_FifoBuffer {
+				return log.NewNamedLogger(options.Path, createWriter(options.Path, false)), nil
+			}
+			flushInterval := options.FlushInterval
+			if flushInterval <= 0 {
+				flushInterval = defaultFlushInterval
+			}
+			return log.NewBufferedLogger(options.Path, createWriter(options.Path, true), flushInterval), nil
+		}
+		create := func() (log.WriterCreator, error) {
+			if options.Rotation.MaxSize > 0 {
+				if options.selfTimestamped() {
+					return log.CreateRawRotatingFileLogWriter(options.Path, options.Rotation)
+				}
+				return log.CreateRotatingFileLogWriter(options.Path, options.Rotation)
+			}
+			if options.selfTimestamped() {
+				return log.CreateRawFileLogWriter(options.Path)
+			}
+			return log.CreateFileLogWriter(options.Path)
+		}
+		creator, err := create()
+		if err != nil && options.OpenRetryTimeout > 0 {
+			creator, err = log.CreateDeferredLogWriter(options.Path, create, options.OpenRetryTimeout), nil
+		}
 		if err != nil {
 			return nil, err
 		}
-		return log.NewLogger(creator), nil
+		return options.newLogger(options.Path, options.diskFullWriter(creator)), nil
 	}))
 
 	common.Must(RegisterHandlerCreator(LogType_None, func(lt LogType, options HandlerCreatorOptions) (log.Handler, error) {
@@ -0,0 +1,91 @@
+package log
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+)
+
+func TestTamperEvidentFile(t *testing.T) {
+	t.Setenv("V2RAY_TEST_LOG_KEY", "secret")
+	path := filepath.Join(t.TempDir(), "error.log")
+	spec := &LogSpecification{
+		Type:          LogType_File,
+		Path:          path,
+		TamperEvident: &TamperEvident{KeyEnv: "V2RAY_TEST_LOG_KEY"},
+	}
+	key, err := loadChainKey(spec)
+	common.Must(err)
+	options := HandlerCreatorOptions{Path: path, ChainKey: key}
+	if !options.selfTimestamped() {
+		t.Error("a writer prefixing timestamps would break the chain")
+	}
+	creator, err := log.CreateRawFileLogWriter(path)
+	common.Must(err)
+	handler := newFormattedHandler(options.newLogger(path, creator), spec, nil)
+	for _, content := range []string{"one", "two", "three"} {
+		handler.Handle(&log.GeneralMessage{Severity: log.Severity_Warning, Content: content})
+	}
+	defer common.Close(handler)
+
+	var content []byte
+	deadline := time.Now().Add(5 * time.Second)
+	for strings.Count(string(content), "\n") < 3 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected 3 lines, but actually ", string(content))
+		}
+		time.Sleep(10 * time.Millisecond)
+		content, err = os.ReadFile(path)
+		common.Must(err)
+	}
+	common.Must(log.VerifyChain(strings.NewReader(string(content)), []byte("secret")))
+	altered := strings.Replace(string(content), "two", "too", 1)
+	if err := log.VerifyChain(strings.NewReader(altered), []byte("secret")); err == nil {
+		t.Error("altered log passed verification")
+	}
+
+	spec.TamperEvident = &TamperEvident{KeyEnv: "V2RAY_TEST_LOG_NO_KEY"}
+	if _, err := loadChainKey(spec); err == nil {
+		t.Error("expected an error without a key")
+	}
+}
+
+func TestOpenRetryTimeout(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mnt")
+	config := &Config{
+		Error: &LogSpecification{Type: LogType_File, Level: log.Severity_Info, Path: filepath.Join(dir, "error.log"), OpenRetryTimeout: 10},
+	}
+	started := make(chan *Instance, 1)
+	go func() {
+		logger, err := New(context.Background(), config)
+		common.Must(err)
+		started <- logger
+	}()
+	var logger *Instance
+	select {
+	case logger = <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("logger did not start while its file could not be opened")
+	}
+	defer logger.Close()
+
+	log.Record(&log.GeneralMessage{Severity: log.Severity_Info, Content: "before mount"})
+	common.Must(os.Mkdir(dir, 0o700))
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		content, _ := os.ReadFile(filepath.Join(dir, "error.log"))
+		if strings.Contains(string(content), "before mount") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("record logged before the file opened is missing, file holds ", string(content))
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
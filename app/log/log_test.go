@@ -2,12 +2,24 @@ package log_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
 	"github.com/v2fly/v2ray-core/v5/app/log"
 	"github.com/v2fly/v2ray-core/v5/common"
+	verrors "github.com/v2fly/v2ray-core/v5/common/errors"
 	clog "github.com/v2fly/v2ray-core/v5/common/log"
+	"github.com/v2fly/v2ray-core/v5/common/session"
+	"github.com/v2fly/v2ray-core/v5/features/policy"
 	"github.com/v2fly/v2ray-core/v5/testing/mocks"
 )
 
@@ -49,3 +61,888 @@ func TestCustomLogHandler(t *testing.T) {
 
 	common.Must(logger.Close())
 }
+
+type recordingHandler struct {
+	sync.Mutex
+	values []string
+}
+
+func (h *recordingHandler) Handle(msg clog.Message) {
+	h.Lock()
+	defer h.Unlock()
+	h.values = append(h.values, msg.String())
+}
+
+func TestStaticLabels(t *testing.T) {
+	handler := &recordingHandler{}
+	log.RegisterHandlerCreator(log.LogType_Console, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+		return handler, nil
+	})
+
+	logger, err := log.New(context.Background(), &log.Config{
+		Error:        &log.LogSpecification{Type: log.LogType_Console, Level: clog.Severity_Warning},
+		Access:       &log.LogSpecification{Type: log.LogType_Console},
+		StaticLabels: map[string]string{"region": "eu-west", "cluster": "a b"},
+	})
+	common.Must(err)
+	common.Must(logger.Start())
+
+	clog.Record(&clog.GeneralMessage{
+		Severity: clog.Severity_Warning,
+		Content:  "test",
+	})
+	clog.Record(&clog.AccessMessage{
+		From:   "127.0.0.1:1234",
+		To:     "tcp:example.com:443",
+		Status: clog.AccessAccepted,
+	})
+	common.Must(logger.Close())
+
+	expected := []string{
+		`[Warning] test cluster="a b" region=eu-west`,
+		`127.0.0.1:1234 accepted tcp:example.com:443 cluster="a b" region=eu-west`,
+	}
+	if len(handler.values) != len(expected) {
+		t.Fatal("expected ", expected, ", but actually ", handler.values)
+	}
+	for i := range expected {
+		if handler.values[i] != expected[i] {
+			t.Error("expected '", expected[i], "', but actually '", handler.values[i], "'")
+		}
+	}
+}
+
+func TestInvalidStaticLabel(t *testing.T) {
+	_, err := log.New(context.Background(), &log.Config{
+		StaticLabels: map[string]string{"bad key": "value"},
+	})
+	if err == nil {
+		t.Error("expected error for invalid label name")
+	}
+}
+
+func TestReservedStaticLabel(t *testing.T) {
+	for _, key := range []string{"time", "level", "msg", "type", "seq", "schema_version"} {
+		_, err := log.New(context.Background(), &log.Config{
+			StaticLabels: map[string]string{key: "value"},
+		})
+		if err == nil {
+			t.Error("expected error for reserved label name ", key)
+		}
+	}
+}
+
+func TestLogfmtFormat(t *testing.T) {
+	handler := &recordingHandler{}
+	log.RegisterHandlerCreator(log.LogType_Console, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+		return handler, nil
+	})
+
+	logger, err := log.New(context.Background(), &log.Config{
+		Error:        &log.LogSpecification{Type: log.LogType_Console, Level: clog.Severity_Warning, Format: log.LogFormat_Logfmt},
+		Access:       &log.LogSpecification{Type: log.LogType_Console},
+		StaticLabels: map[string]string{"region": "eu west"},
+	})
+	common.Must(err)
+	common.Must(logger.Start())
+
+	clog.Record(&clog.GeneralMessage{
+		Severity: clog.Severity_Error,
+		Content:  `say "hi" to a=b\c`,
+	})
+	clog.Record(&clog.AccessMessage{
+		From:   "127.0.0.1:1234",
+		To:     "tcp:example.com:443",
+		Status: clog.AccessRejected,
+		Reason: "blocked by rule",
+	})
+	common.Must(logger.Close())
+
+	if len(handler.values) != 2 {
+		t.Fatal("expected 2 log messages, but actually ", handler.values)
+	}
+	expected := []string{
+		` level=error msg="say \"hi\" to a=b\\c" region="eu west"`,
+		`127.0.0.1:1234 rejected tcp:example.com:443 blocked by rule region="eu west"`,
+	}
+	if !strings.HasPrefix(handler.values[0], "time=") || !strings.HasSuffix(handler.values[0], expected[0]) {
+		t.Error("unexpected logfmt error record: ", handler.values[0])
+	}
+	if handler.values[1] != expected[1] {
+		t.Error("expected plain access record '", expected[1], "', but actually '", handler.values[1], "'")
+	}
+}
+
+func TestSessionDebugOverride(t *testing.T) {
+	handler := &recordingHandler{}
+	log.RegisterHandlerCreator(log.LogType_Console, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+		return handler, nil
+	})
+
+	logger, err := log.New(context.Background(), &log.Config{
+		Error:  &log.LogSpecification{Type: log.LogType_Console, Level: clog.Severity_Warning},
+		Access: &log.LogSpecification{Type: log.LogType_None},
+	})
+	common.Must(err)
+	common.Must(logger.Start())
+
+	logger.EnableSessionDebug(7, time.Minute)
+	logger.EnableSessionDebug(8, -time.Minute)
+
+	for _, id := range []uint32{0, 6, 7, 8} {
+		clog.Record(&clog.GeneralMessage{
+			Severity:  clog.Severity_Debug,
+			Content:   "debug from " + strconv.Itoa(int(id)),
+			SessionID: id,
+		})
+	}
+	common.Must(logger.Close())
+
+	expected := []string{"[Debug] debug from 7"}
+	if r := cmp.Diff(handler.values, expected); r != "" {
+		t.Error(r)
+	}
+}
+
+func TestPolicyLog(t *testing.T) {
+	handler := &recordingHandler{}
+	log.RegisterHandlerCreator(log.LogType_Console, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+		return handler, nil
+	})
+
+	logger, err := log.New(context.Background(), &log.Config{
+		Error:  &log.LogSpecification{Type: log.LogType_None},
+		Access: &log.LogSpecification{Type: log.LogType_None},
+		Policy: &log.LogSpecification{Type: log.LogType_Console},
+	})
+	common.Must(err)
+	common.Must(logger.Start())
+
+	sessionPolicy := policy.SessionDefault()
+	sessionPolicy.Timeouts.ConnectionIdle = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(session.ContextWithID(context.Background(), 42))
+	sessionPolicy.CancelAfterInactivity(ctx, cancel)
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection was not closed after the idle timeout")
+	}
+	common.Must(logger.Close())
+
+	expected := []string{"[Policy] [42] dropped: inactive for 10ms"}
+	if r := cmp.Diff(handler.values, expected); r != "" {
+		t.Error(r)
+	}
+}
+
+func TestLogLevelEnvOverride(t *testing.T) {
+	t.Setenv("V2RAY_LOG_LEVEL", "debug")
+
+	handler := &recordingHandler{}
+	log.RegisterHandlerCreator(log.LogType_Console, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+		return handler, nil
+	})
+
+	config := &log.Config{
+		Error:  &log.LogSpecification{Type: log.LogType_Console, Level: clog.Severity_Warning},
+		Access: &log.LogSpecification{Type: log.LogType_None},
+	}
+	logger, err := log.New(context.Background(), config)
+	common.Must(err)
+	common.Must(logger.Start())
+
+	clog.Record(&clog.GeneralMessage{Severity: clog.Severity_Debug, Content: "debug message"})
+	common.Must(logger.Close())
+
+	if config.Error.Level != clog.Severity_Debug {
+		t.Error("expected effective level Debug, but actually ", config.Error.Level)
+	}
+	var overrideLogged, debugLogged bool
+	for _, value := range handler.values {
+		overrideLogged = overrideLogged || strings.Contains(value, "error log level set to Debug by V2RAY_LOG_LEVEL")
+		debugLogged = debugLogged || value == "[Debug] debug message"
+	}
+	if !overrideLogged || !debugLogged {
+		t.Error("expected override and debug message to be logged, but actually ", handler.values)
+	}
+}
+
+func TestSequenceNumbers(t *testing.T) {
+	handler := &recordingHandler{}
+	log.RegisterHandlerCreator(log.LogType_Console, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+		return handler, nil
+	})
+
+	logger, err := log.New(context.Background(), &log.Config{
+		Error:           &log.LogSpecification{Type: log.LogType_Console, Level: clog.Severity_Warning},
+		Access:          &log.LogSpecification{Type: log.LogType_Console},
+		SequenceNumbers: true,
+	})
+	common.Must(err)
+	common.Must(logger.Start())
+
+	const goroutines, records = 8, 200
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < records; i++ {
+				if i%2 == 0 {
+					clog.Record(&clog.GeneralMessage{Severity: clog.Severity_Warning, Content: strconv.Itoa(g)})
+				} else {
+					clog.Record(&clog.AccessMessage{From: strconv.Itoa(g), To: "example.com", Status: clog.AccessAccepted})
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	common.Must(logger.Close())
+
+	seen := make(map[uint64]bool)
+	last := make(map[string]uint64)
+	for _, value := range handler.values {
+		i := strings.LastIndex(value, " seq=")
+		if i < 0 {
+			t.Fatal("record without seq: ", value)
+		}
+		seq, err := strconv.ParseUint(value[i+len(" seq="):], 10, 64)
+		common.Must(err)
+		if seen[seq] {
+			t.Error("duplicate seq ", seq)
+		}
+		seen[seq] = true
+
+		// Records of one goroutine are numbered in the order they were logged.
+		// "[Warning] <goroutine>" or "<goroutine> accepted example.com"
+		fields := strings.Fields(value[:i])
+		source := fields[0]
+		if source == "[Warning]" {
+			source = fields[1]
+		}
+		if seq <= last[source] {
+			t.Error("seq ", seq, " of ", source, " not after ", last[source])
+		}
+		last[source] = seq
+	}
+	if len(seen) != goroutines*records {
+		t.Error("expected ", goroutines*records, " records, but actually ", len(seen))
+	}
+}
+
+func TestMultipleOutputs(t *testing.T) {
+	console := &recordingHandler{}
+	log.RegisterHandlerCreator(log.LogType_Console, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+		return console, nil
+	})
+	file := &recordingHandler{}
+	log.RegisterHandlerCreator(log.LogType_File, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+		return file, nil
+	})
+
+	logger, err := log.New(context.Background(), &log.Config{
+		Error: &log.LogSpecification{
+			Type:  log.LogType_Console,
+			Level: clog.Severity_Warning,
+			Outputs: []*log.LogSpecification{
+				{Type: log.LogType_File, Path: "error.json", Format: log.LogFormat_JSON},
+			},
+		},
+		Access: &log.LogSpecification{Type: log.LogType_None},
+	})
+	common.Must(err)
+	common.Must(logger.Start())
+
+	clog.Record(&clog.GeneralMessage{
+		Severity: clog.Severity_Warning,
+		Content:  "disk almost full",
+	})
+	common.Must(logger.Close())
+
+	if r := cmp.Diff(console.values, []string{"[Warning] disk almost full"}); r != "" {
+		t.Error(r)
+	}
+	if len(file.values) != 1 {
+		t.Fatal("expected 1 JSON record, but actually ", file.values)
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(file.values[0]), &record); err != nil {
+		t.Fatal("invalid JSON record ", file.values[0], ": ", err)
+	}
+	if record["level"] != "warning" || record["msg"] != "disk almost full" {
+		t.Error("unexpected JSON record: ", file.values[0])
+	}
+}
+
+func TestLegacyOutput(t *testing.T) {
+	console := &recordingHandler{}
+	log.RegisterHandlerCreator(log.LogType_Console, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+		return console, nil
+	})
+	file := &recordingHandler{}
+	log.RegisterHandlerCreator(log.LogType_File, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+		return file, nil
+	})
+
+	for _, dropLegacy := range []bool{false, true} {
+		console.values, file.values = nil, nil
+		logger, err := log.New(context.Background(), &log.Config{
+			Error: &log.LogSpecification{Type: log.LogType_None},
+			Access: &log.LogSpecification{
+				Type:       log.LogType_Console,
+				Legacy:     true,
+				DropLegacy: dropLegacy,
+				Outputs: []*log.LogSpecification{
+					{Type: log.LogType_File, Path: "access.json", Format: log.LogFormat_JSON},
+				},
+			},
+		})
+		common.Must(err)
+		common.Must(logger.Start())
+
+		records := []*clog.AccessMessage{
+			{From: "127.0.0.1:1001", To: "tcp:example.com:443", Status: clog.AccessAccepted, Detour: "direct"},
+			{From: "127.0.0.1:1002", To: "tcp:example.org:80", Status: clog.AccessRejected, Reason: "blocked"},
+		}
+		for _, record := range records {
+			clog.Record(record)
+		}
+		common.Must(logger.Close())
+
+		if len(file.values) != len(records) {
+			t.Fatal("expected ", len(records), " JSON records, but actually ", file.values)
+		}
+		for i, value := range file.values {
+			var record map[string]interface{}
+			if err := json.Unmarshal([]byte(value), &record); err != nil {
+				t.Fatal("invalid JSON record ", value, ": ", err)
+			}
+			if record["from"] != records[i].From || record["to"] != records[i].To || record["status"] != string(records[i].Status) {
+				t.Error("JSON record ", value, " differs from ", records[i])
+			}
+		}
+		var expected []string
+		if !dropLegacy {
+			expected = []string{
+				"127.0.0.1:1001 accepted tcp:example.com:443 [direct]",
+				"127.0.0.1:1002 rejected tcp:example.org:80 blocked",
+			}
+		}
+		if r := cmp.Diff(console.values, expected); r != "" {
+			t.Error("drop_legacy ", dropLegacy, ": ", r)
+		}
+	}
+}
+
+func TestSeverityRoutes(t *testing.T) {
+	console := &recordingHandler{}
+	log.RegisterHandlerCreator(log.LogType_Console, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+		return console, nil
+	})
+	file := &recordingHandler{}
+	log.RegisterHandlerCreator(log.LogType_File, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+		return file, nil
+	})
+
+	logger, err := log.New(context.Background(), &log.Config{
+		Error: &log.LogSpecification{
+			Type:  log.LogType_Console,
+			Level: clog.Severity_Info,
+			SeverityRoutes: map[string]*log.LogSpecification{
+				"error": {Type: log.LogType_File, Path: "error.log"},
+			},
+		},
+		Access: &log.LogSpecification{Type: log.LogType_None},
+	})
+	common.Must(err)
+	common.Must(logger.Start())
+
+	clog.Record(&clog.GeneralMessage{Severity: clog.Severity_Error, Content: "disk full"})
+	clog.Record(&clog.GeneralMessage{Severity: clog.Severity_Info, Content: "connection accepted"})
+	clog.Record(&clog.GeneralMessage{Severity: clog.Severity_Debug, Content: "below the level"})
+	common.Must(logger.Close())
+
+	if r := cmp.Diff(file.values, []string{"[Error] disk full"}); r != "" {
+		t.Error("file: ", r)
+	}
+	if r := cmp.Diff(console.values, []string{"[Info] connection accepted"}); r != "" {
+		t.Error("console: ", r)
+	}
+
+	_, err = log.New(context.Background(), &log.Config{
+		Error: &log.LogSpecification{
+			Type:           log.LogType_Console,
+			SeverityRoutes: map[string]*log.LogSpecification{"fatal": {Type: log.LogType_File}},
+		},
+	})
+	if err == nil {
+		t.Error("expected an invalid severity to be rejected")
+	}
+}
+
+func TestHostnameSource(t *testing.T) {
+	osHostname, err := os.Hostname()
+	common.Must(err)
+	t.Setenv("V2RAY_TEST_HOSTNAME", "pod-a")
+
+	cases := []struct {
+		source   log.HostnameSource
+		hostname string
+		want     string
+	}{
+		{log.HostnameSource_OSHostname, "", osHostname},
+		{log.HostnameSource_StaticHostname, "edge-1", "edge-1"},
+		{log.HostnameSource_EnvHostname, "V2RAY_TEST_HOSTNAME", "pod-a"},
+	}
+	for _, c := range cases {
+		handler := &recordingHandler{}
+		log.RegisterHandlerCreator(log.LogType_Console, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+			return handler, nil
+		})
+
+		logger, err := log.New(context.Background(), &log.Config{
+			Error:          &log.LogSpecification{Type: log.LogType_Console, Level: clog.Severity_Warning, Format: log.LogFormat_JSON},
+			Access:         &log.LogSpecification{Type: log.LogType_None},
+			HostnameSource: c.source,
+			Hostname:       c.hostname,
+		})
+		common.Must(err)
+		common.Must(logger.Start())
+		clog.Record(&clog.GeneralMessage{Severity: clog.Severity_Warning, Content: "test"})
+		common.Must(logger.Close())
+
+		if len(handler.values) != 1 {
+			t.Fatal(c.source, ": expected 1 record, but actually ", handler.values)
+		}
+		var record map[string]interface{}
+		common.Must(json.Unmarshal([]byte(handler.values[0]), &record))
+		if record["hostname"] != c.want {
+			t.Error(c.source, ": expected hostname ", c.want, ", but actually ", record["hostname"])
+		}
+	}
+
+	if _, err := log.New(context.Background(), &log.Config{
+		HostnameSource: log.HostnameSource_EnvHostname,
+		Hostname:       "V2RAY_TEST_HOSTNAME_UNSET",
+	}); err == nil {
+		t.Error("expected error for unset hostname variable")
+	}
+}
+
+func TestExcludeTags(t *testing.T) {
+	handler := &recordingHandler{}
+	log.RegisterHandlerCreator(log.LogType_Console, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+		return handler, nil
+	})
+
+	logger, err := log.New(context.Background(), &log.Config{
+		Error:  &log.LogSpecification{Type: log.LogType_None},
+		Access: &log.LogSpecification{Type: log.LogType_Console, ExcludeTags: []string{"healthcheck-in", "healthcheck-out"}},
+	})
+	common.Must(err)
+	common.Must(logger.Start())
+
+	records := []*clog.AccessMessage{
+		{From: "127.0.0.1:1001", To: "tcp:example.com:443", Status: clog.AccessAccepted, InboundTag: "socks", Detour: "direct"},
+		{From: "127.0.0.1:1002", To: "tcp:example.com:80", Status: clog.AccessAccepted, InboundTag: "healthcheck-in", Detour: "direct"},
+		{From: "127.0.0.1:1003", To: "tcp:example.com:80", Status: clog.AccessAccepted, InboundTag: "socks", Detour: "healthcheck-out"},
+		{From: "127.0.0.1:1004", To: "tcp:example.org:443", Status: clog.AccessRejected, InboundTag: "http"},
+	}
+	for _, record := range records {
+		clog.Record(record)
+	}
+	common.Must(logger.Close())
+
+	expected := []string{
+		"127.0.0.1:1001 accepted tcp:example.com:443 [direct]",
+		"127.0.0.1:1004 rejected tcp:example.org:443",
+	}
+	if r := cmp.Diff(handler.values, expected); r != "" {
+		t.Error(r)
+	}
+}
+
+func TestAccessLogOnlyFailures(t *testing.T) {
+	handler := &recordingHandler{}
+	log.RegisterHandlerCreator(log.LogType_Console, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+		return handler, nil
+	})
+
+	logger, err := log.New(context.Background(), &log.Config{
+		Error:                 &log.LogSpecification{Type: log.LogType_None},
+		Access:                &log.LogSpecification{Type: log.LogType_Console},
+		AccessLogOnlyFailures: true,
+	})
+	common.Must(err)
+	common.Must(logger.Start())
+
+	accepted := &clog.AccessMessage{From: "127.0.0.1:1003", To: "tcp:example.net:443", Status: clog.AccessAccepted, Detour: "proxy"}
+	records := []*clog.AccessMessage{
+		{From: "127.0.0.1:1001", To: "tcp:example.com:443", Status: clog.AccessAccepted, Detour: "direct"},
+		{From: "127.0.0.1:1002", To: "tcp:example.org:443", Status: clog.AccessRejected, Reason: "invalid user"},
+		accepted,
+	}
+	for _, record := range records {
+		clog.Record(record)
+	}
+	clog.RecordAccessFailure(clog.ContextWithAccessMessage(context.Background(), accepted), "connection refused")
+	clog.RecordAccessFailure(context.Background(), "no access record")
+	common.Must(logger.Close())
+
+	expected := []string{
+		"127.0.0.1:1002 rejected tcp:example.org:443 invalid user",
+		"127.0.0.1:1003 failed tcp:example.net:443 [proxy] connection refused",
+	}
+	if r := cmp.Diff(handler.values, expected); r != "" {
+		t.Error(r)
+	}
+	if accepted.Status != clog.AccessAccepted {
+		t.Error("expected the access record in the context to be left accepted")
+	}
+}
+
+func TestAccessLogFailureNotDuplicated(t *testing.T) {
+	handler := &recordingHandler{}
+	log.RegisterHandlerCreator(log.LogType_Console, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+		return handler, nil
+	})
+
+	logger, err := log.New(context.Background(), &log.Config{
+		Error:  &log.LogSpecification{Type: log.LogType_None},
+		Access: &log.LogSpecification{Type: log.LogType_Console},
+	})
+	common.Must(err)
+	common.Must(logger.Start())
+
+	accepted := &clog.AccessMessage{From: "127.0.0.1:1003", To: "tcp:example.net:443", Status: clog.AccessAccepted, Detour: "proxy"}
+	clog.Record(accepted)
+	ctx := clog.ContextWithAccessMessage(context.Background(), accepted)
+	clog.RecordAccessFailure(ctx, "connection refused")
+	clog.RecordAccessFailure(ctx, "connection refused")
+	common.Must(logger.Close())
+
+	// The default access log shows the failure once, after the connection
+	// was accepted.
+	expected := []string{
+		"127.0.0.1:1003 accepted tcp:example.net:443 [proxy]",
+		"127.0.0.1:1003 failed tcp:example.net:443 [proxy] connection refused",
+	}
+	if r := cmp.Diff(handler.values, expected); r != "" {
+		t.Error(r)
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	handler := &recordingHandler{}
+	log.RegisterHandlerCreator(log.LogType_Console, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+		return handler, nil
+	})
+
+	logger, err := log.New(context.Background(), &log.Config{
+		Error:             &log.LogSpecification{Type: log.LogType_Console, Level: clog.Severity_Warning},
+		Access:            &log.LogSpecification{Type: log.LogType_Console},
+		AccessLevelFilter: true,
+	})
+	common.Must(err)
+	common.Must(logger.Start())
+
+	// Records written while the level changes.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				clog.Record(&clog.GeneralMessage{Severity: clog.Severity_Debug, Content: "concurrent"})
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		common.Must2(logger.SetLevel("error", clog.Severity_Debug))
+		common.Must2(logger.SetLevel("error", clog.Severity_Warning))
+	}
+	wg.Wait()
+	handler.Lock()
+	handler.values = nil
+	handler.Unlock()
+
+	clog.Record(&clog.GeneralMessage{Severity: clog.Severity_Debug, Content: "filtered"})
+	previous, err := logger.SetLevel("error", clog.Severity_Debug)
+	common.Must(err)
+	if previous != clog.Severity_Warning {
+		t.Error("expected previous level Warning, but actually ", previous)
+	}
+	clog.Record(&clog.GeneralMessage{Severity: clog.Severity_Debug, Content: "emitted"})
+	common.Must2(logger.SetLevel("error", clog.Severity_Warning))
+	clog.Record(&clog.GeneralMessage{Severity: clog.Severity_Debug, Content: "filtered again"})
+
+	clog.Record(&clog.AccessMessage{From: "127.0.0.1:1001", To: "tcp:example.com:443", Status: clog.AccessAccepted})
+	common.Must2(logger.SetLevel("access", clog.Severity_Warning))
+	clog.Record(&clog.AccessMessage{From: "127.0.0.1:1002", To: "tcp:example.com:443", Status: clog.AccessAccepted})
+	common.Must2(logger.SetLevel("access", clog.Severity_Info))
+	clog.Record(&clog.AccessMessage{From: "127.0.0.1:1003", To: "tcp:example.com:443", Status: clog.AccessAccepted})
+
+	if _, err := logger.SetLevel("dns", clog.Severity_Debug); err == nil {
+		t.Error("expected an unknown channel to be rejected")
+	}
+	common.Must(logger.Close())
+
+	expected := []string{
+		"[Debug] emitted",
+		"127.0.0.1:1001 accepted tcp:example.com:443",
+		"127.0.0.1:1003 accepted tcp:example.com:443",
+	}
+	if r := cmp.Diff(handler.values, expected); r != "" {
+		t.Error(r)
+	}
+}
+
+func TestAccessLevelWithoutFilter(t *testing.T) {
+	handler := &recordingHandler{}
+	log.RegisterHandlerCreator(log.LogType_Console, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+		return handler, nil
+	})
+
+	logger, err := log.New(context.Background(), &log.Config{
+		Error:  &log.LogSpecification{Type: log.LogType_None},
+		Access: &log.LogSpecification{Type: log.LogType_Console, Level: clog.Severity_Warning},
+	})
+	common.Must(err)
+	common.Must(logger.Start())
+
+	clog.Record(&clog.AccessMessage{From: "127.0.0.1:1001", To: "tcp:example.com:443", Status: clog.AccessAccepted})
+	if _, err := logger.SetLevel("access", clog.Severity_Error); err == nil {
+		t.Error("expected the access level to be rejected without the filter")
+	}
+	common.Must(logger.Close())
+
+	expected := []string{
+		"127.0.0.1:1001 accepted tcp:example.com:443",
+	}
+	if r := cmp.Diff(handler.values, expected); r != "" {
+		t.Error(r)
+	}
+}
+
+func TestDNSLog(t *testing.T) {
+	errorHandler := &recordingHandler{}
+	log.RegisterHandlerCreator(log.LogType_Console, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+		return errorHandler, nil
+	})
+	dnsHandler := &recordingHandler{}
+	log.RegisterHandlerCreator(log.LogType_File, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+		return dnsHandler, nil
+	})
+	query := &clog.DNSMessage{
+		Server:    "local",
+		Domain:    "example.com",
+		QueryType: "A",
+		IPs:       []net.IP{net.ParseIP("192.0.2.1")},
+		Source:    clog.DNSSourceUpstream,
+		TTL:       60,
+		Latency:   12 * time.Millisecond,
+	}
+
+	logger, err := log.New(context.Background(), &log.Config{
+		Error:  &log.LogSpecification{Type: log.LogType_Console, Level: clog.Severity_Debug},
+		Access: &log.LogSpecification{Type: log.LogType_None},
+		Dns:    &log.LogSpecification{Type: log.LogType_File, Path: "dns.log"},
+	})
+	common.Must(err)
+	common.Must(logger.Start())
+	clog.Record(query)
+	common.Must(logger.Close())
+
+	expected := []string{"[DNS] upstream [local] example.com -> [192.0.2.1] ttl: 60 type: A latency: 12ms"}
+	if r := cmp.Diff(dnsHandler.values, expected); r != "" {
+		t.Error(r)
+	}
+	for _, value := range errorHandler.values {
+		if strings.Contains(value, "[DNS]") {
+			t.Error("dns query also written to the error log: ", value)
+		}
+	}
+
+	// Without a dns log, queries go to the error log at Debug level.
+	errorHandler.values = nil
+	logger, err = log.New(context.Background(), &log.Config{
+		Error:  &log.LogSpecification{Type: log.LogType_Console, Level: clog.Severity_Debug},
+		Access: &log.LogSpecification{Type: log.LogType_None},
+	})
+	common.Must(err)
+	common.Must(logger.Start())
+	clog.Record(query)
+	common.Must(logger.Close())
+	if r := cmp.Diff(errorHandler.values[len(errorHandler.values)-1:], expected); r != "" {
+		t.Error(r)
+	}
+}
+
+func TestDNSLogLevel(t *testing.T) {
+	dnsHandler := &recordingHandler{}
+	log.RegisterHandlerCreator(log.LogType_File, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+		return dnsHandler, nil
+	})
+
+	logger, err := log.New(context.Background(), &log.Config{
+		Error:  &log.LogSpecification{Type: log.LogType_None},
+		Access: &log.LogSpecification{Type: log.LogType_None},
+		Dns:    &log.LogSpecification{Type: log.LogType_File, Path: "dns.log", Level: clog.Severity_Info},
+	})
+	common.Must(err)
+	common.Must(logger.Start())
+	logger.EnableSessionDebug(7, time.Minute)
+	for _, id := range []uint32{6, 7} {
+		clog.Record(&clog.DNSMessage{
+			Domain:     "example.com",
+			IPs:        []net.IP{net.ParseIP("192.0.2.1")},
+			Source:     clog.DNSSourceCache,
+			TTL:        60,
+			SessionID:  id,
+			InboundTag: "socks",
+		})
+	}
+	common.Must(logger.Close())
+
+	// Below Debug level, only the connection with debug logging enabled is
+	// logged.
+	expected := []string{"[DNS] [7] cache example.com -> [192.0.2.1] ttl: 60"}
+	if r := cmp.Diff(dnsHandler.values, expected); r != "" {
+		t.Error(r)
+	}
+}
+
+func TestTraceContext(t *testing.T) {
+	handler := &recordingHandler{}
+	log.RegisterHandlerCreator(log.LogType_Console, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+		return handler, nil
+	})
+
+	logger, err := log.New(context.Background(), &log.Config{
+		Error:        &log.LogSpecification{Type: log.LogType_Console, Level: clog.Severity_Warning, Format: log.LogFormat_JSON},
+		Access:       &log.LogSpecification{Type: log.LogType_Console, Format: log.LogFormat_JSON},
+		TraceContext: &log.TraceContext{Generate: true},
+	})
+	common.Must(err)
+	common.Must(logger.Start())
+
+	trace := session.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if trace == nil {
+		t.Fatal("failed to parse traceparent")
+	}
+	traced := session.ContextWithTrace(session.ContextWithID(context.Background(), 1), trace)
+	verrors.New("connection opened").AtWarning().WriteToLog(session.ExportIDToError(traced))
+	clog.Record(&clog.AccessMessage{
+		From: "127.0.0.1:1001", To: "tcp:example.com:443", Status: clog.AccessAccepted,
+		SessionID: 1, TraceID: trace.TraceID, SpanID: trace.SpanID,
+	})
+	// Connections without a trace context get ids of their own.
+	untraced := session.ContextWithID(context.Background(), 2)
+	verrors.New("connection opened").AtWarning().WriteToLog(session.ExportIDToError(untraced))
+	clog.Record(&clog.AccessMessage{
+		From: "127.0.0.1:1002", To: "tcp:example.org:443", Status: clog.AccessAccepted,
+		SessionID: 2,
+	})
+	common.Must(logger.Close())
+
+	var records []map[string]interface{}
+	for _, value := range handler.values {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(value), &record); err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	if len(records) != 4 {
+		t.Fatal("expected 4 JSON records, but actually ", handler.values)
+	}
+	for _, record := range records[:2] {
+		if record["trace_id"] != trace.TraceID || record["span_id"] != trace.SpanID {
+			t.Error("record without the trace context of the connection: ", record)
+		}
+	}
+	generated, _ := records[2]["trace_id"].(string)
+	if len(generated) != 32 || generated == trace.TraceID {
+		t.Error("unexpected generated trace id: ", records[2])
+	}
+	if records[3]["trace_id"] != generated || records[3]["span_id"] != records[2]["span_id"] {
+		t.Error("records of a connection with different generated ids: ", records[2], records[3])
+	}
+}
+
+// accessSink keeps the access records passed to it.
+type accessSink struct {
+	records []*clog.AccessMessage
+}
+
+func (s *accessSink) HandleAccess(msg *clog.AccessMessage) {
+	s.records = append(s.records, msg)
+}
+
+func TestAccessHandler(t *testing.T) {
+	handler := &recordingHandler{}
+	log.RegisterHandlerCreator(log.LogType_Console, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+		return handler, nil
+	})
+
+	logger, err := log.New(context.Background(), &log.Config{
+		Error:  &log.LogSpecification{Type: log.LogType_None},
+		Access: &log.LogSpecification{Type: log.LogType_Console, ExcludeTags: []string{"healthcheck"}},
+	})
+	common.Must(err)
+	common.Must(logger.Start())
+	sink := &accessSink{}
+	logger.AddAccessHandler(sink)
+
+	tagged := &clog.AccessMessage{
+		From:       "127.0.0.1:1001",
+		To:         "tcp:example.com:443",
+		Status:     clog.AccessAccepted,
+		InboundTag: "socks",
+		Detour:     "direct",
+		Attributes: map[string]string{"tenant": "blue", "plan": "free"},
+	}
+	clog.Record(tagged)
+	clog.Record(&clog.AccessMessage{From: "127.0.0.1:1002", To: "tcp:example.com:80", Status: clog.AccessAccepted, InboundTag: "healthcheck"})
+	logger.RemoveAccessHandler(sink)
+	clog.Record(&clog.AccessMessage{From: "127.0.0.1:1003", To: "tcp:example.org:443", Status: clog.AccessRejected})
+	common.Must(logger.Close())
+
+	if len(sink.records) != 1 || sink.records[0] != tagged {
+		t.Fatal("expected the access handler to be passed the logged record only, but actually ", sink.records)
+	}
+	if tag := sink.records[0].OutboundTag(); tag != "direct" {
+		t.Error("unexpected outbound tag ", tag)
+	}
+	expected := []string{
+		"127.0.0.1:1001 accepted tcp:example.com:443 [direct] plan: free tenant: blue",
+		"127.0.0.1:1003 rejected tcp:example.org:443",
+	}
+	if r := cmp.Diff(handler.values, expected); r != "" {
+		t.Error(r)
+	}
+}
+
+func TestConfigLog(t *testing.T) {
+	handler := &recordingHandler{}
+	log.RegisterHandlerCreator(log.LogType_Console, func(lt log.LogType, options log.HandlerCreatorOptions) (clog.Handler, error) {
+		return handler, nil
+	})
+
+	logger, err := log.New(context.Background(), &log.Config{
+		Error:  &log.LogSpecification{Type: log.LogType_Console, Level: clog.Severity_Warning},
+		Access: &log.LogSpecification{Type: log.LogType_None},
+	})
+	common.Must(err)
+	common.Must(logger.Start())
+
+	clog.Record(&clog.ConfigMessage{Section: "log", Status: clog.ConfigLoaded})
+	clog.Record(&clog.ConfigMessage{Section: "outbounds[1] (broken)", Status: clog.ConfigInvalid, Reason: errors.New("unknown protocol")})
+	common.Must(logger.Close())
+
+	expected := []string{"[Config] outbounds[1] (broken) invalid: unknown protocol"}
+	if r := cmp.Diff(handler.values, expected); r != "" {
+		t.Error(r)
+	}
+}
@@ -0,0 +1,72 @@
+package log
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// debugOverrides tracks connections and inbounds whose records are logged at
+// Debug level regardless of the configured error log level.
+type debugOverrides struct {
+	sync.Mutex
+	// active counts the overrides, so records are matched without locking
+	// while there are none.
+	active   int32
+	sessions map[uint32]time.Time
+	inbounds map[string]time.Time
+}
+
+func (o *debugOverrides) enableSession(id uint32, expire time.Time) {
+	o.Lock()
+	defer o.Unlock()
+	if o.sessions == nil {
+		o.sessions = make(map[uint32]time.Time)
+	}
+	if _, found := o.sessions[id]; !found {
+		atomic.AddInt32(&o.active, 1)
+	}
+	o.sessions[id] = expire
+}
+
+func (o *debugOverrides) enableInbound(tag string, expire time.Time) {
+	o.Lock()
+	defer o.Unlock()
+	if o.inbounds == nil {
+		o.inbounds = make(map[string]time.Time)
+	}
+	if _, found := o.inbounds[tag]; !found {
+		atomic.AddInt32(&o.active, 1)
+	}
+	o.inbounds[tag] = expire
+}
+
+// match returns true if the connection sessionID or the inbound inboundTag
+// has an unexpired override. Expired overrides are removed.
+func (o *debugOverrides) match(sessionID uint32, inboundTag string, now time.Time) bool {
+	if atomic.LoadInt32(&o.active) == 0 {
+		return false
+	}
+	o.Lock()
+	defer o.Unlock()
+
+	if sessionID != 0 {
+		if expire, found := o.sessions[sessionID]; found {
+			if now.Before(expire) {
+				return true
+			}
+			delete(o.sessions, sessionID)
+			atomic.AddInt32(&o.active, -1)
+		}
+	}
+	if len(inboundTag) > 0 {
+		if expire, found := o.inbounds[inboundTag]; found {
+			if now.Before(expire) {
+				return true
+			}
+			delete(o.inbounds, inboundTag)
+			atomic.AddInt32(&o.active, -1)
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package log
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDebugOverridesActive(t *testing.T) {
+	var overrides debugOverrides
+	now := time.Now()
+	if overrides.match(7, "", now) {
+		t.Error("matched without overrides")
+	}
+
+	overrides.enableSession(7, now.Add(time.Minute))
+	overrides.enableSession(7, now.Add(time.Second))
+	overrides.enableInbound("socks", now.Add(time.Minute))
+	if active := overrides.active; active != 2 {
+		t.Error("expected 2 active overrides, but actually ", active)
+	}
+	if !overrides.match(7, "", now) {
+		t.Error("expected the session override to match")
+	}
+	if !overrides.match(0, "socks", now) {
+		t.Error("expected the inbound override to match")
+	}
+
+	later := now.Add(2 * time.Minute)
+	overrides.match(7, "socks", later)
+	if active := overrides.active; active != 0 {
+		t.Error("expected expired overrides to be removed, but ", active, " are active")
+	}
+}
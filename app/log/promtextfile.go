@@ -0,0 +1,148 @@
+package log
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+	"github.com/v2fly/v2ray-core/v5/common/signal/done"
+)
+
+const defaultTextfileInterval = 15 * time.Second
+
+type connectionKey struct {
+	tag    string
+	status log.AccessStatus
+}
+
+// promTextfileHandler counts access records and periodically writes the
+// counters to a file for the Prometheus node exporter textfile collector.
+type promTextfileHandler struct {
+	sync.Mutex
+	path        string
+	connections map[connectionKey]uint64
+	done        *done.Instance
+	finished    chan struct{}
+}
+
+func newPromTextfileHandler(path string, interval time.Duration) (*promTextfileHandler, error) {
+	if path == "" {
+		return nil, newError("no path for prometheus textfile")
+	}
+	if interval <= 0 {
+		interval = defaultTextfileInterval
+	}
+	h := &promTextfileHandler{
+		path:        path,
+		connections: make(map[connectionKey]uint64),
+		done:        done.New(),
+		finished:    make(chan struct{}),
+	}
+	// Write once right away, so the metrics exist before the first record.
+	if err := h.write(); err != nil {
+		return nil, err
+	}
+	go h.run(interval)
+	return h, nil
+}
+
+// Handle implements log.Handler.
+func (h *promTextfileHandler) Handle(msg log.Message) {
+	access, ok := unwrapMessage(msg).(*log.AccessMessage)
+	if !ok {
+		return
+	}
+	h.Lock()
+	h.connections[connectionKey{tag: access.Detour, status: access.Status}]++
+	h.Unlock()
+}
+
+func (h *promTextfileHandler) run(interval time.Duration) {
+	defer close(h.finished)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-h.done.Wait():
+			h.flush()
+			return
+		}
+		h.flush()
+	}
+}
+
+func (h *promTextfileHandler) flush() {
+	if err := h.write(); err != nil {
+		newError("failed to write prometheus textfile ", h.path).Base(err).AtWarning().WriteToLog()
+	}
+}
+
+// write replaces the file atomically, so the collector never reads a
+// partial one.
+func (h *promTextfileHandler) write() error {
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(h.render()), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, h.path)
+}
+
+func (h *promTextfileHandler) render() string {
+	h.Lock()
+	keys := make([]connectionKey, 0, len(h.connections))
+	for key := range h.connections {
+		keys = append(keys, key)
+	}
+	counts := make([]uint64, len(keys))
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].tag != keys[j].tag {
+			return keys[i].tag < keys[j].tag
+		}
+		return keys[i].status < keys[j].status
+	})
+	for i, key := range keys {
+		counts[i] = h.connections[key]
+	}
+	h.Unlock()
+
+	var b strings.Builder
+	b.WriteString("# HELP v2ray_access_connections_total Connections by outbound tag and access status.\n")
+	b.WriteString("# TYPE v2ray_access_connections_total counter\n")
+	for i, key := range keys {
+		b.WriteString(`v2ray_access_connections_total{tag="`)
+		b.WriteString(escapeLabelValue(key.tag))
+		b.WriteString(`",status="`)
+		b.WriteString(escapeLabelValue(string(key.status)))
+		b.WriteString(`"} `)
+		b.WriteString(strconv.FormatUint(counts[i], 10))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(s string) string {
+	return labelValueEscaper.Replace(s)
+}
+
+// Close implements common.Closable. It writes the counters a last time.
+func (h *promTextfileHandler) Close() error {
+	h.done.Close()
+	<-h.finished
+	return nil
+}
+
+func init() {
+	common.Must(RegisterHandlerCreator(LogType_PromTextfile, func(lt LogType, options HandlerCreatorOptions) (log.Handler, error) {
+		return newPromTextfileHandler(options.Path, options.Interval)
+	}))
+}
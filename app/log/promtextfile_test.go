@@ -0,0 +1,76 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+)
+
+var (
+	expositionComment = regexp.MustCompile(`^# (HELP [a-zA-Z_:][a-zA-Z0-9_:]* .*|TYPE [a-zA-Z_:][a-zA-Z0-9_:]* (counter|gauge|histogram|summary|untyped))$`)
+	expositionSample  = regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)(\{[a-zA-Z_][a-zA-Z0-9_]*="(?:[^"\\\n]|\\.)*"(?:,[a-zA-Z_][a-zA-Z0-9_]*="(?:[^"\\\n]|\\.)*")*\})? [0-9]+$`)
+)
+
+// parseExposition checks that text is in the Prometheus text exposition
+// format and returns its samples.
+func parseExposition(t *testing.T, text string) map[string]string {
+	t.Helper()
+
+	if !strings.HasSuffix(text, "\n") {
+		t.Fatal("exposition does not end with a newline")
+	}
+	samples := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		if strings.HasPrefix(line, "#") {
+			if !expositionComment.MatchString(line) {
+				t.Fatalf("invalid comment line: %q", line)
+			}
+			continue
+		}
+		if !expositionSample.MatchString(line) {
+			t.Fatalf("invalid sample line: %q", line)
+		}
+		i := strings.LastIndexByte(line, ' ')
+		samples[line[:i]] = line[i+1:]
+	}
+	return samples
+}
+
+func TestPromTextfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "v2ray.prom")
+	handler, err := createHandler(LogType_PromTextfile, HandlerCreatorOptions{Path: path, Interval: time.Hour})
+	common.Must(err)
+
+	for i := 0; i < 3; i++ {
+		handler.Handle(&labeledMessage{Message: &log.AccessMessage{Status: log.AccessAccepted, Detour: "direct"}})
+	}
+	handler.Handle(&log.AccessMessage{Status: log.AccessRejected, Detour: `we"ird\tag`})
+	handler.Handle(&log.GeneralMessage{Severity: log.Severity_Info, Content: "not an access record"})
+	common.Must(common.Close(handler))
+
+	content, err := os.ReadFile(path)
+	common.Must(err)
+	samples := parseExposition(t, string(content))
+
+	want := map[string]string{
+		`v2ray_access_connections_total{tag="direct",status="accepted"}`:       "3",
+		`v2ray_access_connections_total{tag="we\"ird\\tag",status="rejected"}`: "1",
+	}
+	if len(samples) != len(want) {
+		t.Errorf("got samples %v, want %v", samples, want)
+	}
+	for name, value := range want {
+		if samples[name] != value {
+			t.Errorf("%s = %q, want %q", name, samples[name], value)
+		}
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Error("temporary file left behind: ", err)
+	}
+}
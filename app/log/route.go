@@ -0,0 +1,103 @@
+package log
+
+import (
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/errors"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+)
+
+// severityRouter passes messages to the handler of their severity, or to
+// fallback if there is none.
+type severityRouter struct {
+	routes   map[log.Severity]log.Handler
+	fallback log.Handler
+}
+
+func newSeverityRouter(specs map[string]*LogSpecification, fallback log.Handler, now func() time.Time) (*severityRouter, error) {
+	router := &severityRouter{
+		routes:   make(map[log.Severity]log.Handler, len(specs)),
+		fallback: fallback,
+	}
+	for name, spec := range specs {
+		severity, ok := parseSeverity(name)
+		if !ok {
+			router.closeRoutes()
+			return nil, newError("invalid severity ", name, " in severity_routes")
+		}
+		if spec == nil {
+			spec = &LogSpecification{}
+		}
+		handler, err := createOutputsHandler(spec, now)
+		if err != nil {
+			router.closeRoutes()
+			return nil, newError("failed to create log route for ", name).Base(err)
+		}
+		router.routes[severity] = handler
+	}
+	return router, nil
+}
+
+// messageSeverity returns the severity msg is filtered by, Unknown for
+// records without one.
+func messageSeverity(msg log.Message) log.Severity {
+	switch msg := unwrapMessage(msg).(type) {
+	case *log.GeneralMessage:
+		return msg.Severity
+	case *log.ConfigMessage:
+		return msg.Severity()
+	case *log.DNSMessage:
+		return log.Severity_Debug
+	default:
+		return log.Severity_Unknown
+	}
+}
+
+func (r *severityRouter) Handle(msg log.Message) {
+	if handler, found := r.routes[messageSeverity(msg)]; found {
+		// A route of type None drops the records of its severity.
+		if handler != nil {
+			handler.Handle(msg)
+		}
+		return
+	}
+	if r.fallback != nil {
+		r.fallback.Handle(msg)
+	}
+}
+
+func (r *severityRouter) closeRoutes() error {
+	var errs []error
+	for _, handler := range r.routes {
+		if err := common.Close(handler); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Combine(errs...)
+}
+
+func (r *severityRouter) Close() error {
+	var errs []error
+	if err := r.closeRoutes(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := common.Close(r.fallback); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Combine(errs...)
+}
+
+// Rotate implements log.Rotator.
+func (r *severityRouter) Rotate() error {
+	var errs []error
+	for _, handler := range r.routes {
+		if err := log.Rotate(handler); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := log.Rotate(r.fallback); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Combine(errs...)
+}
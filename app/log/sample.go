@@ -0,0 +1,89 @@
+package log
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+)
+
+// sampleCounters is the number of counters records are hashed to by their
+// text. Records sharing a counter are sampled together.
+const sampleCounters = 4096
+
+type sampleCounter struct {
+	resetAt time.Time
+	count   uint64
+}
+
+// samplingHandler passes the first initial records with the same text in
+// each window on, then every thereafter-th.
+type samplingHandler struct {
+	handler    log.Handler
+	initial    uint64
+	thereafter uint64
+	window     time.Duration
+	now        func() time.Time
+
+	sync.Mutex
+	counters [sampleCounters]sampleCounter
+}
+
+// newSamplingHandler returns handler sampled as configured by spec, or as is
+// if spec does not sample.
+func newSamplingHandler(handler log.Handler, spec *LogSpecification) log.Handler {
+	if handler == nil || spec.SampleInitial == 0 {
+		return handler
+	}
+	window := time.Duration(spec.SampleWindow) * time.Second
+	if window == 0 {
+		window = time.Second
+	}
+	return &samplingHandler{
+		handler:    handler,
+		initial:    uint64(spec.SampleInitial),
+		thereafter: uint64(spec.SampleThereafter),
+		window:     window,
+		now:        time.Now,
+	}
+}
+
+func (h *samplingHandler) Handle(msg log.Message) {
+	if h.sample(unwrapMessage(msg).String()) {
+		h.handler.Handle(msg)
+	}
+}
+
+// sample counts a record with the given text, returning true if it is to be
+// written.
+func (h *samplingHandler) sample(text string) bool {
+	hash := fnv.New32a()
+	hash.Write([]byte(text))
+	now := h.now()
+
+	h.Lock()
+	counter := &h.counters[hash.Sum32()%sampleCounters]
+	if !now.Before(counter.resetAt) {
+		counter.resetAt = now.Add(h.window)
+		counter.count = 0
+	}
+	counter.count++
+	n := counter.count
+	h.Unlock()
+
+	if n <= h.initial {
+		return true
+	}
+	return h.thereafter > 0 && (n-h.initial)%h.thereafter == 0
+}
+
+func (h *samplingHandler) Close() error {
+	return common.Close(h.handler)
+}
+
+// Rotate implements log.Rotator.
+func (h *samplingHandler) Rotate() error {
+	return log.Rotate(h.handler)
+}
@@ -0,0 +1,51 @@
+package log
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+)
+
+type collectingHandler []string
+
+func (h *collectingHandler) Handle(msg log.Message) {
+	*h = append(*h, msg.String())
+}
+
+func TestSampling(t *testing.T) {
+	collected := &collectingHandler{}
+	handler := newSamplingHandler(collected, &LogSpecification{SampleInitial: 2, SampleThereafter: 3, SampleWindow: 10}).(*samplingHandler)
+	now := time.Unix(1700000000, 0)
+	handler.now = func() time.Time { return now }
+
+	flood := func(content string, n int) {
+		for i := 0; i < n; i++ {
+			// Labels, like sequence numbers, do not tell records apart.
+			handler.Handle(&labeledMessage{
+				Message: &log.GeneralMessage{Severity: log.Severity_Warning, Content: content},
+				labels:  []label{{key: "seq", value: strconv.Itoa(i)}},
+			})
+		}
+	}
+	flood("flood", 9)
+	flood("rare", 1)
+	// A new window writes the first records again.
+	now = now.Add(10 * time.Second)
+	flood("flood", 1)
+
+	var expected []string
+	for _, n := range []int{1, 2, 5, 8} {
+		expected = append(expected, "[Warning] flood seq="+strconv.Itoa(n-1))
+	}
+	expected = append(expected, "[Warning] rare seq=0", "[Warning] flood seq=0")
+	if r := cmp.Diff([]string(*collected), expected); r != "" {
+		t.Error(r)
+	}
+
+	if h := newSamplingHandler(collected, &LogSpecification{}); h != log.Handler(collected) {
+		t.Error("expected no sampling without sample_initial")
+	}
+}
@@ -0,0 +1,194 @@
+//go:build sqlite
+// +build sqlite
+
+package log
+
+import (
+	"database/sql"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+	"github.com/v2fly/v2ray-core/v5/common/serial"
+	"github.com/v2fly/v2ray-core/v5/common/signal/done"
+)
+
+const (
+	sqliteBatchSize     = 64
+	sqliteFlushInterval = time.Second
+	sqliteTimeLayout    = "2006-01-02 15:04:05.000"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS access_log (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	time        TEXT NOT NULL,
+	source      TEXT NOT NULL,
+	destination TEXT NOT NULL,
+	status      TEXT NOT NULL,
+	tag         TEXT NOT NULL,
+	reason      TEXT NOT NULL,
+	email       TEXT NOT NULL,
+	bytes       INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS access_log_time ON access_log (time);
+CREATE INDEX IF NOT EXISTS access_log_source ON access_log (source);
+CREATE INDEX IF NOT EXISTS access_log_destination ON access_log (destination);
+CREATE INDEX IF NOT EXISTS access_log_tag ON access_log (tag);
+CREATE INDEX IF NOT EXISTS access_log_bytes ON access_log (bytes);
+`
+
+// sqliteRecord holds the columns of an access record. They are copied as the
+// record is handled, the record itself may change once handled.
+type sqliteRecord struct {
+	time        time.Time
+	source      string
+	destination string
+	status      string
+	tag         string
+	reason      string
+	email       string
+	bytes       int64
+}
+
+// sqliteHandler inserts access records into an SQLite database in batches.
+type sqliteHandler struct {
+	db         *sql.DB
+	maxRecords uint32
+	records    chan sqliteRecord
+	done       *done.Instance
+	finished   chan struct{}
+}
+
+func newSQLiteHandler(path string, maxRecords uint32) (*sqliteHandler, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, newError("failed to open sqlite database ", path).Base(err)
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, newError("failed to create access log table in ", path).Base(err)
+	}
+	h := &sqliteHandler{
+		db:         db,
+		maxRecords: maxRecords,
+		records:    make(chan sqliteRecord, sqliteBatchSize*4),
+		done:       done.New(),
+		finished:   make(chan struct{}),
+	}
+	go h.run()
+	return h, nil
+}
+
+// Handle implements log.Handler. Records are dropped if the writer falls
+// behind, like the other log handlers do.
+func (h *sqliteHandler) Handle(msg log.Message) {
+	access, ok := unwrapMessage(msg).(*log.AccessMessage)
+	if !ok {
+		return
+	}
+	record := sqliteRecord{
+		time:        time.Now(),
+		source:      serial.ToString(access.From),
+		destination: serial.ToString(access.To),
+		status:      string(access.Status),
+		tag:         access.Detour,
+		reason:      serial.ToString(access.Reason),
+		email:       access.Email,
+		bytes:       int64(access.Bytes),
+	}
+	select {
+	case h.records <- record:
+	default:
+	}
+}
+
+func (h *sqliteHandler) run() {
+	defer close(h.finished)
+
+	ticker := time.NewTicker(sqliteFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]sqliteRecord, 0, sqliteBatchSize)
+	for {
+		select {
+		case record := <-h.records:
+			batch = append(batch, record)
+			if len(batch) < sqliteBatchSize {
+				continue
+			}
+		case <-ticker.C:
+		case <-h.done.Wait():
+			for {
+				select {
+				case record := <-h.records:
+					batch = append(batch, record)
+				default:
+					h.flush(batch)
+					return
+				}
+			}
+		}
+		h.flush(batch)
+		batch = batch[:0]
+	}
+}
+
+func (h *sqliteHandler) flush(batch []sqliteRecord) {
+	if len(batch) == 0 {
+		return
+	}
+	if err := h.insert(batch); err != nil {
+		newError("failed to write ", len(batch), " access records to sqlite").Base(err).AtWarning().WriteToLog()
+		return
+	}
+	if h.maxRecords > 0 {
+		if _, err := h.db.Exec("DELETE FROM access_log WHERE id <= (SELECT MAX(id) FROM access_log) - ?", h.maxRecords); err != nil {
+			newError("failed to remove old access records from sqlite").Base(err).AtWarning().WriteToLog()
+		}
+	}
+}
+
+func (h *sqliteHandler) insert(batch []sqliteRecord) error {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare("INSERT INTO access_log (time, source, destination, status, tag, reason, email, bytes) VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, record := range batch {
+		if _, err := stmt.Exec(
+			record.time.UTC().Format(sqliteTimeLayout),
+			record.source,
+			record.destination,
+			record.status,
+			record.tag,
+			record.reason,
+			record.email,
+			record.bytes,
+		); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Close implements common.Closable. Pending records are written first.
+func (h *sqliteHandler) Close() error {
+	h.done.Close()
+	<-h.finished
+	return h.db.Close()
+}
+
+func init() {
+	common.Must(RegisterHandlerCreator(LogType_SQLite, func(lt LogType, options HandlerCreatorOptions) (log.Handler, error) {
+		return newSQLiteHandler(options.Path, options.MaxRecords)
+	}))
+}
@@ -0,0 +1,62 @@
+//go:build sqlite
+// +build sqlite
+
+package log
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+)
+
+func TestSQLiteAccessLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.db")
+	handler, err := createHandler(LogType_SQLite, HandlerCreatorOptions{Path: path, MaxRecords: 3})
+	common.Must(err)
+
+	for i := 0; i < 5; i++ {
+		msg := &log.AccessMessage{
+			From:   "127.0.0.1:" + strconv.Itoa(1000+i),
+			To:     "tcp:example.com:443",
+			Status: log.AccessAccepted,
+			Detour: "direct",
+			Bytes:  uint64(i * 100),
+		}
+		handler.Handle(&labeledMessage{Message: msg})
+		// Records may change once handled, the handled values are stored.
+		msg.Detour = "changed"
+	}
+	handler.Handle(&log.GeneralMessage{Severity: log.Severity_Info, Content: "not an access record"})
+	common.Must(common.Close(handler))
+
+	db, err := sql.Open("sqlite3", path)
+	common.Must(err)
+	defer db.Close()
+
+	rows, err := db.Query("SELECT source FROM access_log WHERE destination = ? AND tag = ? ORDER BY id", "tcp:example.com:443", "direct")
+	common.Must(err)
+	defer rows.Close()
+
+	var sources []string
+	for rows.Next() {
+		var source string
+		common.Must(rows.Scan(&source))
+		sources = append(sources, source)
+	}
+	common.Must(rows.Err())
+
+	if r := cmp.Diff(sources, []string{"127.0.0.1:1002", "127.0.0.1:1003", "127.0.0.1:1004"}); r != "" {
+		t.Error(r)
+	}
+
+	var source string
+	common.Must(db.QueryRow("SELECT source FROM access_log WHERE bytes > ? ORDER BY bytes LIMIT 1", 300).Scan(&source))
+	if source != "127.0.0.1:1004" {
+		t.Error("expected the record with 400 bytes, but actually ", source)
+	}
+}
@@ -0,0 +1,263 @@
+package log
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+	"github.com/v2fly/v2ray-core/v5/common/signal/done"
+)
+
+const (
+	defaultSyslogAddress = "unix:///dev/log"
+	defaultSyslogTag     = "v2ray"
+	syslogQueueSize      = 1024
+	syslogWriteTimeout   = 5 * time.Second
+)
+
+var syslogFacilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+// syslogSeverity returns the syslog severity of records of the given
+// severity. Records without one, like access records, are informational.
+func syslogSeverity(severity log.Severity) int {
+	switch severity {
+	case log.Severity_Error:
+		return 3
+	case log.Severity_Warning:
+		return 4
+	case log.Severity_Debug:
+		return 7
+	default:
+		return 6
+	}
+}
+
+type syslogRecord struct {
+	severity log.Severity
+	content  string
+}
+
+// syslogHandler sends records to a syslog daemon in the format of RFC 3164.
+// Records are dropped and counted if sending falls behind, or the daemon is
+// unreachable even after reconnecting.
+type syslogHandler struct {
+	network  string
+	address  string
+	facility int
+	tag      string
+	hostname string
+	conn     net.Conn
+	stream   bool
+	failing  bool
+	records  chan syslogRecord
+	dropped  uint64 // accessed atomically
+	done     *done.Instance
+	finished chan struct{}
+}
+
+// parseSyslogAddress returns the network and address of a syslog URI.
+func parseSyslogAddress(uri string) (string, string, error) {
+	if uri == "" {
+		uri = defaultSyslogAddress
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", "", newError("invalid syslog address: ", uri).Base(err)
+	}
+	switch u.Scheme {
+	case "udp", "tcp":
+		if u.Port() == "" {
+			return "", "", newError("no port in syslog address: ", uri)
+		}
+		return u.Scheme, u.Host, nil
+	case "unix", "unixgram":
+		if u.Path == "" {
+			return "", "", newError("no path in syslog address: ", uri)
+		}
+		return u.Scheme, u.Path, nil
+	default:
+		return "", "", newError("unsupported syslog address: ", uri)
+	}
+}
+
+func newSyslogHandler(uri string, facility string, tag string) (*syslogHandler, error) {
+	network, address, err := parseSyslogAddress(uri)
+	if err != nil {
+		return nil, err
+	}
+	if facility == "" {
+		facility = "daemon"
+	}
+	code, found := syslogFacilities[strings.ToLower(facility)]
+	if !found {
+		return nil, newError("unknown syslog facility: ", facility)
+	}
+	if tag == "" {
+		tag = defaultSyslogTag
+	}
+	h := &syslogHandler{
+		network:  network,
+		address:  address,
+		facility: code,
+		tag:      tag,
+		records:  make(chan syslogRecord, syslogQueueSize),
+		done:     done.New(),
+		finished: make(chan struct{}),
+	}
+	if network == "udp" || network == "tcp" {
+		// Local daemons know the host, remote ones are told.
+		h.hostname, _ = os.Hostname()
+	}
+	go h.run()
+	return h, nil
+}
+
+// Handle implements log.Handler. Each line of a message is a record.
+func (h *syslogHandler) Handle(msg log.Message) {
+	severity := messageSeverity(msg)
+	for _, line := range strings.Split(msg.String(), "\n") {
+		if line == "" {
+			continue
+		}
+		select {
+		case h.records <- syslogRecord{severity: severity, content: line}:
+		default:
+			atomic.AddUint64(&h.dropped, 1)
+		}
+	}
+}
+
+// Dropped returns the number of records dropped so far.
+func (h *syslogHandler) Dropped() uint64 {
+	return atomic.LoadUint64(&h.dropped)
+}
+
+func (h *syslogHandler) run() {
+	defer close(h.finished)
+
+	for {
+		select {
+		case record := <-h.records:
+			h.send(record)
+		case <-h.done.Wait():
+			for {
+				select {
+				case record := <-h.records:
+					h.send(record)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// send writes record, reconnecting once if the connection is broken.
+func (h *syslogHandler) send(record syslogRecord) {
+	line := h.format(record, time.Now())
+	err := h.write(line)
+	if err != nil {
+		h.disconnect()
+		err = h.write(line)
+	}
+	if err != nil {
+		h.disconnect()
+		dropped := atomic.AddUint64(&h.dropped, 1)
+		if !h.failing {
+			// Reported once per outage, the report itself would fail.
+			h.failing = true
+			newError("failed to send log records to syslog at ", h.address, ", ", dropped, " dropped so far").Base(err).AtWarning().WriteToLog()
+		}
+		return
+	}
+	h.failing = false
+}
+
+func (h *syslogHandler) format(record syslogRecord, t time.Time) string {
+	priority := h.facility*8 + syslogSeverity(record.severity)
+	header := fmt.Sprintf("<%d>%s ", priority, t.Format(time.Stamp))
+	if h.hostname != "" {
+		header += h.hostname + " "
+	}
+	return header + h.tag + "[" + fmt.Sprint(os.Getpid()) + "]: " + record.content
+}
+
+func (h *syslogHandler) write(line string) error {
+	if h.conn == nil {
+		if err := h.dial(); err != nil {
+			return err
+		}
+	}
+	if h.stream {
+		// Non-transparent framing, records end with a newline.
+		line += "\n"
+	}
+	h.conn.SetWriteDeadline(time.Now().Add(syslogWriteTimeout))
+	_, err := h.conn.Write([]byte(line))
+	return err
+}
+
+func (h *syslogHandler) dial() error {
+	network := h.network
+	if network == "unix" {
+		// Local daemons mostly listen on datagram sockets, like /dev/log.
+		if conn, err := net.Dial("unixgram", h.address); err == nil {
+			h.conn, h.stream = conn, false
+			return nil
+		}
+	}
+	conn, err := net.DialTimeout(network, h.address, syslogWriteTimeout)
+	if err != nil {
+		return err
+	}
+	h.conn, h.stream = conn, network == "tcp" || network == "unix"
+	return nil
+}
+
+func (h *syslogHandler) disconnect() {
+	if h.conn != nil {
+		h.conn.Close()
+		h.conn = nil
+	}
+}
+
+// Close implements common.Closable. Pending records are sent first.
+func (h *syslogHandler) Close() error {
+	h.done.Close()
+	<-h.finished
+	h.disconnect()
+	return nil
+}
+
+func init() {
+	common.Must(RegisterHandlerCreator(LogType_Syslog, func(lt LogType, options HandlerCreatorOptions) (log.Handler, error) {
+		return newSyslogHandler(options.Path, options.SyslogFacility, options.SyslogTag)
+	}))
+}
@@ -0,0 +1,62 @@
+package log
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+)
+
+func TestSyslogLog(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	common.Must(err)
+	defer conn.Close()
+
+	handler, err := createSpecHandler(&LogSpecification{
+		Type:           LogType_Syslog,
+		Path:           "udp://" + conn.LocalAddr().String(),
+		SyslogFacility: "local3",
+		SyslogTag:      "proxy",
+	}, nil)
+	common.Must(err)
+	handler.Handle(&log.GeneralMessage{Severity: log.Severity_Error, Content: "broken"})
+	handler.Handle(&log.GeneralMessage{Severity: log.Severity_Debug, Content: "noisy"})
+	handler.Handle(&log.AccessMessage{From: "127.0.0.1:1234", To: "example.com:443", Status: log.AccessAccepted})
+	common.Must(common.Close(handler))
+
+	// local3 is 19, error 3, debug 7 and info 6.
+	for _, c := range []struct {
+		prefix  string
+		content string
+	}{
+		{"<155>", "broken"},
+		{"<159>", "noisy"},
+		{"<158>", "example.com:443"},
+	} {
+		common.Must(conn.SetReadDeadline(time.Now().Add(5 * time.Second)))
+		b := make([]byte, 2048)
+		n, _, err := conn.ReadFrom(b)
+		common.Must(err)
+		record := string(b[:n])
+		if !strings.HasPrefix(record, c.prefix) || !strings.Contains(record, " proxy[") || !strings.HasSuffix(record, c.content) {
+			t.Error("expected a record with priority ", c.prefix, " ending in ", c.content, ", but actually ", record)
+		}
+	}
+}
+
+func TestSyslogConfigValidation(t *testing.T) {
+	cases := []*LogSpecification{
+		{Type: LogType_Syslog, Path: "udp://127.0.0.1"},
+		{Type: LogType_Syslog, Path: "unix://"},
+		{Type: LogType_Syslog, Path: "http://127.0.0.1:514"},
+		{Type: LogType_Syslog, Path: "udp://127.0.0.1:514", SyslogFacility: "local9"},
+	}
+	for i, spec := range cases {
+		if _, err := createSpecHandler(spec, nil); err == nil {
+			t.Error("expected spec ", i, " to be rejected")
+		}
+	}
+}
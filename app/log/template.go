@@ -0,0 +1,123 @@
+package log
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+
+	"github.com/v2fly/v2ray-core/v5/common/log"
+)
+
+// maxTemplateFiles is how many files a path template keeps open at once. The
+// least recently written one is closed beyond it, and reopened when needed.
+const maxTemplateFiles = 64
+
+const untaggedPathValue = "untagged"
+
+var tagPathEscaper = strings.NewReplacer("/", "_", `\`, "_", "..", "_")
+
+// isPathTemplate returns true if path contains placeholders for tags, so
+// records go to a file per tag.
+func isPathTemplate(path string) bool {
+	return strings.Contains(path, "{inbound}") || strings.Contains(path, "{outbound}")
+}
+
+type templateFile struct {
+	path   string
+	writer log.Writer
+}
+
+// templateFileHandler writes each record to the file its tags expand the
+// path template to. Records other than access records have no tags.
+type templateFileHandler struct {
+	sync.Mutex
+	template     string
+	createWriter func(path string) (log.WriterCreator, error)
+	files        map[string]*list.Element
+	lru          *list.List
+}
+
+func newTemplateFileHandler(template string, createWriter func(path string) (log.WriterCreator, error)) *templateFileHandler {
+	return &templateFileHandler{
+		template:     template,
+		createWriter: createWriter,
+		files:        make(map[string]*list.Element),
+		lru:          list.New(),
+	}
+}
+
+func (h *templateFileHandler) path(msg log.Message) string {
+	var inbound, outbound string
+	if access, ok := unwrapMessage(msg).(*log.AccessMessage); ok {
+		inbound, outbound = access.InboundTag, access.Detour
+	}
+	return strings.NewReplacer(
+		"{inbound}", tagPathValue(inbound),
+		"{outbound}", tagPathValue(outbound),
+	).Replace(h.template)
+}
+
+func tagPathValue(tag string) string {
+	if tag == "" {
+		return untaggedPathValue
+	}
+	return tagPathEscaper.Replace(tag)
+}
+
+// Handle implements log.Handler.
+func (h *templateFileHandler) Handle(msg log.Message) {
+	path := h.path(msg)
+
+	h.Lock()
+	defer h.Unlock()
+
+	writer, err := h.writer(path)
+	if err != nil {
+		newError("failed to open log file ", path).Base(err).AtWarning().WriteToLog()
+		return
+	}
+	if err := writer.Write(msg.String()); err != nil {
+		newError("failed to write log file ", path).Base(err).AtWarning().WriteToLog()
+	}
+}
+
+// writer returns the open writer of path, opening it first if needed.
+func (h *templateFileHandler) writer(path string) (log.Writer, error) {
+	if element, found := h.files[path]; found {
+		h.lru.MoveToFront(element)
+		return element.Value.(*templateFile).writer, nil
+	}
+	creator, err := h.createWriter(path)
+	if err != nil {
+		return nil, err
+	}
+	writer := creator()
+	if writer == nil {
+		return nil, newError("failed to create log writer")
+	}
+	h.files[path] = h.lru.PushFront(&templateFile{path: path, writer: writer})
+	for h.lru.Len() > maxTemplateFiles {
+		oldest := h.lru.Remove(h.lru.Back()).(*templateFile)
+		delete(h.files, oldest.path)
+		oldest.writer.Close()
+	}
+	return writer, nil
+}
+
+// Rotate implements log.Rotator. Files are reopened as they are written.
+func (h *templateFileHandler) Rotate() error {
+	return h.Close()
+}
+
+// Close implements common.Closable.
+func (h *templateFileHandler) Close() error {
+	h.Lock()
+	defer h.Unlock()
+
+	for element := h.lru.Front(); element != nil; element = element.Next() {
+		element.Value.(*templateFile).writer.Close()
+	}
+	h.files = make(map[string]*list.Element)
+	h.lru.Init()
+	return nil
+}
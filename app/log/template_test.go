@@ -0,0 +1,77 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+)
+
+func TestPathTemplate(t *testing.T) {
+	dir := t.TempDir()
+	handler, err := createHandler(LogType_File, HandlerCreatorOptions{
+		Path:   filepath.Join(dir, "access-{inbound}.log"),
+		Format: LogFormat_Logfmt,
+	})
+	common.Must(err)
+
+	for _, tag := range []string{"socks", "http", "socks", "../escape", ""} {
+		handler.Handle(&log.AccessMessage{
+			From:       "127.0.0.1:1234",
+			To:         "tcp:example.com:443",
+			Status:     log.AccessAccepted,
+			InboundTag: tag,
+		})
+	}
+	common.Must(common.Close(handler))
+
+	expected := map[string]int{
+		"access-socks.log":    2,
+		"access-http.log":     1,
+		"access-__escape.log": 1,
+		"access-untagged.log": 1,
+	}
+	entries, err := os.ReadDir(dir)
+	common.Must(err)
+	if len(entries) != len(expected) {
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		t.Error("expected files ", expected, ", but actually ", names)
+	}
+	for name, lines := range expected {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if n := strings.Count(string(content), "\n"); n != lines {
+			t.Error("expected ", lines, " records in ", name, ", but actually ", n)
+		}
+	}
+}
+
+func TestPathTemplateOpenFiles(t *testing.T) {
+	dir := t.TempDir()
+	handler := newTemplateFileHandler(filepath.Join(dir, "{outbound}.log"), log.CreateRawFileLogWriter)
+	defer handler.Close()
+
+	for i := 0; i < maxTemplateFiles+10; i++ {
+		handler.Handle(&log.AccessMessage{Status: log.AccessAccepted, Detour: "out" + strings.Repeat("x", i)})
+	}
+	if n := handler.lru.Len(); n != maxTemplateFiles {
+		t.Error("expected ", maxTemplateFiles, " open files, but actually ", n)
+	}
+	// A closed file is reopened and appended to.
+	handler.Handle(&log.AccessMessage{Status: log.AccessAccepted, Detour: "out"})
+	common.Must(handler.Close())
+	content, err := os.ReadFile(filepath.Join(dir, "out.log"))
+	common.Must(err)
+	if n := strings.Count(string(content), "\n"); n != 2 {
+		t.Error("expected 2 records in reopened file, but actually ", n)
+	}
+}
@@ -0,0 +1,65 @@
+package log
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+)
+
+// traceGenerator derives trace and span ids for connections carrying none
+// from their session id, so all records of a connection share them. The ids
+// are keyed randomly at start, for session ids repeating across runs.
+type traceGenerator struct {
+	key []byte
+}
+
+func newTraceGenerator() *traceGenerator {
+	key := make([]byte, 32)
+	common.Must2(rand.Read(key))
+	return &traceGenerator{key: key}
+}
+
+// ids returns the trace and span ids of the connection with sessionID.
+func (t *traceGenerator) ids(sessionID uint32) (string, string) {
+	mac := hmac.New(sha256.New, t.key)
+	var id [4]byte
+	binary.BigEndian.PutUint32(id[:], sessionID)
+	mac.Write(id[:])
+	sum := mac.Sum(nil)
+	return hex.EncodeToString(sum[:16]), hex.EncodeToString(sum[16:24])
+}
+
+// withTrace returns msg with generated ids if it is a record of a connection
+// without them, or msg as is.
+func (t *traceGenerator) withTrace(msg log.Message) log.Message {
+	switch msg := msg.(type) {
+	case *log.GeneralMessage:
+		if msg.SessionID == 0 || len(msg.TraceID) > 0 {
+			return msg
+		}
+		traced := *msg
+		traced.TraceID, traced.SpanID = t.ids(msg.SessionID)
+		return &traced
+	case *log.AccessMessage:
+		if msg.SessionID == 0 || len(msg.TraceID) > 0 {
+			return msg
+		}
+		traced := *msg
+		traced.TraceID, traced.SpanID = t.ids(msg.SessionID)
+		return &traced
+	case *log.DNSMessage:
+		if msg.SessionID == 0 || len(msg.TraceID) > 0 {
+			return msg
+		}
+		traced := *msg
+		traced.TraceID, traced.SpanID = t.ids(msg.SessionID)
+		return &traced
+	default:
+		return msg
+	}
+}
@@ -79,10 +79,11 @@ func (w *tcpWorker) callback(conn internet.Connection) {
 		}
 	}
 	ctx = session.ContextWithInbound(ctx, &session.Inbound{
-		Source:  net.DestinationFromAddr(conn.RemoteAddr()),
-		Gateway: net.TCPDestination(w.address, w.port),
-		Tag:     w.tag,
-		Conn:    conn,
+		Source:    net.DestinationFromAddr(conn.RemoteAddr()),
+		Gateway:   net.TCPDestination(w.address, w.port),
+		Tag:       w.tag,
+		Conn:      conn,
+		Transport: w.stream.ProtocolName,
 	})
 	content := new(session.Content)
 	if w.sniffingConfig != nil {
@@ -305,10 +306,11 @@ func (w *udpWorker) callback(b *buf.Buffer, source net.Destination, originalDest
 				})
 			}
 			ctx = session.ContextWithInbound(ctx, &session.Inbound{
-				Source:  source,
-				Gateway: net.UDPDestination(w.address, w.port),
-				Tag:     w.tag,
-				Conn:    conn,
+				Source:    source,
+				Gateway:   net.UDPDestination(w.address, w.port),
+				Tag:       w.tag,
+				Conn:      conn,
+				Transport: "udp",
 			})
 			content := new(session.Content)
 			if w.sniffingConfig != nil {
@@ -449,10 +451,11 @@ func (w *dsWorker) callback(conn internet.Connection) {
 	ctx = session.ContextWithID(ctx, sid)
 
 	ctx = session.ContextWithInbound(ctx, &session.Inbound{
-		Source:  net.DestinationFromAddr(conn.RemoteAddr()),
-		Gateway: net.UnixDestination(w.address),
-		Tag:     w.tag,
-		Conn:    conn,
+		Source:    net.DestinationFromAddr(conn.RemoteAddr()),
+		Gateway:   net.UnixDestination(w.address),
+		Tag:       w.tag,
+		Conn:      conn,
+		Transport: w.stream.ProtocolName,
 	})
 	content := new(session.Content)
 	if w.sniffingConfig != nil {
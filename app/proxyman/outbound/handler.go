@@ -9,6 +9,7 @@ import (
 	"github.com/v2fly/v2ray-core/v5/common"
 	"github.com/v2fly/v2ray-core/v5/common/buf"
 	"github.com/v2fly/v2ray-core/v5/common/errors"
+	"github.com/v2fly/v2ray-core/v5/common/log"
 	"github.com/v2fly/v2ray-core/v5/common/mux"
 	"github.com/v2fly/v2ray-core/v5/common/net"
 	"github.com/v2fly/v2ray-core/v5/common/net/packetaddr"
@@ -147,6 +148,14 @@ func (h *Handler) Tag() string {
 	return h.tag
 }
 
+// Transport implements outbound.TransportHandler.
+func (h *Handler) Transport() string {
+	if h.streamSettings == nil {
+		return "tcp"
+	}
+	return h.streamSettings.ProtocolName
+}
+
 // Dispatch implements proxy.Outbound.Dispatch.
 func (h *Handler) Dispatch(ctx context.Context, link *transport.Link) {
 	outbound := session.OutboundFromContext(ctx)
@@ -218,6 +227,7 @@ func (h *Handler) Dispatch(ctx context.Context, link *transport.Link) {
 		if err != nil {
 			err := newError("failed to process outbound traffic").Base(err)
 			err.WriteToLog(session.ExportIDToError(ctx))
+			log.RecordAccessFailure(ctx, err)
 			session.SubmitOutboundErrorToOriginator(ctx, err)
 			common.Interrupt(link.Writer)
 		} else {
@@ -409,8 +419,19 @@ func (h *Handler) GetOutbound() proxy.Outbound {
 	return h.proxy
 }
 
+// Healthy implements outbound.HealthReporter.
+func (h *Handler) Healthy() bool {
+	if checker, ok := h.proxy.(proxy.HealthChecker); ok {
+		return checker.Healthy()
+	}
+	return true
+}
+
 // Start implements common.Runnable.
 func (h *Handler) Start() error {
+	if checker, ok := h.proxy.(proxy.HealthChecker); ok {
+		checker.StartHealthCheck(h)
+	}
 	return nil
 }
 
@@ -60,14 +60,22 @@ func (b *Balancer) InjectContext(ctx context.Context) {
 	}
 }
 
-// SelectOutbounds select outbounds with selectors of the Balancer
+// SelectOutbounds select outbounds with selectors of the Balancer, leaving
+// out those reporting their server unreachable.
 func (b *Balancer) SelectOutbounds() ([]string, error) {
 	hs, ok := b.ohm.(outbound.HandlerSelector)
 	if !ok {
 		return nil, newError("outbound.Manager is not a HandlerSelector")
 	}
 	tags := hs.Select(b.selectors)
-	return tags, nil
+	healthy := tags[:0:0]
+	for _, tag := range tags {
+		if reporter, ok := b.ohm.GetHandler(tag).(outbound.HealthReporter); ok && !reporter.Healthy() {
+			continue
+		}
+		healthy = append(healthy, tag)
+	}
+	return healthy, nil
 }
 
 // GetPrincipleTarget implements routing.BalancerPrincipleTarget
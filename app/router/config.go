@@ -17,9 +17,10 @@ import (
 )
 
 type Rule struct {
-	Tag       string
-	Balancer  *Balancer
-	Condition Condition
+	Tag          string
+	Balancer     *Balancer
+	BalancingTag string
+	Condition    Condition
 }
 
 func (r *Rule) GetTag() (string, error) {
@@ -29,6 +30,13 @@ func (r *Rule) GetTag() (string, error) {
 	return r.Tag, nil
 }
 
+func (r *Rule) traceTarget() string {
+	if r.Balancer != nil {
+		return "balancer " + r.BalancingTag
+	}
+	return r.Tag
+}
+
 // Apply checks rule matching of current routing context.
 func (r *Rule) Apply(ctx routing.Context) bool {
 	return r.Condition.Apply(ctx)
@@ -23,6 +23,7 @@ type Router struct {
 	rules          []*Rule
 	balancers      map[string]*Balancer
 	dns            dns.Client
+	traceRouting   bool
 }
 
 // Route is an implementation of routing.Route.
@@ -35,6 +36,7 @@ type Route struct {
 // Init initializes the Router.
 func (r *Router) Init(ctx context.Context, config *Config, d dns.Client, ohm outbound.Manager, dispatcher routing.Dispatcher) error {
 	r.domainStrategy = config.DomainStrategy
+	r.traceRouting = config.TraceRouting
 	r.dns = d
 
 	r.balancers = make(map[string]*Balancer, len(config.BalancingRule))
@@ -64,6 +66,7 @@ func (r *Router) Init(ctx context.Context, config *Config, d dns.Client, ohm out
 				return newError("balancer ", btag, " not found")
 			}
 			rr.Balancer = brule
+			rr.BalancingTag = btag
 		}
 		r.rules = append(r.rules, rr)
 	}
@@ -75,15 +78,47 @@ func (r *Router) Init(ctx context.Context, config *Config, d dns.Client, ohm out
 func (r *Router) PickRoute(ctx routing.Context) (routing.Route, error) {
 	rule, ctx, err := r.pickRouteInternal(ctx)
 	if err != nil {
+		if r.traceRouting {
+			newError("[", traceConnection(ctx), "] no rule matched").AtDebug().WriteToLog()
+		}
 		return nil, err
 	}
 	tag, err := rule.GetTag()
 	if err != nil {
 		return nil, err
 	}
+	if r.traceRouting {
+		newError("[", traceConnection(ctx), "] selected outbound [", tag, "]").AtDebug().WriteToLog()
+	}
 	return &Route{Context: ctx, outboundTag: tag}, nil
 }
 
+// applyRule applies the i-th rule, tracing the result if enabled.
+func (r *Router) applyRule(ctx routing.Context, i int, rule *Rule) bool {
+	matched := rule.Apply(ctx)
+	if r.traceRouting {
+		result := "not matched"
+		if matched {
+			result = "matched"
+		}
+		newError("[", traceConnection(ctx), "] rule ", i, " (", rule.traceTarget(), ") ", result).AtDebug().WriteToLog()
+	}
+	return matched
+}
+
+// traceConnection describes the connection being routed for routing traces.
+func traceConnection(ctx routing.Context) string {
+	dest := net.Destination{Network: ctx.GetNetwork(), Port: ctx.GetTargetPort()}
+	if domain := ctx.GetTargetDomain(); domain != "" {
+		dest.Address = net.DomainAddress(domain)
+	} else if ips := ctx.GetTargetIPs(); len(ips) > 0 {
+		dest.Address = net.IPAddress(ips[0])
+	} else {
+		return ctx.GetInboundTag()
+	}
+	return ctx.GetInboundTag() + " -> " + dest.String()
+}
+
 func (r *Router) pickRouteInternal(ctx routing.Context) (*Rule, routing.Context, error) {
 	// SkipDNSResolve is set from DNS module.
 	// the DOH remote server maybe a domain name,
@@ -94,8 +129,8 @@ func (r *Router) pickRouteInternal(ctx routing.Context) (*Rule, routing.Context,
 		ctx = routing_dns.ContextWithDNSClient(ctx, r.dns)
 	}
 
-	for _, rule := range r.rules {
-		if rule.Apply(ctx) {
+	for i, rule := range r.rules {
+		if r.applyRule(ctx, i, rule) {
 			return rule, ctx, nil
 		}
 	}
@@ -107,8 +142,8 @@ func (r *Router) pickRouteInternal(ctx routing.Context) (*Rule, routing.Context,
 	ctx = routing_dns.ContextWithDNSClient(ctx, r.dns)
 
 	// Try applying rules again if we have IPs.
-	for _, rule := range r.rules {
-		if rule.Apply(ctx) {
+	for i, rule := range r.rules {
+		if r.applyRule(ctx, i, rule) {
 			return rule, ctx, nil
 		}
 	}
@@ -265,6 +300,7 @@ func init() {
 			DomainStrategy: simplifiedConfig.DomainStrategy,
 			Rule:           routingRules,
 			BalancingRule:  simplifiedConfig.BalancingRule,
+			TraceRouting:   simplifiedConfig.TraceRouting,
 		}
 		return common.CreateObject(ctx, fullConfig)
 	}))
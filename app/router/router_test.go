@@ -8,6 +8,8 @@ import (
 	. "github.com/v2fly/v2ray-core/v5/app/router"
 	"github.com/v2fly/v2ray-core/v5/app/router/routercommon"
 	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+	"github.com/v2fly/v2ray-core/v5/common/log/logtest"
 	"github.com/v2fly/v2ray-core/v5/common/net"
 	"github.com/v2fly/v2ray-core/v5/common/session"
 	"github.com/v2fly/v2ray-core/v5/features/outbound"
@@ -20,6 +22,15 @@ type mockOutboundManager struct {
 	outbound.HandlerSelector
 }
 
+type healthReportingHandler struct {
+	outbound.Handler
+	healthy bool
+}
+
+func (h *healthReportingHandler) Healthy() bool {
+	return h.healthy
+}
+
 func TestSimpleRouter(t *testing.T) {
 	config := &Config{
 		Rule: []*RoutingRule{
@@ -53,6 +64,47 @@ func TestSimpleRouter(t *testing.T) {
 	}
 }
 
+func TestTraceRouting(t *testing.T) {
+	config := &Config{
+		Rule: []*RoutingRule{
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "other",
+				},
+				InboundTag: []string{"other-in"},
+			},
+			{
+				TargetTag: &RoutingRule_Tag{
+					Tag: "test",
+				},
+				Networks: []net.Network{net.Network_TCP},
+			},
+		},
+		TraceRouting: true,
+	}
+
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	mockDNS := mocks.NewDNSClient(mockCtl)
+
+	r := new(Router)
+	common.Must(r.Init(context.TODO(), config, mockDNS, nil, nil))
+
+	recorder := logtest.Capture(t)
+	ctx := session.ContextWithInbound(context.Background(), &session.Inbound{Tag: "in"})
+	ctx = session.ContextWithOutbound(ctx, &session.Outbound{Target: net.TCPDestination(net.DomainAddress("v2fly.org"), 80)})
+	route, err := r.PickRoute(routing_session.AsRoutingContext(ctx))
+	common.Must(err)
+	if tag := route.GetOutboundTag(); tag != "test" {
+		t.Error("expect tag 'test', bug actually ", tag)
+	}
+
+	recorder.AssertContains(log.Severity_Debug, "[in -> tcp:v2fly.org:80] rule 0 (other) not matched")
+	recorder.AssertContains(log.Severity_Debug, "[in -> tcp:v2fly.org:80] rule 1 (test) matched")
+	recorder.AssertContains(log.Severity_Debug, "[in -> tcp:v2fly.org:80] selected outbound [test]")
+}
+
 func TestSimpleBalancer(t *testing.T) {
 	config := &Config{
 		Rule: []*RoutingRule{
@@ -79,6 +131,7 @@ func TestSimpleBalancer(t *testing.T) {
 	mockHs := mocks.NewOutboundHandlerSelector(mockCtl)
 
 	mockHs.EXPECT().Select(gomock.Eq([]string{"test-"})).Return([]string{"test"})
+	mockOhm.EXPECT().GetHandler(gomock.Eq("test")).Return(nil)
 
 	r := new(Router)
 	common.Must(r.Init(context.TODO(), config, mockDNS, &mockOutboundManager{
@@ -94,6 +147,49 @@ func TestSimpleBalancer(t *testing.T) {
 	}
 }
 
+func TestBalancerSkipsUnhealthy(t *testing.T) {
+	config := &Config{
+		Rule: []*RoutingRule{
+			{
+				TargetTag: &RoutingRule_BalancingTag{
+					BalancingTag: "balance",
+				},
+				Networks: []net.Network{net.Network_TCP},
+			},
+		},
+		BalancingRule: []*BalancingRule{
+			{
+				Tag:              "balance",
+				OutboundSelector: []string{"test-"},
+			},
+		},
+	}
+
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	mockDNS := mocks.NewDNSClient(mockCtl)
+	mockOhm := mocks.NewOutboundManager(mockCtl)
+	mockHs := mocks.NewOutboundHandlerSelector(mockCtl)
+
+	mockHs.EXPECT().Select(gomock.Eq([]string{"test-"})).Return([]string{"test-down", "test-up"})
+	mockOhm.EXPECT().GetHandler(gomock.Eq("test-down")).Return(&healthReportingHandler{healthy: false})
+	mockOhm.EXPECT().GetHandler(gomock.Eq("test-up")).Return(&healthReportingHandler{healthy: true})
+
+	r := new(Router)
+	common.Must(r.Init(context.TODO(), config, mockDNS, &mockOutboundManager{
+		Manager:         mockOhm,
+		HandlerSelector: mockHs,
+	}, nil))
+
+	ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{Target: net.TCPDestination(net.DomainAddress("v2fly.org"), 80)})
+	route, err := r.PickRoute(routing_session.AsRoutingContext(ctx))
+	common.Must(err)
+	if tag := route.GetOutboundTag(); tag != "test-up" {
+		t.Error("expect tag 'test-up', bug actually ", tag)
+	}
+}
+
 /*
 
 Do not work right now: need a full client setup
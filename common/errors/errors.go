@@ -26,6 +26,7 @@ type Error struct {
 	message  []interface{}
 	inner    error
 	severity log.Severity
+	category string
 }
 
 func (err *Error) WithPathObj(obj interface{}) *Error {
@@ -33,6 +34,24 @@ func (err *Error) WithPathObj(obj interface{}) *Error {
 	return err
 }
 
+// WithCategory tags the error with category, such as routing, so the error
+// log can be filtered by it besides the package the error comes from.
+func (err *Error) WithCategory(category string) *Error {
+	err.category = category
+	return err
+}
+
+// Category returns the category the error is tagged with, empty if none.
+func (err *Error) Category() string {
+	return err.category
+}
+
+// Package returns the path of the package the error comes from, relative to
+// the module, empty if unknown.
+func (err *Error) Package() string {
+	return err.pkgPath()
+}
+
 func (err *Error) pkgPath() string {
 	if err.pathObj == nil {
 		return ""
@@ -141,13 +160,20 @@ func (err *Error) WriteToLog(opts ...ExportOption) {
 	}
 
 	log.Record(&log.GeneralMessage{
-		Severity: GetSeverity(err),
-		Content:  err,
+		Severity:   GetSeverity(err),
+		Content:    err,
+		SessionID:  holder.SessionID,
+		InboundTag: holder.InboundTag,
+		TraceID:    holder.TraceID,
+		SpanID:     holder.SpanID,
 	})
 }
 
 type ExportOptionHolder struct {
-	SessionID uint32
+	SessionID  uint32
+	InboundTag string
+	TraceID    string
+	SpanID     string
 }
 
 type ExportOption func(*ExportOptionHolder)
@@ -58,4 +58,9 @@ func TestErrorMessage(t *testing.T) {
 			t.Error(diff)
 		}
 	}
+
+	err := New("a").WithPathObj(e{}).WithCategory("routing")
+	if err.Package() != "common/errors_test" || err.Category() != "routing" {
+		t.Error("unexpected origin: ", err.Package(), " ", err.Category())
+	}
 }
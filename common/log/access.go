@@ -2,7 +2,10 @@ package log
 
 import (
 	"context"
+	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
 
 	"github.com/v2fly/v2ray-core/v5/common/serial"
 )
@@ -11,6 +14,7 @@ type logKey int
 
 const (
 	accessMessageKey logKey = iota
+	accessFailureKey
 )
 
 type AccessStatus string
@@ -18,6 +22,9 @@ type AccessStatus string
 const (
 	AccessAccepted = AccessStatus("accepted")
 	AccessRejected = AccessStatus("rejected")
+	// AccessFailed is recorded for an accepted connection whose outbound
+	// failed.
+	AccessFailed = AccessStatus("failed")
 )
 
 type AccessMessage struct {
@@ -26,7 +33,49 @@ type AccessMessage struct {
 	Status AccessStatus
 	Reason interface{}
 	Email  string
+	// Detour is the tag of the outbound handling the connection.
 	Detour string
+	// InboundTag is the tag of the inbound that accepted the connection.
+	InboundTag string
+	// Transport protocols carrying the connection on the inbound and the
+	// outbound side, like tcp or websocket.
+	InboundTransport  string
+	OutboundTransport string
+	// Outcome is the cause of a failure, if known. It is empty on records
+	// logged as the connection is accepted, before it ends.
+	Outcome Outcome
+	// Bytes is the traffic of the connection in both directions, 0 if
+	// unknown. It is left to the proxies and embedders that count it.
+	Bytes uint64
+	// Attributes are custom fields set by embedders and proxies, rendered
+	// after the others in order of their keys.
+	Attributes map[string]string
+	// SessionID identifies the connection, 0 if unknown.
+	SessionID uint32
+	// TraceID and SpanID are the distributed tracing ids of the connection,
+	// if any.
+	TraceID string
+	SpanID  string
+}
+
+// OutboundTag returns the tag of the outbound handling the connection.
+func (m *AccessMessage) OutboundTag() string {
+	return m.Detour
+}
+
+// AttributeKeys returns the keys of the attributes of m, sorted.
+func (m *AccessMessage) AttributeKeys() []string {
+	keys := make([]string, 0, len(m.Attributes))
+	for key := range m.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Failed returns true if m records a connection that was rejected or failed.
+func (m *AccessMessage) Failed() bool {
+	return m.Status != AccessAccepted
 }
 
 func (m *AccessMessage) String() string {
@@ -53,11 +102,36 @@ func (m *AccessMessage) String() string {
 		builder.WriteString(m.Email)
 	}
 
+	if len(m.Outcome) > 0 {
+		builder.WriteString(" outcome: ")
+		builder.WriteString(string(m.Outcome))
+	}
+
+	if m.Bytes > 0 {
+		builder.WriteString(" bytes: ")
+		builder.WriteString(strconv.FormatUint(m.Bytes, 10))
+	}
+
+	for _, key := range m.AttributeKeys() {
+		builder.WriteByte(' ')
+		builder.WriteString(key)
+		builder.WriteString(": ")
+		builder.WriteString(m.Attributes[key])
+	}
+
+	if len(m.TraceID) > 0 {
+		builder.WriteString(" trace_id: ")
+		builder.WriteString(m.TraceID)
+		builder.WriteString(" span_id: ")
+		builder.WriteString(m.SpanID)
+	}
+
 	return builder.String()
 }
 
 func ContextWithAccessMessage(ctx context.Context, accessMessage *AccessMessage) context.Context {
-	return context.WithValue(ctx, accessMessageKey, accessMessage)
+	ctx = context.WithValue(ctx, accessMessageKey, accessMessage)
+	return context.WithValue(ctx, accessFailureKey, new(int32))
 }
 
 func AccessMessageFromContext(ctx context.Context) *AccessMessage {
@@ -66,3 +140,24 @@ func AccessMessageFromContext(ctx context.Context) *AccessMessage {
 	}
 	return nil
 }
+
+// RecordAccessFailure records the connection of ctx, if it has an access
+// record, as failed for reason. The outcome is told from reason if it is an
+// error. The access record of ctx is left as is, it may still be queued in a
+// logger. Only the first failure of a connection is recorded.
+func RecordAccessFailure(ctx context.Context, reason interface{}) {
+	accessMessage := AccessMessageFromContext(ctx)
+	if accessMessage == nil {
+		return
+	}
+	if recorded, ok := ctx.Value(accessFailureKey).(*int32); ok && !atomic.CompareAndSwapInt32(recorded, 0, 1) {
+		return
+	}
+	failure := *accessMessage
+	failure.Status = AccessFailed
+	failure.Reason = reason
+	if err, ok := reason.(error); ok {
+		failure.Outcome = OutcomeOf(err)
+	}
+	Record(&failure)
+}
@@ -0,0 +1,56 @@
+package log
+
+import (
+	"strings"
+)
+
+// batchWriter coalesces records into one write per batch of up to size
+// records, so sinks with a cost per write see fewer of them. Partial batches
+// are written by Flush and Close.
+type batchWriter struct {
+	writer  Writer
+	size    int
+	pending strings.Builder
+	count   int
+}
+
+func (w *batchWriter) Write(s string) error {
+	w.pending.WriteString(s)
+	w.count++
+	if w.count >= w.size {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Flush implements Flusher.
+func (w *batchWriter) Flush() error {
+	if w.count == 0 {
+		return nil
+	}
+	batch := w.pending.String()
+	w.pending.Reset()
+	w.count = 0
+	return w.writer.Write(batch)
+}
+
+func (w *batchWriter) Close() error {
+	err := w.Flush()
+	if closeErr := w.writer.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// CreateBatchWriter returns a WriterCreator whose writers pass records on to
+// the ones of creator in batches of up to size. Use it with NewBufferedLogger
+// so partial batches are written in time.
+func CreateBatchWriter(creator WriterCreator, size int) WriterCreator {
+	return func() Writer {
+		writer := creator()
+		if writer == nil {
+			return nil
+		}
+		return &batchWriter{writer: writer, size: size}
+	}
+}
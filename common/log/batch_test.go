@@ -0,0 +1,95 @@
+package log_test
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/v2fly/v2ray-core/v5/common"
+	. "github.com/v2fly/v2ray-core/v5/common/log"
+)
+
+type recordingWriter struct {
+	sync.Mutex
+	writes []string
+	closed bool
+}
+
+func (w *recordingWriter) Write(s string) error {
+	w.Lock()
+	defer w.Unlock()
+	w.writes = append(w.writes, s)
+	return nil
+}
+
+func (w *recordingWriter) Close() error {
+	w.Lock()
+	defer w.Unlock()
+	w.closed = true
+	return nil
+}
+
+func (w *recordingWriter) Writes() []string {
+	w.Lock()
+	defer w.Unlock()
+	return append([]string(nil), w.writes...)
+}
+
+func TestBatchWriter(t *testing.T) {
+	inner := &recordingWriter{}
+	writer := CreateBatchWriter(func() Writer { return inner }, 4)()
+
+	for i := 1; i <= 6; i++ {
+		common.Must(writer.Write(strconv.Itoa(i) + "\n"))
+	}
+	if r := cmp.Diff(inner.Writes(), []string{"1\n2\n3\n4\n"}); r != "" {
+		t.Error(r)
+	}
+
+	common.Must(writer.Close())
+	if r := cmp.Diff(inner.Writes(), []string{"1\n2\n3\n4\n", "5\n6\n"}); r != "" {
+		t.Error(r)
+	}
+	if !inner.closed {
+		t.Error("underlying writer not closed")
+	}
+}
+
+func TestBatchedLogger(t *testing.T) {
+	inner := &recordingWriter{}
+	handler := NewBufferedLogger("batched", CreateBatchWriter(func() Writer { return inner }, 4), 50*time.Millisecond)
+	defer common.Close(handler)
+
+	for i := 1; i <= 10; i++ {
+		handler.Handle(&GeneralMessage{Severity: Severity_Info, Content: strconv.Itoa(i)})
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for strings.Count(strings.Join(inner.Writes(), ""), "\n") < 10 {
+		if time.Now().After(deadline) {
+			t.Fatal("records not written: ", inner.Writes())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	writes := inner.Writes()
+	if len(writes) > 3 {
+		t.Error("expected records in batches of up to 4, got ", len(writes), " writes")
+	}
+	var records []string
+	for _, write := range writes {
+		for _, line := range strings.Split(strings.TrimSpace(write), "\n") {
+			records = append(records, strings.TrimSpace(line))
+		}
+	}
+	var expected []string
+	for i := 1; i <= 10; i++ {
+		expected = append(expected, "[Info] "+strconv.Itoa(i))
+	}
+	if r := cmp.Diff(records, expected); r != "" {
+		t.Error(r)
+	}
+}
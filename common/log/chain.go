@@ -0,0 +1,121 @@
+package log
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/v2fly/v2ray-core/v5/common/platform"
+)
+
+const (
+	chainField     = " hmac="
+	chainJSONField = `,"hmac":"`
+)
+
+// chainWriter appends to every line an HMAC of the line and the HMAC of the
+// line before, so altering, inserting or removing a line breaks the chain
+// from there on.
+type chainWriter struct {
+	writer Writer
+	key    []byte
+	prev   []byte
+}
+
+func (w *chainWriter) Write(s string) error {
+	builder := strings.Builder{}
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if line == "" {
+			continue
+		}
+		w.prev = chainMAC(w.key, w.prev, line)
+		builder.WriteString(appendChainMAC(line, hex.EncodeToString(w.prev)))
+		builder.WriteString(platform.LineSeparator())
+	}
+	return w.writer.Write(builder.String())
+}
+
+func (w *chainWriter) Close() error {
+	return w.writer.Close()
+}
+
+// Flush implements Flusher.
+func (w *chainWriter) Flush() error {
+	if flusher, ok := w.writer.(Flusher); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+func chainMAC(key, prev []byte, line string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(prev)
+	mac.Write([]byte(line))
+	return mac.Sum(nil)
+}
+
+// appendChainMAC adds mac to line, as a field of JSON objects and a logfmt
+// field otherwise.
+func appendChainMAC(line, mac string) string {
+	if strings.HasPrefix(line, "{") && strings.HasSuffix(line, "}") {
+		return line[:len(line)-1] + chainJSONField + mac + `"}`
+	}
+	return line + chainField + mac
+}
+
+// splitChainMAC reverses appendChainMAC.
+func splitChainMAC(line string) (string, string, bool) {
+	if strings.HasPrefix(line, "{") && strings.HasSuffix(line, `"}`) {
+		if i := strings.LastIndex(line, chainJSONField); i >= 0 {
+			return line[:i] + "}", line[i+len(chainJSONField) : len(line)-2], true
+		}
+	}
+	if i := strings.LastIndex(line, chainField); i >= 0 {
+		return line[:i], line[i+len(chainField):], true
+	}
+	return "", "", false
+}
+
+// CreateChainWriter returns a WriterCreator whose writers pass records on to
+// the ones of creator with an HMAC chain for tamper-evidence. Each writer
+// starts a new chain, so every file or rotated part verifies on its own.
+// The lines must not be prefixed by a timestamp afterwards.
+func CreateChainWriter(creator WriterCreator, key []byte) WriterCreator {
+	return func() Writer {
+		writer := creator()
+		if writer == nil {
+			return nil
+		}
+		return &chainWriter{writer: writer, key: key}
+	}
+}
+
+// VerifyChain checks the lines of a log written by a CreateChainWriter writer
+// with key. Each line ends with hmac=<hex>, or has an "hmac" field if it is a
+// JSON object, holding HMAC-SHA256(key, hmac of previous line || line without
+// the hmac). The first line has no previous hmac. It returns an error naming
+// the first line that does not match, which is where the log was altered.
+// Lines cut off the end of the log can not be detected.
+func VerifyChain(r io.Reader, key []byte) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	var prev []byte
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		content, encoded, ok := splitChainMAC(line)
+		if !ok {
+			return fmt.Errorf("line %d has no hmac", number)
+		}
+		mac, err := hex.DecodeString(encoded)
+		if err != nil || !hmac.Equal(mac, chainMAC(key, prev, content)) {
+			return fmt.Errorf("hmac mismatch on line %d", number)
+		}
+		prev = mac
+	}
+	return scanner.Err()
+}
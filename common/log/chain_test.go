@@ -0,0 +1,49 @@
+package log_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/v2fly/v2ray-core/v5/common"
+	. "github.com/v2fly/v2ray-core/v5/common/log"
+)
+
+func TestChainWriter(t *testing.T) {
+	key := []byte("secret")
+	inner := &recordingWriter{}
+	writer := CreateChainWriter(func() Writer { return inner }, key)()
+	common.Must(writer.Write("2026/01/01 00:00:00 [Info] first\n"))
+	common.Must(writer.Write(`{"schema_version":1,"msg":"second"}` + "\n"))
+	common.Must(writer.Write("third\nfourth\n"))
+	common.Must(writer.Close())
+
+	log := strings.Join(inner.Writes(), "")
+	if err := VerifyChain(strings.NewReader(log), key); err != nil {
+		t.Fatal("untouched log failed verification: ", err)
+	}
+	lines := strings.Split(strings.TrimSpace(log), "\n")
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &record); err != nil || record["hmac"] == nil {
+		t.Error("expected a json record with an hmac field, but actually ", lines[1])
+	}
+
+	cases := []struct {
+		name     string
+		log      string
+		expected string
+	}{
+		{"altered", strings.Replace(log, "third", "thirds", 1), "line 3"},
+		{"removed", strings.Replace(log, lines[1]+"\n", "", 1), "line 2"},
+		{"reordered", strings.Join([]string{lines[0], lines[2], lines[1], lines[3]}, "\n"), "line 2"},
+	}
+	for _, c := range cases {
+		err := VerifyChain(strings.NewReader(c.log), key)
+		if err == nil || !strings.Contains(err.Error(), c.expected) {
+			t.Error(c.name, ": expected a mismatch on ", c.expected, ", but actually ", err)
+		}
+	}
+	if err := VerifyChain(strings.NewReader(log), []byte("other")); err == nil {
+		t.Error("log verified with the wrong key")
+	}
+}
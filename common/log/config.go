@@ -0,0 +1,70 @@
+package log
+
+import (
+	"strings"
+	"sync"
+)
+
+type ConfigStatus string
+
+const (
+	ConfigLoaded  = ConfigStatus("loaded")
+	ConfigInvalid = ConfigStatus("invalid")
+)
+
+// ConfigMessage is a log message for the result of loading one section of
+// the config.
+type ConfigMessage struct {
+	Section string
+	Status  ConfigStatus
+	Reason  error
+}
+
+// Severity returns how severe the result is, so successful sections are
+// info and invalid ones errors.
+func (m *ConfigMessage) Severity() Severity {
+	if m.Status == ConfigInvalid {
+		return Severity_Error
+	}
+	return Severity_Info
+}
+
+func (m *ConfigMessage) String() string {
+	builder := strings.Builder{}
+	builder.WriteString("[Config] ")
+	builder.WriteString(m.Section)
+	builder.WriteString(" ")
+	builder.WriteString(string(m.Status))
+	if m.Reason != nil {
+		builder.WriteString(": ")
+		builder.WriteString(m.Reason.Error())
+	}
+	return builder.String()
+}
+
+// pendingConfig holds the config messages recorded while the config is built,
+// before the log app that decides whether and where they are logged starts.
+var pendingConfig struct {
+	sync.Mutex
+	messages []*ConfigMessage
+}
+
+// RecordConfig queues msg until FlushConfig is called.
+func RecordConfig(msg *ConfigMessage) {
+	pendingConfig.Lock()
+	defer pendingConfig.Unlock()
+
+	pendingConfig.messages = append(pendingConfig.messages, msg)
+}
+
+// FlushConfig records the queued config messages to the current handler.
+func FlushConfig() {
+	pendingConfig.Lock()
+	messages := pendingConfig.messages
+	pendingConfig.messages = nil
+	pendingConfig.Unlock()
+
+	for _, msg := range messages {
+		Record(msg)
+	}
+}
@@ -0,0 +1,141 @@
+package log
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// maxDeferredRecords is the number of records kept in memory while a
+	// deferred writer waits for its file. Later ones are dropped.
+	maxDeferredRecords    = 4096
+	deferredRetryDelay    = 100 * time.Millisecond
+	deferredRetryMaxDelay = 5 * time.Second
+)
+
+// deferredCreator creates writers for a file that could not be opened yet,
+// keeping what they write until create succeeds.
+type deferredCreator struct {
+	sync.Mutex
+	name    string
+	creator WriterCreator
+	err     error
+	pending []string
+	dropped int
+}
+
+// CreateDeferredLogWriter returns a WriterCreator for a file that could not
+// be opened yet, such as on a network mount not ready at boot. create is
+// retried with backoff for up to timeout, records written meanwhile are kept
+// in memory and written once it succeeds. name identifies the logger in
+// write errors reported to OnWriteError, like the failure to open the file
+// within timeout.
+func CreateDeferredLogWriter(name string, create func() (WriterCreator, error), timeout time.Duration) WriterCreator {
+	d := &deferredCreator{name: name}
+	go d.retry(create, time.Now().Add(timeout))
+	return func() Writer {
+		d.Lock()
+		defer d.Unlock()
+
+		switch {
+		case d.creator != nil:
+			return d.creator()
+		case d.err != nil:
+			return nil
+		default:
+			return &deferredWriter{creator: d}
+		}
+	}
+}
+
+func (d *deferredCreator) retry(create func() (WriterCreator, error), deadline time.Time) {
+	delay := deferredRetryDelay
+	for {
+		creator, err := create()
+		if err == nil {
+			d.open(creator)
+			return
+		}
+		if time.Now().Add(delay).After(deadline) {
+			d.Lock()
+			d.err = err
+			d.pending = nil
+			d.Unlock()
+			reportWriteError(d.name, err)
+			return
+		}
+		time.Sleep(delay)
+		if delay *= 2; delay > deferredRetryMaxDelay {
+			delay = deferredRetryMaxDelay
+		}
+	}
+}
+
+// open writes the pending records with a writer of creator, then lets
+// writers write through it.
+func (d *deferredCreator) open(creator WriterCreator) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.creator = creator
+	if len(d.pending) == 0 && d.dropped == 0 {
+		return
+	}
+	writer := creator()
+	if writer == nil {
+		reportWriteError(d.name, errNoWriter)
+		return
+	}
+	defer writer.Close()
+	for _, s := range d.pending {
+		if err := writer.Write(s); err != nil {
+			reportWriteError(d.name, err)
+			break
+		}
+	}
+	d.pending = nil
+	if d.dropped > 0 {
+		reportWriteError(d.name, errors.New(strconv.Itoa(d.dropped)+" log records dropped while waiting for the file to open"))
+	}
+}
+
+// deferredWriter keeps records in its creator until the file opens, and
+// writes through a writer of the file after.
+type deferredWriter struct {
+	creator *deferredCreator
+	writer  Writer
+}
+
+func (w *deferredWriter) Write(s string) error {
+	d := w.creator
+	d.Lock()
+	if w.writer == nil && d.creator != nil {
+		if w.writer = d.creator(); w.writer == nil {
+			d.Unlock()
+			return errNoWriter
+		}
+	}
+	if w.writer != nil {
+		d.Unlock()
+		return w.writer.Write(s)
+	}
+	defer d.Unlock()
+	if d.err != nil {
+		return d.err
+	}
+	if len(d.pending) >= maxDeferredRecords {
+		d.dropped++
+		return nil
+	}
+	d.pending = append(d.pending, s)
+	return nil
+}
+
+func (w *deferredWriter) Close() error {
+	if w.writer != nil {
+		return w.writer.Close()
+	}
+	return nil
+}
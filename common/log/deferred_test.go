@@ -0,0 +1,70 @@
+package log_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+)
+
+func TestDeferredLogWriter(t *testing.T) {
+	// The directory of the log is not mounted yet.
+	dir := filepath.Join(t.TempDir(), "mnt")
+	path := filepath.Join(dir, "access.log")
+	create := func() (log.WriterCreator, error) {
+		return log.CreateRawFileLogWriter(path)
+	}
+	if _, err := create(); err == nil {
+		t.Fatal("expected the log file not to open yet")
+	}
+
+	creator := log.CreateDeferredLogWriter(path, create, 10*time.Second)
+	writer := creator()
+	common.Must(writer.Write("one\n"))
+	common.Must(writer.Write("two\n"))
+
+	time.Sleep(200 * time.Millisecond)
+	common.Must(os.Mkdir(dir, 0o700))
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		content, _ := os.ReadFile(path)
+		if string(content) == "one\ntwo\n" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("buffered records not written once the file opened, file holds ", string(content))
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	common.Must(writer.Write("three\n"))
+	common.Must(writer.Close())
+	content, err := os.ReadFile(path)
+	common.Must(err)
+	if string(content) != "one\ntwo\nthree\n" {
+		t.Error("unexpected log file ", string(content))
+	}
+}
+
+func TestDeferredLogWriterTimeout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "access.log")
+	creator := log.CreateDeferredLogWriter(path, func() (log.WriterCreator, error) {
+		return log.CreateRawFileLogWriter(path)
+	}, 150*time.Millisecond)
+	writer := creator()
+	common.Must(writer.Write("lost\n"))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for writer.Write("lost\n") == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("expected writes to fail after the retry timeout")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if creator() != nil {
+		t.Error("expected no writer once the retry timeout passed")
+	}
+}
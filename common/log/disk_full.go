@@ -0,0 +1,113 @@
+package log
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DiskFullPolicy is what a writer does once a write fails as the disk is
+// full.
+type DiskFullPolicy int
+
+const (
+	// DiskFullDrop drops records while the disk is full, retrying at most
+	// every diskFullRetryInterval. The number dropped is reported to
+	// OnWriteError once a write succeeds again.
+	DiskFullDrop DiskFullPolicy = iota
+	// DiskFullStop stops writing for good, after a notice to the console.
+	DiskFullStop
+	// DiskFullConsole writes the records to the console from then on.
+	DiskFullConsole
+)
+
+const diskFullRetryInterval = time.Second
+
+// diskFullCreator holds the state of the disk across the writers of a
+// creator, so one created after the disk filled up does not retry at once.
+type diskFullCreator struct {
+	sync.Mutex
+	name     string
+	policy   DiskFullPolicy
+	console  WriterCreator
+	stopped  bool
+	redirect Writer
+	retryAt  time.Time
+	dropped  int
+}
+
+// CreateDiskFullWriter returns a WriterCreator whose writers pass records on
+// to the ones of creator, applying policy once a write fails as the disk is
+// full instead of failing every write after. console writes the notice of
+// DiskFullStop and the records of DiskFullConsole. name identifies the logger
+// in write errors reported to OnWriteError.
+func CreateDiskFullWriter(name string, creator WriterCreator, policy DiskFullPolicy, console WriterCreator) WriterCreator {
+	d := &diskFullCreator{name: name, policy: policy, console: console}
+	return func() Writer {
+		writer := creator()
+		if writer == nil {
+			return nil
+		}
+		return &diskFullWriter{writer: writer, creator: d}
+	}
+}
+
+func (d *diskFullCreator) write(writer Writer, s string) error {
+	d.Lock()
+	defer d.Unlock()
+
+	switch {
+	case d.stopped:
+		return nil
+	case d.redirect != nil:
+		return d.redirect.Write(s)
+	case time.Now().Before(d.retryAt):
+		d.dropped++
+		return nil
+	}
+	err := writer.Write(s)
+	if err == nil {
+		if d.dropped > 0 {
+			reportWriteError(d.name, errors.New(strconv.Itoa(d.dropped)+" log records dropped while the disk was full"))
+			d.dropped = 0
+		}
+		return nil
+	}
+	if !isDiskFull(err) {
+		return err
+	}
+	switch d.policy {
+	case DiskFullStop:
+		d.stopped = true
+		if console := d.console(); console != nil {
+			console.Write("disk full, stopped writing log " + d.name + ": " + err.Error())
+			console.Close()
+		}
+		return nil
+	case DiskFullConsole:
+		redirect := d.console()
+		if redirect == nil {
+			return err
+		}
+		d.redirect = redirect
+		return redirect.Write(s)
+	default:
+		d.dropped++
+		d.retryAt = time.Now().Add(diskFullRetryInterval)
+		return nil
+	}
+}
+
+type diskFullWriter struct {
+	writer  Writer
+	creator *diskFullCreator
+}
+
+func (w *diskFullWriter) Write(s string) error {
+	return w.creator.write(w.writer, s)
+}
+
+func (w *diskFullWriter) Close() error {
+	return w.writer.Close()
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+// +build !windows
+
+package log
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isDiskFull returns true if err is a write failing as the disk is full.
+func isDiskFull(err error) bool {
+	return errors.Is(err, syscall.ENOSPC)
+}
@@ -0,0 +1,152 @@
+package log_test
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+)
+
+// fullDisk holds the records written to a file on a disk that fails writes
+// while full.
+type fullDisk struct {
+	sync.Mutex
+	full    bool
+	records []string
+}
+
+func (d *fullDisk) setFull(full bool) {
+	d.Lock()
+	defer d.Unlock()
+	d.full = full
+}
+
+func (d *fullDisk) written() []string {
+	d.Lock()
+	defer d.Unlock()
+	return append([]string(nil), d.records...)
+}
+
+func (d *fullDisk) creator() log.WriterCreator {
+	return func() log.Writer {
+		return &recordingWriter{disk: d}
+	}
+}
+
+// recordingWriter writes records to disk, failing with ENOSPC if it is full.
+type recordingWriter struct {
+	disk *fullDisk
+}
+
+func (w *recordingWriter) Write(s string) error {
+	w.disk.Lock()
+	defer w.disk.Unlock()
+	if w.disk.full {
+		return &os.PathError{Op: "write", Path: "access.log", Err: syscall.ENOSPC}
+	}
+	w.disk.records = append(w.disk.records, s)
+	return nil
+}
+
+func (w *recordingWriter) Close() error {
+	return nil
+}
+
+func writeAll(t *testing.T, writer log.Writer, records ...string) {
+	for _, record := range records {
+		if err := writer.Write(record); err != nil {
+			t.Error("failed to write ", record, ": ", err)
+		}
+	}
+}
+
+func TestDiskFullStop(t *testing.T) {
+	disk, console := &fullDisk{}, &fullDisk{}
+	creator := log.CreateDiskFullWriter("access.log", disk.creator(), log.DiskFullStop, console.creator())
+	writer := creator()
+	writeAll(t, writer, "one")
+	disk.setFull(true)
+	writeAll(t, writer, "two", "three")
+	disk.setFull(false)
+	writeAll(t, writer, "four")
+	writeAll(t, creator(), "five")
+
+	if records := disk.written(); len(records) != 1 || records[0] != "one" {
+		t.Error("expected writing to stop once the disk is full, but actually ", records)
+	}
+	if notices := console.written(); len(notices) != 1 || !strings.Contains(notices[0], "access.log") {
+		t.Error("expected a single notice, but actually ", notices)
+	}
+}
+
+func TestDiskFullDrop(t *testing.T) {
+	failures := make(chan log.WriteError, 4)
+	log.OnWriteError(func(e log.WriteError) {
+		failures <- e
+	})
+	defer log.OnWriteError(nil)
+
+	disk := &fullDisk{}
+	writer := log.CreateDiskFullWriter("drop.log", disk.creator(), log.DiskFullDrop, nil)()
+	disk.setFull(true)
+	writeAll(t, writer, "one")
+	disk.setFull(false)
+	// Not retried right after the disk was found full.
+	writeAll(t, writer, "two")
+	if records := disk.written(); len(records) != 0 {
+		t.Error("expected records to be dropped, but actually ", records)
+	}
+	select {
+	case e := <-failures:
+		t.Error("expected drops not to be reported while the disk is full, but got ", e.Err)
+	default:
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	writeAll(t, writer, "three")
+	if records := disk.written(); len(records) != 1 || records[0] != "three" {
+		t.Error("expected writes to resume, but actually ", records)
+	}
+	select {
+	case e := <-failures:
+		if e.Logger != "drop.log" || !strings.HasPrefix(e.Err.Error(), "2 log records dropped") {
+			t.Error("unexpected write error: ", e.Logger, " ", e.Err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("drops not reported")
+	}
+}
+
+func TestDiskFullConsole(t *testing.T) {
+	disk, console := &fullDisk{}, &fullDisk{}
+	writer := log.CreateDiskFullWriter("access.log", disk.creator(), log.DiskFullConsole, console.creator())()
+	writeAll(t, writer, "one")
+	disk.setFull(true)
+	writeAll(t, writer, "two")
+	disk.setFull(false)
+	writeAll(t, writer, "three")
+
+	if records := disk.written(); len(records) != 1 || records[0] != "one" {
+		t.Error("unexpected file records ", records)
+	}
+	if records := console.written(); len(records) != 2 || records[0] != "two" || records[1] != "three" {
+		t.Error("expected the remaining records on the console, but actually ", records)
+	}
+}
+
+func TestDiskFullOtherErrors(t *testing.T) {
+	writer := log.CreateDiskFullWriter("error.log", func() log.Writer {
+		return failingWriter{}
+	}, log.DiskFullStop, nil)()
+	for i := 0; i < 2; i++ {
+		if err := writer.Write("record"); err == nil {
+			t.Error("expected errors other than a full disk to be returned")
+		}
+	}
+	common.Must(writer.Close())
+}
@@ -0,0 +1,19 @@
+//go:build windows
+// +build windows
+
+package log
+
+import (
+	"errors"
+	"syscall"
+)
+
+const (
+	errorHandleDiskFull = syscall.Errno(39)  // ERROR_HANDLE_DISK_FULL
+	errorDiskFull       = syscall.Errno(112) // ERROR_DISK_FULL
+)
+
+// isDiskFull returns true if err is a write failing as the disk is full.
+func isDiskFull(err error) bool {
+	return errors.Is(err, errorDiskFull) || errors.Is(err, errorHandleDiskFull) || errors.Is(err, syscall.ENOSPC)
+}
@@ -0,0 +1,80 @@
+package log
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type DNSSource string
+
+const (
+	DNSSourceCache    = DNSSource("cache")
+	DNSSourceUpstream = DNSSource("upstream")
+)
+
+// DNSMessage is a log record for a resolved DNS query.
+type DNSMessage struct {
+	Server string
+	Domain string
+	// QueryType is the record types queried, like A or A,AAAA.
+	QueryType string
+	IPs       []net.IP
+	Source    DNSSource
+	// TTL is the remaining time to live, in seconds, of the returned records.
+	TTL uint32
+	// Latency is the time the upstream took to answer, 0 for the cache.
+	Latency time.Duration
+	// SessionID and InboundTag identify the connection the query was made
+	// for, if any.
+	SessionID  uint32
+	InboundTag string
+	// TraceID and SpanID are the distributed tracing ids of the connection,
+	// if any.
+	TraceID string
+	SpanID  string
+}
+
+func (m *DNSMessage) String() string {
+	builder := strings.Builder{}
+	builder.WriteString("[DNS] ")
+	if m.SessionID > 0 {
+		builder.WriteByte('[')
+		builder.WriteString(strconv.FormatUint(uint64(m.SessionID), 10))
+		builder.WriteString("] ")
+	}
+	builder.WriteString(string(m.Source))
+	if len(m.Server) > 0 {
+		builder.WriteString(" [")
+		builder.WriteString(m.Server)
+		builder.WriteByte(']')
+	}
+	builder.WriteByte(' ')
+	builder.WriteString(m.Domain)
+	builder.WriteString(" -> [")
+	for i, ip := range m.IPs {
+		if i > 0 {
+			builder.WriteByte(' ')
+		}
+		builder.WriteString(ip.String())
+	}
+	builder.WriteString("] ttl: ")
+	builder.WriteString(strconv.FormatUint(uint64(m.TTL), 10))
+	if len(m.QueryType) > 0 {
+		builder.WriteString(" type: ")
+		builder.WriteString(m.QueryType)
+	}
+	if m.Latency > 0 {
+		builder.WriteString(" latency: ")
+		builder.WriteString(m.Latency.Round(time.Millisecond).String())
+	}
+	if len(m.TraceID) > 0 {
+		builder.WriteString(" trace_id: ")
+		builder.WriteString(m.TraceID)
+		builder.WriteString(" span_id: ")
+		builder.WriteString(m.SpanID)
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,142 @@
+package log
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"time"
+)
+
+const (
+	fifoWriteTimeout = 100 * time.Millisecond
+	fifoBufferSize   = 64 * 1024
+)
+
+// IsNamedPipe returns true if path is an existing named pipe (FIFO).
+func IsNamedPipe(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode()&os.ModeNamedPipe != 0
+}
+
+// fifoOutput writes to a named pipe without blocking while no reader is
+// attached. Data written meanwhile is dropped, or kept up to fifoBufferSize
+// bytes if buffer is set.
+type fifoOutput struct {
+	path    string
+	buffer  bool
+	file    *os.File
+	pending []byte
+}
+
+func (o *fifoOutput) open() {
+	if o.file != nil {
+		return
+	}
+	// Opening the write end without O_NONBLOCK blocks until a reader
+	// attaches, with it the open fails instead.
+	file, err := os.OpenFile(o.path, os.O_WRONLY|fifoNonBlock, 0)
+	if err != nil {
+		return
+	}
+	o.file = file
+}
+
+func (o *fifoOutput) Write(p []byte) (int, error) {
+	o.open()
+
+	data := p
+	if o.buffer {
+		o.pending = append(o.pending, p...)
+		if excess := len(o.pending) - fifoBufferSize; excess > 0 {
+			// Drop the oldest lines.
+			cut := excess
+			if i := bytes.IndexByte(o.pending[excess:], '\n'); i >= 0 {
+				cut += i + 1
+			}
+			o.pending = o.pending[cut:]
+		}
+		data = o.pending
+	}
+	o.write(data)
+	return len(p), nil
+}
+
+func (o *fifoOutput) write(data []byte) {
+	if o.file == nil {
+		return
+	}
+	o.file.SetWriteDeadline(time.Now().Add(fifoWriteTimeout))
+	n, err := o.file.Write(data)
+	if o.buffer {
+		o.pending = append(o.pending[:0], o.pending[n:]...)
+	}
+	if err != nil && !os.IsTimeout(err) {
+		// The reader went away.
+		o.file.Close()
+		o.file = nil
+	}
+}
+
+// Flush writes the pending data if a reader is attached.
+func (o *fifoOutput) Flush() error {
+	if len(o.pending) == 0 {
+		return nil
+	}
+	o.open()
+	o.write(o.pending)
+	return nil
+}
+
+// Close closes the pipe but keeps pending data for the next writer.
+func (o *fifoOutput) Close() error {
+	if o.file == nil {
+		return nil
+	}
+	err := o.file.Close()
+	o.file = nil
+	return err
+}
+
+type fifoLogWriter struct {
+	output *fifoOutput
+	logger *log.Logger
+}
+
+func (w *fifoLogWriter) Write(s string) error {
+	w.logger.Print(s)
+	return nil
+}
+
+// Flush implements Flusher.
+func (w *fifoLogWriter) Flush() error {
+	return w.output.Flush()
+}
+
+func (w *fifoLogWriter) Close() error {
+	return w.output.Close()
+}
+
+// CreateFIFOLogWriter returns a WriterCreator that writes to the named pipe at
+// path without blocking while no reader is attached. If buffer is set, logs
+// written meanwhile are kept for the next reader instead of being dropped.
+func CreateFIFOLogWriter(path string, buffer bool) WriterCreator {
+	return createFIFOLogWriter(path, buffer, log.Ldate|log.Ltime)
+}
+
+// CreateRawFIFOLogWriter is like CreateFIFOLogWriter, but without a timestamp
+// prefix.
+func CreateRawFIFOLogWriter(path string, buffer bool) WriterCreator {
+	return createFIFOLogWriter(path, buffer, 0)
+}
+
+func createFIFOLogWriter(path string, buffer bool, flag int) WriterCreator {
+	// Shared by all writers, so buffered logs survive the writer being
+	// recreated. Writers never run concurrently.
+	output := &fifoOutput{path: path, buffer: buffer}
+	return func() Writer {
+		return &fifoLogWriter{
+			output: output,
+			logger: log.New(output, "", flag),
+		}
+	}
+}
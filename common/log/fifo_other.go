@@ -0,0 +1,6 @@
+//go:build windows || wasm
+// +build windows wasm
+
+package log
+
+const fifoNonBlock = 0
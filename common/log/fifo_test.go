@@ -0,0 +1,87 @@
+//go:build !windows && !wasm
+// +build !windows,!wasm
+
+package log_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common"
+	. "github.com/v2fly/v2ray-core/v5/common/log"
+)
+
+func testFIFOLogWriter(t *testing.T, buffer bool) string {
+	path := filepath.Join(t.TempDir(), "log.fifo")
+	common.Must(syscall.Mkfifo(path, 0o600))
+	if !IsNamedPipe(path) {
+		t.Fatal("expected ", path, " to be detected as a named pipe")
+	}
+
+	writer := CreateRawFIFOLogWriter(path, buffer)()
+
+	start := time.Now()
+	common.Must(writer.Write("without reader"))
+	if time.Since(start) > time.Second {
+		t.Error("write without reader blocked")
+	}
+
+	reader, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	common.Must(err)
+	defer reader.Close()
+
+	common.Must(writer.Write("with reader"))
+	common.Must(writer.Close())
+
+	common.Must(reader.SetReadDeadline(time.Now().Add(5 * time.Second)))
+	b, err := io.ReadAll(reader)
+	common.Must(err)
+	return string(b)
+}
+
+func TestFIFOLogWriterDrop(t *testing.T) {
+	if s := testFIFOLogWriter(t, false); s != "with reader\n" {
+		t.Error("unexpected output: ", s)
+	}
+}
+
+func TestFIFOLogWriterBuffer(t *testing.T) {
+	if s := testFIFOLogWriter(t, true); s != "without reader\nwith reader\n" {
+		t.Error("unexpected output: ", s)
+	}
+}
+
+func TestBufferedLoggerFlushInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.fifo")
+	common.Must(syscall.Mkfifo(path, 0o600))
+
+	handler := NewBufferedLogger(path, CreateRawFIFOLogWriter(path, true), 50*time.Millisecond)
+	defer common.Close(handler)
+
+	handler.Handle(&GeneralMessage{Severity: Severity_Info, Content: "low volume"})
+	// Let the record reach the buffer while no reader is attached.
+	time.Sleep(100 * time.Millisecond)
+
+	reader, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	common.Must(err)
+	defer reader.Close()
+
+	// No further records are written, only the periodic flush delivers it.
+	deadline := time.Now().Add(time.Second)
+	var output []byte
+	for time.Now().Before(deadline) {
+		b := make([]byte, 1024)
+		n, _ := reader.Read(b)
+		output = append(output, b[:n]...)
+		if strings.Contains(string(output), "low volume") {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("record not flushed within a second, got %q", output)
+}
@@ -0,0 +1,8 @@
+//go:build !windows && !wasm
+// +build !windows,!wasm
+
+package log
+
+import "syscall"
+
+const fifoNonBlock = syscall.O_NONBLOCK
@@ -22,14 +22,38 @@ type Follower interface {
 	RemoveFollower(func(msg Message))
 }
 
+// Rotator is implemented by Handlers writing to files, to start writing them
+// anew after they were moved away.
+type Rotator interface {
+	Rotate() error
+}
+
+// Rotate rotates h if it is a Rotator.
+func Rotate(h interface{}) error {
+	if rotator, ok := h.(Rotator); ok {
+		return rotator.Rotate()
+	}
+	return nil
+}
+
 // GeneralMessage is a general log message that can contain all kind of content.
 type GeneralMessage struct {
 	Severity Severity
 	Content  interface{}
+	// SessionID and InboundTag identify the connection the message belongs to, if any.
+	SessionID  uint32
+	InboundTag string
+	// TraceID and SpanID are the distributed tracing ids of the connection,
+	// if any.
+	TraceID string
+	SpanID  string
 }
 
 // String implements Message.
 func (m *GeneralMessage) String() string {
+	if len(m.TraceID) > 0 {
+		return serial.Concat("[", m.Severity, "] ", m.Content, " trace_id: ", m.TraceID, " span_id: ", m.SpanID)
+	}
 	return serial.Concat("[", m.Severity, "] ", m.Content)
 }
 
@@ -48,6 +72,15 @@ func RegisterHandler(handler Handler) {
 	logHandler.Set(handler)
 }
 
+// ReplaceHandler registers a new handler like RegisterHandler, and returns the
+// previous one.
+func ReplaceHandler(handler Handler) Handler {
+	if handler == nil {
+		panic("Log handler is nil")
+	}
+	return logHandler.Swap(handler)
+}
+
 type syncHandler struct {
 	sync.RWMutex
 	Handler
@@ -68,3 +101,12 @@ func (h *syncHandler) Set(handler Handler) {
 
 	h.Handler = handler
 }
+
+func (h *syncHandler) Swap(handler Handler) Handler {
+	h.Lock()
+	defer h.Unlock()
+
+	previous := h.Handler
+	h.Handler = handler
+	return previous
+}
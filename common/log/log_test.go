@@ -30,3 +30,23 @@ func TestLogRecord(t *testing.T) {
 		t.Error(diff)
 	}
 }
+
+func TestRecordConfig(t *testing.T) {
+	var logger testLogger
+	log.RegisterHandler(&logger)
+
+	log.RecordConfig(&log.ConfigMessage{Section: "dns", Status: log.ConfigLoaded})
+	if logger.value != "" {
+		t.Error("config message recorded before flush: ", logger.value)
+	}
+	log.FlushConfig()
+	if diff := cmp.Diff("[Config] dns loaded", logger.value); diff != "" {
+		t.Error(diff)
+	}
+
+	logger.value = ""
+	log.FlushConfig()
+	if logger.value != "" {
+		t.Error("config message recorded twice: ", logger.value)
+	}
+}
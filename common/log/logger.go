@@ -1,6 +1,7 @@
 package log
 
 import (
+	"errors"
 	"io"
 	"log"
 	"os"
@@ -17,26 +18,60 @@ type Writer interface {
 	io.Closer
 }
 
+// Flusher is implemented by Writers that buffer output.
+type Flusher interface {
+	// Flush writes out the buffered output.
+	Flush() error
+}
+
 // WriterCreator is a function to create LogWriters.
 type WriterCreator func() Writer
 
+var errNoWriter = errors.New("failed to create log writer")
+
 type generalLogger struct {
-	creator WriterCreator
-	buffer  chan Message
-	access  *semaphore.Instance
-	done    *done.Instance
+	name          string
+	creator       WriterCreator
+	flushInterval time.Duration
+	buffer        chan Message
+	rotate        chan chan error
+	access        *semaphore.Instance
+	done          *done.Instance
 }
 
 // NewLogger returns a generic log handler that can handle all type of messages.
 func NewLogger(logWriterCreator WriterCreator) Handler {
+	return NewNamedLogger("", logWriterCreator)
+}
+
+// NewNamedLogger is like NewLogger, with name identifying the logger in
+// write errors reported to OnWriteError.
+func NewNamedLogger(name string, logWriterCreator WriterCreator) Handler {
 	return &generalLogger{
+		name:    name,
 		creator: logWriterCreator,
 		buffer:  make(chan Message, 16),
+		rotate:  make(chan chan error),
 		access:  semaphore.New(1),
 		done:    done.New(),
 	}
 }
 
+// NewBufferedLogger is like NewNamedLogger, for writers that buffer output.
+// Writers implementing Flusher are flushed every flushInterval, so output
+// written at a low rate does not linger in the buffer.
+func NewBufferedLogger(name string, logWriterCreator WriterCreator, flushInterval time.Duration) Handler {
+	return &generalLogger{
+		name:          name,
+		creator:       logWriterCreator,
+		flushInterval: flushInterval,
+		buffer:        make(chan Message, 16),
+		rotate:        make(chan chan error),
+		access:        semaphore.New(1),
+		done:          done.New(),
+	}
+}
+
 func (l *generalLogger) run() {
 	defer l.access.Signal()
 
@@ -46,17 +81,50 @@ func (l *generalLogger) run() {
 
 	logger := l.creator()
 	if logger == nil {
+		reportWriteError(l.name, errNoWriter)
 		return
 	}
-	defer logger.Close()
+	defer func() {
+		if logger != nil {
+			logger.Close()
+		}
+	}()
+
+	// The ticker runs whether or not the writer buffers, as a rotated writer
+	// may differ.
+	var flush <-chan time.Time
+	flusher, _ := logger.(Flusher)
+	if l.flushInterval > 0 {
+		flushTicker := time.NewTicker(l.flushInterval)
+		defer flushTicker.Stop()
+		flush = flushTicker.C
+	}
 
 	for {
 		select {
 		case <-l.done.Wait():
 			return
 		case msg := <-l.buffer:
-			logger.Write(msg.String() + platform.LineSeparator())
+			if err := logger.Write(msg.String() + platform.LineSeparator()); err != nil {
+				reportWriteError(l.name, err)
+			}
 			dataWritten = true
+		case rotated := <-l.rotate:
+			err := logger.Close()
+			logger = l.creator()
+			rotated <- err
+			if logger == nil {
+				reportWriteError(l.name, errNoWriter)
+				return
+			}
+			flusher, _ = logger.(Flusher)
+		case <-flush:
+			if flusher == nil {
+				continue
+			}
+			if err := flusher.Flush(); err != nil {
+				reportWriteError(l.name, err)
+			}
 		case <-ticker.C:
 			if !dataWritten {
 				return
@@ -79,6 +147,22 @@ func (l *generalLogger) Handle(msg Message) {
 	}
 }
 
+// Rotate implements Rotator. It closes the writer and creates a new one, which
+// reopens the file if it was moved away.
+func (l *generalLogger) Rotate() error {
+	rotated := make(chan error, 1)
+	select {
+	case l.rotate <- rotated:
+		return <-rotated
+	case <-l.access.Wait():
+		// Not running, the writer is created with the next message.
+		l.access.Signal()
+		return nil
+	case <-l.done.Wait():
+		return nil
+	}
+}
+
 func (l *generalLogger) Close() error {
 	return l.done.Close()
 }
@@ -88,8 +172,7 @@ type consoleLogWriter struct {
 }
 
 func (w *consoleLogWriter) Write(s string) error {
-	w.logger.Print(s)
-	return nil
+	return w.logger.Output(2, s)
 }
 
 func (w *consoleLogWriter) Close() error {
@@ -102,8 +185,7 @@ type fileLogWriter struct {
 }
 
 func (w *fileLogWriter) Write(s string) error {
-	w.logger.Print(s)
-	return nil
+	return w.logger.Output(2, s)
 }
 
 func (w *fileLogWriter) Close() error {
@@ -128,8 +210,28 @@ func CreateStderrLogWriter() WriterCreator {
 	}
 }
 
+// CreateRawStdoutLogWriter returns a WriterCreator that writes to stdout
+// without a timestamp prefix, for messages that carry their own.
+func CreateRawStdoutLogWriter() WriterCreator {
+	return func() Writer {
+		return &consoleLogWriter{
+			logger: log.New(os.Stdout, "", 0),
+		}
+	}
+}
+
 // CreateFileLogWriter returns a LogWriterCreator that creates LogWriter for the given file.
 func CreateFileLogWriter(path string) (WriterCreator, error) {
+	return createFileLogWriter(path, log.Ldate|log.Ltime)
+}
+
+// CreateRawFileLogWriter is like CreateFileLogWriter, but without a timestamp
+// prefix.
+func CreateRawFileLogWriter(path string) (WriterCreator, error) {
+	return createFileLogWriter(path, 0)
+}
+
+func createFileLogWriter(path string, flag int) (WriterCreator, error) {
 	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o600)
 	if err != nil {
 		return nil, err
@@ -142,7 +244,7 @@ func CreateFileLogWriter(path string) (WriterCreator, error) {
 		}
 		return &fileLogWriter{
 			file:   file,
-			logger: log.New(file, "", log.Ldate|log.Ltime),
+			logger: log.New(file, "", flag),
 		}
 	}, nil
 }
@@ -1,9 +1,12 @@
 package log_test
 
 import (
+	"errors"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -37,3 +40,138 @@ func TestFileLogger(t *testing.T) {
 		t.Fatal("Expect log text contains 'Test Log', but actually: ", string(b))
 	}
 }
+
+type failingWriter struct{}
+
+func (failingWriter) Write(string) error {
+	return errors.New("no space left on device")
+}
+
+func (failingWriter) Close() error {
+	return nil
+}
+
+func TestWriteErrorCallback(t *testing.T) {
+	failures := make(chan WriteError, 4)
+	OnWriteError(func(e WriteError) {
+		failures <- e
+	})
+	defer OnWriteError(nil)
+
+	handler := NewNamedLogger("/var/log/v2ray/error.log", func() Writer {
+		return failingWriter{}
+	})
+	defer common.Close(handler)
+	handler.Handle(&GeneralMessage{Content: "first"})
+	handler.Handle(&GeneralMessage{Content: "second"})
+
+	select {
+	case e := <-failures:
+		if e.Logger != "/var/log/v2ray/error.log" || e.Err == nil || e.Err.Error() != "no space left on device" {
+			t.Error("unexpected write error: ", e.Logger, " ", e.Err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("write error not reported")
+	}
+	select {
+	case e := <-failures:
+		t.Error("expected repeated failures to be rate limited, but got ", e.Err)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestRotateFileLogger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "error.log")
+	creator, err := CreateRawFileLogWriter(path)
+	common.Must(err)
+
+	handler := NewLogger(creator)
+	defer common.Close(handler)
+
+	waitContains := func(path, text string) {
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			content, _ := os.ReadFile(path)
+			if strings.Contains(string(content), text) {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("expected ", path, " to contain ", text, ", but actually: ", string(content))
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	handler.Handle(&GeneralMessage{Severity: Severity_Info, Content: "before rotation"})
+	waitContains(path, "before rotation")
+
+	common.Must(os.Rename(path, path+".1"))
+	common.Must(Rotate(handler))
+	handler.Handle(&GeneralMessage{Severity: Severity_Info, Content: "after rotation"})
+	waitContains(path, "after rotation")
+
+	content, err := os.ReadFile(path)
+	common.Must(err)
+	if strings.Contains(string(content), "before rotation") {
+		t.Error("new file contains records from before rotation: ", string(content))
+	}
+	rotated, err := os.ReadFile(path + ".1")
+	common.Must(err)
+	if strings.Contains(string(rotated), "after rotation") {
+		t.Error("rotated file still written: ", string(rotated))
+	}
+}
+
+type plainWriter struct{}
+
+func (plainWriter) Write(string) error {
+	return nil
+}
+
+func (plainWriter) Close() error {
+	return nil
+}
+
+type flushingWriter struct {
+	plainWriter
+	flushed chan struct{}
+}
+
+func (w flushingWriter) Flush() error {
+	select {
+	case w.flushed <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func TestRotateBufferedLogger(t *testing.T) {
+	flushed := make(chan struct{}, 1)
+	writers := []Writer{flushingWriter{flushed: flushed}, plainWriter{}, flushingWriter{flushed: flushed}}
+	var created int32
+	handler := NewBufferedLogger("rotated", func() Writer {
+		return writers[atomic.AddInt32(&created, 1)-1]
+	}, 10*time.Millisecond)
+	defer common.Close(handler)
+
+	waitFlushed := func() {
+		select {
+		case <-flushed:
+		case <-time.After(5 * time.Second):
+			t.Fatal("buffered writer not flushed")
+		}
+	}
+
+	handler.Handle(&GeneralMessage{Content: "buffered"})
+	waitFlushed()
+
+	// The writer after this rotation does not buffer, so ticks skip it.
+	common.Must(Rotate(handler))
+	time.Sleep(50 * time.Millisecond)
+
+	common.Must(Rotate(handler))
+	for len(flushed) > 0 {
+		<-flushed
+	}
+	waitFlushed()
+}
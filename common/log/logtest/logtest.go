@@ -0,0 +1,90 @@
+// Package logtest captures log records in memory for tests to assert on.
+package logtest
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/v2fly/v2ray-core/v5/common/log"
+	"github.com/v2fly/v2ray-core/v5/common/serial"
+)
+
+// Recorder is a log.Handler keeping all messages it handles.
+type Recorder struct {
+	sync.Mutex
+	t        testing.TB
+	messages []log.Message
+}
+
+// Capture registers a new Recorder as the log handler until the test ends,
+// then restores the previous handler. Creating an app/log instance replaces it.
+func Capture(t testing.TB) *Recorder {
+	r := &Recorder{t: t}
+	previous := log.ReplaceHandler(r)
+	t.Cleanup(func() {
+		if previous != nil {
+			log.RegisterHandler(previous)
+		}
+	})
+	return r
+}
+
+// Handle implements log.Handler.
+func (r *Recorder) Handle(msg log.Message) {
+	r.Lock()
+	defer r.Unlock()
+	r.messages = append(r.messages, msg)
+}
+
+// Messages returns the messages recorded so far.
+func (r *Recorder) Messages() []log.Message {
+	r.Lock()
+	defer r.Unlock()
+	return append([]log.Message(nil), r.messages...)
+}
+
+// Reset forgets the messages recorded so far.
+func (r *Recorder) Reset() {
+	r.Lock()
+	defer r.Unlock()
+	r.messages = nil
+}
+
+// Contains returns true if a general message at severity with content
+// containing substring was recorded.
+func (r *Recorder) Contains(severity log.Severity, substring string) bool {
+	for _, msg := range r.Messages() {
+		general, ok := msg.(*log.GeneralMessage)
+		if ok && general.Severity == severity && strings.Contains(serial.ToString(general.Content), substring) {
+			return true
+		}
+	}
+	return false
+}
+
+// AssertContains fails the test unless Contains(severity, substring).
+func (r *Recorder) AssertContains(severity log.Severity, substring string) {
+	r.t.Helper()
+	if !r.Contains(severity, substring) {
+		r.t.Errorf("no %s record containing %q in:\n%s", severity, substring, r)
+	}
+}
+
+// AssertNotContains fails the test if Contains(severity, substring).
+func (r *Recorder) AssertNotContains(severity log.Severity, substring string) {
+	r.t.Helper()
+	if r.Contains(severity, substring) {
+		r.t.Errorf("unexpected %s record containing %q in:\n%s", severity, substring, r)
+	}
+}
+
+// String returns the recorded messages, one per line.
+func (r *Recorder) String() string {
+	builder := strings.Builder{}
+	for _, msg := range r.Messages() {
+		builder.WriteString(msg.String())
+		builder.WriteByte('\n')
+	}
+	return builder.String()
+}
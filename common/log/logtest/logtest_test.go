@@ -0,0 +1,68 @@
+package logtest_test
+
+import (
+	"testing"
+
+	"github.com/v2fly/v2ray-core/v5/common/log"
+	"github.com/v2fly/v2ray-core/v5/common/log/logtest"
+)
+
+type fakeTB struct {
+	testing.TB
+	failures int
+}
+
+func (*fakeTB) Helper() {}
+
+func (t *fakeTB) Errorf(format string, args ...interface{}) {
+	t.failures++
+}
+
+func TestRecorder(t *testing.T) {
+	recorder := logtest.Capture(t)
+
+	log.Record(&log.GeneralMessage{Severity: log.Severity_Warning, Content: "connection refused by upstream"})
+	log.Record(&log.AccessMessage{From: "127.0.0.1:1234", To: "example.com:443", Status: log.AccessAccepted})
+
+	if !recorder.Contains(log.Severity_Warning, "refused by") {
+		t.Error("expected warning to be recorded")
+	}
+	for _, c := range []struct {
+		severity  log.Severity
+		substring string
+	}{
+		{log.Severity_Error, "refused by"},
+		{log.Severity_Warning, "accepted"},
+		{log.Severity_Warning, "timeout"},
+	} {
+		if recorder.Contains(c.severity, c.substring) {
+			t.Error("unexpected match of ", c.severity, " ", c.substring)
+		}
+	}
+	if n := len(recorder.Messages()); n != 2 {
+		t.Error("expected 2 messages, but actually ", n)
+	}
+
+	recorder.Reset()
+	if recorder.Contains(log.Severity_Warning, "refused by") {
+		t.Error("expected no messages after reset")
+	}
+}
+
+func TestRecorderAssertions(t *testing.T) {
+	tb := &fakeTB{TB: t}
+	recorder := logtest.Capture(tb)
+
+	log.Record(&log.GeneralMessage{Severity: log.Severity_Info, Content: "started"})
+
+	recorder.AssertContains(log.Severity_Info, "start")
+	recorder.AssertNotContains(log.Severity_Info, "stopped")
+	if tb.failures != 0 {
+		t.Error("expected matching assertions to pass")
+	}
+	recorder.AssertContains(log.Severity_Debug, "start")
+	recorder.AssertNotContains(log.Severity_Info, "start")
+	if tb.failures != 2 {
+		t.Error("expected 2 failed assertions, but actually ", tb.failures)
+	}
+}
@@ -0,0 +1,91 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+)
+
+// Outcome classifies how a connection ended, for aggregating failures by
+// their cause.
+type Outcome string
+
+const (
+	OutcomeOK             = Outcome("ok")
+	OutcomeDNSFailure     = Outcome("dns_failure")
+	OutcomeConnectRefused = Outcome("connect_refused")
+	OutcomeTimeout        = Outcome("timeout")
+	OutcomeAuthFailure    = Outcome("auth_failure")
+	OutcomeBlocked        = Outcome("blocked")
+	OutcomeUpstreamReset  = Outcome("upstream_reset")
+)
+
+type outcomeError struct {
+	error
+	outcome Outcome
+}
+
+func (e *outcomeError) Outcome() Outcome {
+	return e.outcome
+}
+
+func (e *outcomeError) Inner() error {
+	return e.error
+}
+
+func (e *outcomeError) Severity() Severity {
+	if s, ok := e.error.(interface{ Severity() Severity }); ok {
+		return s.Severity()
+	}
+	return Severity_Info
+}
+
+// WithOutcome returns err marked as causing outcome, for causes that can not
+// be told from err itself.
+func WithOutcome(err error, outcome Outcome) error {
+	if err == nil {
+		return nil
+	}
+	return &outcomeError{error: err, outcome: outcome}
+}
+
+// OutcomeOf returns the outcome of a connection failed with err: the
+// outermost one marked by WithOutcome, or else the one of the first network
+// error in the chain of err that has one. It returns an empty Outcome if the
+// cause is unknown.
+func OutcomeOf(err error) Outcome {
+	for err != nil {
+		if marked, ok := err.(interface{ Outcome() Outcome }); ok {
+			return marked.Outcome()
+		}
+		if outcome := networkOutcome(err); outcome != "" {
+			return outcome
+		}
+		if inner, ok := err.(interface{ Inner() error }); ok {
+			err = inner.Inner()
+		} else {
+			err = errors.Unwrap(err)
+		}
+	}
+	return ""
+}
+
+func networkOutcome(err error) Outcome {
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &dnsErr):
+		return OutcomeDNSFailure
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return OutcomeConnectRefused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return OutcomeUpstreamReset
+	case errors.Is(err, context.DeadlineExceeded):
+		return OutcomeTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return OutcomeTimeout
+	}
+	return ""
+}
@@ -0,0 +1,109 @@
+package log_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/errors"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+	"github.com/v2fly/v2ray-core/v5/common/net"
+)
+
+// refusedDial returns the error of connecting to a closed port.
+func refusedDial(t *testing.T) error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	address := listener.Addr().String()
+	listener.Close()
+	conn, err := net.Dial("tcp", address)
+	if err == nil {
+		conn.Close()
+		t.Skip("connecting to a closed port succeeded")
+	}
+	return err
+}
+
+func TestOutcomeOf(t *testing.T) {
+	refused := refusedDial(t)
+
+	_, dnsErr := (&net.Resolver{}).LookupHost(context.Background(), "name.invalid")
+
+	cases := []struct {
+		err     error
+		outcome log.Outcome
+	}{
+		{errors.New("failed to dial").Base(refused), log.OutcomeConnectRefused},
+		{errors.New("failed to resolve").Base(dnsErr), log.OutcomeDNSFailure},
+		{errors.New("failed").Base(context.DeadlineExceeded), log.OutcomeTimeout},
+		{errors.New("failed").Base(log.WithOutcome(errors.New("ssh: unable to authenticate"), log.OutcomeAuthFailure)), log.OutcomeAuthFailure},
+		{log.WithOutcome(errors.New("denied").Base(refused), log.OutcomeBlocked), log.OutcomeBlocked},
+		{errors.New("unknown"), ""},
+	}
+	for _, c := range cases {
+		if outcome := log.OutcomeOf(c.err); outcome != c.outcome {
+			t.Error("expected outcome ", c.outcome, " of ", c.err, ", but actually ", outcome)
+		}
+	}
+
+	if severity := errors.New("wrapped").Base(log.WithOutcome(errors.New("inner").AtDebug(), log.OutcomeTimeout)).AtWarning().Severity(); severity != log.Severity_Warning {
+		t.Error("expected the severity of the wrapping error, but actually ", severity)
+	}
+}
+
+func TestRecordAccessFailureOutcome(t *testing.T) {
+	var logger testLogger
+	log.RegisterHandler(&logger)
+
+	refused := refusedDial(t)
+	accessMessage := &log.AccessMessage{
+		From:   "127.0.0.1:1001",
+		To:     "tcp:127.0.0.1:1",
+		Status: log.AccessAccepted,
+	}
+	ctx := log.ContextWithAccessMessage(context.Background(), accessMessage)
+	log.RecordAccessFailure(ctx, errors.New("failed to process outbound traffic").Base(refused))
+	if !strings.HasPrefix(logger.value, "127.0.0.1:1001 failed tcp:127.0.0.1:1 ") || !strings.HasSuffix(logger.value, " outcome: connect_refused") {
+		t.Error("expected a failed record with outcome connect_refused, but actually ", logger.value)
+	}
+	if accessMessage.Status != log.AccessAccepted || len(accessMessage.Outcome) > 0 {
+		t.Error("expected the access record in the context to be left as is, but actually ", accessMessage)
+	}
+}
+
+type queueHandler chan log.Message
+
+func (h queueHandler) Handle(msg log.Message) {
+	h <- msg
+}
+
+// TestRecordAccessFailureConcurrentFormat formats the accepted record while
+// the failure is recorded, as an asynchronous logger does. Run with -race.
+func TestRecordAccessFailureConcurrentFormat(t *testing.T) {
+	refused := refusedDial(t)
+	queue := make(queueHandler, 2)
+	if previous := log.ReplaceHandler(queue); previous != nil {
+		defer log.RegisterHandler(previous)
+	}
+
+	accessMessage := &log.AccessMessage{
+		From:   "127.0.0.1:1002",
+		To:     "tcp:127.0.0.1:1",
+		Status: log.AccessAccepted,
+	}
+	ctx := log.ContextWithAccessMessage(context.Background(), accessMessage)
+	log.Record(accessMessage)
+	formatted := make(chan string)
+	go func() {
+		formatted <- (<-queue).String()
+	}()
+	log.RecordAccessFailure(ctx, errors.New("failed to process outbound traffic").Base(refused))
+
+	if accepted := <-formatted; accepted != "127.0.0.1:1002 accepted tcp:127.0.0.1:1" {
+		t.Error("expected the accepted record unchanged, but actually ", accepted)
+	}
+	if failed := (<-queue).String(); !strings.HasPrefix(failed, "127.0.0.1:1002 failed tcp:127.0.0.1:1 ") || !strings.HasSuffix(failed, " outcome: connect_refused") {
+		t.Error("expected a failed record with outcome connect_refused, but actually ", failed)
+	}
+}
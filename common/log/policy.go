@@ -0,0 +1,38 @@
+package log
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/v2fly/v2ray-core/v5/common/serial"
+)
+
+type PolicyAction string
+
+const (
+	PolicyDropped = PolicyAction("dropped")
+)
+
+// PolicyMessage is a log message for a connection affected by policy
+// enforcement.
+type PolicyMessage struct {
+	SessionID uint32
+	Action    PolicyAction
+	Reason    interface{}
+}
+
+func (m *PolicyMessage) String() string {
+	builder := strings.Builder{}
+	builder.WriteString("[Policy] ")
+	if m.SessionID > 0 {
+		builder.WriteByte('[')
+		builder.WriteString(strconv.FormatUint(uint64(m.SessionID), 10))
+		builder.WriteString("] ")
+	}
+	builder.WriteString(string(m.Action))
+	if reason := serial.ToString(m.Reason); len(reason) > 0 {
+		builder.WriteString(": ")
+		builder.WriteString(reason)
+	}
+	return builder.String()
+}
@@ -0,0 +1,266 @@
+package log
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotationOptions limit the size of a log file and the number and age of
+// the backups kept of it.
+type RotationOptions struct {
+	// MaxSize is the size in bytes a file is rotated at.
+	MaxSize int64
+	// MaxBackups is the number of backups kept, 0 for all.
+	MaxBackups int
+	// MaxAge is how long backups are kept, 0 for ever.
+	MaxAge time.Duration
+	// Compress gzips backups.
+	Compress bool
+}
+
+const backupTimeLayout = "2006-01-02T15-04-05.000"
+
+// millLock serializes compressing and removing backups, done in the
+// background so writing logs does not wait for it.
+var millLock sync.Mutex
+
+// rotatingFile is a log file renamed to a backup with a timestamp, and
+// replaced by a new one, when a write would make it exceed MaxSize.
+type rotatingFile struct {
+	path    string
+	options RotationOptions
+	file    *os.File
+	size    int64
+	milling sync.WaitGroup
+}
+
+func openRotatingFile(path string, options RotationOptions) (*rotatingFile, error) {
+	f := &rotatingFile{path: path, options: options}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	if f.size > 0 && f.size+int64(len(p)) > f.options.MaxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.path, backupName(f.path, time.Now())); err != nil {
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.milling.Add(1)
+	go func() {
+		defer f.milling.Done()
+		millLock.Lock()
+		defer millLock.Unlock()
+		if err := millBackups(f.path, f.options, time.Now()); err != nil {
+			reportWriteError(f.path, err)
+		}
+	}()
+	return nil
+}
+
+// Close closes the file once backups are done being compressed and removed.
+func (f *rotatingFile) Close() error {
+	err := f.file.Close()
+	f.milling.Wait()
+	return err
+}
+
+// backupName returns the name of the backup of path rotated at t, like
+// access-2006-01-02T15-04-05.000.log for access.log, unused by any backup.
+func backupName(path string, t time.Time) string {
+	prefix, ext := backupParts(path)
+	name := prefix + t.Format(backupTimeLayout)
+	for i := 1; ; i++ {
+		candidate := name + ext
+		if i > 1 {
+			candidate = name + "." + strconv.Itoa(i) + ext
+		}
+		_, err := os.Stat(candidate)
+		_, gzErr := os.Stat(candidate + ".gz")
+		if os.IsNotExist(err) && os.IsNotExist(gzErr) {
+			return candidate
+		}
+	}
+}
+
+func backupParts(path string) (prefix, ext string) {
+	ext = filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-", ext
+}
+
+type backup struct {
+	path string
+	time time.Time
+}
+
+// backups returns the backups of path, newest first.
+func backups(path string) ([]backup, error) {
+	prefix, ext := backupParts(path)
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	base := filepath.Base(prefix)
+	var found []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, base) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ext)
+		stamp = strings.TrimPrefix(stamp, base)
+		if len(stamp) > len(backupTimeLayout) && stamp[len(backupTimeLayout)] == '.' {
+			// A counter after the timestamp.
+			stamp = stamp[:len(backupTimeLayout)]
+		}
+		t, err := time.ParseInLocation(backupTimeLayout, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		found = append(found, backup{path: filepath.Join(filepath.Dir(path), name), time: t})
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].time.After(found[j].time) })
+	return found, nil
+}
+
+// millBackups removes the backups of path beyond the count or age limits,
+// then compresses the remaining ones if configured.
+func millBackups(path string, options RotationOptions, now time.Time) error {
+	found, err := backups(path)
+	if err != nil {
+		return err
+	}
+	for i, b := range found {
+		expired := options.MaxAge > 0 && now.Sub(b.time) > options.MaxAge
+		if (options.MaxBackups > 0 && i >= options.MaxBackups) || expired {
+			if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		if options.Compress && !strings.HasSuffix(b.path, ".gz") {
+			if err := compressFile(b.path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compressFile replaces name with a gzipped name.gz.
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	tmp := name + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, name+".gz")
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	src.Close()
+	return os.Remove(name)
+}
+
+type rotatingFileLogWriter struct {
+	file   *rotatingFile
+	logger *log.Logger
+}
+
+func (w *rotatingFileLogWriter) Write(s string) error {
+	return w.logger.Output(2, s)
+}
+
+func (w *rotatingFileLogWriter) Close() error {
+	return w.file.Close()
+}
+
+// CreateRotatingFileLogWriter is like CreateFileLogWriter, rotating the file
+// as options says.
+func CreateRotatingFileLogWriter(path string, options RotationOptions) (WriterCreator, error) {
+	return createRotatingFileLogWriter(path, options, log.Ldate|log.Ltime)
+}
+
+// CreateRawRotatingFileLogWriter is like CreateRotatingFileLogWriter, but
+// without a timestamp prefix.
+func CreateRawRotatingFileLogWriter(path string, options RotationOptions) (WriterCreator, error) {
+	return createRotatingFileLogWriter(path, options, 0)
+}
+
+func createRotatingFileLogWriter(path string, options RotationOptions, flag int) (WriterCreator, error) {
+	if options.MaxSize <= 0 {
+		return nil, errors.New("log rotation requires a maximum size")
+	}
+	file, err := openRotatingFile(path, options)
+	if err != nil {
+		return nil, err
+	}
+	file.Close()
+	return func() Writer {
+		file, err := openRotatingFile(path, options)
+		if err != nil {
+			return nil
+		}
+		return &rotatingFileLogWriter{
+			file:   file,
+			logger: log.New(file, "", flag),
+		}
+	}, nil
+}
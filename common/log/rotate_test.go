@@ -0,0 +1,81 @@
+package log_test
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+)
+
+func TestRotatingFileLogWriter(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+	// A backup older than max age, and a file of another log.
+	expired := filepath.Join(dir, "access-"+time.Now().Add(-72*time.Hour).Format("2006-01-02T15-04-05.000")+".log")
+	common.Must(os.WriteFile(expired, []byte("old\n"), 0o600))
+	other := filepath.Join(dir, "access-other.log")
+	common.Must(os.WriteFile(other, []byte("other\n"), 0o600))
+
+	creator, err := log.CreateRawRotatingFileLogWriter(path, log.RotationOptions{
+		MaxSize:    100,
+		MaxBackups: 2,
+		MaxAge:     48 * time.Hour,
+		Compress:   true,
+	})
+	common.Must(err)
+	writer := creator()
+	line := strings.Repeat("x", 39) + "\n"
+	// 2 lines per file, the last 2 lines end up in the active file.
+	for i := 0; i < 10; i++ {
+		common.Must(writer.Write(line))
+	}
+	common.Must(writer.Close())
+
+	active, err := os.ReadFile(path)
+	common.Must(err)
+	if string(active) != line+line {
+		t.Error("unexpected active file ", string(active))
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, "access-*"))
+	common.Must(err)
+	sort.Strings(matches)
+	var backups []string
+	for _, match := range matches {
+		if match != other {
+			backups = append(backups, match)
+		}
+	}
+	if len(backups) != 2 {
+		t.Fatal("expected 2 backups, but actually ", backups)
+	}
+	for _, backup := range backups {
+		if !strings.HasSuffix(backup, ".log.gz") {
+			t.Error("expected a compressed backup, but actually ", backup)
+			continue
+		}
+		file, err := os.Open(backup)
+		common.Must(err)
+		reader, err := gzip.NewReader(file)
+		common.Must(err)
+		content, err := io.ReadAll(reader)
+		common.Must(err)
+		file.Close()
+		if string(content) != line+line {
+			t.Error("unexpected backup content ", string(content))
+		}
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Error("expected the file of another log to be kept: ", err)
+	}
+
+	if _, err := log.CreateRotatingFileLogWriter(path, log.RotationOptions{}); err == nil {
+		t.Error("expected rotation without a maximum size to be rejected")
+	}
+}
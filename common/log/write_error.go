@@ -0,0 +1,51 @@
+package log
+
+import (
+	"sync"
+	"time"
+)
+
+// WriteError describes a log write that failed.
+type WriteError struct {
+	// Logger identifies the failing handler, as named by NewNamedLogger.
+	Logger string
+	Err    error
+}
+
+// writeErrorInterval is the minimum time between two notifications for the
+// same logger.
+const writeErrorInterval = 10 * time.Second
+
+var writeErrorCallback = struct {
+	sync.Mutex
+	f    func(WriteError)
+	last map[string]time.Time
+}{last: make(map[string]time.Time)}
+
+// OnWriteError registers f to be notified when a log write fails, replacing
+// the previous callback, or removes it if f is nil. f runs in its own
+// goroutine, at most once every 10 seconds per logger. Failures in between
+// are not reported.
+func OnWriteError(f func(WriteError)) {
+	writeErrorCallback.Lock()
+	defer writeErrorCallback.Unlock()
+
+	writeErrorCallback.f = f
+	writeErrorCallback.last = make(map[string]time.Time)
+}
+
+func reportWriteError(logger string, err error) {
+	writeErrorCallback.Lock()
+	defer writeErrorCallback.Unlock()
+
+	f := writeErrorCallback.f
+	if f == nil {
+		return
+	}
+	now := time.Now()
+	if last, found := writeErrorCallback.last[logger]; found && now.Sub(last) < writeErrorInterval {
+		return
+	}
+	writeErrorCallback.last[logger] = now
+	go f(WriteError{Logger: logger, Err: err})
+}
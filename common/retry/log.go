@@ -0,0 +1,15 @@
+package retry
+
+import (
+	"context"
+
+	"github.com/v2fly/v2ray-core/v5/common/session"
+)
+
+// LogAttempts returns an observer for WithObserver that records each failed
+// attempt of operation as a Debug message of the session in ctx.
+func LogAttempts(ctx context.Context, operation string) func(Attempt) {
+	return func(attempt Attempt) {
+		newError(operation, " attempt ", attempt.Number, "/", attempt.Total, " failed, waiting ", attempt.Delay).Base(attempt.Err).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+	}
+}
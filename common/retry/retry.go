@@ -14,9 +14,20 @@ type Strategy interface {
 	On(func() error) error
 }
 
+// Attempt describes a failed attempt of a retry strategy.
+type Attempt struct {
+	// Number of the attempt, starting from 1.
+	Number int
+	Total  int
+	// Delay before the next attempt.
+	Delay time.Duration
+	Err   error
+}
+
 type retryer struct {
 	totalAttempt int
 	nextDelay    func() uint32
+	observer     func(Attempt)
 }
 
 // On implements Strategy.On.
@@ -32,9 +43,12 @@ func (r *retryer) On(method func() error) error {
 		if numErrors == 0 || err.Error() != accumulatedError[numErrors-1].Error() {
 			accumulatedError = append(accumulatedError, err)
 		}
-		delay := r.nextDelay()
-		time.Sleep(time.Duration(delay) * time.Millisecond)
+		delay := time.Duration(r.nextDelay()) * time.Millisecond
 		attempt++
+		if r.observer != nil {
+			r.observer(Attempt{Number: attempt, Total: r.totalAttempt, Delay: delay, Err: err})
+		}
+		time.Sleep(delay)
 	}
 	return newError(accumulatedError).Base(ErrRetryFailed)
 }
@@ -60,3 +74,15 @@ func ExponentialBackoff(attempts int, delay uint32) Strategy {
 		},
 	}
 }
+
+// WithObserver returns a copy of strategy that calls observer after each failed
+// attempt, before waiting for the next one.
+func WithObserver(strategy Strategy, observer func(Attempt)) Strategy {
+	r, ok := strategy.(*retryer)
+	if !ok {
+		return strategy
+	}
+	observed := *r
+	observed.observer = observer
+	return &observed
+}
@@ -1,11 +1,16 @@
 package retry_test
 
 import (
+	"context"
+	"net"
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/v2fly/v2ray-core/v5/common"
 	"github.com/v2fly/v2ray-core/v5/common/errors"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+	"github.com/v2fly/v2ray-core/v5/common/log/logtest"
 	. "github.com/v2fly/v2ray-core/v5/common/retry"
 )
 
@@ -94,3 +99,35 @@ func TestExponentialBackoff(t *testing.T) {
 		t.Error("duration: ", v)
 	}
 }
+
+func TestLogAttempts(t *testing.T) {
+	recorder := logtest.Capture(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	addr := listener.Addr().String()
+	common.Must(listener.Close())
+
+	var delays []time.Duration
+	observer := LogAttempts(context.Background(), "dial")
+	err = WithObserver(ExponentialBackoff(3, 10), func(attempt Attempt) {
+		delays = append(delays, attempt.Delay)
+		observer(attempt)
+	}).On(func() error {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+		}
+		return err
+	})
+	if errors.Cause(err) != ErrRetryFailed {
+		t.Fatal("expected dial to fail, but actually: ", err)
+	}
+
+	if r := cmp.Diff(delays, []time.Duration{0, 10 * time.Millisecond, 20 * time.Millisecond}); r != "" {
+		t.Error(r)
+	}
+	for _, expected := range []string{"dial attempt 1/3 failed, waiting 0s", "dial attempt 2/3 failed, waiting 10ms", "dial attempt 3/3 failed, waiting 20ms"} {
+		recorder.AssertContains(log.Severity_Debug, expected)
+	}
+}
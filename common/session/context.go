@@ -15,6 +15,7 @@ const (
 	sockoptSessionKey
 	trackedConnectionErrorKey
 	handlerSessionKey // nolint: varcheck
+	traceSessionKey
 )
 
 // ContextWithID returns a new context with the given ID.
@@ -52,6 +53,21 @@ func OutboundFromContext(ctx context.Context) *Outbound {
 	return nil
 }
 
+// ContextWithTrace returns a new context with the tracing context of the
+// connection.
+func ContextWithTrace(ctx context.Context, trace *Trace) context.Context {
+	return context.WithValue(ctx, traceSessionKey, trace)
+}
+
+// TraceFromContext returns the tracing context of the connection, or nil if
+// it has none.
+func TraceFromContext(ctx context.Context) *Trace {
+	if trace, ok := ctx.Value(traceSessionKey).(*Trace); ok {
+		return trace
+	}
+	return nil
+}
+
 func ContextWithContent(ctx context.Context, content *Content) context.Context {
 	return context.WithValue(ctx, contentSessionKey, content)
 }
@@ -3,7 +3,9 @@ package session
 
 import (
 	"context"
+	"encoding/hex"
 	"math/rand"
+	"strings"
 
 	"github.com/v2fly/v2ray-core/v5/common/errors"
 	"github.com/v2fly/v2ray-core/v5/common/net"
@@ -28,8 +30,17 @@ func NewID() ID {
 // This can be used with error.WriteToLog().
 func ExportIDToError(ctx context.Context) errors.ExportOption {
 	id := IDFromContext(ctx)
+	inbound := InboundFromContext(ctx)
+	trace := TraceFromContext(ctx)
 	return func(h *errors.ExportOptionHolder) {
 		h.SessionID = uint32(id)
+		if inbound != nil {
+			h.InboundTag = inbound.Tag
+		}
+		if trace != nil {
+			h.TraceID = trace.TraceID
+			h.SpanID = trace.SpanID
+		}
 	}
 }
 
@@ -46,6 +57,9 @@ type Inbound struct {
 
 	// Conn is actually internet.Connection. May be nil.
 	Conn net.Conn
+	// Transport is the name of the transport protocol carrying the
+	// connection, like tcp or websocket. Empty if unknown.
+	Transport string
 
 	// SagerNet private
 	Uid         uint32
@@ -88,6 +102,42 @@ type Sockopt struct {
 	Mark uint32
 }
 
+// Trace is the distributed tracing context a connection was received with.
+type Trace struct {
+	// TraceID and SpanID are lowercase hex, of 32 and 16 digits.
+	TraceID string
+	SpanID  string
+}
+
+// ParseTraceparent parses the value of a W3C traceparent header, as in
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01. It returns nil if
+// the value is malformed or its ids are all zeros.
+func ParseTraceparent(value string) *Trace {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[3]) != 2 {
+		return nil
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return nil
+	}
+	traceID, spanID := parts[1], parts[2]
+	if !isTraceID(traceID, 32) || !isTraceID(spanID, 16) {
+		return nil
+	}
+	return &Trace{TraceID: traceID, SpanID: spanID}
+}
+
+// isTraceID returns true if id is length lowercase hex digits, not all zeros.
+func isTraceID(id string, length int) bool {
+	if len(id) != length || strings.ToLower(id) != id {
+		return false
+	}
+	if _, err := hex.DecodeString(id); err != nil {
+		return false
+	}
+	return strings.Trim(id, "0") != ""
+}
+
 // SetAttribute attachs additional string attributes to content.
 func (c *Content) SetAttribute(name string, value string) {
 	if c.Attributes == nil {
@@ -15,9 +15,11 @@ type ActivityUpdater interface {
 
 type ActivityTimer struct {
 	sync.RWMutex
-	updated   chan struct{}
-	checkTask *task.Periodic
-	onTimeout func()
+	updated    chan struct{}
+	checkTask  *task.Periodic
+	timeout    time.Duration
+	onTimeout  func()
+	onInactive func(timeout time.Duration)
 }
 
 func (t *ActivityTimer) Update() {
@@ -31,11 +33,31 @@ func (t *ActivityTimer) check() error {
 	select {
 	case <-t.updated:
 	default:
+		t.expire()
 		t.finish()
 	}
 	return nil
 }
 
+func (t *ActivityTimer) expire() {
+	t.RLock()
+	defer t.RUnlock()
+
+	if t.onTimeout != nil && t.onInactive != nil {
+		t.onInactive(t.timeout)
+	}
+}
+
+// OnInactive sets a function to be called with the timeout when the timer
+// fires because there was no activity, as opposed to SetTimeout(0). It only
+// applies to the timeout the timer was created with, SetTimeout clears it.
+func (t *ActivityTimer) OnInactive(f func(timeout time.Duration)) {
+	t.Lock()
+	defer t.Unlock()
+
+	t.onInactive = f
+}
+
 func (t *ActivityTimer) finish() {
 	t.Lock()
 	defer t.Unlock()
@@ -66,7 +88,13 @@ func (t *ActivityTimer) SetTimeout(timeout time.Duration) {
 	if t.checkTask != nil {
 		t.checkTask.Close()
 	}
+	if t.timeout != 0 {
+		// Later timeouts, like the ones after a side of the connection
+		// finished, expire as the connection winds down.
+		t.onInactive = nil
+	}
 	t.checkTask = checkTask
+	t.timeout = timeout
 	t.Unlock()
 	t.Update()
 	common.Must(checkTask.Start())
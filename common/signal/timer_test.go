@@ -58,3 +58,37 @@ func TestActivityTimerZeroTimeout(t *testing.T) {
 	}
 	runtime.KeepAlive(timer)
 }
+
+func TestActivityTimerInactive(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	timer := CancelAfterInactivity(ctx, cancel, time.Second)
+	inactive := make(chan time.Duration, 1)
+	timer.OnInactive(func(timeout time.Duration) {
+		inactive <- timeout
+	})
+	select {
+	case timeout := <-inactive:
+		if timeout != time.Second {
+			t.Error("expected the idle timeout, but actually ", timeout)
+		}
+	case <-time.After(3 * time.Second):
+		t.Error("inactivity not reported")
+	}
+
+	// Timeouts set as the connection winds down are not inactivity.
+	ctx, cancel = context.WithCancel(context.Background())
+	timer = CancelAfterInactivity(ctx, cancel, time.Minute)
+	timer.OnInactive(func(timeout time.Duration) {
+		inactive <- timeout
+	})
+	timer.SetTimeout(100 * time.Millisecond)
+	time.Sleep(time.Second)
+	if ctx.Err() == nil {
+		t.Error("expected the context to be cancelled")
+	}
+	select {
+	case timeout := <-inactive:
+		t.Error("unexpected inactivity after ", timeout)
+	default:
+	}
+}
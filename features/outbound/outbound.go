@@ -18,6 +18,18 @@ type Handler interface {
 	Dispatch(ctx context.Context, link *transport.Link)
 }
 
+// TransportHandler is implemented by Handlers that know the transport protocol
+// carrying their connections.
+type TransportHandler interface {
+	Transport() string
+}
+
+// HealthReporter is implemented by Handlers that know whether their server is
+// reachable.
+type HealthReporter interface {
+	Healthy() bool
+}
+
 type ConnHandler interface {
 	IsConnDispatcher() bool
 	DispatchConn(ctx context.Context, conn net.Conn)
@@ -0,0 +1,30 @@
+package policy
+
+import (
+	"context"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common/log"
+	"github.com/v2fly/v2ray-core/v5/common/session"
+	"github.com/v2fly/v2ray-core/v5/common/signal"
+)
+
+// CancelAfterInactivity cancels the connection in ctx once it has been idle
+// for the ConnectionIdle timeout of s, or any timeout set later on the
+// returned timer. Connections closed by the ConnectionIdle timeout while
+// still open are recorded as policy enforcement.
+func (s Session) CancelAfterInactivity(ctx context.Context, cancel context.CancelFunc) *signal.ActivityTimer {
+	timer := signal.CancelAfterInactivity(ctx, cancel, s.Timeouts.ConnectionIdle)
+	id := uint32(session.IDFromContext(ctx))
+	timer.OnInactive(func(timeout time.Duration) {
+		if ctx.Err() != nil {
+			return
+		}
+		log.Record(&log.PolicyMessage{
+			SessionID: id,
+			Action:    log.PolicyDropped,
+			Reason:    "inactive for " + timeout.String(),
+		})
+	})
+	return timer
+}
@@ -0,0 +1,53 @@
+package policy_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common/log"
+	"github.com/v2fly/v2ray-core/v5/common/log/logtest"
+	"github.com/v2fly/v2ray-core/v5/features/policy"
+)
+
+func policyMessages(recorder *logtest.Recorder) int {
+	n := 0
+	for _, msg := range recorder.Messages() {
+		if _, ok := msg.(*log.PolicyMessage); ok {
+			n++
+		}
+	}
+	return n
+}
+
+func TestCancelAfterInactivity(t *testing.T) {
+	recorder := logtest.Capture(t)
+	s := policy.Session{Timeouts: policy.Timeout{
+		ConnectionIdle: 200 * time.Millisecond,
+		UplinkOnly:     100 * time.Millisecond,
+		DownlinkOnly:   100 * time.Millisecond,
+	}}
+
+	// A connection closing normally.
+	ctx, cancel := context.WithCancel(context.Background())
+	timer := s.CancelAfterInactivity(ctx, cancel)
+	timer.SetTimeout(s.Timeouts.DownlinkOnly)
+	time.Sleep(500 * time.Millisecond)
+	if ctx.Err() == nil {
+		t.Fatal("expected the connection to be closed")
+	}
+	if n := policyMessages(recorder); n != 0 {
+		t.Error("expected no policy message for a connection closing normally, but got ", n)
+	}
+
+	// An idle connection.
+	ctx, cancel = context.WithCancel(context.Background())
+	s.CancelAfterInactivity(ctx, cancel)
+	time.Sleep(600 * time.Millisecond)
+	if ctx.Err() == nil {
+		t.Fatal("expected the idle connection to be closed")
+	}
+	if n := policyMessages(recorder); n != 1 {
+		t.Error("expected a policy message for the idle connection, but got ", n)
+	}
+}
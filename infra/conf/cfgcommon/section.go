@@ -0,0 +1,48 @@
+package cfgcommon
+
+import (
+	"strconv"
+
+	"github.com/v2fly/v2ray-core/v5/common/errors"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+)
+
+// SectionReport records whether each section of the config loaded, so every
+// invalid section is reported rather than only the first. The results are
+// queued until the log app starts, so they honor its level and destination.
+type SectionReport struct {
+	errs []error
+}
+
+// Record queues the result of section, with the reason if it failed
+// validation.
+func (r *SectionReport) Record(section string, err error) {
+	msg := &log.ConfigMessage{Section: section, Status: log.ConfigLoaded}
+	if err != nil {
+		msg.Status = log.ConfigInvalid
+		msg.Reason = err
+		r.errs = append(r.errs, newError("invalid ", section).Base(err))
+	}
+	log.RecordConfig(msg)
+}
+
+// Err returns the errors of all invalid sections, or nil if there are none.
+func (r *SectionReport) Err() error {
+	if len(r.errs) == 0 {
+		return nil
+	}
+	// The build fails and no log app starts, so the queued results are logged
+	// at once.
+	log.FlushConfig()
+	return errors.Combine(r.errs...)
+}
+
+// HandlerSection names the section of the i-th inbound or outbound, with its
+// tag if it has one.
+func HandlerSection(kind string, i int, tag string) string {
+	section := kind + "[" + strconv.Itoa(i) + "]"
+	if tag != "" {
+		section += " (" + tag + ")"
+	}
+	return section
+}
@@ -88,6 +88,7 @@ type RouterConfig struct { // nolint: revive
 	Balancers      []*BalancingRule   `json:"balancers"`
 
 	DomainMatcher string `json:"domainMatcher"`
+	TraceRouting  bool   `json:"traceRouting"`
 
 	cfgctx context.Context
 }
@@ -120,6 +121,7 @@ func (c *RouterConfig) BuildV5(ctx context.Context) (*router.Config, error) {
 func (c *RouterConfig) Build() (*router.Config, error) {
 	config := new(router.Config)
 	config.DomainStrategy = c.getDomainStrategy()
+	config.TraceRouting = c.TraceRouting
 
 	if c.cfgctx == nil {
 		c.cfgctx = cfgcommon.NewConfigureLoadingContext(context.Background())
@@ -1,36 +1,258 @@
 package v4
 
 import (
+	"strings"
+
 	"github.com/golang/protobuf/proto"
 	"github.com/v2fly/v2ray-core/v5/infra/conf/cfgcommon"
 	"github.com/v2fly/v2ray-core/v5/proxy/ssh"
 )
 
+type SSHEndpointConfig struct {
+	Address           *cfgcommon.Address `json:"address"`
+	Port              uint32             `json:"port"`
+	KeepAliveInterval uint32             `json:"keepAliveInterval"`
+	HandshakeDeadline uint32             `json:"handshakeDeadline"`
+	PublicKey         string             `json:"publicKey"`
+}
+
+type SSHResolveRuleConfig struct {
+	Domain     []string `json:"domain"`
+	Resolution string   `json:"resolution"`
+}
+
+type SSHJumpHostConfig struct {
+	Address    *cfgcommon.Address `json:"address"`
+	Port       uint32             `json:"port"`
+	User       string             `json:"user"`
+	Password   string             `json:"password"`
+	PrivateKey string             `json:"privateKey"`
+	PublicKey  string             `json:"publicKey"`
+}
+
+type SSHHealthCheckConfig struct {
+	Enabled   bool   `json:"enabled"`
+	Interval  uint32 `json:"interval"`
+	Timeout   uint32 `json:"timeout"`
+	Handshake bool   `json:"handshake"`
+}
+
 type SSHClientConfig struct {
-	Address           *cfgcommon.Address    `json:"address"`
-	Port              uint32                `json:"port"`
-	User              string                `json:"user"`
-	Password          string                `json:"password"`
-	PrivateKey        string                `json:"privateKey"`
-	PublicKey         string                `json:"publicKey"`
-	ClientVersion     string                `json:"clientVersion"`
-	HostKeyAlgorithms *cfgcommon.StringList `json:"hostKeyAlgorithms"`
-	UserLevel         uint32                `json:"userLevel"`
+	Address                    *cfgcommon.Address      `json:"address"`
+	Port                       uint32                  `json:"port"`
+	User                       string                  `json:"user"`
+	Password                   string                  `json:"password"`
+	PrivateKey                 string                  `json:"privateKey"`
+	PrivateKeys                []string                `json:"privateKeys"`
+	PublicKey                  string                  `json:"publicKey"`
+	ClientVersion              string                  `json:"clientVersion"`
+	HostKeyAlgorithms          *cfgcommon.StringList   `json:"hostKeyAlgorithms"`
+	Ciphers                    *cfgcommon.StringList   `json:"ciphers"`
+	MACs                       *cfgcommon.StringList   `json:"macs"`
+	KeyExchanges               *cfgcommon.StringList   `json:"keyExchanges"`
+	UserLevel                  uint32                  `json:"userLevel"`
+	ChannelType                string                  `json:"channelType"`
+	AllowEmptyUser             bool                    `json:"allowEmptyUser"`
+	URI                        string                  `json:"uri"`
+	ReuseConnection            bool                    `json:"reuseConnection"`
+	Servers                    []*SSHEndpointConfig    `json:"servers"`
+	DialStrategy               string                  `json:"dialStrategy"`
+	HostCertAuthorities        []string                `json:"hostCertAuthorities"`
+	ResolveRules               []*SSHResolveRuleConfig `json:"resolveRules"`
+	ExpectBannerContains       string                  `json:"expectBannerContains"`
+	BufferMode                 string                  `json:"bufferMode"`
+	OriginatorPort             uint32                  `json:"originatorPort"`
+	KeepAliveInterval          uint32                  `json:"keepAliveInterval"`
+	KeepAliveMaxFailures       uint32                  `json:"keepAliveMaxFailures"`
+	MaxCachedClients           uint32                  `json:"maxCachedClients"`
+	HandshakeDeadline          uint32                  `json:"handshakeDeadline"`
+	ConnectTimeout             uint32                  `json:"connectTimeout"`
+	AuthFailureSummary         bool                    `json:"authFailureSummary"`
+	Compression                bool                    `json:"compression"`
+	ForwardHostForm            string                  `json:"forwardHostForm"`
+	MaxChannelsPerConnection   uint32                  `json:"maxChannelsPerConnection"`
+	ChannelsCounter            string                  `json:"channelsCounter"`
+	AgentSocket                string                  `json:"agentSocket"`
+	MaxConnectionDuration      uint32                  `json:"maxConnectionDuration"`
+	KeyboardInteractiveAnswers map[string]string       `json:"keyboardInteractiveAnswers"`
+	AvoidTerrapinAlgorithms    bool                    `json:"avoidTerrapinAlgorithms"`
+	ServerAliveInterval        uint32                  `json:"serverAliveInterval"`
+	ServerAliveCountMax        uint32                  `json:"serverAliveCountMax"`
+	ReconnectOnWriteFailure    bool                    `json:"reconnectOnWriteFailure"`
+	StrictPublicKeyParse       bool                    `json:"strictPublicKeyParse"`
+	MaxConnections             uint32                  `json:"maxConnections"`
+	HostKeyCheckTimeout        uint32                  `json:"hostKeyCheckTimeout"`
+	KnownHostsPath             string                  `json:"knownHostsPath"`
+	InsecureSkipHostKeyCheck   bool                    `json:"insecureSkipHostKeyCheck"`
+	HealthCheck                *SSHHealthCheckConfig   `json:"healthCheck"`
+	JumpHosts                  []*SSHJumpHostConfig    `json:"jumpHosts"`
+	EnableUDP                  bool                    `json:"enableUdp"`
+	UDPRelay                   string                  `json:"udpRelay"`
 }
 
 func (v *SSHClientConfig) Build() (proto.Message, error) {
 	c := &ssh.Config{
-		Address:       v.Address.Build(),
-		Port:          v.Port,
-		User:          v.User,
-		Password:      v.Password,
-		PrivateKey:    v.PrivateKey,
-		PublicKey:     v.PublicKey,
-		ClientVersion: v.ClientVersion,
-		UserLevel:     v.UserLevel,
+		Port:                       v.Port,
+		User:                       v.User,
+		Password:                   v.Password,
+		PrivateKey:                 v.PrivateKey,
+		PrivateKeys:                v.PrivateKeys,
+		PublicKey:                  v.PublicKey,
+		ClientVersion:              v.ClientVersion,
+		UserLevel:                  v.UserLevel,
+		ChannelType:                v.ChannelType,
+		AllowEmptyUser:             v.AllowEmptyUser,
+		Uri:                        v.URI,
+		ReuseConnection:            v.ReuseConnection,
+		HostCertAuthorities:        v.HostCertAuthorities,
+		ExpectBannerContains:       v.ExpectBannerContains,
+		OriginatorPort:             v.OriginatorPort,
+		KeepAliveInterval:          v.KeepAliveInterval,
+		KeepAliveMaxFailures:       v.KeepAliveMaxFailures,
+		MaxCachedClients:           v.MaxCachedClients,
+		HandshakeDeadline:          v.HandshakeDeadline,
+		ConnectTimeout:             v.ConnectTimeout,
+		AuthFailureSummary:         v.AuthFailureSummary,
+		Compression:                v.Compression,
+		MaxChannelsPerConnection:   v.MaxChannelsPerConnection,
+		ChannelsCounter:            v.ChannelsCounter,
+		AgentSocket:                v.AgentSocket,
+		MaxConnectionDuration:      v.MaxConnectionDuration,
+		KeyboardInteractiveAnswers: v.KeyboardInteractiveAnswers,
+		AvoidTerrapinAlgorithms:    v.AvoidTerrapinAlgorithms,
+		ServerAliveInterval:        v.ServerAliveInterval,
+		ServerAliveCountMax:        v.ServerAliveCountMax,
+		ReconnectOnWriteFailure:    v.ReconnectOnWriteFailure,
+		StrictPublicKeyParse:       v.StrictPublicKeyParse,
+		MaxConnections:             v.MaxConnections,
+		HostKeyCheckTimeout:        v.HostKeyCheckTimeout,
+		KnownHostsPath:             v.KnownHostsPath,
+		InsecureSkipHostKeyCheck:   v.InsecureSkipHostKeyCheck,
+		EnableUdp:                  v.EnableUDP,
+		UdpRelay:                   v.UDPRelay,
+	}
+	if v.Address != nil {
+		c.Address = v.Address.Build()
 	}
 	if v.HostKeyAlgorithms != nil {
 		c.HostKeyAlgorithms = *v.HostKeyAlgorithms
 	}
+	if v.Ciphers != nil {
+		c.Ciphers = *v.Ciphers
+	}
+	if v.MACs != nil {
+		c.Macs = *v.MACs
+	}
+	if v.KeyExchanges != nil {
+		c.KeyExchanges = *v.KeyExchanges
+	}
+	if v.HealthCheck != nil {
+		c.HealthCheck = &ssh.HealthCheck{
+			Enabled:   v.HealthCheck.Enabled,
+			Interval:  v.HealthCheck.Interval,
+			Timeout:   v.HealthCheck.Timeout,
+			Handshake: v.HealthCheck.Handshake,
+		}
+	}
+	for _, server := range v.Servers {
+		if server.Address == nil {
+			return nil, newError("ssh server address is not set")
+		}
+		c.Servers = append(c.Servers, &ssh.Endpoint{
+			Address:           server.Address.Build(),
+			Port:              server.Port,
+			KeepAliveInterval: server.KeepAliveInterval,
+			HandshakeDeadline: server.HandshakeDeadline,
+			PublicKey:         server.PublicKey,
+		})
+	}
+	for _, jump := range v.JumpHosts {
+		if jump.Address == nil {
+			return nil, newError("ssh jump host address is not set")
+		}
+		c.JumpHosts = append(c.JumpHosts, &ssh.JumpHost{
+			Address:    jump.Address.Build(),
+			Port:       jump.Port,
+			User:       jump.User,
+			Password:   jump.Password,
+			PrivateKey: jump.PrivateKey,
+			PublicKey:  jump.PublicKey,
+		})
+	}
+	switch strings.ToLower(v.DialStrategy) {
+	case "", "sequential":
+		c.DialStrategy = ssh.DialStrategy_Sequential
+	case "parallel":
+		c.DialStrategy = ssh.DialStrategy_Parallel
+	default:
+		return nil, newError("unknown ssh dial strategy: ", v.DialStrategy)
+	}
+	for _, rule := range v.ResolveRules {
+		resolveRule := &ssh.ResolveRule{Domain: rule.Domain}
+		switch strings.ToLower(rule.Resolution) {
+		case "", "remote":
+			resolveRule.Resolution = ssh.Resolution_Remote
+		case "local":
+			resolveRule.Resolution = ssh.Resolution_Local
+		default:
+			return nil, newError("unknown ssh resolution: ", rule.Resolution)
+		}
+		c.ResolveRules = append(c.ResolveRules, resolveRule)
+	}
+	switch strings.ToLower(v.BufferMode) {
+	case "", "auto":
+		c.BufferMode = ssh.BufferMode_Auto
+	case "interactive":
+		c.BufferMode = ssh.BufferMode_Interactive
+	case "bulk":
+		c.BufferMode = ssh.BufferMode_Bulk
+	default:
+		return nil, newError("unknown ssh buffer mode: ", v.BufferMode)
+	}
+	switch strings.ToLower(v.ForwardHostForm) {
+	case "", "auto":
+		c.ForwardHostForm = ssh.ForwardHostForm_ForwardHostAuto
+	case "ip":
+		c.ForwardHostForm = ssh.ForwardHostForm_ForwardHostIP
+	case "name":
+		c.ForwardHostForm = ssh.ForwardHostForm_ForwardHostName
+	default:
+		return nil, newError("unknown ssh forward host form: ", v.ForwardHostForm)
+	}
+	return c, nil
+}
+
+type SSHAccountConfig struct {
+	User              string   `json:"user"`
+	Password          string   `json:"password"`
+	AuthorizedKeys    []string `json:"authorizedKeys"`
+	Level             uint32   `json:"level"`
+	AuthorizedKeysDir string   `json:"authorizedKeysDir"`
+	PermitOpen        []string `json:"permitOpen"`
+}
+
+type SSHServerConfig struct {
+	HostKeys      []string            `json:"hostKeys"`
+	Accounts      []*SSHAccountConfig `json:"accounts"`
+	ServerVersion string              `json:"serverVersion"`
+	PermitOpen    []string            `json:"permitOpen"`
+}
+
+func (v *SSHServerConfig) Build() (proto.Message, error) {
+	c := &ssh.ServerConfig{
+		HostKeys:      v.HostKeys,
+		ServerVersion: v.ServerVersion,
+		PermitOpen:    v.PermitOpen,
+	}
+	for _, account := range v.Accounts {
+		c.Accounts = append(c.Accounts, &ssh.Account{
+			User:              account.User,
+			Password:          account.Password,
+			AuthorizedKeys:    account.AuthorizedKeys,
+			Level:             account.Level,
+			AuthorizedKeysDir: account.AuthorizedKeysDir,
+			PermitOpen:        account.PermitOpen,
+		})
+	}
 	return c, nil
 }
@@ -26,6 +26,7 @@ var (
 		"http":          func() interface{} { return new(HTTPServerConfig) },
 		"shadowsocks":   func() interface{} { return new(ShadowsocksServerConfig) },
 		"socks":         func() interface{} { return new(SocksServerConfig) },
+		"ssh":           func() interface{} { return new(SSHServerConfig) },
 		"vless":         func() interface{} { return new(VLessInboundConfig) },
 		"vmess":         func() interface{} { return new(VMessInboundConfig) },
 		"trojan":        func() interface{} { return new(TrojanServerConfig) },
@@ -384,26 +385,28 @@ func (c *Config) Build() (*core.Config, error) {
 			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
 		},
 	}
+	report := &cfgcommon.SectionReport{}
 
 	if c.API != nil {
 		apiConf, err := c.API.Build()
-		if err != nil {
-			return nil, err
+		report.Record("api", err)
+		if err == nil {
+			config.App = append(config.App, serial.ToTypedMessage(apiConf))
 		}
-		config.App = append(config.App, serial.ToTypedMessage(apiConf))
 	}
 
 	if c.Stats != nil {
 		statsConf, err := c.Stats.Build()
-		if err != nil {
-			return nil, err
+		report.Record("stats", err)
+		if err == nil {
+			config.App = append(config.App, serial.ToTypedMessage(statsConf))
 		}
-		config.App = append(config.App, serial.ToTypedMessage(statsConf))
 	}
 
 	var logConfMsg *anypb.Any
 	if c.LogConfig != nil {
 		logConfMsg = serial.ToTypedMessage(c.LogConfig.Build())
+		report.Record("log", nil)
 	} else {
 		logConfMsg = serial.ToTypedMessage(log.DefaultLogConfig())
 	}
@@ -413,86 +416,93 @@ func (c *Config) Build() (*core.Config, error) {
 
 	if c.RouterConfig != nil {
 		routerConfig, err := c.RouterConfig.Build()
-		if err != nil {
-			return nil, err
+		report.Record("routing", err)
+		if err == nil {
+			config.App = append(config.App, serial.ToTypedMessage(routerConfig))
 		}
-		config.App = append(config.App, serial.ToTypedMessage(routerConfig))
 	}
 
 	if c.DNSConfig != nil {
 		dnsApp, err := c.DNSConfig.Build()
 		if err != nil {
-			return nil, newError("failed to parse DNS config").Base(err)
+			err = newError("failed to parse DNS config").Base(err)
+		}
+		report.Record("dns", err)
+		if err == nil {
+			config.App = append(config.App, serial.ToTypedMessage(dnsApp))
 		}
-		config.App = append(config.App, serial.ToTypedMessage(dnsApp))
 	}
 
 	if c.Policy != nil {
 		pc, err := c.Policy.Build()
-		if err != nil {
-			return nil, err
+		report.Record("policy", err)
+		if err == nil {
+			config.App = append(config.App, serial.ToTypedMessage(pc))
 		}
-		config.App = append(config.App, serial.ToTypedMessage(pc))
 	}
 
 	if c.Reverse != nil {
 		r, err := c.Reverse.Build()
-		if err != nil {
-			return nil, err
+		report.Record("reverse", err)
+		if err == nil {
+			config.App = append(config.App, serial.ToTypedMessage(r))
 		}
-		config.App = append(config.App, serial.ToTypedMessage(r))
 	}
 
 	if c.BrowserForwarder != nil {
 		r, err := c.BrowserForwarder.Build()
-		if err != nil {
-			return nil, err
+		report.Record("browserForwarder", err)
+		if err == nil {
+			config.App = append(config.App, serial.ToTypedMessage(r))
 		}
-		config.App = append(config.App, serial.ToTypedMessage(r))
 	}
 
 	if c.Observatory != nil {
 		r, err := c.Observatory.Build()
-		if err != nil {
-			return nil, err
+		report.Record("observatory", err)
+		if err == nil {
+			config.App = append(config.App, serial.ToTypedMessage(r))
 		}
-		config.App = append(config.App, serial.ToTypedMessage(r))
 	}
 
 	if c.BurstObservatory != nil {
 		r, err := c.BurstObservatory.Build()
-		if err != nil {
-			return nil, err
+		report.Record("burstObservatory", err)
+		if err == nil {
+			config.App = append(config.App, serial.ToTypedMessage(r))
 		}
-		config.App = append(config.App, serial.ToTypedMessage(r))
 	}
 
 	if c.MultiObservatory != nil {
 		r, err := c.MultiObservatory.Build()
-		if err != nil {
-			return nil, err
+		report.Record("multiObservatory", err)
+		if err == nil {
+			config.App = append(config.App, serial.ToTypedMessage(r))
 		}
-		config.App = append(config.App, serial.ToTypedMessage(r))
 	}
 
 	if c.Ping != nil {
 		r, err := c.Ping.Build()
-		if err != nil {
-			return nil, err
+		report.Record("ping", err)
+		if err == nil {
+			config.App = append(config.App, serial.ToTypedMessage(r))
 		}
-		config.App = append(config.App, serial.ToTypedMessage(r))
 	}
 
 	// Load Additional Services that do not have a json translator
 
-	if msg, err := c.BuildServices(c.Services); err != nil {
+	msg, err := c.BuildServices(c.Services)
+	if err != nil {
 		developererr := newError("Loading a V2Ray Features as a service is intended for developers only. " +
 			"This is used for developers to prototype new features or for an advanced client to use special features in V2Ray," +
 			" instead of allowing end user to enable it without special tool and knowledge.")
 		sb := strings.Builder{}
-		return nil, newError("Cannot load service").Base(developererr).Base(err).Base(newError(sb.String()))
-	} else { // nolint: revive
-		// Using a else here is required to keep msg in scope
+		err = newError("Cannot load service").Base(developererr).Base(err).Base(newError(sb.String()))
+	}
+	if len(c.Services) > 0 {
+		report.Record("services", err)
+	}
+	if err == nil {
 		config.App = append(config.App, msg...)
 	}
 
@@ -518,7 +528,7 @@ func (c *Config) Build() (*core.Config, error) {
 		}
 	}
 
-	for _, rawInboundConfig := range inbounds {
+	for i, rawInboundConfig := range inbounds {
 		if c.Transport != nil {
 			if rawInboundConfig.StreamSetting == nil {
 				rawInboundConfig.StreamSetting = &StreamConfig{}
@@ -526,10 +536,10 @@ func (c *Config) Build() (*core.Config, error) {
 			applyTransportConfig(rawInboundConfig.StreamSetting, c.Transport)
 		}
 		ic, err := rawInboundConfig.Build()
-		if err != nil {
-			return nil, err
+		report.Record(cfgcommon.HandlerSection("inbounds", i, rawInboundConfig.Tag), err)
+		if err == nil {
+			config.Inbound = append(config.Inbound, ic)
 		}
-		config.Inbound = append(config.Inbound, ic)
 	}
 
 	var outbounds []OutboundDetourConfig
@@ -546,7 +556,7 @@ func (c *Config) Build() (*core.Config, error) {
 		outbounds = append(outbounds, c.OutboundConfigs...)
 	}
 
-	for _, rawOutboundConfig := range outbounds {
+	for i, rawOutboundConfig := range outbounds {
 		if c.Transport != nil {
 			if rawOutboundConfig.StreamSetting == nil {
 				rawOutboundConfig.StreamSetting = &StreamConfig{}
@@ -554,11 +564,14 @@ func (c *Config) Build() (*core.Config, error) {
 			applyTransportConfig(rawOutboundConfig.StreamSetting, c.Transport)
 		}
 		oc, err := rawOutboundConfig.Build()
-		if err != nil {
-			return nil, err
+		report.Record(cfgcommon.HandlerSection("outbounds", i, rawOutboundConfig.Tag), err)
+		if err == nil {
+			config.Outbound = append(config.Outbound, oc)
 		}
-		config.Outbound = append(config.Outbound, oc)
 	}
 
+	if err := report.Err(); err != nil {
+		return nil, err
+	}
 	return config, nil
 }
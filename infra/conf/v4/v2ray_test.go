@@ -14,6 +14,7 @@ import (
 	"github.com/v2fly/v2ray-core/v5/app/router/routercommon"
 	"github.com/v2fly/v2ray-core/v5/common"
 	clog "github.com/v2fly/v2ray-core/v5/common/log"
+	"github.com/v2fly/v2ray-core/v5/common/log/logtest"
 	"github.com/v2fly/v2ray-core/v5/common/net"
 	"github.com/v2fly/v2ray-core/v5/common/protocol"
 	"github.com/v2fly/v2ray-core/v5/common/serial"
@@ -380,3 +381,39 @@ func TestMuxConfig_Build(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildReportsAllInvalidSections(t *testing.T) {
+	recorder := logtest.Capture(t)
+
+	config := new(v4.Config)
+	common.Must(json.Unmarshal([]byte(`{
+		"log": {},
+		"inbounds": [{"protocol": "nonexistent", "port": 1080, "tag": "in"}],
+		"outbounds": [
+			{"protocol": "blackhole", "tag": "block"},
+			{"protocol": "nonexistent", "tag": "broken"}
+		]
+	}`), config))
+	if _, err := config.Build(); err == nil {
+		t.Fatal("expected an error for the invalid sections")
+	}
+
+	results := make(map[string]clog.ConfigStatus)
+	for _, msg := range recorder.Messages() {
+		if configMsg, ok := msg.(*clog.ConfigMessage); ok {
+			results[configMsg.Section] = configMsg.Status
+		}
+	}
+
+	expected := map[string]clog.ConfigStatus{
+		"log":                   clog.ConfigLoaded,
+		"inbounds[0] (in)":      clog.ConfigInvalid,
+		"outbounds[0] (block)":  clog.ConfigLoaded,
+		"outbounds[1] (broken)": clog.ConfigInvalid,
+	}
+	for section, status := range expected {
+		if results[section] != status {
+			t.Error("section ", section, ": expected ", status, ", got ", results[section])
+		}
+	}
+}
@@ -3,6 +3,7 @@ package v5cfg
 import (
 	"context"
 	"encoding/json"
+	"sort"
 
 	"github.com/golang/protobuf/proto"
 	core "github.com/v2fly/v2ray-core/v5"
@@ -24,86 +25,74 @@ func (c RootConfig) BuildV5(ctx context.Context) (proto.Message, error) {
 			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
 		},
 	}
+	report := &cfgcommon.SectionReport{}
 
 	var logConfMsg *anypb.Any
 	if c.LogConfig != nil {
 		logConfMsgUnpacked, err := loadHeterogeneousConfigFromRawJSON("service", "log", c.LogConfig)
-		if err != nil {
-			return nil, err
+		report.Record("log", err)
+		if err == nil {
+			logConfMsg = serial.ToTypedMessage(logConfMsgUnpacked)
 		}
-		logConfMsg = serial.ToTypedMessage(logConfMsgUnpacked)
 	} else {
 		logConfMsg = serial.ToTypedMessage(log.DefaultLogConfig())
 	}
 	// let logger module be the first App to start,
 	// so that other modules could print log during initiating
-	config.App = append([]*anypb.Any{logConfMsg}, config.App...)
+	if logConfMsg != nil {
+		config.App = append([]*anypb.Any{logConfMsg}, config.App...)
+	}
 
 	if c.RouterConfig != nil {
 		routerConfig, err := loadHeterogeneousConfigFromRawJSON("service", "router", c.RouterConfig)
-		if err != nil {
-			return nil, err
+		report.Record("router", err)
+		if err == nil {
+			config.App = append(config.App, serial.ToTypedMessage(routerConfig))
 		}
-		config.App = append(config.App, serial.ToTypedMessage(routerConfig))
 	}
 
 	if c.DNSConfig != nil {
 		dnsApp, err := loadHeterogeneousConfigFromRawJSON("service", "dns", c.DNSConfig)
 		if err != nil {
-			return nil, newError("failed to parse DNS config").Base(err)
+			err = newError("failed to parse DNS config").Base(err)
+		}
+		report.Record("dns", err)
+		if err == nil {
+			config.App = append(config.App, serial.ToTypedMessage(dnsApp))
 		}
-		config.App = append(config.App, serial.ToTypedMessage(dnsApp))
 	}
 
-	for _, rawInboundConfig := range c.Inbounds {
+	for i, rawInboundConfig := range c.Inbounds {
 		ic, err := rawInboundConfig.BuildV5(ctx)
-		if err != nil {
-			return nil, err
+		report.Record(cfgcommon.HandlerSection("inbounds", i, rawInboundConfig.Tag), err)
+		if err == nil {
+			config.Inbound = append(config.Inbound, ic.(*core.InboundHandlerConfig))
 		}
-		config.Inbound = append(config.Inbound, ic.(*core.InboundHandlerConfig))
 	}
 
-	for _, rawOutboundConfig := range c.Outbounds {
+	for i, rawOutboundConfig := range c.Outbounds {
 		ic, err := rawOutboundConfig.BuildV5(ctx)
-		if err != nil {
-			return nil, err
+		report.Record(cfgcommon.HandlerSection("outbounds", i, rawOutboundConfig.Tag), err)
+		if err == nil {
+			config.Outbound = append(config.Outbound, ic.(*core.OutboundHandlerConfig))
 		}
-		config.Outbound = append(config.Outbound, ic.(*core.OutboundHandlerConfig))
 	}
 
-	for serviceName, service := range c.Services {
-		servicePackedConfig, err := loadHeterogeneousConfigFromRawJSON("service", serviceName, service)
-		if err != nil {
-			return nil, err
-		}
-		config.App = append(config.App, serial.ToTypedMessage(servicePackedConfig))
+	serviceNames := make([]string, 0, len(c.Services))
+	for serviceName := range c.Services {
+		serviceNames = append(serviceNames, serviceName)
 	}
-	return config, nil
-}
-
-func loadJSONConfig(data []byte) (*core.Config, error) {
-	rootConfig := &RootConfig{}
-
-	err := json.Unmarshal(data, rootConfig)
-	if err != nil {
-		return nil, newError("unable to load json").Base(err)
-	}
-
-	buildctx := cfgcommon.NewConfigureLoadingContext(context.Background())
-
-	geoloadername := platform.NewEnvFlag("v2ray.conf.geoloader").GetValue(func() string {
-		return "standard"
-	})
-
-	if loader, err := geodata.GetGeoDataLoader(geoloadername); err == nil {
-		cfgcommon.SetGeoDataLoader(buildctx, loader)
-	} else {
-		return nil, newError("unable to create geo data loader ").Base(err)
+	sort.Strings(serviceNames)
+	for _, serviceName := range serviceNames {
+		servicePackedConfig, err := loadHeterogeneousConfigFromRawJSON("service", serviceName, c.Services[serviceName])
+		report.Record("services."+serviceName, err)
+		if err == nil {
+			config.App = append(config.App, serial.ToTypedMessage(servicePackedConfig))
+		}
 	}
 
-	message, err := rootConfig.BuildV5(buildctx)
-	if err != nil {
-		return nil, newError("unable to build config").Base(err)
+	if err := report.Err(); err != nil {
+		return nil, err
 	}
-	return message.(*core.Config), nil
+	return config, nil
 }
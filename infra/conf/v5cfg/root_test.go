@@ -0,0 +1,83 @@
+package v5cfg_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	_ "github.com/v2fly/v2ray-core/v5/app/log"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+	"github.com/v2fly/v2ray-core/v5/common/log/logtest"
+	"github.com/v2fly/v2ray-core/v5/infra/conf/v5cfg"
+	_ "github.com/v2fly/v2ray-core/v5/proxy/blackhole"
+)
+
+func TestBuildRecordsSectionResults(t *testing.T) {
+	recorder := logtest.Capture(t)
+
+	config := v5cfg.RootConfig{
+		LogConfig: json.RawMessage(`{}`),
+		Outbounds: []v5cfg.OutboundConfig{
+			{Protocol: "blackhole", Tag: "block"},
+			{Protocol: "nonexistent", Tag: "broken"},
+		},
+		Services: map[string]json.RawMessage{
+			"nonexistent": json.RawMessage(`{}`),
+		},
+	}
+	if _, err := config.BuildV5(context.Background()); err == nil {
+		t.Fatal("expected an error for the invalid sections")
+	}
+
+	results := make(map[string]log.ConfigStatus)
+	for _, msg := range recorder.Messages() {
+		configMsg, ok := msg.(*log.ConfigMessage)
+		if !ok {
+			continue
+		}
+		results[configMsg.Section] = configMsg.Status
+		if configMsg.Status == log.ConfigInvalid && configMsg.Reason == nil {
+			t.Error("no reason for invalid section ", configMsg.Section)
+		}
+	}
+
+	expected := map[string]log.ConfigStatus{
+		"log":                   log.ConfigLoaded,
+		"outbounds[0] (block)":  log.ConfigLoaded,
+		"outbounds[1] (broken)": log.ConfigInvalid,
+		"services.nonexistent":  log.ConfigInvalid,
+	}
+	for section, status := range expected {
+		if results[section] != status {
+			t.Error("section ", section, ": expected ", status, ", got ", results[section])
+		}
+	}
+}
+
+func TestBuildQueuesLoadedSections(t *testing.T) {
+	recorder := logtest.Capture(t)
+
+	config := v5cfg.RootConfig{
+		LogConfig: json.RawMessage(`{}`),
+		Outbounds: []v5cfg.OutboundConfig{{Protocol: "blackhole", Tag: "block"}},
+	}
+	if _, err := config.BuildV5(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range recorder.Messages() {
+		if _, ok := msg.(*log.ConfigMessage); ok {
+			t.Error("config message logged before the log app started: ", msg)
+		}
+	}
+
+	log.FlushConfig()
+	loaded := 0
+	for _, msg := range recorder.Messages() {
+		if _, ok := msg.(*log.ConfigMessage); ok {
+			loaded++
+		}
+	}
+	if loaded != 2 {
+		t.Error("expected 2 queued config messages, but actually ", loaded)
+	}
+}
@@ -26,6 +26,9 @@ Arguments:
 	-restart 
 		Restart the logger
 
+	-rotate
+		Reopen the log files, after moving them away
+
 	-s, -server <server:port>
 		The API server address. Default 127.0.0.1:8080
 
@@ -36,13 +39,15 @@ Example:
 
     {{.Exec}} {{.LongName}}
     {{.Exec}} {{.LongName}} --restart
+    {{.Exec}} {{.LongName}} --rotate
 `,
 	Run: executeLog,
 }
 
 func executeLog(cmd *base.Command, args []string) {
-	var restart bool
+	var restart, rotate bool
 	cmd.Flag.BoolVar(&restart, "restart", false, "")
+	cmd.Flag.BoolVar(&rotate, "rotate", false, "")
 	setSharedFlags(cmd)
 	cmd.Flag.Parse(args)
 
@@ -50,6 +55,10 @@ func executeLog(cmd *base.Command, args []string) {
 		restartLogger()
 		return
 	}
+	if rotate {
+		rotateLogs()
+		return
+	}
 	followLogger()
 }
 
@@ -64,6 +73,17 @@ func restartLogger() {
 	}
 }
 
+func rotateLogs() {
+	conn, ctx, close := dialAPIServer()
+	defer close()
+	client := logService.NewLoggerServiceClient(conn)
+	r := &logService.RotateLogsRequest{}
+	_, err := client.RotateLogs(ctx, r)
+	if err != nil {
+		base.Fatalf("failed to rotate logs: %s", err)
+	}
+}
+
 func followLogger() {
 	conn, ctx, close := dialAPIServerWithoutTimeout()
 	defer close()
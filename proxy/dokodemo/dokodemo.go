@@ -15,7 +15,6 @@ import (
 	"github.com/v2fly/v2ray-core/v5/common/net"
 	"github.com/v2fly/v2ray-core/v5/common/protocol"
 	"github.com/v2fly/v2ray-core/v5/common/session"
-	"github.com/v2fly/v2ray-core/v5/common/signal"
 	"github.com/v2fly/v2ray-core/v5/common/task"
 	"github.com/v2fly/v2ray-core/v5/features/policy"
 	"github.com/v2fly/v2ray-core/v5/features/routing"
@@ -130,7 +129,7 @@ func (d *Door) Process(ctx context.Context, network net.Network, conn internet.C
 
 	plcy := d.policy()
 	ctx, cancel := context.WithCancel(ctx)
-	timer := signal.CancelAfterInactivity(ctx, cancel, plcy.Timeouts.ConnectionIdle)
+	timer := plcy.CancelAfterInactivity(ctx, cancel)
 	ctx = policy.ContextWithBufferPolicy(ctx, plcy.Buffer)
 	ctx = proxyman.SetPreferUseIP(ctx, true)
 
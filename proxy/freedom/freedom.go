@@ -14,7 +14,6 @@ import (
 	"github.com/v2fly/v2ray-core/v5/common/net"
 	"github.com/v2fly/v2ray-core/v5/common/retry"
 	"github.com/v2fly/v2ray-core/v5/common/session"
-	"github.com/v2fly/v2ray-core/v5/common/signal"
 	"github.com/v2fly/v2ray-core/v5/common/task"
 	"github.com/v2fly/v2ray-core/v5/features/dns"
 	"github.com/v2fly/v2ray-core/v5/features/policy"
@@ -119,7 +118,7 @@ func (h *Handler) Process(ctx context.Context, link *transport.Link, dialer inte
 	output := link.Writer
 
 	var conn internet.Connection
-	err := retry.ExponentialBackoff(5, 100).On(func() error {
+	err := retry.WithObserver(retry.ExponentialBackoff(5, 100), retry.LogAttempts(ctx, "dial")).On(func() error {
 		dialDest := destination
 		if h.config.useIP() && dialDest.Address.Family().IsDomain() {
 			ip := h.resolveIP(ctx, dialDest.Address.Domain(), dialer.Address())
@@ -149,7 +148,7 @@ func (h *Handler) Process(ctx context.Context, link *transport.Link, dialer inte
 
 	plcy := h.policy()
 	ctx, cancel := context.WithCancel(ctx)
-	timer := signal.CancelAfterInactivity(ctx, cancel, plcy.Timeouts.ConnectionIdle)
+	timer := plcy.CancelAfterInactivity(ctx, cancel)
 
 	requestDone := func() error {
 		defer timer.SetTimeout(plcy.Timeouts.DownlinkOnly)
@@ -209,7 +208,7 @@ func (h *Handler) ProcessConn(ctx context.Context, conn net.Conn, dialer interne
 	newError("opening connection to ", destination).WriteToLog(session.ExportIDToError(ctx))
 
 	var outboundConn internet.Connection
-	err := retry.ExponentialBackoff(5, 100).On(func() error {
+	err := retry.WithObserver(retry.ExponentialBackoff(5, 100), retry.LogAttempts(ctx, "dial")).On(func() error {
 		dialDest := destination
 		if h.config.useIP() && dialDest.Address.Family().IsDomain() {
 			ip := h.resolveIP(ctx, dialDest.Address.Domain(), dialer.Address())
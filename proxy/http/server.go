@@ -118,6 +118,10 @@ Start:
 		}
 	}
 
+	if trace := session.ParseTraceparent(request.Header.Get("traceparent")); trace != nil {
+		ctx = session.ContextWithTrace(ctx, trace)
+	}
+
 	newError("request to Method [", request.Method, "] Host [", request.Host, "] with URL [", request.URL, "]").WriteToLog(session.ExportIDToError(ctx))
 	if err := conn.SetReadDeadline(time.Time{}); err != nil {
 		newError("failed to clear read deadline").Base(err).WriteToLog(session.ExportIDToError(ctx))
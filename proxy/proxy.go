@@ -34,6 +34,16 @@ type Outbound interface {
 	Process(context.Context, *transport.Link, internet.Dialer) error
 }
 
+// HealthChecker is implemented by Outbounds that check whether their server is
+// reachable.
+type HealthChecker interface {
+	// StartHealthCheck starts checking the server with the given dialer, until the Outbound is closed.
+	StartHealthCheck(internet.Dialer)
+
+	// Healthy returns false while the server is found unreachable.
+	Healthy() bool
+}
+
 type RawOutbound interface {
 	ProcessConn(context.Context, net.Conn, internet.Dialer) error
 }
@@ -14,7 +14,6 @@ import (
 	"github.com/v2fly/v2ray-core/v5/common/protocol"
 	"github.com/v2fly/v2ray-core/v5/common/retry"
 	"github.com/v2fly/v2ray-core/v5/common/session"
-	"github.com/v2fly/v2ray-core/v5/common/signal"
 	"github.com/v2fly/v2ray-core/v5/common/task"
 	"github.com/v2fly/v2ray-core/v5/features/policy"
 	"github.com/v2fly/v2ray-core/v5/transport"
@@ -119,7 +118,7 @@ func (c *Client) Process(ctx context.Context, link *transport.Link, dialer inter
 	var user *protocol.MemoryUser
 	var uot bool
 
-	err := retry.ExponentialBackoff(2, 100).On(func() error {
+	err := retry.WithObserver(retry.ExponentialBackoff(2, 100), retry.LogAttempts(ctx, "dial")).On(func() error {
 		server = c.serverPicker.PickServer()
 		user = server.PickUser()
 		account, ok := user.Account.(*MemoryAccount)
@@ -174,7 +173,7 @@ func (c *Client) Process(ctx context.Context, link *transport.Link, dialer inter
 
 	sessionPolicy := c.policyManager.ForLevel(user.Level)
 	ctx, cancel := context.WithCancel(ctx)
-	timer := signal.CancelAfterInactivity(ctx, cancel, sessionPolicy.Timeouts.ConnectionIdle)
+	timer := sessionPolicy.CancelAfterInactivity(ctx, cancel)
 
 	var protocolConn *ProtocolConn
 	var iv []byte
@@ -14,7 +14,6 @@ import (
 	"github.com/v2fly/v2ray-core/v5/common/protocol"
 	"github.com/v2fly/v2ray-core/v5/common/retry"
 	"github.com/v2fly/v2ray-core/v5/common/session"
-	"github.com/v2fly/v2ray-core/v5/common/signal"
 	"github.com/v2fly/v2ray-core/v5/common/task"
 	"github.com/v2fly/v2ray-core/v5/features/dns"
 	"github.com/v2fly/v2ray-core/v5/features/policy"
@@ -75,7 +74,7 @@ func (c *Client) ProcessConn(ctx context.Context, conn net.Conn, dialer internet
 	// Connection to the outbound server.
 	var outboundConn internet.Connection
 
-	if err := retry.ExponentialBackoff(5, 100).On(func() error {
+	if err := retry.WithObserver(retry.ExponentialBackoff(5, 100), retry.LogAttempts(ctx, "dial")).On(func() error {
 		server = c.serverPicker.PickServer()
 		dest = server.Destination()
 		rawConn, err := dialer.Dial(ctx, dest)
@@ -177,7 +176,7 @@ func (c *Client) Process(ctx context.Context, link *transport.Link, dialer inter
 	// Connection to the outbound server.
 	var conn internet.Connection
 
-	if err := retry.ExponentialBackoff(5, 100).On(func() error {
+	if err := retry.WithObserver(retry.ExponentialBackoff(5, 100), retry.LogAttempts(ctx, "dial")).On(func() error {
 		server = c.serverPicker.PickServer()
 		dest = server.Destination()
 		rawConn, err := dialer.Dial(ctx, dest)
@@ -262,7 +261,7 @@ func (c *Client) Process(ctx context.Context, link *transport.Link, dialer inter
 	}
 
 	ctx, cancel := context.WithCancel(ctx)
-	timer := signal.CancelAfterInactivity(ctx, cancel, p.Timeouts.ConnectionIdle)
+	timer := p.CancelAfterInactivity(ctx, cancel)
 
 	if packetConn, err := packetaddr.ToPacketAddrConn(link, destination); err == nil {
 		udpConn, err := dialer.Dial(ctx, udpRequest.Destination())
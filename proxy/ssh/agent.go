@@ -0,0 +1,32 @@
+package ssh
+
+import (
+	"os"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common/net"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+const agentDialTimeout = 5 * time.Second
+
+// agentSigners connects to the ssh-agent of agent_socket and returns its
+// keys. The connection stays open for the signatures until attempts is
+// closed.
+func (c *Client) agentSigners(attempts *authAttempts) ([]ssh.Signer, error) {
+	socket := os.ExpandEnv(c.config.AgentSocket)
+	if socket == "" {
+		return nil, newError("ssh agent socket ", c.config.AgentSocket, " expands to nothing")
+	}
+	conn, err := (&net.Dialer{Timeout: agentDialTimeout}).Dial("unix", socket)
+	if err != nil {
+		return nil, newError("failed to connect to ssh agent ", socket).Base(err)
+	}
+	attempts.setAgent(conn)
+	signers, err := agent.NewClient(conn).Signers()
+	if err != nil {
+		return nil, newError("failed to list the keys of ssh agent ", socket).Base(err)
+	}
+	return signers, nil
+}
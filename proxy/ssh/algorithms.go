@@ -0,0 +1,148 @@
+package ssh
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+var noCommonAlgorithmRegexp = regexp.MustCompile(`no common algorithm for ([^;]+); client offered: \[([^\]]*)\], server offered: \[([^\]]*)\]`)
+
+// algorithmMismatchError re-renders the library's terse negotiation failure
+// into a diagnostic naming what we offered and what the server supports, or
+// returns nil if err is not a negotiation failure.
+func algorithmMismatchError(err error, config *ssh.ClientConfig) error {
+	match := noCommonAlgorithmRegexp.FindStringSubmatch(err.Error())
+	if match == nil {
+		return nil
+	}
+	what := match[1]
+	clientOffered := strings.Fields(match[2])
+	serverOffered := strings.Fields(match[3])
+
+	option, configured := algorithmOption(what, config)
+	offered := "library defaults: " + strings.Join(clientOffered, ", ")
+	if configured {
+		offered = "configured " + option + ": " + strings.Join(clientOffered, ", ")
+	}
+	hint := "the server likely supports: " + strings.Join(serverOffered, ", ")
+	if option != "" {
+		hint += "; set " + option + " to include one of them"
+	}
+	return newError("no common ", what, " algorithm with ssh server, client offered ", offered, "; ", hint).Base(err)
+}
+
+// algorithmOption returns the config option that controls the algorithms
+// negotiated for what, and whether it was set explicitly.
+func algorithmOption(what string, config *ssh.ClientConfig) (string, bool) {
+	switch what {
+	case "host key":
+		return "host_key_algorithms", len(config.HostKeyAlgorithms) > 0
+	case "key exchange":
+		return "key_exchanges", len(config.KeyExchanges) > 0
+	case "client to server cipher", "server to client cipher":
+		return "ciphers", len(config.Ciphers) > 0
+	case "client to server MAC", "server to client MAC":
+		return "macs", len(config.MACs) > 0
+	default:
+		return "", false
+	}
+}
+
+// knownHostKeyAlgorithms are the host key algorithms the ssh library
+// implements.
+var knownHostKeyAlgorithms = map[string]bool{
+	ssh.KeyAlgoRSA:            true,
+	ssh.KeyAlgoRSASHA256:      true,
+	ssh.KeyAlgoRSASHA512:      true,
+	ssh.KeyAlgoDSA:            true,
+	ssh.KeyAlgoECDSA256:       true,
+	ssh.KeyAlgoSKECDSA256:     true,
+	ssh.KeyAlgoECDSA384:       true,
+	ssh.KeyAlgoECDSA521:       true,
+	ssh.KeyAlgoED25519:        true,
+	ssh.KeyAlgoSKED25519:      true,
+	ssh.CertAlgoRSAv01:        true,
+	ssh.CertAlgoRSASHA256v01:  true,
+	ssh.CertAlgoRSASHA512v01:  true,
+	ssh.CertAlgoDSAv01:        true,
+	ssh.CertAlgoECDSA256v01:   true,
+	ssh.CertAlgoSKECDSA256v01: true,
+	ssh.CertAlgoECDSA384v01:   true,
+	ssh.CertAlgoECDSA521v01:   true,
+	ssh.CertAlgoED25519v01:    true,
+	ssh.CertAlgoSKED25519v01:  true,
+}
+
+// knownCiphers, knownMACs and knownKeyExchanges are the algorithms of each
+// kind the ssh library implements on the client side.
+var (
+	knownCiphers = map[string]bool{
+		"aes128-ctr":                    true,
+		"aes192-ctr":                    true,
+		"aes256-ctr":                    true,
+		"aes128-gcm@openssh.com":        true,
+		"chacha20-poly1305@openssh.com": true,
+		"arcfour256":                    true,
+		"arcfour128":                    true,
+		"arcfour":                       true,
+		"aes128-cbc":                    true,
+		"3des-cbc":                      true,
+	}
+	knownMACs = map[string]bool{
+		"hmac-sha2-256-etm@openssh.com": true,
+		"hmac-sha2-256":                 true,
+		"hmac-sha1":                     true,
+		"hmac-sha1-96":                  true,
+	}
+	knownKeyExchanges = map[string]bool{
+		"curve25519-sha256":                    true,
+		"curve25519-sha256@libssh.org":         true,
+		"ecdh-sha2-nistp256":                   true,
+		"ecdh-sha2-nistp384":                   true,
+		"ecdh-sha2-nistp521":                   true,
+		"diffie-hellman-group14-sha256":        true,
+		"diffie-hellman-group14-sha1":          true,
+		"diffie-hellman-group1-sha1":           true,
+		"diffie-hellman-group-exchange-sha256": true,
+		"diffie-hellman-group-exchange-sha1":   true,
+	}
+)
+
+// validateAlgorithms returns an error naming option and the first of its
+// algorithms the ssh library does not implement, as it would only fail the
+// negotiation.
+func validateAlgorithms(option, what string, algorithms []string, known map[string]bool) error {
+	for _, algorithm := range algorithms {
+		if !known[algorithm] {
+			return newError("unknown ", what, " ", strconv.Quote(algorithm), " in ", option, ", expected one of ", strings.Join(sortedKeys(known), ", "))
+		}
+	}
+	return nil
+}
+
+// validateConfigAlgorithms checks every algorithm list of config.
+func validateConfigAlgorithms(config *Config) error {
+	if err := validateAlgorithms("host_key_algorithms", "host key algorithm", config.HostKeyAlgorithms, knownHostKeyAlgorithms); err != nil {
+		return err
+	}
+	if err := validateAlgorithms("ciphers", "cipher", config.Ciphers, knownCiphers); err != nil {
+		return err
+	}
+	if err := validateAlgorithms("macs", "MAC", config.Macs, knownMACs); err != nil {
+		return err
+	}
+	return validateAlgorithms("key_exchanges", "key exchange", config.KeyExchanges, knownKeyExchanges)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
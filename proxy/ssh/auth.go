@@ -0,0 +1,187 @@
+package ssh
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	authPublicKey           = "publickey"
+	authPassword            = "password"
+	authKeyboardInteractive = "keyboard-interactive"
+)
+
+// authAttempts records the methods a handshake tried. The library only tries
+// the methods the server allows, so these are the configured methods the
+// server accepted to try.
+type authAttempts struct {
+	sync.Mutex
+	methods []string
+	// agent is the connection to the ssh-agent the keys of agentKeys are
+	// signed with, if any.
+	agent     io.Closer
+	agentKeys []ssh.Signer
+	// exchanged is set once the key exchange completed and the host key was
+	// accepted, failed once a callback of the auth methods failed.
+	exchanged bool
+	failed    bool
+}
+
+func (a *authAttempts) keyExchanged() {
+	a.Lock()
+	defer a.Unlock()
+	a.exchanged = true
+}
+
+func (a *authAttempts) callbackFailed() {
+	a.Lock()
+	defer a.Unlock()
+	a.failed = true
+}
+
+func (a *authAttempts) setAgent(conn io.Closer) {
+	a.Lock()
+	defer a.Unlock()
+	a.agent = conn
+}
+
+func (a *authAttempts) offeredAgentKeys(signers []ssh.Signer) {
+	a.Lock()
+	defer a.Unlock()
+	a.agentKeys = signers
+}
+
+// close closes the connection to the ssh-agent, once the handshake is over.
+func (a *authAttempts) close() {
+	a.Lock()
+	defer a.Unlock()
+	if a.agent != nil {
+		a.agent.Close()
+		a.agent = nil
+	}
+}
+
+func (a *authAttempts) tried(method string) {
+	a.Lock()
+	defer a.Unlock()
+	for _, m := range a.methods {
+		if m == method {
+			return
+		}
+	}
+	a.methods = append(a.methods, method)
+}
+
+func (a *authAttempts) contains(method string) bool {
+	a.Lock()
+	defer a.Unlock()
+	for _, m := range a.methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// isAuthFailure returns true if a handshake over conn failed as the server
+// accepted none of the auth methods. The library reports this, like any
+// failed handshake, as an error with nothing but a message, so it is told
+// from the handshake instead: the key exchange completed, the connection did
+// not fail and none of the auth callbacks did, like on an unexpected banner.
+// It must be called before conn is closed.
+func isAuthFailure(attempts *authAttempts, conn *countingConn) bool {
+	attempts.Lock()
+	defer attempts.Unlock()
+	return attempts.exchanged && !attempts.failed && conn.readError() == nil
+}
+
+// authPassword returns the password to authenticate with server, nil for a
+// jump host. A password taken as the passphrase of a private key is not,
+// until server refused every method.
+func (c *Client) authPassword(server *sshServer) string {
+	if c.password == "" && server != nil && atomic.LoadInt32(&server.offerPassphrase) == 1 {
+		return c.passphrase
+	}
+	return c.password
+}
+
+// forgetPassphraseAssumption stops taking the password as nothing but the
+// passphrase of a private key for server, after it refused every method.
+// Servers chaining publickey and password want it for both.
+func (c *Client) forgetPassphraseAssumption(server *sshServer) {
+	if c.passphrase != "" && atomic.CompareAndSwapInt32(&server.offerPassphrase, 0, 1) {
+		newError("the private key passphrase is also offered as password to ", server.destination, " from now on").AtInfo().WriteToLog()
+	}
+}
+
+// configuredAuthMethods returns the methods authMethods offers server, in
+// order.
+func (c *Client) configuredAuthMethods(server *sshServer) []string {
+	var methods []string
+	if len(c.signers) > 0 || c.config.AgentSocket != "" {
+		methods = append(methods, authPublicKey)
+	}
+	password := c.authPassword(server)
+	if password != "" {
+		methods = append(methods, authPassword)
+	}
+	if password != "" || len(c.config.KeyboardInteractiveAnswers) > 0 {
+		methods = append(methods, authKeyboardInteractive)
+	}
+	return methods
+}
+
+// interactiveAnswer returns the answer to a keyboard-interactive question:
+// that of the longest keyboard_interactive_answers key contained in it,
+// ignoring case, or else password.
+func (c *Client) interactiveAnswer(question, password string) (string, bool) {
+	question = strings.ToLower(question)
+	var key, answer string
+	found := false
+	for k, v := range c.config.KeyboardInteractiveAnswers {
+		if strings.Contains(question, strings.ToLower(k)) && (!found || len(k) > len(key) || len(k) == len(key) && k < key) {
+			key, answer, found = k, v, true
+		}
+	}
+	if found {
+		return answer, true
+	}
+	return password, password != ""
+}
+
+// authSummary describes a failed authentication in logfmt fields. It names
+// the methods and the fingerprints of the keys, never the secrets.
+func (c *Client) authSummary(server *sshServer, attempts *authAttempts) string {
+	var allowed, refused []string
+	configured := c.configuredAuthMethods(server)
+	for _, method := range configured {
+		if attempts.contains(method) {
+			allowed = append(allowed, method)
+		} else {
+			refused = append(refused, method)
+		}
+	}
+	var keys []string
+	if attempts.contains(authPublicKey) {
+		for _, signer := range c.signers {
+			keys = append(keys, ssh.FingerprintSHA256(signer.PublicKey()))
+		}
+		attempts.Lock()
+		for _, signer := range attempts.agentKeys {
+			keys = append(keys, ssh.FingerprintSHA256(signer.PublicKey()))
+		}
+		attempts.Unlock()
+	}
+	fields := []string{
+		"user=" + c.config.User,
+		"configured=" + strings.Join(configured, ","),
+		"server_allowed=" + strings.Join(allowed, ","),
+		"server_refused=" + strings.Join(refused, ","),
+		"keys_offered=" + strings.Join(keys, ","),
+	}
+	return strings.Join(fields, " ")
+}
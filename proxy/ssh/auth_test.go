@@ -0,0 +1,236 @@
+package ssh
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+	"github.com/v2fly/v2ray-core/v5/common/log/logtest"
+	"github.com/v2fly/v2ray-core/v5/common/net"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestAuthFailureSummary(t *testing.T) {
+	key, public := generatePrivateKey(t)
+	config := newTestServerConfig(t)
+	config.NoClientAuth = false
+	config.PublicKeyCallback = func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+		return nil, newError("unknown key")
+	}
+	config.PasswordCallback = func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+		return nil, newError("wrong password")
+	}
+
+	recorder := logtest.Capture(t)
+	client := newTestClient(t, &Config{
+		User:               "v2ray",
+		Password:           "hunter2",
+		PrivateKey:         key,
+		AuthFailureSummary: true,
+	})
+	_, _, _, err := client.connect(context.Background(), &pipeDialer{config: config})
+	if err == nil {
+		t.Fatal("expected authentication to fail")
+	}
+	recorder.AssertContains(log.Severity_Warning, "user=v2ray configured=publickey,password,keyboard-interactive server_allowed=publickey,password server_refused=keyboard-interactive keys_offered="+ssh.FingerprintSHA256(public))
+	if strings.Contains(recorder.String(), "hunter2") {
+		t.Error("password leaked into the log: ", recorder.String())
+	}
+
+	recorder.Reset()
+	quiet := newTestClient(t, &Config{Password: "hunter2"})
+	if _, _, _, err := quiet.connect(context.Background(), &pipeDialer{config: config}); err == nil {
+		t.Fatal("expected authentication to fail")
+	}
+	recorder.AssertNotContains(log.Severity_Warning, "server_allowed=")
+}
+
+func TestAuthFailureForgetsPassphraseAssumption(t *testing.T) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	common.Must(err)
+	der, err := x509.MarshalECPrivateKey(private)
+	common.Must(err)
+	// nolint: staticcheck
+	block, err := x509.EncryptPEMBlock(rand.Reader, "EC PRIVATE KEY", der, []byte("hunter2"), x509.PEMCipherAES256)
+	common.Must(err)
+	key := string(pem.EncodeToMemory(block))
+
+	var passwords []string
+	config := newTestServerConfig(t)
+	config.NoClientAuth = false
+	config.PublicKeyCallback = func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+		return nil, newError("unknown key")
+	}
+	config.PasswordCallback = func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+		passwords = append(passwords, string(password))
+		return nil, nil
+	}
+
+	for _, summary := range []bool{false, true} {
+		passwords = nil
+		client := newTestClient(t, &Config{
+			User:               "v2ray",
+			Password:           "hunter2",
+			PrivateKey:         key,
+			AuthFailureSummary: summary,
+			Servers: []*Endpoint{
+				{Address: net.NewIPOrDomain(net.LocalHostIP), Port: 2222},
+			},
+		})
+		// The server on 2222 is down.
+		dialer := &upDialer{pipeDialer: pipeDialer{config: config}, up: 22}
+		if _, _, _, err := client.connect(context.Background(), dialer); err == nil {
+			t.Fatal("expected authentication with the key alone to fail")
+		} else if log.OutcomeOf(err) != log.OutcomeAuthFailure {
+			t.Error("expected an auth failure, but actually ", err)
+		}
+		if len(passwords) > 0 {
+			t.Error("passphrase offered as password before the server refused every method")
+		}
+		// Only the server that refused every method is offered the passphrase.
+		if password := client.authPassword(client.servers[0]); password != "hunter2" {
+			t.Error("expected the passphrase to be offered to the refusing server, auth_failure_summary ", summary)
+		}
+		if password := client.authPassword(client.servers[1]); password != "" {
+			t.Error("expected the passphrase not to be offered to another server, auth_failure_summary ", summary)
+		}
+		_, sc, _, err := client.connect(context.Background(), dialer)
+		if err != nil {
+			t.Error("expected the passphrase to be offered as password after every method failed, but actually ", err)
+		} else {
+			sc.Close()
+		}
+		client.Close()
+	}
+}
+
+func TestAuthFailureNotConnectionFailure(t *testing.T) {
+	config := newTestServerConfig(t)
+	config.NoClientAuth = false
+	config.PasswordCallback = func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+		return nil, newError("wrong password")
+	}
+	config.BannerCallback = func(conn ssh.ConnMetadata) string {
+		return "authorized use only"
+	}
+	client := newTestClient(t, &Config{Password: "hunter2", ExpectBannerContains: "welcome"})
+	_, _, _, err := client.connect(context.Background(), &pipeDialer{config: config})
+	if err == nil {
+		t.Fatal("expected the unexpected banner to fail the handshake")
+	}
+	if log.OutcomeOf(err) == log.OutcomeAuthFailure {
+		t.Error("rejected banner reported as auth failure: ", err)
+	}
+}
+
+func TestAgentAuth(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	common.Must(err)
+	keyring := agent.NewKeyring()
+	common.Must(keyring.Add(agent.AddedKey{PrivateKey: private}))
+	signer, err := ssh.NewSignerFromKey(private)
+	common.Must(err)
+
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", socket)
+	common.Must(err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				agent.ServeAgent(keyring, conn)
+				conn.Close()
+			}()
+		}
+	}()
+
+	config := newTestServerConfig(t)
+	config.NoClientAuth = false
+	config.PublicKeyCallback = func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+		if string(key.Marshal()) != string(signer.PublicKey().Marshal()) {
+			return nil, newError("unknown key")
+		}
+		return nil, nil
+	}
+	config.PasswordCallback = func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+		if string(password) != "secret" {
+			return nil, newError("wrong password")
+		}
+		return nil, nil
+	}
+
+	t.Setenv("TEST_SSH_AUTH_SOCK", socket)
+	client := newTestClient(t, &Config{AgentSocket: "$TEST_SSH_AUTH_SOCK"})
+	_, sc, _, err := client.connect(context.Background(), &pipeDialer{config: config})
+	if err != nil {
+		t.Fatal("expected authentication with the agent key to succeed, but got ", err)
+	}
+	sc.Close()
+
+	// Without the agent, the password is tried alone.
+	recorder := logtest.Capture(t)
+	unavailable := newTestClient(t, &Config{AgentSocket: filepath.Join(t.TempDir(), "missing.sock"), Password: "secret"})
+	_, sc, _, err = unavailable.connect(context.Background(), &pipeDialer{config: config})
+	if err != nil {
+		t.Fatal("expected fallback to the password, but got ", err)
+	}
+	sc.Close()
+	recorder.AssertContains(log.Severity_Warning, "failed to connect to ssh agent")
+
+	if _, _, _, err := newTestClient(t, &Config{AgentSocket: "$TEST_SSH_AUTH_SOCK_UNSET"}).connect(context.Background(), &pipeDialer{config: config}); err == nil {
+		t.Error("expected authentication to fail without an agent or other method")
+	}
+}
+
+func TestKeyboardInteractiveAnswers(t *testing.T) {
+	config := newTestServerConfig(t)
+	config.NoClientAuth = false
+	config.BannerCallback = func(conn ssh.ConnMetadata) string {
+		return "Authorized use only\n"
+	}
+	config.KeyboardInteractiveCallback = func(conn ssh.ConnMetadata, challenge ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error) {
+		answers, err := challenge("", "", []string{"Password: ", "Verification code: "}, []bool{false, true})
+		if err != nil {
+			return nil, err
+		}
+		if len(answers) != 2 || answers[0] != "secret" || answers[1] != "123456" {
+			return nil, newError("wrong answers")
+		}
+		return nil, nil
+	}
+
+	recorder := logtest.Capture(t)
+	client := newTestClient(t, &Config{
+		Password: "secret",
+		KeyboardInteractiveAnswers: map[string]string{
+			"code":              "000000",
+			"VERIFICATION CODE": "123456",
+		},
+	})
+	_, sc, _, err := client.connect(context.Background(), &pipeDialer{config: config})
+	if err != nil {
+		t.Fatal("expected keyboard-interactive authentication to succeed, but got ", err)
+	}
+	sc.Close()
+	recorder.AssertContains(log.Severity_Info, "| Authorized use only")
+
+	// Without the password, the question about it has no answer.
+	unanswered := newTestClient(t, &Config{KeyboardInteractiveAnswers: map[string]string{"code": "123456"}})
+	if _, _, _, err := unanswered.connect(context.Background(), &pipeDialer{config: config}); err == nil {
+		t.Error("expected authentication to fail with an unanswered question")
+	}
+}
@@ -0,0 +1,241 @@
+package ssh
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// reuseGracePeriod is how long a shared connection outlives its last user, so
+// the instance created by a config reload can adopt it.
+const reuseGracePeriod = 30 * time.Second
+
+type sharedConn struct {
+	client    *ssh.Client
+	refs      int
+	channels  int64
+	timer     *time.Timer
+	idleSince time.Time
+}
+
+// connCache holds live connections of outbounds with reuse_connection set,
+// keyed by everything that determines where and as whom they connect.
+var connCache = struct {
+	sync.Mutex
+	conns map[string]*sharedConn
+}{conns: make(map[string]*sharedConn)}
+
+// connectionKey returns the cache key for config. Connections are only shared
+// between outbounds whose server, credentials, host verification and
+// keepalive and timeout settings match exactly.
+func connectionKey(config *Config) string {
+	hash := sha256.New()
+	write := func(s string) {
+		var length [4]byte
+		binary.BigEndian.PutUint32(length[:], uint32(len(s)))
+		hash.Write(length[:])
+		hash.Write([]byte(s))
+	}
+	write(config.Address.AsAddress().String())
+	write(strconv.FormatUint(uint64(config.Port), 10))
+	for _, server := range config.Servers {
+		write(server.Address.AsAddress().String())
+		write(strconv.FormatUint(uint64(server.Port), 10))
+		write(server.PublicKey)
+		write(strconv.FormatUint(uint64(server.KeepAliveInterval), 10))
+		write(strconv.FormatUint(uint64(server.HandshakeDeadline), 10))
+	}
+	write(config.User)
+	write(config.Password)
+	write(config.PrivateKey)
+	for _, key := range config.PrivateKeys {
+		write(key)
+	}
+	write(config.PublicKey)
+	for _, jump := range config.JumpHosts {
+		write(jump.Address.AsAddress().String())
+		write(strconv.FormatUint(uint64(jump.Port), 10))
+		write(jump.User)
+		write(jump.Password)
+		write(jump.PrivateKey)
+		write(jump.PublicKey)
+	}
+	for _, authority := range config.HostCertAuthorities {
+		write(authority)
+	}
+	for _, algorithm := range config.HostKeyAlgorithms {
+		write(algorithm)
+	}
+	for _, algorithm := range config.Ciphers {
+		write(algorithm)
+	}
+	for _, algorithm := range config.Macs {
+		write(algorithm)
+	}
+	for _, algorithm := range config.KeyExchanges {
+		write(algorithm)
+	}
+	write(config.AgentSocket)
+	questions := make([]string, 0, len(config.KeyboardInteractiveAnswers))
+	for question := range config.KeyboardInteractiveAnswers {
+		questions = append(questions, question)
+	}
+	sort.Strings(questions)
+	for _, question := range questions {
+		write(question)
+		write(config.KeyboardInteractiveAnswers[question])
+	}
+	write(config.KnownHostsPath)
+	write(strconv.FormatBool(config.InsecureSkipHostKeyCheck))
+	write(strconv.FormatUint(uint64(config.HostKeyCheckTimeout), 10))
+	write(strconv.FormatBool(config.StrictPublicKeyParse))
+	write(strconv.FormatBool(config.AvoidTerrapinAlgorithms))
+	write(strconv.FormatBool(config.AllowEmptyUser))
+	write(config.ExpectBannerContains)
+	write(strconv.FormatUint(uint64(config.KeepAliveInterval), 10))
+	write(strconv.FormatUint(uint64(config.KeepAliveMaxFailures), 10))
+	write(strconv.FormatUint(uint64(config.ServerAliveInterval), 10))
+	write(strconv.FormatUint(uint64(config.ServerAliveCountMax), 10))
+	write(strconv.FormatUint(uint64(config.HandshakeDeadline), 10))
+	write(strconv.FormatUint(uint64(config.ConnectTimeout), 10))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// acquireConn registers a user of key and returns the live connection for it,
+// if any.
+func acquireConn(key string) *ssh.Client {
+	connCache.Lock()
+	defer connCache.Unlock()
+
+	conn := connCache.conns[key]
+	if conn == nil {
+		conn = &sharedConn{}
+		connCache.conns[key] = conn
+	}
+	conn.refs++
+	if conn.timer != nil {
+		conn.timer.Stop()
+		conn.timer = nil
+	}
+	return conn.client
+}
+
+// storeConn makes client the live connection for key, then evicts idle
+// connections beyond limit.
+func storeConn(key string, client *ssh.Client, limit uint32) {
+	connCache.Lock()
+	defer connCache.Unlock()
+
+	if conn := connCache.conns[key]; conn != nil {
+		conn.client = client
+	}
+	evictIdle(limit)
+}
+
+// removeConn forgets client once it is closed.
+func removeConn(key string, client *ssh.Client) {
+	connCache.Lock()
+	defer connCache.Unlock()
+
+	if conn := connCache.conns[key]; conn != nil && conn.client == client {
+		conn.client = nil
+	}
+}
+
+// trackSharedChannel counts a channel opened, for a delta of 1, or closed, for
+// -1, on the connection of key.
+func trackSharedChannel(key string, delta int64) {
+	connCache.Lock()
+	defer connCache.Unlock()
+
+	if conn := connCache.conns[key]; conn != nil {
+		conn.channels += delta
+	}
+}
+
+// sharedStatus returns whether the connection of key is live, the number of
+// outbounds using it and of channels open on it.
+func sharedStatus(key string) (bool, int, int64) {
+	connCache.Lock()
+	defer connCache.Unlock()
+
+	conn := connCache.conns[key]
+	if conn == nil {
+		return false, 0, 0
+	}
+	return conn.client != nil, conn.refs, conn.channels
+}
+
+// releaseConn unregisters a user of key. The connection is closed after
+// reuseGracePeriod if nobody acquires it meanwhile, or earlier if more than
+// limit connections are cached.
+func releaseConn(key string, limit uint32) {
+	connCache.Lock()
+	defer connCache.Unlock()
+
+	conn := connCache.conns[key]
+	if conn == nil {
+		return
+	}
+	conn.refs--
+	if conn.refs > 0 {
+		return
+	}
+	if conn.client == nil {
+		delete(connCache.conns, key)
+		return
+	}
+	conn.idleSince = time.Now()
+	conn.timer = time.AfterFunc(reuseGracePeriod, func() {
+		connCache.Lock()
+		defer connCache.Unlock()
+
+		if connCache.conns[key] != conn || conn.refs > 0 {
+			return
+		}
+		delete(connCache.conns, key)
+		if conn.client != nil {
+			conn.client.Close()
+		}
+	})
+	evictIdle(limit)
+}
+
+// evictIdle closes the longest idle connections while more than limit, if
+// not 0, are cached. Connections in use are never evicted. connCache must be
+// locked.
+func evictIdle(limit uint32) {
+	if limit == 0 {
+		return
+	}
+	cached := 0
+	var idle []string
+	for key, conn := range connCache.conns {
+		if conn.client == nil {
+			continue
+		}
+		cached++
+		if conn.refs == 0 {
+			idle = append(idle, key)
+		}
+	}
+	sort.Slice(idle, func(i, j int) bool {
+		return connCache.conns[idle[i]].idleSince.Before(connCache.conns[idle[j]].idleSince)
+	})
+	for _, key := range idle {
+		if cached <= int(limit) {
+			return
+		}
+		conn := connCache.conns[key]
+		conn.timer.Stop()
+		delete(connCache.conns, key)
+		conn.client.Close()
+		cached--
+	}
+}
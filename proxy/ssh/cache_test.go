@@ -0,0 +1,183 @@
+package ssh
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/net"
+	"github.com/v2fly/v2ray-core/v5/common/session"
+	"github.com/v2fly/v2ray-core/v5/transport"
+	"github.com/v2fly/v2ray-core/v5/transport/pipe"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestMaxCachedClients(t *testing.T) {
+	const limit = 2
+	dialer := &pipeDialer{config: newTestServerConfig(t)}
+
+	var keys []string
+	var clients []*ssh.Client
+	for i := 0; i < 4; i++ {
+		key := "test-max-cached-clients-" + strconv.Itoa(i)
+		_, client, _, err := newTestClient(t, &Config{}).connect(context.Background(), dialer)
+		common.Must(err)
+		defer client.Close()
+
+		acquireConn(key)
+		storeConn(key, client, limit)
+		keys = append(keys, key)
+		clients = append(clients, client)
+	}
+	// All connections are in use, none is evicted.
+	for i, client := range clients {
+		if _, _, err := client.SendRequest("test", false, nil); err != nil {
+			t.Error("connection ", i, " in use was closed: ", err)
+		}
+	}
+
+	for _, key := range keys[:3] {
+		releaseConn(key, limit)
+	}
+	// Three idle and one in use, the two longest idle are evicted.
+	for i, client := range clients {
+		_, _, err := client.SendRequest("test", false, nil)
+		if evicted := i < 2; evicted != (err != nil) {
+			t.Error("connection ", i, ": expected evicted ", evicted, ", but request error is ", err)
+		}
+	}
+	for _, key := range keys[:2] {
+		if client := acquireConn(key); client != nil {
+			t.Error("evicted connection still cached for ", key)
+		}
+		releaseConn(key, limit)
+	}
+
+	acquireConn(keys[2])
+	releaseConn(keys[2], limit)
+	releaseConn(keys[3], limit)
+}
+
+func TestSnapshotSharedChannels(t *testing.T) {
+	hold := make(chan struct{})
+	dialer := &pipeDialer{
+		config: newTestServerConfig(t),
+		handleChannel: func(newChannel ssh.NewChannel) {
+			channel, reqs, err := newChannel.Accept()
+			if err != nil {
+				return
+			}
+			go ssh.DiscardRequests(reqs)
+			<-hold
+			channel.Close()
+		},
+	}
+	// Unique, so no connection cached by an earlier run is adopted.
+	password := "snapshot-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	first := newTestClient(t, &Config{Password: password, ReuseConnection: true})
+	defer first.Close()
+	if snapshot := first.Snapshot(); snapshot.Reusing || snapshot.Connections != 0 {
+		t.Error("expected no shared connection before connecting, but actually ", snapshot)
+	}
+	common.Must2(first.sshClient(context.Background(), dialer))
+	second := newTestClient(t, &Config{Password: password, ReuseConnection: true})
+	defer second.Close()
+
+	ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{
+		Target: net.TCPDestination(net.DomainAddress("example.com"), 443),
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		client := first
+		if i%2 == 1 {
+			client = second
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			uplinkReader, uplinkWriter := pipe.New()
+			_, downlinkWriter := pipe.New()
+			uplinkWriter.Close()
+			if err := client.Process(ctx, &transport.Link{Reader: uplinkReader, Writer: downlinkWriter}, dialer); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for first.Snapshot().SharedChannels != 4 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected 4 channels multiplexed on the shared connection, but actually ", first.Snapshot())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	snapshot := second.Snapshot()
+	if !snapshot.Reusing || snapshot.SharedUsers != 2 || snapshot.SharedChannels != 4 || snapshot.Connections != 1 {
+		t.Error("expected the connection shared by 2 outbounds with 4 channels, but actually ", snapshot)
+	}
+	if snapshot.ActiveChannels != 2 {
+		t.Error("expected 2 channels of the outbound itself, but actually ", snapshot.ActiveChannels)
+	}
+
+	close(hold)
+	wg.Wait()
+	if snapshot := first.Snapshot(); snapshot.SharedChannels != 0 {
+		t.Error("expected no channels left on the shared connection, but actually ", snapshot.SharedChannels)
+	}
+}
+
+func TestConnectionKey(t *testing.T) {
+	newConfig := func() *Config {
+		return &Config{
+			Address:  net.NewIPOrDomain(net.LocalHostIP),
+			Port:     22,
+			User:     "alice",
+			Password: "secret",
+			KeyboardInteractiveAnswers: map[string]string{
+				"Password: ": "secret",
+				"Code: ":     "123456",
+			},
+			Servers: []*Endpoint{{Address: net.NewIPOrDomain(net.LocalHostIP), Port: 2222}},
+		}
+	}
+	base := connectionKey(newConfig())
+	if key := connectionKey(newConfig()); key != base {
+		t.Error("expected equal configs to share a connection")
+	}
+
+	cases := []struct {
+		field  string
+		change func(*Config)
+	}{
+		{"AgentSocket", func(c *Config) { c.AgentSocket = "/run/user/1000/agent.sock" }},
+		{"KeyboardInteractiveAnswers", func(c *Config) { c.KeyboardInteractiveAnswers["Code: "] = "654321" }},
+		{"KeyboardInteractiveQuestions", func(c *Config) { c.KeyboardInteractiveAnswers["Token: "] = "123456" }},
+		{"KnownHostsPath", func(c *Config) { c.KnownHostsPath = "/etc/ssh/ssh_known_hosts" }},
+		{"InsecureSkipHostKeyCheck", func(c *Config) { c.InsecureSkipHostKeyCheck = true }},
+		{"HostKeyCheckTimeout", func(c *Config) { c.HostKeyCheckTimeout = 5 }},
+		{"StrictPublicKeyParse", func(c *Config) { c.StrictPublicKeyParse = true }},
+		{"AvoidTerrapinAlgorithms", func(c *Config) { c.AvoidTerrapinAlgorithms = true }},
+		{"AllowEmptyUser", func(c *Config) { c.AllowEmptyUser = true }},
+		{"ExpectBannerContains", func(c *Config) { c.ExpectBannerContains = "OpenSSH" }},
+		{"KeepAliveInterval", func(c *Config) { c.KeepAliveInterval = 30 }},
+		{"KeepAliveMaxFailures", func(c *Config) { c.KeepAliveMaxFailures = 3 }},
+		{"ServerAliveInterval", func(c *Config) { c.ServerAliveInterval = 30 }},
+		{"ServerAliveCountMax", func(c *Config) { c.ServerAliveCountMax = 3 }},
+		{"HandshakeDeadline", func(c *Config) { c.HandshakeDeadline = 30 }},
+		{"ConnectTimeout", func(c *Config) { c.ConnectTimeout = 5 }},
+		{"ServerKeepAliveInterval", func(c *Config) { c.Servers[0].KeepAliveInterval = 30 }},
+		{"ServerHandshakeDeadline", func(c *Config) { c.Servers[0].HandshakeDeadline = 30 }},
+	}
+	for _, c := range cases {
+		t.Run(c.field, func(t *testing.T) {
+			config := newConfig()
+			c.change(config)
+			if key := connectionKey(config); key == base {
+				t.Error("changing ", c.field, " reused the cached connection")
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package ssh
+
+import (
+	"context"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common/log"
+	"github.com/v2fly/v2ray-core/v5/common/net"
+	"github.com/v2fly/v2ray-core/v5/common/session"
+	"golang.org/x/crypto/ssh"
+)
+
+const defaultChannelType = "direct-tcpip"
+
+// directTCPIPPayload is the channel open payload of direct-tcpip, RFC 4254
+// section 7.2. Custom channel types are opened with the same payload.
+type directTCPIPPayload struct {
+	Raddr string
+	Rport uint32
+	Laddr string
+	Lport uint32
+}
+
+// openChannel opens a forwarding channel of the configured type to destination.
+func (c *Client) openChannel(ctx context.Context, sc *ssh.Client, destination net.Destination) (net.Conn, error) {
+	payload := directTCPIPPayload{
+		Raddr: destination.Address.String(),
+		Rport: uint32(destination.Port),
+		Laddr: net.AnyIP.String(),
+		Lport: c.config.OriginatorPort,
+	}
+	if payload.Lport == 0 {
+		if inbound := session.InboundFromContext(ctx); inbound != nil && inbound.Source.IsValid() {
+			payload.Lport = uint32(inbound.Source.Port)
+		}
+	}
+	channel, reqs, err := sc.OpenChannel(c.config.ChannelType, ssh.Marshal(&payload))
+	if err != nil {
+		return nil, channelOutcome(err)
+	}
+	go ssh.DiscardRequests(reqs)
+
+	zeroAddr := &net.TCPAddr{IP: net.AnyIP.IP()}
+	return &channelConn{Channel: channel, laddr: zeroAddr, raddr: zeroAddr}, nil
+}
+
+// channelOutcome marks the refusal of the server to open a channel with its
+// cause. Servers like OpenSSH report a failed connect as ConnectionFailed.
+func channelOutcome(err error) error {
+	openErr, ok := err.(*ssh.OpenChannelError)
+	if !ok {
+		return err
+	}
+	switch openErr.Reason {
+	case ssh.Prohibited:
+		return log.WithOutcome(err, log.OutcomeBlocked)
+	case ssh.ConnectionFailed:
+		return log.WithOutcome(err, log.OutcomeConnectRefused)
+	}
+	return err
+}
+
+// channelConn adapts an ssh.Channel to net.Conn.
+type channelConn struct {
+	ssh.Channel
+	laddr, raddr net.Addr
+}
+
+func (c *channelConn) LocalAddr() net.Addr {
+	return c.laddr
+}
+
+func (c *channelConn) RemoteAddr() net.Addr {
+	return c.raddr
+}
+
+func (c *channelConn) SetDeadline(time.Time) error {
+	return newError("ssh channel does not support deadlines")
+}
+
+func (c *channelConn) SetReadDeadline(time.Time) error {
+	return newError("ssh channel does not support deadlines")
+}
+
+func (c *channelConn) SetWriteDeadline(time.Time) error {
+	return newError("ssh channel does not support deadlines")
+}
@@ -1,52 +1,86 @@
 package ssh
 
 import (
-	"bytes"
 	"context"
 	"encoding/base64"
 	"math/rand"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/sagernet/sing/common/bufio"
 	core "github.com/v2fly/v2ray-core/v5"
 	"github.com/v2fly/v2ray-core/v5/common"
 	"github.com/v2fly/v2ray-core/v5/common/buf"
+	"github.com/v2fly/v2ray-core/v5/common/errors"
+	"github.com/v2fly/v2ray-core/v5/common/log"
 	"github.com/v2fly/v2ray-core/v5/common/net"
 	"github.com/v2fly/v2ray-core/v5/common/retry"
 	"github.com/v2fly/v2ray-core/v5/common/session"
 	"github.com/v2fly/v2ray-core/v5/common/signal"
+	"github.com/v2fly/v2ray-core/v5/common/signal/done"
 	"github.com/v2fly/v2ray-core/v5/common/task"
+	"github.com/v2fly/v2ray-core/v5/features/dns"
 	"github.com/v2fly/v2ray-core/v5/features/policy"
+	"github.com/v2fly/v2ray-core/v5/features/stats"
 	"github.com/v2fly/v2ray-core/v5/proxy"
 	"github.com/v2fly/v2ray-core/v5/transport"
 	"github.com/v2fly/v2ray-core/v5/transport/internet"
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
 )
 
 func init() {
 	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
 		c := &Client{}
-		return c, core.RequireFeatures(ctx, func(policyManager policy.Manager) error {
-			return c.Init(config.(*Config), policyManager)
+		if name := config.(*Config).ChannelsCounter; name != "" {
+			if err := core.RequireFeatures(ctx, func(statsManager stats.Manager) error {
+				counter, err := stats.GetOrRegisterCounter(statsManager, name)
+				if err != nil {
+					newError("ssh channels counter ", name, " is not available, stats may not be enabled").Base(err).AtWarning().WriteToLog()
+					return nil
+				}
+				c.channelsCounter = counter
+				return nil
+			}); err != nil {
+				return nil, err
+			}
+		}
+		return c, core.RequireFeatures(ctx, func(policyManager policy.Manager, dnsClient dns.Client) error {
+			return c.Init(config.(*Config), policyManager, dnsClient)
 		})
 	}))
 }
 
 var (
-	_ proxy.Outbound  = (*Client)(nil)
-	_ common.Closable = (*Client)(nil)
+	_ proxy.Outbound      = (*Client)(nil)
+	_ proxy.HealthChecker = (*Client)(nil)
+	_ common.Closable     = (*Client)(nil)
 )
 
 type Client struct {
-	sync.Mutex
+	// Accessed atomically, first for 64-bit alignment.
+	activeChannels int64
+	bytesInFlight  int64
+	unhealthy      int32
+
 	config          *Config
 	sessionPolicy   policy.Session
-	server          net.Destination
-	client          *ssh.Client
-	auth            []ssh.AuthMethod
+	dns             dns.Client
+	resolveRules    []resolveRule
+	servers         []*sshServer
+	slots           []*poolSlot
+	nextSlotIndex   uint32
+	signers         []ssh.Signer
+	password        string
+	passphrase      string
 	hostKeyCallback ssh.HostKeyCallback
+	cacheKey        string
+	healthDone      *done.Instance
+	jumps           []*jumpHost
+	udpRelay        net.Destination
+	channelsCounter stats.Counter
 }
 
 func randomVersion() string {
@@ -59,73 +93,216 @@ func randomVersion() string {
 	return version
 }
 
-func (c *Client) Init(config *Config, policyManager policy.Manager) error {
+func (c *Client) Init(config *Config, policyManager policy.Manager, dnsClient dns.Client) error {
+	if config.Uri != "" {
+		if err := applyURI(config); err != nil {
+			return err
+		}
+	}
 	c.config = config
 	c.sessionPolicy = policyManager.ForLevel(config.UserLevel)
-	c.server = net.Destination{
-		Network: net.Network_TCP,
-		Address: config.Address.AsAddress(),
-		Port:    net.Port(config.Port),
+	c.dns = dnsClient
+	resolveRules, err := newResolveRules(config.ResolveRules)
+	if err != nil {
+		return err
 	}
-	if config.User == "" {
+	c.resolveRules = resolveRules
+	if config.User == "" && !config.AllowEmptyUser {
 		config.User = "root"
 	}
 	if config.HostKeyAlgorithms != nil && len(config.HostKeyAlgorithms) == 0 {
 		config.HostKeyAlgorithms = nil
 	}
+	// An empty list would disable every algorithm instead of the defaults.
+	if len(config.Ciphers) == 0 {
+		config.Ciphers = nil
+	}
+	if len(config.Macs) == 0 {
+		config.Macs = nil
+	}
+	if len(config.KeyExchanges) == 0 {
+		config.KeyExchanges = nil
+	}
+	if err := validateConfigAlgorithms(config); err != nil {
+		return err
+	}
+	if config.AvoidTerrapinAlgorithms {
+		ciphers, macs := withoutTerrapinAffected(ssh.Config{Ciphers: config.Ciphers, MACs: config.Macs})
+		if len(ciphers) == 0 || len(macs) == 0 {
+			return newError("avoid_terrapin_algorithms leaves no cipher or MAC to offer, chacha20-poly1305 and encrypt-then-MAC MACs are open to the Terrapin attack")
+		}
+	}
+	if config.Compression {
+		return newError("compression is not supported, the ssh library implements no compression algorithm")
+	}
+	if err := validateKeepAlive(config); err != nil {
+		return err
+	}
 	if config.ClientVersion == "" {
 		config.ClientVersion = randomVersion()
 	}
+	if config.ChannelType == "" {
+		config.ChannelType = defaultChannelType
+	}
 
-	if config.PrivateKey != "" {
-		var signer ssh.Signer
-		var err error
-		if config.Password == "" {
-			signer, err = ssh.ParsePrivateKey([]byte(config.PrivateKey))
-		} else {
-			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(config.PrivateKey), []byte(config.Password))
+	password := config.Password
+	var keyErrs []error
+	addKey := func(name, key string) {
+		signer, err := ssh.ParsePrivateKey([]byte(key))
+		if _, encrypted := err.(*ssh.PassphraseMissingError); encrypted && config.Password != "" {
+			// The password is the key passphrase, not a second factor.
+			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(key), []byte(config.Password))
+			password = ""
+			c.passphrase = config.Password
 		}
 		if err != nil {
-			return newError("parse private key").Base(err)
+			err = newError("parse ", name).Base(err)
+			newError("skipping private key").Base(err).AtWarning().WriteToLog()
+			keyErrs = append(keyErrs, err)
+			return
 		}
-		c.auth = []ssh.AuthMethod{ssh.PublicKeys(signer)}
-	} else if config.Password != "" {
-		c.auth = []ssh.AuthMethod{ssh.Password(config.Password)}
+		c.signers = append(c.signers, signer)
+	}
+	if config.PrivateKey != "" {
+		addKey("private_key", config.PrivateKey)
+	}
+	for i, key := range config.PrivateKeys {
+		addKey("private_keys["+strconv.Itoa(i)+"]", key)
 	}
+	if len(keyErrs) > 0 && len(c.signers) == 0 {
+		return newError("no valid private key").Base(errors.Combine(keyErrs...))
+	}
+	c.password = password
 
-	var keys []ssh.PublicKey
-	if config.PublicKey != "" {
-		for _, str := range strings.Split(config.PublicKey, "\n") {
+	var knownHostsCallback ssh.HostKeyCallback
+	if config.KnownHostsPath != "" {
+		knownHostsCallback, err = knownhosts.New(config.KnownHostsPath)
+		if err != nil {
+			return newError("failed to load known hosts ", config.KnownHostsPath).Base(err)
+		}
+	}
+	hostKeyCallback, err := newHostKeyCallback(config, config.PublicKey, knownHostsCallback)
+	if err != nil {
+		return err
+	}
+	c.hostKeyCallback = hostKeyCallback
+	servers, err := c.newServers(config, knownHostsCallback)
+	if err != nil {
+		return err
+	}
+	c.servers = servers
+	jumps, err := c.newJumpHosts(config)
+	if err != nil {
+		return err
+	}
+	c.jumps = jumps
+	if config.EnableUdp {
+		if config.UdpRelay == "" {
+			return newError("enable_udp requires udp_relay")
+		}
+		relay, err := net.ParseDestination("tcp:" + config.UdpRelay)
+		if err != nil || relay.Port == 0 {
+			return newError("invalid udp_relay ", config.UdpRelay).Base(err)
+		}
+		c.udpRelay = relay
+	}
+
+	c.slots = newPool(config.MaxConnections, config.MaxChannelsPerConnection)
+	if config.ReuseConnection {
+		c.cacheKey = connectionKey(config)
+		if client := acquireConn(c.cacheKey); client != nil {
+			c.slots[0].client = client
+			go c.watch(c.slots[0], client)
+		}
+	}
+	return nil
+}
+
+// newHostKeyCallback returns the callback verifying host keys with the keys
+// in publicKey, known_hosts and the host cert authorities of config.
+func newHostKeyCallback(config *Config, publicKey string, knownHostsCallback ssh.HostKeyCallback) (ssh.HostKeyCallback, error) {
+	keys := newHostKeys()
+	if publicKey != "" {
+		for i, str := range strings.Split(publicKey, "\n") {
 			str = strings.TrimSpace(str)
 			if str == "" {
 				continue
 			}
-			key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(str))
-			if err != nil {
-				if err != nil {
-					return newError(err, "parse public key").Base(err)
+			if err := keys.add(str); err != nil {
+				if config.StrictPublicKeyParse {
+					return nil, newError("parse public key on line ", i+1).Base(err)
 				}
+				newError("skipping malformed public key on line ", i+1).Base(err).AtDebug().WriteToLog()
 			}
-			keys = append(keys, key)
+		}
+		if keys.empty() {
+			// Keys that are all malformed are a mistake, not a request to skip
+			// the verification.
+			return nil, newError("no valid public key")
 		}
 	}
-	if keys != nil {
-		c.hostKeyCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
-			for _, pk := range keys {
-				if bytes.Equal(key.Marshal(), pk.Marshal()) {
+	var hostKeyCallback ssh.HostKeyCallback
+	if !keys.empty() || knownHostsCallback != nil {
+		hostKeyCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if keys.trusted(hostname, key) {
+				return nil
+			}
+			var err error
+			if knownHostsCallback != nil {
+				if err = knownHostsCallback(hostname, remote, key); err == nil {
 					return nil
 				}
 			}
-			return newError("ssh host key mismatch, server send ", key.Type(), " ", base64.StdEncoding.EncodeToString(key.Marshal()))
+			return newError("ssh host key mismatch, server send ", key.Type(), " ", base64.StdEncoding.EncodeToString(key.Marshal())).Base(err)
 		}
-	} else {
-		c.hostKeyCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	} else if len(config.HostCertAuthorities) > 0 {
+		hostKeyCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			return newError("ssh host key is not signed by a trusted authority, server send ", key.Type(), " ", base64.StdEncoding.EncodeToString(key.Marshal()))
+		}
+	} else if config.InsecureSkipHostKeyCheck {
+		// Only log a server's key when it changes, reconnections would flood
+		// the log otherwise.
+		var seenLock sync.Mutex
+		seen := make(map[string]string)
+		hostKeyCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			marshaled := string(key.Marshal())
+			seenLock.Lock()
+			changed := seen[hostname] != marshaled
+			seen[hostname] = marshaled
+			seenLock.Unlock()
+			if !changed {
+				return nil
+			}
 			newError("please save server public key for verifying").AtWarning().WriteToLog()
 			newError(key.Type(), " ", base64.StdEncoding.EncodeToString(key.Marshal())).AtWarning().WriteToLog()
 			return nil
 		}
+	} else {
+		hostKeyCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			return newError("no key to verify the ssh server with, set public_key or known_hosts_path, server send ", key.Type(), " ", base64.StdEncoding.EncodeToString(key.Marshal()))
+		}
 	}
-	return nil
+	if len(config.HostCertAuthorities) > 0 {
+		authorities := make(map[string]bool)
+		for _, str := range config.HostCertAuthorities {
+			key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(str))
+			if err != nil {
+				return nil, newError("parse host cert authority").Base(err)
+			}
+			authorities[string(key.Marshal())] = true
+		}
+		checker := &ssh.CertChecker{
+			IsHostAuthority: func(auth ssh.PublicKey, address string) bool {
+				return authorities[string(auth.Marshal())]
+			},
+			HostKeyFallback: hostKeyCallback,
+		}
+		hostKeyCallback = checker.CheckHostKey
+	}
+	if config.HostKeyCheckTimeout > 0 {
+		hostKeyCallback = hostKeyCallbackWithTimeout(hostKeyCallback, time.Duration(config.HostKeyCheckTimeout)*time.Second)
+	}
+	return hostKeyCallback, nil
 }
 
 func (c *Client) Process(ctx context.Context, link *transport.Link, dialer internet.Dialer) error {
@@ -135,55 +312,126 @@ func (c *Client) Process(ctx context.Context, link *transport.Link, dialer inter
 	}
 	destination := outbound.Target
 	network := destination.Network
-	if network != net.Network_TCP {
-		return newError("only TCP is supported in SSH proxy")
+	if network == net.Network_UDP && !c.config.EnableUdp {
+		return errUDPNotSupported
+	}
+	if network != net.Network_TCP && network != net.Network_UDP {
+		return newError("only TCP and UDP are supported in SSH proxy")
+	}
+
+	destination, err := c.resolve(ctx, destination)
+	if err != nil {
+		return err
+	}
+	if network == net.Network_UDP {
+		return c.processUDP(ctx, link, dialer, destination)
 	}
 
-	sc := c.client
-	if sc == nil {
-		c.Lock()
-		sc = c.client
-		if c.client == nil {
-			conn, client, err := c.connect(ctx, dialer)
+	ctx, cancel := c.withMaxDuration(ctx)
+	defer cancel()
+	timer := c.sessionPolicy.CancelAfterInactivity(ctx, cancel)
+	copying := copyConfigFor(c.config.BufferMode, destination)
+
+	var reader buf.Reader = link.Reader
+	for retried := false; ; retried = true {
+		slot, release, err := c.acquireChannel(ctx)
+		if err != nil {
+			return err
+		}
+		sc, err := c.slotClient(ctx, slot, dialer)
+		if err != nil {
+			release()
+			return err
+		}
+
+		conn, err := c.openChannel(ctx, sc, destination)
+		if err != nil {
+			release()
+			return newError("failed to open ssh proxy connection").Base(err)
+		}
+
+		c.trackChannel(slot, 1)
+		pending, err := c.copyChannel(ctx, conn, reader, link.Writer, copying, timer)
+		conn.Close()
+		c.trackChannel(slot, -1)
+		release()
+		if pending.IsEmpty() || retried || !c.config.ReconnectOnWriteFailure {
+			buf.ReleaseMulti(pending)
 			if err != nil {
-				return err
+				return c.endError(ctx, err)
 			}
-			connElem := net.AddConnection(conn)
-			go func() {
-				err = client.Wait()
-				if err != nil {
-					newError("ssh client closed").Base(err).AtDebug().WriteToLog()
-				}
-				c.Lock()
-				c.client = nil
-				c.Unlock()
-				net.RemoveConnection(connElem)
-			}()
-			sc = client
+			return nil
 		}
-		c.Unlock()
-	}
 
-	conn, err := sc.Dial("tcp", destination.NetAddr())
-	if err != nil {
-		return newError("failed to open ssh proxy connection").Base(err)
+		newError("failed to write to ssh connection, reconnecting").Base(err).AtInfo().WriteToLog(session.ExportIDToError(ctx))
+		c.dropClient(sc)
+		timer.SetTimeout(c.sessionPolicy.Timeouts.ConnectionIdle)
+		reader = &pendingReader{Reader: link.Reader, pending: pending}
 	}
-	defer conn.Close()
+}
 
-	ctx, cancel := context.WithCancel(ctx)
-	timer := signal.CancelAfterInactivity(ctx, cancel, c.sessionPolicy.Timeouts.ConnectionIdle)
+// copyChannel copies between the link and conn. If the first write to conn
+// fails before anything was received from it, the connection was likely
+// stale, and the data of that write is returned to be sent again.
+func (c *Client) copyChannel(ctx context.Context, conn net.Conn, reader buf.Reader, writer buf.Writer, copying copyConfig, timer *signal.ActivityTimer) (buf.MultiBuffer, error) {
+	counter := &countingConn{Conn: conn}
+	uplink := &firstWriteWriter{Writer: &inFlightWriter{Writer: copying.writer(counter), inFlight: &c.bytesInFlight}}
+	downlinkDone := make(chan struct{})
 
-	if err := task.Run(ctx, func() error {
+	err := task.Run(ctx, func() error {
 		defer timer.SetTimeout(c.sessionPolicy.Timeouts.DownlinkOnly)
-		return buf.Copy(link.Reader, buf.NewWriter(conn), buf.UpdateActivity(timer))
+		err := buf.Copy(reader, uplink, buf.UpdateActivity(timer))
+		logCopyError(ctx, "uplink", err, counter)
+		return err
 	}, func() error {
+		defer close(downlinkDone)
 		defer timer.SetTimeout(c.sessionPolicy.Timeouts.UplinkOnly)
-		return buf.Copy(buf.NewReader(conn), link.Writer, buf.UpdateActivity(timer))
-	}); err != nil {
-		return newError("connection ends").Base(err)
+		err := buf.Copy(copying.reader(counter), writer, buf.UpdateActivity(timer))
+		logCopyError(ctx, "downlink", err, counter)
+		return err
+	})
+	if uplink.failed.IsEmpty() {
+		return nil, err
 	}
+	// Nothing must have been received when the write is repeated elsewhere.
+	conn.Close()
+	<-downlinkDone
+	if counter.bytesRead() > 0 {
+		buf.ReleaseMulti(uplink.failed)
+		return nil, err
+	}
+	return uplink.failed, err
+}
 
-	return nil
+// logCopyError logs which direction of a channel failed, and whether reading
+// or writing, with the bytes it carried so far.
+func logCopyError(ctx context.Context, direction string, err error, counter *countingConn) {
+	if err == nil {
+		return
+	}
+	switch {
+	case buf.IsReadError(err):
+		direction += " read"
+	case buf.IsWriteError(err):
+		direction += " write"
+	}
+	newError(direction, " failed after ", counter.bytesWritten(), " bytes sent and ", counter.bytesRead(), " received").Base(err).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+}
+
+// dropClient closes sc and forgets it at once, so the next connection does
+// not reuse it.
+func (c *Client) dropClient(sc *ssh.Client) {
+	for _, slot := range c.slots {
+		slot.Lock()
+		if slot.client == sc {
+			slot.client = nil
+		}
+		slot.Unlock()
+	}
+	if c.cacheKey != "" {
+		removeConn(c.cacheKey, sc)
+	}
+	sc.Close()
 }
 
 func (c *Client) ProcessConn(ctx context.Context, conn net.Conn, dialer internet.Dialer) error {
@@ -193,63 +441,271 @@ func (c *Client) ProcessConn(ctx context.Context, conn net.Conn, dialer internet
 	}
 	destination := outbound.Target
 	network := destination.Network
+	if network == net.Network_UDP {
+		return errUDPNotSupported
+	}
 	if network != net.Network_TCP {
 		return newError("only TCP is supported in SSH proxy")
 	}
 
-	sc := c.client
-	if sc == nil {
-		c.Lock()
-		sc = c.client
-		if c.client == nil {
-			conn, client, err := c.connect(ctx, dialer)
-			if err != nil {
-				return err
-			}
-			connElem := net.AddConnection(conn)
+	destination, err := c.resolve(ctx, destination)
+	if err != nil {
+		return err
+	}
+
+	slot, release, err := c.acquireChannel(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	sc, err := c.slotClient(ctx, slot, dialer)
+	if err != nil {
+		return err
+	}
+
+	outboundConn, err := c.openChannel(ctx, sc, destination)
+	if err != nil {
+		return newError("failed to open ssh proxy connection").Base(err)
+	}
+
+	c.trackChannel(slot, 1)
+	defer c.trackChannel(slot, -1)
+	ctx, cancel := c.withMaxDuration(ctx)
+	defer cancel()
+	if err := bufio.CopyConn(ctx, conn, outboundConn); err != nil {
+		return c.endError(ctx, err)
+	}
+	return nil
+}
+
+// sshClient returns a connection to the ssh server from the pool, connecting
+// first if its slot has none.
+func (c *Client) sshClient(ctx context.Context, dialer internet.Dialer) (*ssh.Client, error) {
+	return c.slotClient(ctx, c.nextSlot(), dialer)
+}
+
+// slotClient returns the connection of slot, connecting first if it has none.
+func (c *Client) slotClient(ctx context.Context, slot *poolSlot, dialer internet.Dialer) (*ssh.Client, error) {
+	slot.Lock()
+	if client := slot.client; client != nil {
+		slot.Unlock()
+		return client, nil
+	}
+	if future := slot.dialing; future != nil {
+		slot.Unlock()
+		return future.wait(ctx)
+	}
+	future := &dialFuture{done: make(chan struct{})}
+	slot.dialing = future
+	slot.Unlock()
+
+	conn, client, server, err := c.connect(ctx, dialer)
+	slot.Lock()
+	// Cleared on failure as well, so the next caller dials again.
+	slot.dialing = nil
+	if err == nil {
+		slot.client = client
+	}
+	slot.Unlock()
+	future.resolve(client, err)
+	if err != nil {
+		return nil, err
+	}
+	if c.shared(slot) {
+		storeConn(c.cacheKey, client, c.config.MaxCachedClients)
+	}
+	connElem := net.AddConnection(conn)
+	closed := make(chan struct{})
+	go func() {
+		c.watch(slot, client)
+		net.RemoveConnection(connElem)
+		close(closed)
+	}()
+	if server.keepAliveInterval > 0 {
+		interval := server.keepAliveInterval
+		maxFailures := c.config.KeepAliveMaxFailures
+		if maxFailures == 0 {
+			maxFailures = 1
+		}
+		go runKeepAlive(closed, interval, maxFailures, func() error {
+			return sendKeepAlive(client, interval)
+		}, func() {
+			client.Close()
+		})
+	}
+	if c.config.ServerAliveInterval > 0 {
+		received := func() int64 { return 0 }
+		if counter, ok := conn.(*countingConn); ok {
+			received = counter.bytesRead
+		}
+		countMax := c.config.ServerAliveCountMax
+		if countMax == 0 {
+			countMax = defaultServerAliveCountMax
+		}
+		go runServerAlive(closed, time.Duration(c.config.ServerAliveInterval)*time.Second, countMax, received, func(answered func()) {
 			go func() {
-				err = client.Wait()
-				if err != nil {
-					newError("ssh client closed").Base(err).AtDebug().WriteToLog()
+				if _, _, err := client.SendRequest(keepAliveRequest, true, nil); err == nil {
+					answered()
 				}
-				c.Lock()
-				c.client = nil
-				c.Unlock()
-				net.RemoveConnection(connElem)
 			}()
-			sc = client
-		}
-		c.Unlock()
+		}, func() {
+			client.Close()
+		})
 	}
+	return client, nil
+}
 
-	outboundConn, err := sc.Dial("tcp", destination.NetAddr())
+// watch waits for client to be closed and empties its slot, leaving the
+// other connections of the pool alone.
+func (c *Client) watch(slot *poolSlot, client *ssh.Client) {
+	if err := client.Wait(); err != nil {
+		newError("ssh client closed").Base(err).AtDebug().WriteToLog()
+	}
+	if c.shared(slot) {
+		removeConn(c.cacheKey, client)
+	}
+	slot.Lock()
+	if slot.client == client {
+		slot.client = nil
+	}
+	slot.Unlock()
+}
+
+// connect returns the connection established and the server connected to.
+func (c *Client) connect(ctx context.Context, dialer internet.Dialer) (net.Conn, *ssh.Client, *sshServer, error) {
+	var conn net.Conn
+	var client *ssh.Client
+	var server *sshServer
+	var err error
+	if c.config.DialStrategy == DialStrategy_Parallel && len(c.servers) > 1 {
+		conn, client, server, err = c.connectParallel(ctx, dialer)
+	} else {
+		conn, client, server, err = c.connectSequential(ctx, dialer)
+	}
 	if err != nil {
-		return newError("failed to open ssh proxy connection").Base(err)
+		return nil, nil, nil, err
 	}
+	return conn, client, server, nil
+}
 
-	return bufio.CopyConn(ctx, conn, outboundConn)
+// connectSequential tries the servers in order and returns the first
+// connection established.
+func (c *Client) connectSequential(ctx context.Context, dialer internet.Dialer) (net.Conn, *ssh.Client, *sshServer, error) {
+	var errs []error
+	for _, server := range c.servers {
+		conn, client, err := c.handshake(ctx, dialer, server)
+		if err == nil {
+			return conn, client, server, nil
+		}
+		if len(c.servers) == 1 {
+			return nil, nil, nil, err
+		}
+		newError("failed to connect to ssh server ", server.destination).Base(err).AtWarning().WriteToLog(session.ExportIDToError(ctx))
+		errs = append(errs, err)
+	}
+	return nil, nil, nil, newError("failed to connect to any ssh server").Base(errors.Combine(errs...))
 }
 
-func (c *Client) connect(ctx context.Context, dialer internet.Dialer) (net.Conn, *ssh.Client, error) {
-	config := &ssh.ClientConfig{
-		User:              c.config.User,
-		Auth:              c.auth,
-		ClientVersion:     c.config.ClientVersion,
-		HostKeyAlgorithms: c.config.HostKeyAlgorithms,
-		HostKeyCallback:   c.hostKeyCallback,
-		BannerCallback: func(message string) error {
-			for _, line := range strings.Split(message, "\n") {
-				newError("| ", line).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+// connectParallel connects to all servers at once and keeps the first
+// connection established, closing the others.
+func (c *Client) connectParallel(ctx context.Context, dialer internet.Dialer) (net.Conn, *ssh.Client, *sshServer, error) {
+	type result struct {
+		conn   net.Conn
+		client *ssh.Client
+		server *sshServer
+		err    error
+	}
+	results := make(chan result, len(c.servers))
+	for _, server := range c.servers {
+		server := server
+		go func() {
+			conn, client, err := c.handshake(ctx, dialer, server)
+			results <- result{conn: conn, client: client, server: server, err: err}
+		}()
+	}
+
+	var errs []error
+	for range c.servers {
+		r := <-results
+		if r.err != nil {
+			errs = append(errs, r.err)
+			continue
+		}
+		go func(pending int) {
+			for i := 0; i < pending; i++ {
+				if loser := <-results; loser.err == nil {
+					loser.client.Close()
+				}
 			}
-			return nil
-		},
+		}(len(c.servers) - len(errs) - 1)
+		return r.conn, r.client, r.server, nil
+	}
+	return nil, nil, nil, newError("failed to connect to any ssh server").Base(errors.Combine(errs...))
+}
+
+// handshake connects to server and establishes the ssh connection. If the
+// server drops the connection as throttled, it retries with a jittered
+// backoff.
+func (c *Client) handshake(ctx context.Context, dialer internet.Dialer, server *sshServer) (net.Conn, *ssh.Client, error) {
+	for attempt := 0; ; attempt++ {
+		conn, client, err := c.handshakeOnce(ctx, dialer, server)
+		if err != errThrottled || attempt >= throttleRetries {
+			return conn, client, err
+		}
+		delay := throttleDelay(attempt)
+		newError("ssh server ", server.destination, " throttled the connection, retrying in ", delay).AtInfo().WriteToLog(session.ExportIDToError(ctx))
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, nil, newError("failed to connect to throttling ssh server").Base(ctx.Err())
+		}
+	}
+}
+
+// defaultHandshakeDeadline is how long the handshake, from the connection
+// being established to authentication, may take by default.
+const defaultHandshakeDeadline = time.Minute
+
+func (c *Client) handshakeDeadline() time.Duration {
+	if c.config.HandshakeDeadline > 0 {
+		return time.Duration(c.config.HandshakeDeadline) * time.Second
 	}
+	return defaultHandshakeDeadline
+}
 
-	newError("open connection to ", c.server).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+// defaultConnectTimeout is how long dialing the server may take by default.
+const defaultConnectTimeout = 10 * time.Second
+
+func (c *Client) connectTimeout() time.Duration {
+	if c.config.ConnectTimeout > 0 {
+		return time.Duration(c.config.ConnectTimeout) * time.Second
+	}
+	return defaultConnectTimeout
+}
+
+// handshakeLimit returns how long a handshake with server may take, its
+// handshake deadline or less if ctx expires before.
+func (c *Client) handshakeLimit(ctx context.Context, server *sshServer) time.Duration {
+	limit := server.handshakeDeadline
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline); remaining < limit {
+			limit = remaining
+		}
+	}
+	return limit
+}
+
+func (c *Client) handshakeOnce(ctx context.Context, dialer internet.Dialer, server *sshServer) (net.Conn, *ssh.Client, error) {
+	// Only dialing, through the jump hosts if any, is bound by the connect
+	// timeout. The handshake has its own deadline.
+	dialCtx, cancel := context.WithTimeout(ctx, c.connectTimeout())
+	defer cancel()
+
+	newError("open connection to ", server.destination).AtDebug().WriteToLog(session.ExportIDToError(ctx))
 
 	var conn internet.Connection
-	err := retry.ExponentialBackoff(2, 100).On(func() error {
-		rawConn, err := dialer.Dial(ctx, c.server)
+	err := retry.WithObserver(retry.ExponentialBackoff(2, 100), retry.LogAttempts(ctx, "dial")).On(func() error {
+		rawConn, err := c.dial(dialCtx, dialer, server)
 		if err != nil {
 			return err
 		}
@@ -259,22 +715,181 @@ func (c *Client) connect(ctx context.Context, dialer internet.Dialer) (net.Conn,
 	if err != nil {
 		return nil, nil, newError("failed to connect to ssh server").AtWarning().Base(err)
 	}
+	return c.handshakeConn(ctx, conn, server)
+}
+
+// handshakeConn sets up an ssh client over conn, an established connection to
+// server, which is closed if the handshake fails. It lets embedders bring a
+// connection from their own transport instead of an internet.Dialer.
+func (c *Client) handshakeConn(ctx context.Context, conn net.Conn, server *sshServer) (net.Conn, *ssh.Client, error) {
+	bannerSeen := false
+	attempts := &authAttempts{}
+	config := &ssh.ClientConfig{
+		User:              c.config.User,
+		Auth:              c.authMethods(server, attempts),
+		ClientVersion:     c.config.ClientVersion,
+		HostKeyAlgorithms: c.config.HostKeyAlgorithms,
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if err := server.hostKeyCallback(hostname, remote, key); err != nil {
+				return err
+			}
+			attempts.keyExchanged()
+			return nil
+		},
+		Config: ssh.Config{
+			Ciphers:      c.config.Ciphers,
+			MACs:         c.config.Macs,
+			KeyExchanges: c.config.KeyExchanges,
+		},
+		BannerCallback: func(message string) error {
+			bannerSeen = true
+			for _, line := range strings.Split(message, "\n") {
+				newError("| ", line).AtInfo().WriteToLog(session.ExportIDToError(ctx))
+			}
+			if err := c.checkBanner(message); err != nil {
+				attempts.callbackFailed()
+				return err
+			}
+			return nil
+		},
+	}
+
+	if c.config.AvoidTerrapinAlgorithms {
+		config.Ciphers, config.MACs = withoutTerrapinAffected(config.Config)
+	}
+
+	// Timeouts of single reads do not stop a server trickling bytes, the
+	// watchdog bounds the whole handshake.
+	deadline := c.handshakeLimit(ctx, server)
+	watchdog := time.AfterFunc(deadline, func() {
+		conn.Close()
+	})
 
-	clientConn, chans, reqs, err := ssh.NewClientConn(conn, c.server.Address.String(), config)
+	counter := &countingConn{Conn: conn}
+	clientConn, chans, reqs, err := ssh.NewClientConn(counter, server.destination.NetAddr(), config)
+	attempts.close()
+	if !watchdog.Stop() {
+		if err == nil {
+			clientConn.Close()
+		}
+		return nil, nil, log.WithOutcome(newError("ssh handshake with ", server.destination, " did not complete within ", deadline.Round(time.Millisecond)).AtWarning(), log.OutcomeTimeout)
+	}
 	if err != nil {
+		authFailed := isAuthFailure(attempts, counter)
+		throttled := isThrottled(counter)
 		conn.Close()
+		if throttled {
+			return nil, nil, errThrottled
+		}
+		if mismatchErr := algorithmMismatchError(err, config); mismatchErr != nil {
+			return nil, nil, mismatchErr
+		}
+		if authFailed {
+			if c.config.AuthFailureSummary {
+				newError("ssh authentication with ", server.destination, " failed: ", c.authSummary(server, attempts)).AtWarning().WriteToLog(session.ExportIDToError(ctx))
+			}
+			c.forgetPassphraseAssumption(server)
+			err = log.WithOutcome(err, log.OutcomeAuthFailure)
+		}
 		return nil, nil, newError("failed to create ssh connection").Base(err)
 	}
 
 	client := ssh.NewClient(clientConn, chans, reqs)
-	c.client = client
-	return conn, client, nil
+	if c.config.ExpectBannerContains != "" && !bannerSeen {
+		client.Close()
+		return nil, nil, newError("ssh server ", server.destination, " sent no login banner, expected one containing ", strconv.Quote(c.config.ExpectBannerContains))
+	}
+	// The counter tells server alive checks whether the server was active.
+	return counter, client, nil
 }
 
-func (c *Client) Close() error {
-	sc := c.client
-	if sc != nil {
-		return sc.Close()
+// checkBanner rejects a login banner without the expected text. Servers send
+// the banner on the first auth request, so this happens before any
+// credentials are sent.
+func (c *Client) checkBanner(message string) error {
+	if expected := c.config.ExpectBannerContains; expected != "" && !strings.Contains(message, expected) {
+		return newError("unexpected ssh login banner, expected one containing ", strconv.Quote(expected))
 	}
 	return nil
 }
+
+// authMethods returns the auth methods for a new connection, noting in
+// attempts those the server let us try. They are in the order multi-factor
+// servers usually chain them: publickey, then password or
+// keyboard-interactive. After a partial success the client continues with the
+// next method the server lists.
+func (c *Client) authMethods(server *sshServer, attempts *authAttempts) []ssh.AuthMethod {
+	var methods []ssh.AuthMethod
+	password := c.authPassword(server)
+	if len(c.signers) > 0 || c.config.AgentSocket != "" {
+		// Offer the keys only once, so a server listing publickey again after
+		// it partially succeeded moves on to the next method.
+		offered := false
+		methods = append(methods, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+			if offered {
+				return nil, nil
+			}
+			offered = true
+			attempts.tried(authPublicKey)
+			signers := c.signers
+			if c.config.AgentSocket != "" {
+				// The library tries publickey once, so the keys of the agent
+				// are offered along with the configured ones.
+				agentSigners, err := c.agentSigners(attempts)
+				if err != nil {
+					newError("skipping ssh agent").Base(err).AtWarning().WriteToLog()
+				}
+				attempts.offeredAgentKeys(agentSigners)
+				signers = append(signers[:len(signers):len(signers)], agentSigners...)
+			}
+			return signers, nil
+		}))
+	}
+	if password != "" {
+		methods = append(methods, ssh.PasswordCallback(func() (string, error) {
+			attempts.tried(authPassword)
+			return password, nil
+		}))
+	}
+	if password != "" || len(c.config.KeyboardInteractiveAnswers) > 0 {
+		methods = append(methods, ssh.KeyboardInteractive(func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+			attempts.tried(authKeyboardInteractive)
+			answers := make([]string, len(questions))
+			for i, question := range questions {
+				answer, ok := c.interactiveAnswer(question, password)
+				if !ok {
+					attempts.callbackFailed()
+					return nil, newError("no answer to ssh keyboard-interactive question ", strconv.Quote(question))
+				}
+				answers[i] = answer
+			}
+			return answers, nil
+		}))
+	}
+	return methods
+}
+
+func (c *Client) Close() error {
+	if status := c.DrainStatus(); status.ActiveChannels > 0 {
+		newError("closing with ", status.ActiveChannels, " channels active, ", status.BytesInFlight, " bytes in flight").AtInfo().WriteToLog()
+	}
+	if c.healthDone != nil {
+		c.healthDone.Close()
+	}
+	var errs []error
+	for _, slot := range c.slots {
+		if c.shared(slot) {
+			releaseConn(c.cacheKey, c.config.MaxCachedClients)
+			continue
+		}
+		slot.Lock()
+		sc := slot.client
+		slot.Unlock()
+		if sc != nil {
+			if err := sc.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Combine(errs...)
+}
@@ -0,0 +1,1615 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	gonet "net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/buf"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+	"github.com/v2fly/v2ray-core/v5/common/log/logtest"
+	"github.com/v2fly/v2ray-core/v5/common/net"
+	"github.com/v2fly/v2ray-core/v5/common/session"
+	"github.com/v2fly/v2ray-core/v5/features/policy"
+	"github.com/v2fly/v2ray-core/v5/transport"
+	"github.com/v2fly/v2ray-core/v5/transport/internet"
+	"github.com/v2fly/v2ray-core/v5/transport/pipe"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func generateHostKeys(t testing.TB, n int) ([]ssh.PublicKey, string) {
+	keys := make([]ssh.PublicKey, 0, n)
+	builder := strings.Builder{}
+	for i := 0; i < n; i++ {
+		pub, _, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := ssh.NewPublicKey(pub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+		builder.Write(ssh.MarshalAuthorizedKey(key))
+	}
+	return keys, builder.String()
+}
+
+func newTestClient(t testing.TB, config *Config) *Client {
+	if config.Address == nil {
+		config.Address = net.NewIPOrDomain(net.LocalHostIP)
+	}
+	if config.Port == 0 {
+		config.Port = 22
+	}
+	if config.PublicKey == "" && config.KnownHostsPath == "" && len(config.HostCertAuthorities) == 0 {
+		// Most tests do not care about the server key.
+		config.InsecureSkipHostKeyCheck = true
+	}
+	client := &Client{}
+	if err := client.Init(config, policy.DefaultManager{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestPinnedHostKeys(t *testing.T) {
+	keys, pinned := generateHostKeys(t, 16)
+	client := newTestClient(t, &Config{PublicKey: pinned})
+
+	for _, key := range keys {
+		if err := client.hostKeyCallback("localhost:22", nil, key); err != nil {
+			t.Error("pinned key rejected: ", err)
+		}
+	}
+
+	unknown, _ := generateHostKeys(t, 1)
+	if err := client.hostKeyCallback("localhost:22", nil, unknown[0]); err == nil {
+		t.Error("expected unknown key to be rejected")
+	}
+}
+
+func TestKnownHostsNonStandardPort(t *testing.T) {
+	keys, _ := generateHostKeys(t, 2)
+	knownHosts := knownhosts.Line([]string{"[example.com]:2222"}, keys[0]) + "\n" +
+		knownhosts.Line([]string{"other.example.com"}, keys[1])
+	client := newTestClient(t, &Config{PublicKey: knownHosts})
+
+	if err := client.hostKeyCallback("example.com:2222", nil, keys[0]); err != nil {
+		t.Error("key of [host]:port entry rejected: ", err)
+	}
+	if err := client.hostKeyCallback("example.com:2223", nil, keys[0]); err == nil {
+		t.Error("expected key to be rejected on another port")
+	}
+	if err := client.hostKeyCallback("other.example.com:2222", nil, keys[1]); err != nil {
+		t.Error("key of plain host entry rejected: ", err)
+	}
+	if err := client.hostKeyCallback("example.com:2222", nil, keys[1]); err == nil {
+		t.Error("expected key of another host to be rejected")
+	}
+}
+
+func TestPublicKeyParseModes(t *testing.T) {
+	keys, pinned := generateHostKeys(t, 2)
+	lines := strings.Split(strings.TrimSpace(pinned), "\n")
+	mixed := lines[0] + "\nssh-ed25519 not-base64\n" + lines[1]
+
+	recorder := logtest.Capture(t)
+	client := newTestClient(t, &Config{PublicKey: mixed})
+	for _, key := range keys {
+		if err := client.hostKeyCallback("localhost:22", nil, key); err != nil {
+			t.Error("valid key rejected: ", err)
+		}
+	}
+	if !recorder.Contains(log.Severity_Debug, "line 2") {
+		t.Error("malformed line not logged")
+	}
+
+	strict := &Client{}
+	err := strict.Init(&Config{
+		Address:              net.NewIPOrDomain(net.LocalHostIP),
+		Port:                 22,
+		PublicKey:            mixed,
+		StrictPublicKeyParse: true,
+	}, policy.DefaultManager{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Error("expected error on line 2, got ", err)
+	}
+
+	invalid := &Client{}
+	err = invalid.Init(&Config{
+		Address:   net.NewIPOrDomain(net.LocalHostIP),
+		Port:      22,
+		PublicKey: "ssh-ed25519 not-base64",
+	}, policy.DefaultManager{}, nil)
+	if err == nil {
+		t.Error("expected an error without any valid key")
+	}
+}
+
+func TestKnownHostsFile(t *testing.T) {
+	keys, _ := generateHostKeys(t, 3)
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	common.Must(os.WriteFile(path, []byte(knownhosts.Line([]string{"127.0.0.1"}, keys[0])+"\n"), 0o600))
+
+	client := newTestClient(t, &Config{
+		PublicKey:      string(ssh.MarshalAuthorizedKey(keys[1])),
+		KnownHostsPath: path,
+	})
+	remote := &gonet.TCPAddr{IP: gonet.IPv4(127, 0, 0, 1), Port: 22}
+	if err := client.hostKeyCallback("127.0.0.1:22", remote, keys[1]); err != nil {
+		t.Error("inline key rejected: ", err)
+	}
+	if err := client.hostKeyCallback("127.0.0.1:22", remote, keys[0]); err != nil {
+		t.Error("key from known_hosts rejected: ", err)
+	}
+	if err := client.hostKeyCallback("127.0.0.1:22", remote, keys[2]); err == nil {
+		t.Error("expected unknown key to be rejected")
+	}
+
+	missing := &Client{}
+	err := missing.Init(&Config{
+		Address:        net.NewIPOrDomain(net.LocalHostIP),
+		Port:           22,
+		KnownHostsPath: filepath.Join(t.TempDir(), "missing"),
+	}, policy.DefaultManager{}, nil)
+	if err == nil {
+		t.Error("expected an error for a missing known_hosts file")
+	}
+}
+
+func TestUnverifiedHostKeyRejected(t *testing.T) {
+	keys, _ := generateHostKeys(t, 1)
+
+	client := &Client{}
+	common.Must(client.Init(&Config{
+		Address: net.NewIPOrDomain(net.LocalHostIP),
+		Port:    22,
+	}, policy.DefaultManager{}, nil))
+	if err := client.hostKeyCallback("localhost:22", nil, keys[0]); err == nil {
+		t.Error("expected a host key to be rejected without anything to verify it with")
+	}
+
+	insecure := newTestClient(t, &Config{InsecureSkipHostKeyCheck: true})
+	if err := insecure.hostKeyCallback("localhost:22", nil, keys[0]); err != nil {
+		t.Error("host key rejected with insecure_skip_host_key_check: ", err)
+	}
+}
+
+func BenchmarkPinnedHostKeys(b *testing.B) {
+	keys, pinned := generateHostKeys(b, 5000)
+	client := newTestClient(b, &Config{PublicKey: pinned})
+	key := keys[len(keys)-1]
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		common.Must(client.hostKeyCallback("localhost:22", nil, key))
+	}
+}
+
+// pipeDialer hands one end of a loopback connection to the client and serves
+// the other end with the given ssh server config. Channels are passed to
+// handleChannel, or rejected if it is nil. Global requests are rejected, or
+// left unanswered if unresponsive is set.
+type pipeDialer struct {
+	config        *ssh.ServerConfig
+	handleChannel func(ssh.NewChannel)
+	unresponsive  bool
+}
+
+func (d *pipeDialer) Dial(ctx context.Context, destination net.Destination) (internet.Connection, error) {
+	clientConn, serverConn, err := connPair()
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		conn, chans, reqs, err := ssh.NewServerConn(serverConn, d.config)
+		if err != nil {
+			serverConn.Close()
+			return
+		}
+		defer conn.Close()
+		if d.unresponsive {
+			go func() {
+				for range reqs {
+				}
+			}()
+		} else {
+			go ssh.DiscardRequests(reqs)
+		}
+		for newChannel := range chans {
+			if d.handleChannel == nil {
+				newChannel.Reject(ssh.Prohibited, "not supported")
+				continue
+			}
+			go d.handleChannel(newChannel)
+		}
+	}()
+	return clientConn, nil
+}
+
+func (d *pipeDialer) Address() net.Address {
+	return nil
+}
+
+// connPair returns both ends of a loopback TCP connection. Unlike net.Pipe,
+// writes are buffered, so both ssh peers can send their version at once.
+func connPair() (gonet.Conn, gonet.Conn, error) {
+	listener, err := gonet.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer listener.Close()
+	clientConn, err := gonet.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		return nil, nil, err
+	}
+	serverConn, err := listener.Accept()
+	if err != nil {
+		clientConn.Close()
+		return nil, nil, err
+	}
+	return clientConn, serverConn, nil
+}
+
+func newTestServerConfig(t testing.TB) *ssh.ServerConfig {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+	return config
+}
+
+func TestAlgorithmMismatchDiagnostic(t *testing.T) {
+	client := newTestClient(t, &Config{HostKeyAlgorithms: []string{ssh.KeyAlgoRSASHA512}})
+
+	_, _, _, err := client.connect(context.Background(), &pipeDialer{config: newTestServerConfig(t)})
+	if err == nil {
+		t.Fatal("expected handshake to fail")
+	}
+	for _, expected := range []string{
+		"no common host key algorithm",
+		"configured host_key_algorithms: " + ssh.KeyAlgoRSASHA512,
+		"the server likely supports: " + ssh.KeyAlgoED25519,
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Error("expected diagnostic to contain '", expected, "', but actually: ", err)
+		}
+	}
+}
+
+func TestHostKeyAlgorithms(t *testing.T) {
+	client := newTestClient(t, &Config{Password: "secret", HostKeyAlgorithms: []string{ssh.KeyAlgoED25519}})
+	sc, err := client.sshClient(context.Background(), &pipeDialer{config: newTestServerConfig(t)})
+	common.Must(err)
+	sc.Close()
+
+	invalid := &Client{}
+	err = invalid.Init(&Config{
+		Address:                  net.NewIPOrDomain(net.LocalHostIP),
+		Port:                     22,
+		InsecureSkipHostKeyCheck: true,
+		HostKeyAlgorithms:        []string{ssh.KeyAlgoED25519, "ssh-ed448"},
+	}, policy.DefaultManager{}, nil)
+	if err == nil || !strings.Contains(err.Error(), `unknown host key algorithm "ssh-ed448"`) {
+		t.Error("expected an error naming the unknown algorithm, got ", err)
+	}
+}
+
+func TestNegotiatedAlgorithms(t *testing.T) {
+	client := newTestClient(t, &Config{
+		Ciphers:      []string{"aes256-ctr"},
+		Macs:         []string{"hmac-sha1"},
+		KeyExchanges: []string{"ecdh-sha2-nistp384"},
+	})
+	sc, err := client.sshClient(context.Background(), &pipeDialer{config: newTestServerConfig(t)})
+	common.Must(err)
+	sc.Close()
+
+	server := newTestServerConfig(t)
+	server.Ciphers = []string{"aes128-ctr"}
+	_, _, _, err = client.connect(context.Background(), &pipeDialer{config: server})
+	if err == nil || !strings.Contains(err.Error(), "configured ciphers: aes256-ctr") {
+		t.Error("expected a cipher mismatch naming the configured ciphers, got ", err)
+	}
+
+	cases := []struct {
+		config   *Config
+		expected string
+	}{
+		{&Config{Ciphers: []string{"aes128-ctr", "des-cbc"}}, `unknown cipher "des-cbc" in ciphers`},
+		{&Config{Macs: []string{"hmac-md5"}}, `unknown MAC "hmac-md5" in macs`},
+		{&Config{KeyExchanges: []string{"sntrup761x25519-sha512@openssh.com"}}, `unknown key exchange "sntrup761x25519-sha512@openssh.com" in key_exchanges`},
+	}
+	for _, c := range cases {
+		c.config.Address = net.NewIPOrDomain(net.LocalHostIP)
+		c.config.Port = 22
+		c.config.InsecureSkipHostKeyCheck = true
+		err := (&Client{}).Init(c.config, policy.DefaultManager{}, nil)
+		if err == nil || !strings.Contains(err.Error(), c.expected) {
+			t.Error("expected an error containing ", c.expected, ", got ", err)
+		}
+	}
+}
+
+func TestCompressionUnsupported(t *testing.T) {
+	err := (&Client{}).Init(&Config{
+		Address:                  net.NewIPOrDomain(net.LocalHostIP),
+		Port:                     22,
+		InsecureSkipHostKeyCheck: true,
+		Compression:              true,
+	}, policy.DefaultManager{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "compression is not supported") {
+		t.Error("expected compression to be rejected, but got ", err)
+	}
+}
+
+func TestCustomChannelType(t *testing.T) {
+	const channelType = "tunnel@example.com"
+	dialer := &pipeDialer{
+		config: newTestServerConfig(t),
+		handleChannel: func(newChannel ssh.NewChannel) {
+			if newChannel.ChannelType() != channelType {
+				newChannel.Reject(ssh.UnknownChannelType, "unexpected channel type")
+				return
+			}
+			var payload directTCPIPPayload
+			if err := ssh.Unmarshal(newChannel.ExtraData(), &payload); err != nil {
+				newChannel.Reject(ssh.ConnectionFailed, err.Error())
+				return
+			}
+			channel, reqs, err := newChannel.Accept()
+			if err != nil {
+				return
+			}
+			go ssh.DiscardRequests(reqs)
+			channel.Write([]byte(payload.Raddr + ":" + strconv.Itoa(int(payload.Rport))))
+			channel.Close()
+		},
+	}
+
+	client := newTestClient(t, &Config{ChannelType: channelType})
+	_, sc, _, err := client.connect(context.Background(), dialer)
+	common.Must(err)
+	defer sc.Close()
+
+	conn, err := client.openChannel(context.Background(), sc, net.TCPDestination(net.DomainAddress("example.com"), 443))
+	common.Must(err)
+	defer conn.Close()
+
+	received, err := io.ReadAll(conn)
+	common.Must(err)
+	if string(received) != "example.com:443" {
+		t.Error("expected target example.com:443, but actually ", string(received))
+	}
+
+	if c := newTestClient(t, &Config{}); c.config.ChannelType != defaultChannelType {
+		t.Error("expected default channel type ", defaultChannelType, ", but actually ", c.config.ChannelType)
+	}
+}
+
+func TestMultiFactorAuth(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	common.Must(err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	common.Must(err)
+	signer, err := ssh.NewSignerFromKey(private)
+	common.Must(err)
+
+	// The library server can't report partial success, so emulate a
+	// publickey then keyboard-interactive chain by failing the key and
+	// only accepting keyboard-interactive after it was presented.
+	var methods []string
+	presented := false
+	config := newTestServerConfig(t)
+	config.NoClientAuth = false
+	config.PublicKeyCallback = func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+		methods = append(methods, "publickey")
+		presented = string(key.Marshal()) == string(signer.PublicKey().Marshal())
+		return nil, newError("second factor required")
+	}
+	config.KeyboardInteractiveCallback = func(conn ssh.ConnMetadata, challenge ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error) {
+		methods = append(methods, "keyboard-interactive")
+		answers, err := challenge("", "", []string{"Verification code: "}, []bool{false})
+		if err != nil {
+			return nil, err
+		}
+		if !presented || len(answers) != 1 || answers[0] != "123456" {
+			return nil, newError("access denied")
+		}
+		return nil, nil
+	}
+
+	client := newTestClient(t, &Config{
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		Password:   "123456",
+	})
+	_, sc, _, err := client.connect(context.Background(), &pipeDialer{config: config})
+	if err != nil {
+		t.Fatal("multi-factor authentication failed: ", err)
+	}
+	sc.Close()
+
+	if r := cmp.Diff(methods, []string{"publickey", "keyboard-interactive"}); r != "" {
+		t.Error(r)
+	}
+}
+
+func generatePrivateKey(t testing.TB) (string, ssh.PublicKey) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	common.Must(err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	common.Must(err)
+	signer, err := ssh.NewSignerFromKey(private)
+	common.Must(err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), signer.PublicKey()
+}
+
+func TestMultiplePrivateKeys(t *testing.T) {
+	oldKey, oldPublic := generatePrivateKey(t)
+	newKey, newPublic := generatePrivateKey(t)
+
+	var offered []ssh.PublicKey
+	config := newTestServerConfig(t)
+	config.NoClientAuth = false
+	config.PublicKeyCallback = func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+		offered = append(offered, key)
+		if string(key.Marshal()) != string(newPublic.Marshal()) {
+			return nil, newError("unknown key")
+		}
+		return nil, nil
+	}
+
+	client := newTestClient(t, &Config{
+		PrivateKey:  oldKey,
+		PrivateKeys: []string{"not a key", newKey},
+	})
+	_, sc, _, err := client.connect(context.Background(), &pipeDialer{config: config})
+	if err != nil {
+		t.Fatal("authentication with the second key failed: ", err)
+	}
+	sc.Close()
+
+	if len(offered) != 2 || string(offered[0].Marshal()) != string(oldPublic.Marshal()) {
+		t.Error("expected the keys to be offered in order, but actually ", len(offered), " were")
+	}
+
+	err = (&Client{}).Init(&Config{
+		Address:     net.NewIPOrDomain(net.LocalHostIP),
+		Port:        22,
+		PrivateKeys: []string{"not a key", "neither"},
+	}, policy.DefaultManager{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "no valid private key") || !strings.Contains(err.Error(), "private_keys[1]") {
+		t.Error("expected an error naming the invalid keys, but actually ", err)
+	}
+}
+
+func TestAllowEmptyUser(t *testing.T) {
+	users := make(chan string, 1)
+	config := newTestServerConfig(t)
+	config.AuthLogCallback = func(conn ssh.ConnMetadata, method string, err error) {
+		select {
+		case users <- conn.User():
+		default:
+		}
+	}
+
+	client := newTestClient(t, &Config{AllowEmptyUser: true})
+	_, sc, _, err := client.connect(context.Background(), &pipeDialer{config: config})
+	common.Must(err)
+	sc.Close()
+
+	if user := <-users; user != "" {
+		t.Error("expected empty user, but actually ", user)
+	}
+
+	if c := newTestClient(t, &Config{}); c.config.User != "root" {
+		t.Error("expected default user root, but actually ", c.config.User)
+	}
+}
+
+func TestReuseConnectionAcrossReload(t *testing.T) {
+	dialer := &pipeDialer{config: newTestServerConfig(t)}
+
+	old := newTestClient(t, &Config{Password: "secret", ReuseConnection: true})
+	sc, err := old.sshClient(context.Background(), dialer)
+	common.Must(err)
+	defer sc.Close()
+
+	reloaded := newTestClient(t, &Config{Password: "secret", ReuseConnection: true})
+	common.Must(old.Close())
+	if reloaded.slots[0].client != sc {
+		t.Error("expected reloaded client to adopt the live connection")
+	}
+
+	other := newTestClient(t, &Config{Password: "other", ReuseConnection: true})
+	if other.slots[0].client != nil {
+		t.Error("connection shared with different credentials")
+	}
+	common.Must(other.Close())
+
+	common.Must(reloaded.Close())
+}
+
+type systemDialer struct{}
+
+func (systemDialer) Dial(ctx context.Context, destination net.Destination) (internet.Connection, error) {
+	return internet.DialSystem(ctx, destination, nil)
+}
+
+func (systemDialer) Address() net.Address {
+	return nil
+}
+
+func TestEchoRoundTrip(t *testing.T) {
+	server := &echoServer{Passwords: map[string]string{"v2ray": "secret"}}
+	dest, err := server.Start()
+	common.Must(err)
+	defer server.Close()
+
+	client := newTestClient(t, &Config{
+		Address:   net.NewIPOrDomain(dest.Address),
+		Port:      uint32(dest.Port),
+		User:      "v2ray",
+		Password:  "secret",
+		PublicKey: string(ssh.MarshalAuthorizedKey(server.HostKey.PublicKey())),
+	})
+	sc, err := client.sshClient(context.Background(), systemDialer{})
+	common.Must(err)
+	defer sc.Close()
+
+	conn, err := client.openChannel(context.Background(), sc, net.TCPDestination(net.DomainAddress("example.com"), 80))
+	common.Must(err)
+	defer conn.Close()
+
+	payload := make([]byte, 64*1024)
+	common.Must2(rand.Read(payload))
+	go func() {
+		conn.Write(payload)
+		conn.(*channelConn).CloseWrite()
+	}()
+	received, err := io.ReadAll(conn)
+	common.Must(err)
+	if !bytes.Equal(received, payload) {
+		t.Error("payload mismatch after echo, received ", len(received), " bytes")
+	}
+}
+
+// delayDialer serves each destination port with its own ssh server config,
+// after the delay given for that port.
+type delayDialer struct {
+	configs map[net.Port]*ssh.ServerConfig
+	delays  map[net.Port]time.Duration
+}
+
+func (d *delayDialer) Dial(ctx context.Context, destination net.Destination) (internet.Connection, error) {
+	select {
+	case <-time.After(d.delays[destination.Port]):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	dialer := &pipeDialer{config: d.configs[destination.Port]}
+	return dialer.Dial(ctx, destination)
+}
+
+func (d *delayDialer) Address() net.Address {
+	return nil
+}
+
+func TestParallelDialStrategy(t *testing.T) {
+	slow := newTestServerConfig(t)
+	slow.ServerVersion = "SSH-2.0-slow"
+	fast := newTestServerConfig(t)
+	fast.ServerVersion = "SSH-2.0-fast"
+	dialer := &delayDialer{
+		configs: map[net.Port]*ssh.ServerConfig{22: slow, 23: fast},
+		delays:  map[net.Port]time.Duration{22: time.Second},
+	}
+
+	client := newTestClient(t, &Config{
+		Port: 22,
+		Servers: []*Endpoint{
+			{Address: net.NewIPOrDomain(net.LocalHostIP), Port: 23},
+		},
+		DialStrategy: DialStrategy_Parallel,
+	})
+	start := time.Now()
+	_, sc, _, err := client.connect(context.Background(), dialer)
+	common.Must(err)
+	defer sc.Close()
+
+	if version := string(sc.ServerVersion()); version != "SSH-2.0-fast" {
+		t.Error("expected fast server to win, but connected to ", version)
+	}
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Error("parallel dial waited for the slow server: ", elapsed)
+	}
+}
+
+func TestHostCertAuthorities(t *testing.T) {
+	newSigner := func() ssh.Signer {
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		common.Must(err)
+		signer, err := ssh.NewSignerFromKey(private)
+		common.Must(err)
+		return signer
+	}
+	authority := newSigner()
+	hostKey := newSigner()
+	cert := &ssh.Certificate{
+		Key:             hostKey.PublicKey(),
+		CertType:        ssh.HostCert,
+		ValidPrincipals: []string{"ssh.example.com"},
+		ValidBefore:     ssh.CertTimeInfinity,
+	}
+	common.Must(cert.SignCert(rand.Reader, authority))
+	certSigner, err := ssh.NewCertSigner(cert, hostKey)
+	common.Must(err)
+
+	serverConfig := &ssh.ServerConfig{NoClientAuth: true}
+	serverConfig.AddHostKey(certSigner)
+	dialer := &pipeDialer{config: serverConfig}
+	authorities := []string{string(ssh.MarshalAuthorizedKey(authority.PublicKey()))}
+
+	client := newTestClient(t, &Config{
+		Address:             net.NewIPOrDomain(net.DomainAddress("ssh.example.com")),
+		HostCertAuthorities: authorities,
+	})
+	_, sc, _, err := client.connect(context.Background(), dialer)
+	if err != nil {
+		t.Fatal("host certificate rejected: ", err)
+	}
+	sc.Close()
+
+	client = newTestClient(t, &Config{
+		Address:             net.NewIPOrDomain(net.DomainAddress("other.example.com")),
+		HostCertAuthorities: authorities,
+	})
+	if _, _, _, err := client.connect(context.Background(), dialer); err == nil {
+		t.Error("expected host certificate for another principal to be rejected")
+	}
+}
+
+func TestThrottledServer(t *testing.T) {
+	defer func(backoff time.Duration) {
+		throttleBackoff = backoff
+	}(throttleBackoff)
+	throttleBackoff = 20 * time.Millisecond
+
+	listener, err := gonet.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	defer listener.Close()
+	config := newTestServerConfig(t)
+	var access sync.Mutex
+	var accepted []time.Time
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			access.Lock()
+			accepted = append(accepted, time.Now())
+			attempt := len(accepted)
+			access.Unlock()
+			if attempt <= 2 {
+				// Dropped before the version exchange, like sshd over MaxStartups.
+				conn.Close()
+				continue
+			}
+			go func() {
+				sc, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				defer sc.Close()
+				go ssh.DiscardRequests(reqs)
+				for newChannel := range chans {
+					newChannel.Reject(ssh.Prohibited, "not supported")
+				}
+			}()
+		}
+	}()
+
+	port := listener.Addr().(*gonet.TCPAddr).Port
+	client := newTestClient(t, &Config{Port: uint32(port)})
+	_, sc, _, err := client.connect(context.Background(), systemDialer{})
+	if err != nil {
+		t.Fatal("expected connection after throttling, but got ", err)
+	}
+	defer sc.Close()
+
+	access.Lock()
+	defer access.Unlock()
+	if len(accepted) != 3 {
+		t.Fatal("expected 3 connection attempts, but actually ", len(accepted))
+	}
+	for i := 1; i < len(accepted); i++ {
+		if wait := accepted[i].Sub(accepted[i-1]); wait < throttleBackoff<<(i-1)/2 {
+			t.Error("retry ", i, " after ", wait, ", expected a backoff")
+		}
+	}
+}
+
+func TestResetServerNotThrottled(t *testing.T) {
+	listener, err := gonet.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	defer listener.Close()
+	var accepted int32
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&accepted, 1)
+			// Reset instead of a clean close, nothing like MaxStartups.
+			conn.(*gonet.TCPConn).SetLinger(0)
+			conn.Close()
+		}
+	}()
+
+	port := listener.Addr().(*gonet.TCPAddr).Port
+	client := newTestClient(t, &Config{Port: uint32(port)})
+	_, _, _, err = client.connect(context.Background(), systemDialer{})
+	if err == nil {
+		t.Fatal("expected the connection to fail")
+	}
+	if strings.Contains(err.Error(), "throttling") {
+		t.Error("reset connection taken for throttling: ", err)
+	}
+	if n := atomic.LoadInt32(&accepted); n != 1 {
+		t.Error("expected 1 connection attempt, but actually ", n)
+	}
+}
+
+func TestExpectBannerContains(t *testing.T) {
+	const expected = "Authorized use only"
+	cases := []struct {
+		banner string
+		accept bool
+	}{
+		{"*** Authorized use only. Activity is monitored. ***\n", true},
+		{"Welcome!\n", false},
+		{"", false},
+	}
+	for _, c := range cases {
+		config := newTestServerConfig(t)
+		banner := c.banner
+		config.BannerCallback = func(conn ssh.ConnMetadata) string {
+			return banner
+		}
+		client := newTestClient(t, &Config{ExpectBannerContains: expected})
+		_, sc, _, err := client.connect(context.Background(), &pipeDialer{config: config})
+		if c.accept {
+			if err != nil {
+				t.Error("expected banner ", strconv.Quote(c.banner), " to be accepted, but got ", err)
+				continue
+			}
+			sc.Close()
+		} else if err == nil {
+			sc.Close()
+			t.Error("expected banner ", strconv.Quote(c.banner), " to be rejected")
+		}
+	}
+}
+
+func TestHostKeyLogDeduplicated(t *testing.T) {
+	recorder := logtest.Capture(t)
+	countKeyLogs := func() int {
+		n := 0
+		for _, msg := range recorder.Messages() {
+			if strings.Contains(msg.String(), "please save server public key") {
+				n++
+			}
+		}
+		return n
+	}
+
+	client := newTestClient(t, &Config{})
+	config := newTestServerConfig(t)
+	for i := 0; i < 5; i++ {
+		_, sc, _, err := client.connect(context.Background(), &pipeDialer{config: config})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sc.Close()
+	}
+	if n := countKeyLogs(); n != 1 {
+		t.Errorf("got %d host key logs for an unchanged key, want 1", n)
+	}
+
+	_, sc, _, err := client.connect(context.Background(), &pipeDialer{config: newTestServerConfig(t)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc.Close()
+	if n := countKeyLogs(); n != 2 {
+		t.Errorf("got %d host key logs after the key changed, want 2", n)
+	}
+}
+
+func TestOriginatorPort(t *testing.T) {
+	dialer := &pipeDialer{
+		config: newTestServerConfig(t),
+		handleChannel: func(newChannel ssh.NewChannel) {
+			var payload directTCPIPPayload
+			if err := ssh.Unmarshal(newChannel.ExtraData(), &payload); err != nil {
+				newChannel.Reject(ssh.ConnectionFailed, err.Error())
+				return
+			}
+			channel, reqs, err := newChannel.Accept()
+			if err != nil {
+				return
+			}
+			go ssh.DiscardRequests(reqs)
+			channel.Write([]byte(strconv.Itoa(int(payload.Lport))))
+			channel.Close()
+		},
+	}
+	ctx := session.ContextWithInbound(context.Background(), &session.Inbound{
+		Source: net.TCPDestination(net.LocalHostIP, 40000),
+	})
+
+	cases := []struct {
+		originatorPort uint32
+		want           string
+	}{
+		{2222, "2222"},
+		{0, "40000"},
+	}
+	for _, c := range cases {
+		client := newTestClient(t, &Config{OriginatorPort: c.originatorPort})
+		_, sc, _, err := client.connect(ctx, dialer)
+		common.Must(err)
+
+		conn, err := client.openChannel(ctx, sc, net.TCPDestination(net.DomainAddress("example.com"), 443))
+		common.Must(err)
+		received, err := io.ReadAll(conn)
+		common.Must(err)
+		conn.Close()
+		sc.Close()
+
+		if string(received) != c.want {
+			t.Error("expected originator port ", c.want, ", but server saw ", string(received))
+		}
+	}
+}
+
+// stallDialer connects to a server that sends its version and the length of
+// a packet, then trickles the packet a byte now and then.
+type stallDialer struct{}
+
+func (stallDialer) Dial(ctx context.Context, destination net.Destination) (internet.Connection, error) {
+	clientConn, serverConn, err := connPair()
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		defer serverConn.Close()
+		if _, err := serverConn.Write([]byte("SSH-2.0-OpenSSH_8.9\r\n\x00\x00\x01\x00")); err != nil {
+			return
+		}
+		go io.Copy(io.Discard, serverConn)
+		for {
+			time.Sleep(100 * time.Millisecond)
+			if _, err := serverConn.Write([]byte{8}); err != nil {
+				return
+			}
+		}
+	}()
+	return clientConn, nil
+}
+
+func (stallDialer) Address() net.Address {
+	return nil
+}
+
+func TestHandshakeDeadline(t *testing.T) {
+	client := newTestClient(t, &Config{HandshakeDeadline: 1})
+
+	start := time.Now()
+	_, _, _, err := client.connect(context.Background(), stallDialer{})
+	if err == nil {
+		t.Fatal("expected stalled handshake to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Error("watchdog fired after ", elapsed)
+	}
+	if !strings.Contains(err.Error(), "did not complete within") {
+		t.Error("expected handshake deadline error, but got ", err)
+	}
+}
+
+// hangDialer never connects, like a server whose packets are dropped.
+type hangDialer struct{}
+
+func (hangDialer) Dial(ctx context.Context, destination net.Destination) (internet.Connection, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (hangDialer) Address() net.Address {
+	return nil
+}
+
+// lateDialer connects to a server that starts its handshake after delay.
+type lateDialer struct {
+	config *ssh.ServerConfig
+	delay  time.Duration
+}
+
+func (d lateDialer) Dial(ctx context.Context, destination net.Destination) (internet.Connection, error) {
+	clientConn, serverConn, err := connPair()
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		time.Sleep(d.delay)
+		conn, chans, reqs, err := ssh.NewServerConn(serverConn, d.config)
+		if err != nil {
+			serverConn.Close()
+			return
+		}
+		defer conn.Close()
+		go ssh.DiscardRequests(reqs)
+		for newChannel := range chans {
+			newChannel.Reject(ssh.Prohibited, "not supported")
+		}
+	}()
+	return clientConn, nil
+}
+
+func (lateDialer) Address() net.Address {
+	return nil
+}
+
+func TestConnectTimeout(t *testing.T) {
+	client := newTestClient(t, &Config{ConnectTimeout: 1})
+	if deadline := client.handshakeDeadline(); deadline != defaultHandshakeDeadline {
+		t.Fatal("expected the default handshake deadline, but actually ", deadline)
+	}
+
+	start := time.Now()
+	_, _, _, err := client.connect(context.Background(), hangDialer{})
+	if err == nil {
+		t.Fatal("expected hanging dial to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Error("connect timeout fired after ", elapsed)
+	}
+
+	if timeout := newTestClient(t, &Config{}).connectTimeout(); timeout != defaultConnectTimeout {
+		t.Error("expected default connect timeout ", defaultConnectTimeout, ", but actually ", timeout)
+	}
+}
+
+func TestConnectTimeoutLeavesHandshakeDeadline(t *testing.T) {
+	// The handshake may take up to its deadline beyond the connect timeout.
+	client := newTestClient(t, &Config{ConnectTimeout: 1, HandshakeDeadline: 30})
+	if limit := client.handshakeLimit(context.Background(), client.servers[0]); limit != 30*time.Second {
+		t.Error("expected the handshake deadline of 30s, but actually ", limit)
+	}
+	_, sc, _, err := client.connect(context.Background(), lateDialer{config: newTestServerConfig(t), delay: 2 * time.Second})
+	if err != nil {
+		t.Fatal("expected a handshake longer than the connect timeout to complete, but got ", err)
+	}
+	sc.Close()
+}
+
+func TestHandshakeOverConn(t *testing.T) {
+	// A connection made outside of any internet.Dialer, standing for one from
+	// an embedder's transport.
+	clientConn, serverConn, err := connPair()
+	common.Must(err)
+	go func() {
+		conn, chans, reqs, err := ssh.NewServerConn(serverConn, newTestServerConfig(t))
+		if err != nil {
+			serverConn.Close()
+			return
+		}
+		defer conn.Close()
+		go ssh.DiscardRequests(reqs)
+		for newChannel := range chans {
+			channel, channelReqs, err := newChannel.Accept()
+			if err != nil {
+				continue
+			}
+			go ssh.DiscardRequests(channelReqs)
+			channel.Write([]byte("over pipe"))
+			channel.Close()
+		}
+	}()
+
+	client := newTestClient(t, &Config{})
+	server := client.newServer(net.TCPDestination(net.DomainAddress("server.example.com"), 22))
+	_, sc, err := client.handshakeConn(context.Background(), clientConn, server)
+	if err != nil {
+		t.Fatal("handshake over the provided connection failed: ", err)
+	}
+	defer sc.Close()
+
+	conn, err := client.openChannel(context.Background(), sc, net.TCPDestination(net.DomainAddress("example.com"), 80))
+	common.Must(err)
+	defer conn.Close()
+	received, err := io.ReadAll(conn)
+	common.Must(err)
+	if string(received) != "over pipe" {
+		t.Error("expected 'over pipe', but actually ", string(received))
+	}
+}
+
+func TestReconnectOnWriteFailure(t *testing.T) {
+	var channels int32
+	dialer := &pipeDialer{
+		config: newTestServerConfig(t),
+		handleChannel: func(newChannel ssh.NewChannel) {
+			channel, reqs, err := newChannel.Accept()
+			if err != nil {
+				return
+			}
+			go ssh.DiscardRequests(reqs)
+			defer channel.Close()
+			if atomic.AddInt32(&channels, 1) == 1 {
+				// Behave like a stale connection, where writes fail.
+				return
+			}
+			b := make([]byte, 5)
+			if _, err := io.ReadFull(channel, b); err == nil {
+				channel.Write(b)
+			}
+		},
+	}
+
+	client := newTestClient(t, &Config{ReconnectOnWriteFailure: true})
+	ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{
+		Target: net.TCPDestination(net.DomainAddress("example.com"), 443),
+	})
+	uplinkReader, uplinkWriter := pipe.New()
+	downlinkReader, downlinkWriter := pipe.New()
+	go client.Process(ctx, &transport.Link{Reader: uplinkReader, Writer: downlinkWriter}, dialer)
+
+	// Let the channel close before the first write.
+	time.Sleep(200 * time.Millisecond)
+	common.Must(uplinkWriter.WriteMultiBuffer(buf.MergeBytes(nil, []byte("hello"))))
+
+	var received []byte
+	for len(received) < 5 {
+		mb, err := downlinkReader.ReadMultiBufferTimeout(5 * time.Second)
+		if err != nil {
+			t.Fatal("no reply after reconnecting: ", err)
+		}
+		for _, b := range mb {
+			received = append(received, b.Bytes()...)
+		}
+		buf.ReleaseMulti(mb)
+	}
+	if string(received) != "hello" {
+		t.Error("expected hello, but actually ", string(received))
+	}
+	if n := atomic.LoadInt32(&channels); n != 2 {
+		t.Error("expected 2 channels, but actually ", n)
+	}
+	uplinkWriter.Close()
+}
+
+func TestKeepAliveDropsDeadConnection(t *testing.T) {
+	dialer := &pipeDialer{config: newTestServerConfig(t), unresponsive: true}
+	client := newTestClient(t, &Config{KeepAliveInterval: 1})
+	defer client.Close()
+	ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{
+		Target: net.TCPDestination(net.DomainAddress("example.com"), 443),
+	})
+
+	sc, err := client.sshClient(ctx, dialer)
+	common.Must(err)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		slot := client.slots[0]
+		slot.Lock()
+		current := slot.client
+		slot.Unlock()
+		if current == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("connection with unanswered keepalives was not dropped")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if _, _, err := sc.SendRequest(keepAliveRequest, false, nil); err == nil {
+		t.Error("expected the dropped connection to be closed")
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	buf.ReleaseMulti(mb)
+	return newError("client went away")
+}
+
+func TestCopyErrorDirection(t *testing.T) {
+	dialer := &pipeDialer{
+		config: newTestServerConfig(t),
+		handleChannel: func(newChannel ssh.NewChannel) {
+			channel, reqs, err := newChannel.Accept()
+			if err != nil {
+				return
+			}
+			go ssh.DiscardRequests(reqs)
+			defer channel.Close()
+			channel.Write([]byte("hello"))
+			io.Copy(io.Discard, channel)
+		},
+	}
+
+	client := newTestClient(t, &Config{})
+	ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{
+		Target: net.TCPDestination(net.DomainAddress("example.com"), 443),
+	})
+	recorder := logtest.Capture(t)
+	uplinkReader, uplinkWriter := pipe.New()
+	defer uplinkWriter.Close()
+	err := client.Process(ctx, &transport.Link{Reader: uplinkReader, Writer: failingWriter{}}, dialer)
+	if err == nil {
+		t.Fatal("expected the failing downlink to end the connection")
+	}
+	recorder.AssertContains(log.Severity_Debug, "downlink write failed after 0 bytes sent and 5 received")
+	recorder.AssertNotContains(log.Severity_Debug, "uplink")
+}
+
+func TestDrainStatus(t *testing.T) {
+	dialer := &pipeDialer{
+		config: newTestServerConfig(t),
+		handleChannel: func(newChannel ssh.NewChannel) {
+			channel, reqs, err := newChannel.Accept()
+			if err != nil {
+				return
+			}
+			go ssh.DiscardRequests(reqs)
+			defer channel.Close()
+			b := make([]byte, 3)
+			io.ReadFull(channel, b)
+		},
+	}
+
+	client := newTestClient(t, &Config{})
+	ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{
+		Target: net.TCPDestination(net.DomainAddress("example.com"), 443),
+	})
+	waitChannels := func(expected int64) {
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			status := client.DrainStatus()
+			if status.ActiveChannels == expected {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("expected ", expected, " active channels, but actually ", status.ActiveChannels)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// Connect first, so both channels share the connection.
+	common.Must2(client.sshClient(ctx, dialer))
+	var uplinks []*pipe.Writer
+	for i := 0; i < 2; i++ {
+		uplinkReader, uplinkWriter := pipe.New()
+		_, downlinkWriter := pipe.New()
+		uplinks = append(uplinks, uplinkWriter)
+		go client.Process(ctx, &transport.Link{Reader: uplinkReader, Writer: downlinkWriter}, dialer)
+	}
+	waitChannels(2)
+
+	for i, uplink := range uplinks {
+		common.Must(uplink.WriteMultiBuffer(buf.MergeBytes(nil, []byte("bye"))))
+		uplink.Close()
+		waitChannels(int64(len(uplinks) - i - 1))
+	}
+	if status := client.DrainStatus(); status.BytesInFlight != 0 {
+		t.Error("expected no bytes in flight, but actually ", status.BytesInFlight)
+	}
+}
+
+// slowDialer counts the connections made through pipeDialer, each after a
+// delay, failing those while fail is set.
+type slowDialer struct {
+	pipeDialer
+	delay time.Duration
+	dials int32
+	fail  int32
+}
+
+func (d *slowDialer) Dial(ctx context.Context, destination net.Destination) (internet.Connection, error) {
+	atomic.AddInt32(&d.dials, 1)
+	time.Sleep(d.delay)
+	if atomic.LoadInt32(&d.fail) != 0 {
+		return nil, newError("refused")
+	}
+	return d.pipeDialer.Dial(ctx, destination)
+}
+
+func TestConcurrentHandshake(t *testing.T) {
+	dialer := &slowDialer{
+		pipeDialer: pipeDialer{
+			config: newTestServerConfig(t),
+			handleChannel: func(newChannel ssh.NewChannel) {
+				channel, reqs, err := newChannel.Accept()
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				channel.Close()
+			},
+		},
+		delay: 200 * time.Millisecond,
+	}
+	client := newTestClient(t, &Config{})
+	defer client.Close()
+	ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{
+		Target: net.TCPDestination(net.DomainAddress("example.com"), 443),
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			uplinkReader, uplinkWriter := pipe.New()
+			_, downlinkWriter := pipe.New()
+			uplinkWriter.Close()
+			if err := client.Process(ctx, &transport.Link{Reader: uplinkReader, Writer: downlinkWriter}, dialer); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if dials := atomic.LoadInt32(&dialer.dials); dials != 1 {
+		t.Error("expected a single handshake, but actually ", dials)
+	}
+
+	// A failed handshake is not remembered, the next caller dials again.
+	failing := newTestClient(t, &Config{})
+	dialer.delay = 0
+	atomic.StoreInt32(&dialer.fail, 1)
+	atomic.StoreInt32(&dialer.dials, 0)
+	if _, err := failing.sshClient(context.Background(), dialer); err == nil {
+		t.Fatal("expected the handshake to fail")
+	}
+	atomic.StoreInt32(&dialer.fail, 0)
+	sc, err := failing.sshClient(context.Background(), dialer)
+	if err != nil {
+		t.Fatal("expected a new handshake after the failure, but got ", err)
+	}
+	sc.Close()
+}
+
+func TestConnectionPool(t *testing.T) {
+	dialer := &pipeDialer{config: newTestServerConfig(t)}
+	client := newTestClient(t, &Config{Password: "secret", MaxConnections: 2})
+	defer client.Close()
+
+	clients := make(map[*ssh.Client]int)
+	for i := 0; i < 4; i++ {
+		sc, err := client.sshClient(context.Background(), dialer)
+		common.Must(err)
+		clients[sc]++
+	}
+	if len(clients) != 2 {
+		t.Fatal("expected 2 pooled connections, but actually ", len(clients))
+	}
+	for _, uses := range clients {
+		if uses != 2 {
+			t.Error("expected round-robin use of connections, but one was used ", uses, " times")
+		}
+	}
+
+	closed := client.slots[0].client
+	kept := client.slots[1].client
+	closed.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		client.slots[0].Lock()
+		emptied := client.slots[0].client == nil
+		client.slots[0].Unlock()
+		if emptied {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("closed connection not removed from the pool")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for i := 0; i < 2; i++ {
+		common.Must2(client.sshClient(context.Background(), dialer))
+	}
+	if client.slots[1].client != kept {
+		t.Error("expected the other connection to be kept")
+	}
+	if replaced := client.slots[0].client; replaced == nil || replaced == closed {
+		t.Error("expected the closed connection to be replaced")
+	}
+
+	single := newTestClient(t, &Config{Password: "secret"})
+	defer single.Close()
+	first, err := single.sshClient(context.Background(), dialer)
+	common.Must(err)
+	if second, _ := single.sshClient(context.Background(), dialer); second != first {
+		t.Error("expected a single connection without max_connections")
+	}
+}
+
+// peakCounter is a stats counter remembering its highest value.
+type peakCounter struct {
+	sync.Mutex
+	value int64
+	peak  int64
+}
+
+func (c *peakCounter) Value() int64 {
+	c.Lock()
+	defer c.Unlock()
+	return c.value
+}
+
+func (c *peakCounter) Set(value int64) int64 {
+	c.Lock()
+	defer c.Unlock()
+	previous := c.value
+	c.value = value
+	if value > c.peak {
+		c.peak = value
+	}
+	return previous
+}
+
+func (c *peakCounter) Add(delta int64) int64 {
+	c.Lock()
+	defer c.Unlock()
+	previous := c.value
+	c.value += delta
+	if c.value > c.peak {
+		c.peak = c.value
+	}
+	return previous
+}
+
+func TestMaxChannelsPerConnection(t *testing.T) {
+	var open, peak int32
+	dialer := &pipeDialer{
+		config: newTestServerConfig(t),
+		handleChannel: func(newChannel ssh.NewChannel) {
+			if n := atomic.AddInt32(&open, 1); n > 2 {
+				// The server would refuse it.
+				atomic.AddInt32(&open, -1)
+				newChannel.Reject(ssh.ResourceShortage, "too many channels")
+				return
+			} else if n > atomic.LoadInt32(&peak) {
+				atomic.StoreInt32(&peak, n)
+			}
+			channel, reqs, err := newChannel.Accept()
+			if err != nil {
+				atomic.AddInt32(&open, -1)
+				return
+			}
+			go ssh.DiscardRequests(reqs)
+			time.Sleep(50 * time.Millisecond)
+			// Counted closed before the client sees the end of the channel.
+			atomic.AddInt32(&open, -1)
+			channel.Close()
+		},
+	}
+	client := newTestClient(t, &Config{MaxChannelsPerConnection: 2})
+	defer client.Close()
+	counter := &peakCounter{}
+	client.channelsCounter = counter
+	ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{
+		Target: net.TCPDestination(net.DomainAddress("example.com"), 443),
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			uplinkReader, uplinkWriter := pipe.New()
+			_, downlinkWriter := pipe.New()
+			uplinkWriter.Close()
+			if err := client.Process(ctx, &transport.Link{Reader: uplinkReader, Writer: downlinkWriter}, dialer); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&peak); n != 2 {
+		t.Error("expected 2 channels open at most, but actually ", n)
+	}
+	if counter.peak != 2 || counter.Value() != 0 {
+		t.Error("expected the channels counter to peak at 2 and end at 0, but actually ", counter.peak, " and ", counter.Value())
+	}
+
+	waiting := newTestClient(t, &Config{MaxChannelsPerConnection: 1})
+	_, release, err := waiting.acquireChannel(context.Background())
+	common.Must(err)
+	defer release()
+	timeout, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, _, err := waiting.acquireChannel(timeout); err == nil {
+		t.Error("expected waiting for a free channel to end with the context")
+	}
+}
+
+func TestHostKeyCheckTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	slow := func(hostname string, remote gonet.Addr, key ssh.PublicKey) error {
+		<-release
+		return nil
+	}
+
+	client := newTestClient(t, &Config{Password: "secret"})
+	for _, server := range client.servers {
+		server.hostKeyCallback = hostKeyCallbackWithTimeout(slow, 100*time.Millisecond)
+	}
+
+	start := time.Now()
+	_, _, _, err := client.connect(context.Background(), &pipeDialer{config: newTestServerConfig(t)})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatal("expected the handshake to fail on the verification timeout, got ", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Error("handshake aborted only after ", elapsed)
+	}
+
+	keys, _ := generateHostKeys(t, 1)
+	fast := hostKeyCallbackWithTimeout(func(string, gonet.Addr, ssh.PublicKey) error { return nil }, time.Second)
+	if err := fast("localhost:22", nil, keys[0]); err != nil {
+		t.Error("fast verification failed: ", err)
+	}
+}
+
+func TestMaxConnectionDuration(t *testing.T) {
+	dialer := &pipeDialer{
+		config: newTestServerConfig(t),
+		handleChannel: func(newChannel ssh.NewChannel) {
+			channel, reqs, err := newChannel.Accept()
+			if err != nil {
+				return
+			}
+			go ssh.DiscardRequests(reqs)
+			io.Copy(channel, channel)
+			channel.Close()
+		},
+	}
+	client := newTestClient(t, &Config{MaxConnectionDuration: 1})
+	defer client.Close()
+	ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{
+		Target: net.TCPDestination(net.DomainAddress("example.com"), 443),
+	})
+	uplinkReader, uplinkWriter := pipe.New()
+	downlinkReader, downlinkWriter := pipe.New()
+	defer uplinkWriter.Close()
+
+	// Keep the transfer active, so only the maximum duration can end it.
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := uplinkWriter.WriteMultiBuffer(buf.MergeBytes(nil, []byte("data"))); err != nil {
+					return
+				}
+			}
+		}
+	}()
+	go func() {
+		for {
+			mb, err := downlinkReader.ReadMultiBuffer()
+			if err != nil {
+				return
+			}
+			buf.ReleaseMulti(mb)
+		}
+	}()
+
+	start := time.Now()
+	err := client.Process(ctx, &transport.Link{Reader: uplinkReader, Writer: downlinkWriter}, dialer)
+	elapsed := time.Since(start)
+	if err == nil || !strings.Contains(err.Error(), "max_connection_duration") {
+		t.Error("expected the connection to be closed at max_connection_duration, but got ", err)
+	}
+	if elapsed < time.Second || elapsed > 3*time.Second {
+		t.Error("expected the connection to last about 1s, but actually ", elapsed)
+	}
+}
+
+func TestChannelOutcome(t *testing.T) {
+	reason := ssh.ConnectionFailed
+	dialer := &pipeDialer{
+		config: newTestServerConfig(t),
+		handleChannel: func(newChannel ssh.NewChannel) {
+			newChannel.Reject(reason, "Connection refused")
+		},
+	}
+	client := newTestClient(t, &Config{})
+	defer client.Close()
+	ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{
+		Target: net.TCPDestination(net.DomainAddress("example.com"), 443),
+	})
+	ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{From: "127.0.0.1:1001", To: "tcp:example.com:443", Status: log.AccessAccepted})
+
+	for _, c := range []struct {
+		reason  ssh.RejectionReason
+		outcome log.Outcome
+	}{
+		{ssh.ConnectionFailed, log.OutcomeConnectRefused},
+		{ssh.Prohibited, log.OutcomeBlocked},
+	} {
+		reason = c.reason
+		recorder := logtest.Capture(t)
+		uplinkReader, _ := pipe.New()
+		_, downlinkWriter := pipe.New()
+		err := client.Process(ctx, &transport.Link{Reader: uplinkReader, Writer: downlinkWriter}, dialer)
+		if err == nil {
+			t.Fatal("expected the channel to be refused")
+		}
+		log.RecordAccessFailure(ctx, err)
+		var outcome log.Outcome
+		for _, msg := range recorder.Messages() {
+			if access, ok := msg.(*log.AccessMessage); ok {
+				outcome = access.Outcome
+			}
+		}
+		if outcome != c.outcome {
+			t.Error("expected outcome ", c.outcome, " for ", c.reason, ", but actually ", outcome)
+		}
+	}
+}
+
+func TestBenignCloseLoggedAtDebug(t *testing.T) {
+	client := newTestClient(t, &Config{})
+	recorder := logtest.Capture(t)
+
+	closed := newError("failed to read").Base(&gonet.OpError{Op: "read", Net: "tcp", Err: gonet.ErrClosed})
+	if err := client.endError(context.Background(), closed); err != nil {
+		t.Error("expected a benign close to end the connection without error, but got ", err)
+	}
+	recorder.AssertContains(log.Severity_Debug, "use of closed network connection")
+	recorder.AssertNotContains(log.Severity_Warning, "use of closed network connection")
+
+	reset := newError("failed to read").Base(&gonet.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)})
+	if err := client.endError(context.Background(), reset); err == nil {
+		t.Error("expected a reset connection to be reported")
+	}
+}
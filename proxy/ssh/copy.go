@@ -0,0 +1,143 @@
+package ssh
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/v2fly/v2ray-core/v5/common/buf"
+	"github.com/v2fly/v2ray-core/v5/common/net"
+)
+
+// interactivePorts are the destination ports BufferMode_Auto treats as
+// interactive.
+var interactivePorts = map[net.Port]bool{
+	22:   true, // ssh
+	23:   true, // telnet
+	80:   true, // http
+	3389: true, // rdp
+	5900: true, // vnc
+}
+
+// copyConfig holds the parameters for copying between a link and a channel.
+type copyConfig struct {
+	// readAhead is the size of the buffer reading from the channel, 0 to
+	// pass on every read directly.
+	readAhead int
+	// coalesce merges the buffers of a write into a single channel write.
+	coalesce bool
+}
+
+var (
+	interactiveCopy = copyConfig{}
+	bulkCopy        = copyConfig{readAhead: 64 * 1024, coalesce: true}
+)
+
+func copyConfigFor(mode BufferMode, destination net.Destination) copyConfig {
+	switch mode {
+	case BufferMode_Interactive:
+		return interactiveCopy
+	case BufferMode_Bulk:
+		return bulkCopy
+	default:
+		if interactivePorts[destination.Port] {
+			return interactiveCopy
+		}
+		return bulkCopy
+	}
+}
+
+func (c copyConfig) reader(conn net.Conn) buf.Reader {
+	if c.readAhead == 0 {
+		return buf.NewReader(conn)
+	}
+	return &readAheadReader{bufio.NewReaderSize(conn, c.readAhead)}
+}
+
+func (c copyConfig) writer(conn net.Conn) buf.Writer {
+	if !c.coalesce {
+		return buf.NewWriter(conn)
+	}
+	return &coalescingWriter{conn}
+}
+
+// readAheadReader returns everything its buffer holds after each read, so
+// data arriving in bursts is passed on in one go.
+type readAheadReader struct {
+	*bufio.Reader
+}
+
+func (r *readAheadReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	b, err := buf.ReadBuffer(r.Reader)
+	if err != nil {
+		return buf.MultiBuffer{b}, err
+	}
+	mb := buf.MultiBuffer{b}
+	for r.Buffered() > 0 {
+		// Served from the buffer without blocking.
+		b, err := buf.ReadBuffer(r.Reader)
+		if b != nil {
+			mb = append(mb, b)
+		}
+		if err != nil {
+			break
+		}
+	}
+	return mb, nil
+}
+
+// coalescingWriter writes each MultiBuffer with a single Write call, so it is
+// sent in as few ssh packets as possible.
+type coalescingWriter struct {
+	io.Writer
+}
+
+func (w *coalescingWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	defer buf.ReleaseMulti(mb)
+
+	if len(mb) == 1 {
+		_, err := w.Write(mb[0].Bytes())
+		return err
+	}
+	data := make([]byte, mb.Len())
+	mb.Copy(data)
+	_, err := w.Write(data)
+	return err
+}
+
+// firstWriteWriter keeps the data of the first write if it fails, so it can be
+// sent again on another connection.
+type firstWriteWriter struct {
+	buf.Writer
+	written bool
+	failed  buf.MultiBuffer
+}
+
+func (w *firstWriteWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	if w.written {
+		return w.Writer.WriteMultiBuffer(mb)
+	}
+	w.written = true
+
+	data := make([]byte, mb.Len())
+	mb.Copy(data)
+	if err := w.Writer.WriteMultiBuffer(mb); err != nil {
+		w.failed = buf.MergeBytes(nil, data)
+		return err
+	}
+	return nil
+}
+
+// pendingReader returns pending before reading from Reader.
+type pendingReader struct {
+	buf.Reader
+	pending buf.MultiBuffer
+}
+
+func (r *pendingReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	if r.pending != nil {
+		mb := r.pending
+		r.pending = nil
+		return mb, nil
+	}
+	return r.Reader.ReadMultiBuffer()
+}
@@ -0,0 +1,75 @@
+package ssh
+
+import (
+	"testing"
+
+	"github.com/v2fly/v2ray-core/v5/common/buf"
+	"github.com/v2fly/v2ray-core/v5/common/net"
+)
+
+func TestCopyConfigFor(t *testing.T) {
+	cases := []struct {
+		mode BufferMode
+		port net.Port
+		want copyConfig
+	}{
+		{BufferMode_Auto, 22, interactiveCopy},
+		{BufferMode_Auto, 80, interactiveCopy},
+		{BufferMode_Auto, 443, bulkCopy},
+		{BufferMode_Interactive, 443, interactiveCopy},
+		{BufferMode_Bulk, 22, bulkCopy},
+	}
+	for _, c := range cases {
+		destination := net.TCPDestination(net.DomainAddress("example.com"), c.port)
+		if got := copyConfigFor(c.mode, destination); got != c.want {
+			t.Errorf("copyConfigFor(%v, %v) = %+v, want %+v", c.mode, c.port, got, c.want)
+		}
+	}
+}
+
+func TestCopyConfigBuffering(t *testing.T) {
+	local, remote, err := connPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer local.Close()
+	defer remote.Close()
+
+	if _, ok := interactiveCopy.reader(local).(*readAheadReader); ok {
+		t.Error("interactive copy reads ahead")
+	}
+	if _, ok := interactiveCopy.writer(local).(*coalescingWriter); ok {
+		t.Error("interactive copy coalesces writes")
+	}
+
+	go func() {
+		remote.Write([]byte("hello "))
+		remote.Write([]byte("world"))
+		remote.Close()
+	}()
+	reader := bulkCopy.reader(local)
+	if _, ok := reader.(*readAheadReader); !ok {
+		t.Fatalf("bulk copy reader is %T", reader)
+	}
+	var data []byte
+	for {
+		mb, err := reader.ReadMultiBuffer()
+		for _, b := range mb {
+			if b != nil {
+				data = append(data, b.Bytes()...)
+			}
+		}
+		buf.ReleaseMulti(mb)
+		if err != nil {
+			break
+		}
+	}
+	if string(data) != "hello world" {
+		t.Errorf("read %q", data)
+	}
+
+	writer := bulkCopy.writer(local)
+	if _, ok := writer.(*coalescingWriter); !ok {
+		t.Fatalf("bulk copy writer is %T", writer)
+	}
+}
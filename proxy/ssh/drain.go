@@ -0,0 +1,70 @@
+package ssh
+
+import (
+	"sync/atomic"
+
+	"github.com/v2fly/v2ray-core/v5/common/buf"
+)
+
+// DrainStatus is the traffic a Client still carries, for deciding how long
+// to wait for it on shutdown.
+type DrainStatus struct {
+	// ActiveChannels is the number of forwarding channels still open.
+	ActiveChannels int64
+	// BytesInFlight is the number of bytes being written to channels that the
+	// server has not accepted yet.
+	BytesInFlight int64
+}
+
+// DrainStatus returns the traffic c still carries.
+func (c *Client) DrainStatus() DrainStatus {
+	return DrainStatus{
+		ActiveChannels: atomic.LoadInt64(&c.activeChannels),
+		BytesInFlight:  atomic.LoadInt64(&c.bytesInFlight),
+	}
+}
+
+// Snapshot is the state of a Client at a point in time, for metrics.
+type Snapshot struct {
+	DrainStatus
+	// Connections is the number of connections of the pool established.
+	Connections int
+	// Reusing is true if the connection is shared with other outbounds by
+	// reuse_connection and established.
+	Reusing bool
+	// SharedUsers is the number of outbounds using the shared connection,
+	// this one included, and SharedChannels the number of channels they
+	// multiplex on it.
+	SharedUsers    int
+	SharedChannels int64
+}
+
+// Snapshot returns the state of c.
+func (c *Client) Snapshot() Snapshot {
+	snapshot := Snapshot{DrainStatus: c.DrainStatus()}
+	for _, slot := range c.slots {
+		slot.Lock()
+		if slot.client != nil {
+			snapshot.Connections++
+		}
+		slot.Unlock()
+	}
+	if c.cacheKey != "" {
+		snapshot.Reusing, snapshot.SharedUsers, snapshot.SharedChannels = sharedStatus(c.cacheKey)
+	}
+	return snapshot
+}
+
+// inFlightWriter counts the bytes of each write in inFlight until the write
+// returns.
+type inFlightWriter struct {
+	buf.Writer
+	inFlight *int64
+}
+
+func (w *inFlightWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	n := int64(mb.Len())
+	atomic.AddInt64(w.inFlight, n)
+	defer atomic.AddInt64(w.inFlight, -n)
+	return w.Writer.WriteMultiBuffer(mb)
+}
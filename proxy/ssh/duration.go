@@ -0,0 +1,48 @@
+package ssh
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common/errors"
+	"github.com/v2fly/v2ray-core/v5/common/session"
+)
+
+// withMaxDuration returns ctx canceled after max_connection_duration, if set,
+// however active the connection is. The idle timeout of the policy only
+// cancels it after a period without traffic.
+func (c *Client) withMaxDuration(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.config.MaxConnectionDuration == 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Duration(c.config.MaxConnectionDuration)*time.Second)
+}
+
+// endError wraps err ending the connection of ctx, telling apart a connection
+// cut at max_connection_duration. A benign close is logged at Debug instead,
+// and nil returned.
+func (c *Client) endError(ctx context.Context, err error) error {
+	if isBenignClose(err) {
+		// Not Base(err): the severity of err would outrank Debug.
+		newError("connection closed: ", err).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+		return nil
+	}
+	if c.config.MaxConnectionDuration > 0 && ctx.Err() == context.DeadlineExceeded {
+		return newError("connection closed at max_connection_duration of ", c.config.MaxConnectionDuration, "s").Base(err).AtInfo()
+	}
+	return newError("connection ends").Base(err)
+}
+
+// isBenignClose returns true if err only reports that the other side or this
+// one closed the connection, as opposed to it failing.
+func isBenignClose(err error) bool {
+	cause := errors.Cause(err)
+	if cause == io.EOF || cause == io.ErrClosedPipe {
+		return true
+	}
+	// The text of net.ErrClosed, which is wrapped in errors not unwrapped by
+	// Cause, or only copied by some transports.
+	return strings.Contains(cause.Error(), "use of closed network connection")
+}
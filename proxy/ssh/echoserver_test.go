@@ -0,0 +1,154 @@
+package ssh
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"io"
+
+	"github.com/v2fly/v2ray-core/v5/common/net"
+	"golang.org/x/crypto/ssh"
+)
+
+// echoServer is a minimal SSH server for tests. It authenticates users by
+// password, public key or user certificate, and echoes back everything
+// written to direct-tcpip channels, so a client can check a byte round trip
+// without a real destination.
+type echoServer struct {
+	Port net.Port
+	// HostKey is the server host key, generated on Start if nil.
+	HostKey ssh.Signer
+	// Passwords maps user names to their password, accepted by both password
+	// and keyboard-interactive auth.
+	Passwords map[string]string
+	// AuthorizedKeys are accepted for any user.
+	AuthorizedKeys []ssh.PublicKey
+	// CertAuthorities sign user certificates accepted for their principals.
+	CertAuthorities []ssh.PublicKey
+	// ChannelType of forwarding channels, default "direct-tcpip".
+	ChannelType string
+
+	config   *ssh.ServerConfig
+	listener net.Listener
+}
+
+// Start listens on localhost and serves connections until Close.
+func (server *echoServer) Start() (net.Destination, error) {
+	if server.HostKey == nil {
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return net.Destination{}, err
+		}
+		signer, err := ssh.NewSignerFromKey(private)
+		if err != nil {
+			return net.Destination{}, err
+		}
+		server.HostKey = signer
+	}
+	if server.ChannelType == "" {
+		server.ChannelType = "direct-tcpip"
+	}
+	server.config = server.serverConfig()
+
+	listener, err := net.Listen("tcp", net.LocalHostIP.String()+":"+server.Port.String())
+	if err != nil {
+		return net.Destination{}, err
+	}
+	localAddr := listener.Addr().(*net.TCPAddr)
+	server.Port = net.Port(localAddr.Port)
+	server.listener = listener
+	go server.acceptConnections(listener)
+
+	return net.TCPDestination(net.IPAddress(localAddr.IP), server.Port), nil
+}
+
+func (server *echoServer) serverConfig() *ssh.ServerConfig {
+	checker := &ssh.CertChecker{
+		IsUserAuthority: func(auth ssh.PublicKey) bool {
+			return containsKey(server.CertAuthorities, auth)
+		},
+		UserKeyFallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if containsKey(server.AuthorizedKeys, key) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unknown public key for %s", conn.User())
+		},
+	}
+	checkPassword := func(user, password string) error {
+		if expected, found := server.Passwords[user]; found && expected == password {
+			return nil
+		}
+		return fmt.Errorf("password rejected for %s", user)
+	}
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: checker.Authenticate,
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			return nil, checkPassword(conn.User(), string(password))
+		},
+		KeyboardInteractiveCallback: func(conn ssh.ConnMetadata, challenge ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error) {
+			answers, err := challenge("", "", []string{"Password: "}, []bool{false})
+			if err != nil {
+				return nil, err
+			}
+			if len(answers) != 1 {
+				return nil, fmt.Errorf("unexpected answers from %s", conn.User())
+			}
+			return nil, checkPassword(conn.User(), answers[0])
+		},
+	}
+	config.AddHostKey(server.HostKey)
+	return config
+}
+
+func containsKey(keys []ssh.PublicKey, key ssh.PublicKey) bool {
+	for _, k := range keys {
+		if bytes.Equal(k.Marshal(), key.Marshal()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (server *echoServer) acceptConnections(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go server.handleConnection(conn)
+	}
+}
+
+func (server *echoServer) handleConnection(conn net.Conn) {
+	sshConn, chans, reqs, err := ssh.NewServerConn(conn, server.config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	defer sshConn.Close()
+
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		if newChannel.ChannelType() != server.ChannelType {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go ssh.DiscardRequests(requests)
+		go func() {
+			defer channel.Close()
+			io.Copy(channel, channel)
+			channel.CloseWrite()
+		}()
+	}
+}
+
+// Close stops accepting new connections.
+func (server *echoServer) Close() error {
+	return server.listener.Close()
+}
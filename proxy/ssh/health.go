@@ -0,0 +1,113 @@
+package ssh
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common/errors"
+	"github.com/v2fly/v2ray-core/v5/common/signal/done"
+	"github.com/v2fly/v2ray-core/v5/transport/internet"
+)
+
+const (
+	defaultHealthCheckInterval = 30 * time.Second
+	defaultHealthCheckTimeout  = 5 * time.Second
+)
+
+// StartHealthCheck implements proxy.HealthChecker. It does nothing unless
+// health_check is enabled.
+func (c *Client) StartHealthCheck(dialer internet.Dialer) {
+	check := c.config.GetHealthCheck()
+	if !check.GetEnabled() || c.healthDone != nil {
+		return
+	}
+	interval := defaultHealthCheckInterval
+	if check.Interval > 0 {
+		interval = time.Duration(check.Interval) * time.Second
+	}
+	timeout := defaultHealthCheckTimeout
+	if check.Timeout > 0 {
+		timeout = time.Duration(check.Timeout) * time.Second
+	}
+	c.healthDone = done.New()
+	go c.runHealthCheck(c.healthDone.Wait(), dialer, interval, timeout, check.Handshake)
+}
+
+// Healthy implements proxy.HealthChecker.
+func (c *Client) Healthy() bool {
+	return atomic.LoadInt32(&c.unhealthy) == 0
+}
+
+// runHealthCheck checks the servers right away, then every interval until
+// closed is closed.
+func (c *Client) runHealthCheck(closed <-chan struct{}, dialer internet.Dialer, interval, timeout time.Duration, handshake bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		c.setHealth(c.checkHealth(dialer, timeout, handshake))
+		select {
+		case <-closed:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Client) setHealth(err error) {
+	if err != nil {
+		if atomic.SwapInt32(&c.unhealthy, 1) == 0 {
+			newError("ssh server unreachable").Base(err).AtWarning().WriteToLog()
+		}
+		return
+	}
+	if atomic.SwapInt32(&c.unhealthy, 0) == 1 {
+		newError("ssh server reachable again").AtInfo().WriteToLog()
+	}
+}
+
+// checkHealth returns nil once a server is reachable within timeout.
+func (c *Client) checkHealth(dialer internet.Dialer, timeout time.Duration, handshake bool) error {
+	var errs []error
+	for _, server := range c.servers {
+		err := c.probe(dialer, server, timeout, handshake)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return errors.Combine(errs...)
+}
+
+// probe connects to server and, with handshake, completes an ssh handshake.
+// A probe taking longer than timeout fails, and is cleaned up once it
+// returns.
+func (c *Client) probe(dialer internet.Dialer, server *sshServer, timeout time.Duration, handshake bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	result := make(chan error, 1)
+	go func() {
+		if handshake {
+			_, client, err := c.handshakeOnce(ctx, dialer, server)
+			if err == nil {
+				client.Close()
+			}
+			result <- err
+			return
+		}
+		conn, err := dialer.Dial(ctx, server.destination)
+		if err == nil {
+			conn.Close()
+		}
+		result <- err
+	}()
+	select {
+	case err := <-result:
+		if err != nil {
+			return newError("failed to reach ", server.destination).Base(err)
+		}
+		return nil
+	case <-ctx.Done():
+		return newError("no response from ", server.destination, " within ", timeout)
+	}
+}
@@ -0,0 +1,60 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common/net"
+	"github.com/v2fly/v2ray-core/v5/transport/internet"
+)
+
+// switchDialer fails to dial while down is set.
+type switchDialer struct {
+	pipeDialer
+	down int32
+}
+
+func (d *switchDialer) Dial(ctx context.Context, destination net.Destination) (internet.Connection, error) {
+	if atomic.LoadInt32(&d.down) == 1 {
+		return nil, errors.New("connection refused")
+	}
+	return d.pipeDialer.Dial(ctx, destination)
+}
+
+func TestHealthCheck(t *testing.T) {
+	dialer := &switchDialer{pipeDialer: pipeDialer{config: newTestServerConfig(t)}, down: 1}
+	client := newTestClient(t, &Config{})
+	closed := make(chan struct{})
+	defer close(closed)
+	go client.runHealthCheck(closed, dialer, 10*time.Millisecond, time.Second, true)
+
+	waitHealth := func(expected bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for client.Healthy() != expected {
+			if time.Now().After(deadline) {
+				t.Fatal("expected healthy ", expected, ", but actually ", !expected)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitHealth(false)
+	atomic.StoreInt32(&dialer.down, 0)
+	waitHealth(true)
+	atomic.StoreInt32(&dialer.down, 1)
+	waitHealth(false)
+}
+
+func TestHealthCheckDisabled(t *testing.T) {
+	client := newTestClient(t, &Config{})
+	client.StartHealthCheck(&switchDialer{down: 1})
+	if client.healthDone != nil {
+		t.Error("health check started without being enabled")
+	}
+	if !client.Healthy() {
+		t.Error("expected a client without health check to be healthy")
+	}
+}
@@ -0,0 +1,178 @@
+package ssh
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common/net"
+	"github.com/v2fly/v2ray-core/v5/transport/internet"
+	"golang.org/x/crypto/ssh"
+)
+
+// jumpHost is an intermediate server connections are tunneled through, like
+// with OpenSSH's ProxyJump.
+type jumpHost struct {
+	destination     net.Destination
+	user            string
+	auth            func(attempts *authAttempts) []ssh.AuthMethod
+	hostKeyCallback ssh.HostKeyCallback
+}
+
+func (c *Client) newJumpHosts(config *Config) ([]*jumpHost, error) {
+	jumps := make([]*jumpHost, 0, len(config.JumpHosts))
+	// Without their own credentials, jump hosts are given those of the server.
+	serverAuth := func(attempts *authAttempts) []ssh.AuthMethod {
+		return c.authMethods(nil, attempts)
+	}
+	for i, host := range config.JumpHosts {
+		if host.Address == nil {
+			return nil, newError("ssh jump host ", i, " has no address")
+		}
+		port := host.Port
+		if port == 0 {
+			port = 22
+		}
+		jump := &jumpHost{
+			destination:     net.TCPDestination(host.Address.AsAddress(), net.Port(port)),
+			user:            host.User,
+			auth:            serverAuth,
+			hostKeyCallback: c.hostKeyCallback,
+		}
+		if jump.user == "" {
+			jump.user = config.User
+		}
+		if host.PrivateKey != "" || host.Password != "" {
+			auth, err := jumpAuth(host)
+			if err != nil {
+				return nil, newError("ssh jump host ", i).Base(err)
+			}
+			jump.auth = auth
+		}
+		if host.PublicKey != "" {
+			callback, err := jumpHostKeyCallback(host.PublicKey)
+			if err != nil {
+				return nil, newError("ssh jump host ", i).Base(err)
+			}
+			jump.hostKeyCallback = callback
+		}
+		jumps = append(jumps, jump)
+	}
+	return jumps, nil
+}
+
+func jumpAuth(host *JumpHost) (func(*authAttempts) []ssh.AuthMethod, error) {
+	var methods []ssh.AuthMethod
+	password := host.Password
+	if host.PrivateKey != "" {
+		signer, err := ssh.ParsePrivateKey([]byte(host.PrivateKey))
+		if _, encrypted := err.(*ssh.PassphraseMissingError); encrypted && password != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(host.PrivateKey), []byte(password))
+			password = ""
+		}
+		if err != nil {
+			return nil, newError("parse private key").Base(err)
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+	if password != "" {
+		methods = append(methods, ssh.Password(password))
+	}
+	return func(*authAttempts) []ssh.AuthMethod { return methods }, nil
+}
+
+func jumpHostKeyCallback(publicKey string) (ssh.HostKeyCallback, error) {
+	keys := newHostKeys()
+	for i, line := range strings.Split(publicKey, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if err := keys.add(line); err != nil {
+			return nil, newError("parse public key on line ", i+1).Base(err)
+		}
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if keys.trusted(hostname, key) {
+			return nil
+		}
+		return newError("ssh jump host key mismatch, server send ", key.Type(), " ", base64.StdEncoding.EncodeToString(key.Marshal()))
+	}, nil
+}
+
+// jumpConn is a connection tunneled through jump hosts. Closing it closes
+// the connections to all of them.
+type jumpConn struct {
+	sync.Mutex
+	net.Conn
+	hops []*ssh.Client
+}
+
+func (c *jumpConn) Close() error {
+	c.Lock()
+	defer c.Unlock()
+	err := c.Conn.Close()
+	for i := len(c.hops) - 1; i >= 0; i-- {
+		c.hops[i].Close()
+	}
+	return err
+}
+
+func (c *jumpConn) advance(hop *ssh.Client, conn net.Conn) {
+	c.Lock()
+	defer c.Unlock()
+	c.hops = append(c.hops, hop)
+	c.Conn = conn
+}
+
+// dial connects to server, through the jump hosts if there are any.
+func (c *Client) dial(ctx context.Context, dialer internet.Dialer, server *sshServer) (internet.Connection, error) {
+	if len(c.jumps) == 0 {
+		return dialer.Dial(ctx, server.destination)
+	}
+	first := c.jumps[0].destination
+	conn, err := dialer.Dial(ctx, first)
+	if err != nil {
+		return nil, newError("failed to connect to ssh jump host ", first).Base(err)
+	}
+	chain := &jumpConn{Conn: conn}
+	// The handshakes with the jump hosts are bound like the one with the
+	// server.
+	watchdog := time.AfterFunc(c.handshakeLimit(ctx, server), func() {
+		chain.Close()
+	})
+	defer watchdog.Stop()
+	for i, jump := range c.jumps {
+		attempts := &authAttempts{}
+		config := &ssh.ClientConfig{
+			User:            jump.user,
+			Auth:            jump.auth(attempts),
+			ClientVersion:   c.config.ClientVersion,
+			HostKeyCallback: jump.hostKeyCallback,
+		}
+		chain.Lock()
+		tunnel := chain.Conn
+		chain.Unlock()
+		clientConn, chans, reqs, err := ssh.NewClientConn(tunnel, jump.destination.NetAddr(), config)
+		attempts.close()
+		if err != nil {
+			chain.Close()
+			return nil, newError("failed to connect to ssh jump host ", jump.destination).Base(err)
+		}
+		hop := ssh.NewClient(clientConn, chans, reqs)
+		next := server.destination
+		if i+1 < len(c.jumps) {
+			next = c.jumps[i+1].destination
+		}
+		nextConn, err := hop.Dial("tcp", next.NetAddr())
+		if err != nil {
+			hop.Close()
+			chain.Close()
+			return nil, newError("ssh jump host ", jump.destination, " failed to connect to ", next).Base(err)
+		}
+		chain.advance(hop, nextConn)
+	}
+	return chain, nil
+}
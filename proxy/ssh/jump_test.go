@@ -0,0 +1,156 @@
+package ssh
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/net"
+	"github.com/v2fly/v2ray-core/v5/transport/internet"
+	"golang.org/x/crypto/ssh"
+)
+
+// newKeyedServerConfig returns a server config and its host key in
+// authorized_keys format.
+func newKeyedServerConfig(t testing.TB) (*ssh.ServerConfig, string) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	common.Must(err)
+	signer, err := ssh.NewSignerFromKey(private)
+	common.Must(err)
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+	return config, string(ssh.MarshalAuthorizedKey(signer.PublicKey()))
+}
+
+// jumpDialer serves a jump host forwarding direct-tcpip channels to target,
+// which stands for the server the client asked for.
+type jumpDialer struct {
+	config   *ssh.ServerConfig
+	target   *pipeDialer
+	targets  chan string
+	sessions chan *ssh.ServerConn
+}
+
+func (d *jumpDialer) Dial(ctx context.Context, destination net.Destination) (internet.Connection, error) {
+	clientConn, serverConn, err := connPair()
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		conn, chans, reqs, err := ssh.NewServerConn(serverConn, d.config)
+		if err != nil {
+			serverConn.Close()
+			return
+		}
+		d.sessions <- conn
+		go ssh.DiscardRequests(reqs)
+		for newChannel := range chans {
+			var payload struct {
+				Host     string
+				Port     uint32
+				OrigHost string
+				OrigPort uint32
+			}
+			if newChannel.ChannelType() != "direct-tcpip" || ssh.Unmarshal(newChannel.ExtraData(), &payload) != nil {
+				newChannel.Reject(ssh.Prohibited, "not supported")
+				continue
+			}
+			d.targets <- payload.Host + ":" + strconv.Itoa(int(payload.Port))
+			channel, channelReqs, err := newChannel.Accept()
+			if err != nil {
+				continue
+			}
+			go ssh.DiscardRequests(channelReqs)
+			upstream, err := d.target.Dial(ctx, destination)
+			if err != nil {
+				channel.Close()
+				continue
+			}
+			go func() {
+				io.Copy(upstream, channel)
+				upstream.Close()
+			}()
+			go func() {
+				io.Copy(channel, upstream)
+				channel.Close()
+			}()
+		}
+	}()
+	return clientConn, nil
+}
+
+func (d *jumpDialer) Address() net.Address {
+	return nil
+}
+
+func TestJumpHost(t *testing.T) {
+	jumpConfig, jumpKey := newKeyedServerConfig(t)
+	serverConfig, serverKey := newKeyedServerConfig(t)
+	dialer := &jumpDialer{
+		config:   jumpConfig,
+		target:   &pipeDialer{config: serverConfig},
+		targets:  make(chan string, 1),
+		sessions: make(chan *ssh.ServerConn, 1),
+	}
+
+	client := newTestClient(t, &Config{
+		Address:   net.NewIPOrDomain(net.DomainAddress("server.example.com")),
+		Port:      2222,
+		PublicKey: serverKey,
+		JumpHosts: []*JumpHost{{
+			Address:   net.NewIPOrDomain(net.DomainAddress("jump.example.com")),
+			PublicKey: jumpKey,
+		}},
+	})
+	_, sc, _, err := client.connect(context.Background(), dialer)
+	if err != nil {
+		t.Fatal("failed to connect through the jump host: ", err)
+	}
+	if target := <-dialer.targets; target != "server.example.com:2222" {
+		t.Error("expected the jump host to connect to server.example.com:2222, but actually ", target)
+	}
+
+	// Closing the connection to the server closes the one to the jump host.
+	jumpSession := <-dialer.sessions
+	sc.Close()
+	closed := make(chan struct{})
+	go func() {
+		jumpSession.Wait()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Error("connection to the jump host was left open")
+	}
+}
+
+func TestJumpHostKeyMismatch(t *testing.T) {
+	jumpConfig, _ := newKeyedServerConfig(t)
+	serverConfig, serverKey := newKeyedServerConfig(t)
+	dialer := &jumpDialer{
+		config:   jumpConfig,
+		target:   &pipeDialer{config: serverConfig},
+		targets:  make(chan string, 1),
+		sessions: make(chan *ssh.ServerConn, 1),
+	}
+
+	// The jump host must present its own key, not the one of the server.
+	client := newTestClient(t, &Config{
+		PublicKey: serverKey,
+		JumpHosts: []*JumpHost{{
+			Address:   net.NewIPOrDomain(net.DomainAddress("jump.example.com")),
+			PublicKey: serverKey,
+		}},
+	})
+	_, _, _, err := client.connect(context.Background(), dialer)
+	if err == nil || !strings.Contains(err.Error(), "jump host key mismatch") {
+		t.Error("expected a jump host key mismatch, but actually ", err)
+	}
+}
@@ -0,0 +1,111 @@
+package ssh
+
+import (
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const keepAliveRequest = "keepalive@openssh.com"
+
+// defaultServerAliveCountMax is the default of OpenSSH's ServerAliveCountMax.
+const defaultServerAliveCountMax = 3
+
+var errKeepAliveTimeout = newError("no reply to keepalive")
+
+// validateKeepAlive rejects configs setting up both keepalive checks, which
+// would probe the same connection twice with different limits.
+func validateKeepAlive(config *Config) error {
+	if config.ServerAliveInterval == 0 && config.ServerAliveCountMax == 0 {
+		return nil
+	}
+	keepAlive := config.KeepAliveInterval > 0 || config.KeepAliveMaxFailures > 0
+	for _, server := range config.Servers {
+		if server.KeepAliveInterval > 0 {
+			keepAlive = true
+		}
+	}
+	if keepAlive {
+		return newError("server_alive_interval and server_alive_count_max cannot be combined with keep_alive_interval or keep_alive_max_failures, use either")
+	}
+	return nil
+}
+
+// sendKeepAlive sends a keepalive request, as OpenSSH does, and waits up to
+// timeout for the reply. Any reply, including a rejection, shows the
+// connection is alive.
+func sendKeepAlive(client *ssh.Client, timeout time.Duration) error {
+	result := make(chan error, 1)
+	go func() {
+		_, _, err := client.SendRequest(keepAliveRequest, true, nil)
+		result <- err
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(timeout):
+		return errKeepAliveTimeout
+	}
+}
+
+// runKeepAlive calls probe every interval until closed is closed. After
+// maxFailures consecutive failed probes it calls teardown and returns.
+func runKeepAlive(closed <-chan struct{}, interval time.Duration, maxFailures uint32, probe func() error, teardown func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var failures uint32
+	for {
+		select {
+		case <-closed:
+			return
+		case <-ticker.C:
+		}
+		if err := probe(); err != nil {
+			failures++
+			newError("ssh keepalive failed (", failures, "/", maxFailures, ")").Base(err).AtDebug().WriteToLog()
+			if failures >= maxFailures {
+				newError("closing ssh connection after ", failures, " failed keepalives").AtInfo().WriteToLog()
+				teardown()
+				return
+			}
+			continue
+		}
+		failures = 0
+	}
+}
+
+// runServerAlive checks the server like OpenSSH with ServerAliveInterval and
+// ServerAliveCountMax do. When received shows nothing arrived from the server
+// during the last interval, a keepalive is sent without waiting for its
+// reply. A check finding more than countMax keepalives unanswered calls
+// teardown and returns. Any reply, or data arriving, resets the count.
+func runServerAlive(closed <-chan struct{}, interval time.Duration, countMax uint32, received func() int64, send func(answered func()), teardown func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var unanswered uint32
+	answered := func() {
+		atomic.StoreUint32(&unanswered, 0)
+	}
+	lastReceived := received()
+	for {
+		select {
+		case <-closed:
+			return
+		case <-ticker.C:
+		}
+		if r := received(); r != lastReceived {
+			lastReceived = r
+			atomic.StoreUint32(&unanswered, 0)
+			continue
+		}
+		if count := atomic.AddUint32(&unanswered, 1); count > countMax {
+			newError("ssh server not responding, ", count-1, " keepalives unanswered").AtInfo().WriteToLog()
+			teardown()
+			return
+		}
+		send(answered)
+	}
+}
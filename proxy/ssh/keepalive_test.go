@@ -0,0 +1,174 @@
+package ssh
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKeepAliveMaxFailures(t *testing.T) {
+	// Failures interrupted by a success do not add up.
+	results := []bool{false, false, true, false, false, true, false, false, false, true}
+	probes := 0
+	torndown := make(chan int, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runKeepAlive(make(chan struct{}), time.Millisecond, 3, func() error {
+			ok := results[probes]
+			probes++
+			if ok {
+				return nil
+			}
+			return errors.New("no reply")
+		}, func() {
+			torndown <- probes
+		})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("keepalive did not give up")
+	}
+	select {
+	case n := <-torndown:
+		if n != 9 {
+			t.Error("torn down after ", n, " probes, want 9")
+		}
+	default:
+		t.Error("keepalive returned without tearing down")
+	}
+}
+
+func TestKeepAliveStopsOnClose(t *testing.T) {
+	closed := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runKeepAlive(closed, time.Millisecond, 1, func() error {
+			return nil
+		}, func() {
+			t.Error("unexpected teardown")
+		})
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(closed)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("keepalive kept running after the connection closed")
+	}
+}
+
+func TestServerAliveAnswered(t *testing.T) {
+	closed := make(chan struct{})
+	done := make(chan struct{})
+	var sent int32
+	go func() {
+		defer close(done)
+		runServerAlive(closed, time.Millisecond, 3, func() int64 { return 0 }, func(answered func()) {
+			atomic.AddInt32(&sent, 1)
+			answered()
+		}, func() {
+			t.Error("connection with answered keepalives torn down")
+		})
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	close(closed)
+	<-done
+	if atomic.LoadInt32(&sent) < 4 {
+		t.Error("expected keepalives to keep being sent, but only ", sent, " were")
+	}
+}
+
+func TestServerAliveUnanswered(t *testing.T) {
+	sent := 0
+	torndown := false
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runServerAlive(make(chan struct{}), time.Millisecond, 3, func() int64 { return 0 }, func(answered func()) {
+			sent++
+		}, func() {
+			torndown = true
+		})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("unanswered keepalives did not terminate the connection")
+	}
+	// Like OpenSSH, the check after ServerAliveCountMax unanswered
+	// keepalives terminates the connection.
+	if !torndown || sent != 3 {
+		t.Error("expected teardown after 3 unanswered keepalives, but torn down ", torndown, " after ", sent)
+	}
+}
+
+func TestServerAliveDeferredByTraffic(t *testing.T) {
+	closed := make(chan struct{})
+	done := make(chan struct{})
+	var received int64
+	go func() {
+		defer close(done)
+		// Data arrives between any two checks.
+		runServerAlive(closed, time.Millisecond, 1, func() int64 {
+			return atomic.AddInt64(&received, 1)
+		}, func(answered func()) {
+			t.Error("keepalive sent while the server was sending data")
+		}, func() {
+			t.Error("active connection torn down")
+		})
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	close(closed)
+	<-done
+}
+
+func TestServerAliveResetByTraffic(t *testing.T) {
+	closed := make(chan struct{})
+	done := make(chan struct{})
+	var checks int64
+	go func() {
+		defer close(done)
+		// Data arrives between every other check, and no keepalive is
+		// ever answered.
+		runServerAlive(closed, time.Millisecond, 1, func() int64 {
+			return atomic.AddInt64(&checks, 1) / 2
+		}, func(answered func()) {}, func() {
+			t.Error("connection receiving data torn down")
+		})
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	close(closed)
+	<-done
+}
+
+func TestKeepAliveMechanismsExclusive(t *testing.T) {
+	cases := []struct {
+		config *Config
+		valid  bool
+	}{
+		{&Config{KeepAliveInterval: 30, KeepAliveMaxFailures: 3}, true},
+		{&Config{ServerAliveInterval: 30, ServerAliveCountMax: 3}, true},
+		{&Config{ServerAliveInterval: 30, KeepAliveInterval: 30}, false},
+		{&Config{ServerAliveCountMax: 3, KeepAliveMaxFailures: 3}, false},
+		{&Config{ServerAliveInterval: 30, Servers: []*Endpoint{{KeepAliveInterval: 10}}}, false},
+	}
+	for i, c := range cases {
+		err := validateKeepAlive(c.config)
+		if c.valid && err != nil {
+			t.Error("case ", i, ": unexpected error ", err)
+		}
+		if !c.valid && err == nil {
+			t.Error("case ", i, ": expected both keepalive checks to be rejected")
+		}
+	}
+}
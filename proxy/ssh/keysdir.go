@@ -0,0 +1,128 @@
+package ssh
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// authorizedKeysRescan is how often directories of authorized keys are
+// checked for changes.
+var authorizedKeysRescan = 10 * time.Second
+
+// parseAuthorizedKeys adds the keys of text, in authorized_keys format, to
+// keys, indexed by their wire encoding.
+func parseAuthorizedKeys(text string, keys map[string]bool) error {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+		if err != nil {
+			return err
+		}
+		keys[string(key.Marshal())] = true
+	}
+	return nil
+}
+
+// keysDir holds the keys of the files in a directory, reloaded when a file is
+// added, removed or modified. Hidden files are ignored.
+type keysDir struct {
+	path string
+	// state describes the files the keys were loaded from.
+	state string
+	keys  atomic.Value // map[string]bool
+}
+
+func newKeysDir(path string) (*keysDir, error) {
+	d := &keysDir{path: path}
+	if err := d.reload(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// reload loads the keys again if the files changed. A file failing to parse
+// is skipped as a whole with a warning, so one bad file does not lock everyone
+// out and none of its keys are half loaded.
+func (d *keysDir) reload() error {
+	entries, err := os.ReadDir(d.path)
+	if err != nil {
+		return newError("failed to read authorized keys directory ", d.path).Base(err)
+	}
+	var files []os.FileInfo
+	var state strings.Builder
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, info)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name() < files[j].Name() })
+	for _, info := range files {
+		state.WriteString(info.Name())
+		state.WriteByte('/')
+		state.WriteString(strconv.FormatInt(info.Size(), 10))
+		state.WriteByte('/')
+		state.WriteString(strconv.FormatInt(info.ModTime().UnixNano(), 10))
+		state.WriteByte('\n')
+	}
+	if d.keys.Load() != nil && state.String() == d.state {
+		return nil
+	}
+
+	keys := make(map[string]bool)
+	for _, info := range files {
+		name := filepath.Join(d.path, info.Name())
+		content, err := os.ReadFile(name)
+		fileKeys := make(map[string]bool)
+		if err == nil {
+			err = parseAuthorizedKeys(string(content), fileKeys)
+		}
+		if err != nil {
+			newError("skipping authorized key file ", name).Base(err).AtWarning().WriteToLog()
+			continue
+		}
+		for key := range fileKeys {
+			keys[key] = true
+		}
+	}
+	d.state = state.String()
+	d.keys.Store(keys)
+	newError("loaded ", len(keys), " authorized keys from ", d.path).AtInfo().WriteToLog()
+	return nil
+}
+
+func (d *keysDir) contains(key ssh.PublicKey) bool {
+	return d.keys.Load().(map[string]bool)[string(key.Marshal())]
+}
+
+// rescanKeysDirs reloads dirs periodically until closed is closed.
+func rescanKeysDirs(dirs []*keysDir, closed <-chan struct{}) {
+	ticker := time.NewTicker(authorizedKeysRescan)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case <-ticker.C:
+		}
+		for _, d := range dirs {
+			if err := d.reload(); err != nil {
+				newError("keeping the previous authorized keys").Base(err).AtWarning().WriteToLog()
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package ssh
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// hostKeys are the server keys trusted by public_key. A line in known_hosts
+// format only trusts its key for the hosts it lists, other lines trust theirs
+// for any host.
+type hostKeys struct {
+	any   map[string]bool
+	hosts map[string][]string
+}
+
+func newHostKeys() *hostKeys {
+	return &hostKeys{
+		any:   make(map[string]bool),
+		hosts: make(map[string][]string),
+	}
+}
+
+func (k *hostKeys) add(line string) error {
+	key, _, options, _, err := ssh.ParseAuthorizedKey([]byte(line))
+	if err == nil && len(options) == 0 {
+		k.any[string(key.Marshal())] = true
+		return nil
+	}
+	if marker, hosts, knownKey, _, _, knownErr := ssh.ParseKnownHosts([]byte(line)); knownErr == nil && marker == "" {
+		marshaled := string(knownKey.Marshal())
+		k.hosts[marshaled] = append(k.hosts[marshaled], hosts...)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	k.any[string(key.Marshal())] = true
+	return nil
+}
+
+func (k *hostKeys) empty() bool {
+	return len(k.any) == 0 && len(k.hosts) == 0
+}
+
+// trusted returns true if key may be presented by hostname, which is in
+// host:port form. Like OpenSSH, known_hosts entries match the plain host as
+// well as [host]:port.
+func (k *hostKeys) trusted(hostname string, key ssh.PublicKey) bool {
+	marshaled := string(key.Marshal())
+	if k.any[marshaled] {
+		return true
+	}
+	patterns := k.hosts[marshaled]
+	if len(patterns) == 0 {
+		return false
+	}
+	candidates := []string{knownhosts.Normalize(hostname)}
+	if host, port, err := net.SplitHostPort(hostname); err == nil {
+		candidates = append(candidates, host, "["+host+"]:"+port)
+	}
+	for _, pattern := range patterns {
+		for _, candidate := range candidates {
+			if strings.EqualFold(pattern, candidate) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hostKeyCallbackWithTimeout returns a callback failing if callback takes
+// longer than timeout, for verifications that do I/O and may hang. The
+// callback keeps running in the background until it returns.
+func hostKeyCallbackWithTimeout(callback ssh.HostKeyCallback, timeout time.Duration) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		result := make(chan error, 1)
+		go func() {
+			result <- callback(hostname, remote, key)
+		}()
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case err := <-result:
+			return err
+		case <-timer.C:
+			return newError("host key verification timed out after ", timeout)
+		}
+	}
+}
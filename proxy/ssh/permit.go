@@ -0,0 +1,80 @@
+package ssh
+
+import (
+	"net"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// permitOpen restricts the destinations clients may forward to, following the
+// semantics of OpenSSH's PermitOpen option.
+type permitOpen struct {
+	any   bool
+	rules []permitRule
+}
+
+type permitRule struct {
+	host string
+	port string
+}
+
+// newPermitOpen parses a list of host:port patterns. The host part may contain
+// the wildcards '*' and '?', and the port part may be '*' to allow any port.
+// An empty list or the single entry "any" permits everything, "none" permits
+// nothing.
+func newPermitOpen(entries []string) (*permitOpen, error) {
+	if len(entries) == 0 {
+		return &permitOpen{any: true}, nil
+	}
+	p := &permitOpen{}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		switch strings.ToLower(entry) {
+		case "any":
+			p.any = true
+			continue
+		case "none":
+			continue
+		}
+		host, port, err := net.SplitHostPort(entry)
+		if err != nil {
+			return nil, newError("invalid permit open entry ", entry).Base(err)
+		}
+		if host == "" {
+			return nil, newError("invalid permit open entry ", entry, ": empty host")
+		}
+		host = strings.ToLower(host)
+		if _, err := path.Match(host, ""); err != nil {
+			return nil, newError("invalid permit open host pattern ", host).Base(err)
+		}
+		if port != "*" {
+			if n, err := strconv.ParseUint(port, 10, 16); err != nil || n == 0 {
+				return nil, newError("invalid permit open port ", port)
+			}
+		}
+		p.rules = append(p.rules, permitRule{host: host, port: port})
+	}
+	if p.any && len(p.rules) > 0 {
+		return nil, newError("permit open entry any can not be combined with other entries")
+	}
+	return p, nil
+}
+
+// Allowed reports whether forwarding to host:port is permitted.
+func (p *permitOpen) Allowed(host string, port uint32) bool {
+	if p.any {
+		return true
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	portStr := strconv.FormatUint(uint64(port), 10)
+	for _, rule := range p.rules {
+		if rule.port != "*" && rule.port != portStr {
+			continue
+		}
+		if matched, _ := path.Match(rule.host, host); matched {
+			return true
+		}
+	}
+	return false
+}
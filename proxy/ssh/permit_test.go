@@ -0,0 +1,71 @@
+package ssh
+
+import (
+	"testing"
+)
+
+func TestPermitOpen(t *testing.T) {
+	permit, err := newPermitOpen([]string{
+		"example.com:443",
+		"*.internal:*",
+		"10.0.0.?:22",
+		"[2001:db8::1]:80",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		host    string
+		port    uint32
+		allowed bool
+	}{
+		{"example.com", 443, true},
+		{"EXAMPLE.com.", 443, true},
+		{"example.com", 80, false},
+		{"www.example.com", 443, false},
+		{"db.internal", 5432, true},
+		{"db.internal.evil.com", 5432, false},
+		{"10.0.0.1", 22, true},
+		{"10.0.0.10", 22, false},
+		{"2001:db8::1", 80, true},
+		{"2001:db8::2", 80, false},
+	}
+	for _, c := range cases {
+		if allowed := permit.Allowed(c.host, c.port); allowed != c.allowed {
+			t.Error(c.host, ":", c.port, " expected allowed=", c.allowed, ", got ", allowed)
+		}
+	}
+}
+
+func TestPermitOpenAnyAndNone(t *testing.T) {
+	for _, entries := range [][]string{nil, {"any"}} {
+		permit, err := newPermitOpen(entries)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !permit.Allowed("anything.example", 1234) {
+			t.Error(entries, " should permit everything")
+		}
+	}
+
+	permit, err := newPermitOpen([]string{"none"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if permit.Allowed("example.com", 443) {
+		t.Error("none should deny everything")
+	}
+}
+
+func TestPermitOpenInvalid(t *testing.T) {
+	for _, entry := range []string{"example.com", ":22", "example.com:0", "example.com:ssh", "[a-:22", "any"} {
+		entries := []string{entry}
+		if entry == "any" {
+			entries = append(entries, "example.com:22")
+		}
+		if _, err := newPermitOpen(entries); err == nil {
+			t.Error("expected error for ", entries)
+		}
+	}
+}
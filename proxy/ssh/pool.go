@@ -0,0 +1,105 @@
+package ssh
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/v2fly/v2ray-core/v5/common/session"
+	"golang.org/x/crypto/ssh"
+)
+
+// poolSlot holds one of the connections of a Client. A slot connects on first
+// use, and again on the next use after its connection closed.
+type poolSlot struct {
+	sync.Mutex
+	client  *ssh.Client
+	dialing *dialFuture
+	// channels holds a token per channel open on the connection, nil without
+	// max_channels_per_connection.
+	channels chan struct{}
+}
+
+// dialFuture is a connection being established, awaited by every caller that
+// needs it meanwhile, so the slot is not locked during the handshake.
+type dialFuture struct {
+	done   chan struct{}
+	client *ssh.Client
+	err    error
+}
+
+func (f *dialFuture) resolve(client *ssh.Client, err error) {
+	f.client, f.err = client, err
+	close(f.done)
+}
+
+func (f *dialFuture) wait(ctx context.Context) (*ssh.Client, error) {
+	select {
+	case <-f.done:
+		return f.client, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// newPool returns the slots for max_connections, a single one for 0, each
+// limited to maxChannels channels, or unlimited for 0.
+func newPool(maxConnections uint32, maxChannels uint32) []*poolSlot {
+	if maxConnections == 0 {
+		maxConnections = 1
+	}
+	slots := make([]*poolSlot, maxConnections)
+	for i := range slots {
+		slots[i] = &poolSlot{}
+		if maxChannels > 0 {
+			slots[i].channels = make(chan struct{}, maxChannels)
+		}
+	}
+	return slots
+}
+
+// acquireChannel picks the slot for the next channel and waits until the
+// slot has a channel free. The returned function frees the channel again.
+func (c *Client) acquireChannel(ctx context.Context) (*poolSlot, func(), error) {
+	slot := c.nextSlot()
+	if slot.channels == nil {
+		return slot, func() {}, nil
+	}
+	select {
+	case slot.channels <- struct{}{}:
+	default:
+		newError("all ", cap(slot.channels), " channels of the ssh connection are in use, waiting").AtDebug().WriteToLog(session.ExportIDToError(ctx))
+		select {
+		case slot.channels <- struct{}{}:
+		case <-ctx.Done():
+			return nil, nil, newError("no free ssh channel").Base(ctx.Err())
+		}
+	}
+	return slot, func() { <-slot.channels }, nil
+}
+
+// trackChannel counts a channel opened on slot, for a delta of 1, or closed,
+// for -1.
+func (c *Client) trackChannel(slot *poolSlot, delta int64) {
+	atomic.AddInt64(&c.activeChannels, delta)
+	if c.shared(slot) {
+		trackSharedChannel(c.cacheKey, delta)
+	}
+	if c.channelsCounter != nil {
+		c.channelsCounter.Add(delta)
+	}
+}
+
+// nextSlot returns the slot for the next channel, in round-robin order.
+func (c *Client) nextSlot() *poolSlot {
+	if len(c.slots) == 1 {
+		return c.slots[0]
+	}
+	return c.slots[atomic.AddUint32(&c.nextSlotIndex, 1)%uint32(len(c.slots))]
+}
+
+// shared returns true if the connection of slot is the one shared with other
+// outbounds by reuse_connection.
+func (c *Client) shared(slot *poolSlot) bool {
+	return c.cacheKey != "" && slot == c.slots[0]
+}
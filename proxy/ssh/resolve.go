@@ -0,0 +1,82 @@
+package ssh
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/v2fly/v2ray-core/v5/common/net"
+	"github.com/v2fly/v2ray-core/v5/common/session"
+)
+
+type resolveRule struct {
+	patterns   []string
+	resolution Resolution
+}
+
+// newResolveRules validates the domain patterns of rules.
+func newResolveRules(rules []*ResolveRule) ([]resolveRule, error) {
+	parsed := make([]resolveRule, 0, len(rules))
+	for _, rule := range rules {
+		if len(rule.Domain) == 0 {
+			return nil, newError("resolve rule without domain")
+		}
+		patterns := make([]string, 0, len(rule.Domain))
+		for _, pattern := range rule.Domain {
+			pattern = strings.ToLower(strings.TrimSpace(pattern))
+			if pattern == "" {
+				return nil, newError("empty domain pattern in resolve rule")
+			}
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, newError("invalid domain pattern ", pattern, " in resolve rule").Base(err)
+			}
+			patterns = append(patterns, pattern)
+		}
+		parsed = append(parsed, resolveRule{patterns: patterns, resolution: rule.Resolution})
+	}
+	return parsed, nil
+}
+
+// resolution returns where domain is resolved.
+func (c *Client) resolution(domain string) Resolution {
+	switch c.config.ForwardHostForm {
+	case ForwardHostForm_ForwardHostIP:
+		return Resolution_Local
+	case ForwardHostForm_ForwardHostName:
+		return Resolution_Remote
+	}
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	for _, rule := range c.resolveRules {
+		for _, pattern := range rule.patterns {
+			if matched, _ := path.Match(pattern, domain); matched {
+				return rule.resolution
+			}
+		}
+	}
+	return Resolution_Remote
+}
+
+// resolve returns destination with its domain replaced by an IP if a resolve
+// rule or forward_host_form asks for local resolution.
+func (c *Client) resolve(ctx context.Context, destination net.Destination) (net.Destination, error) {
+	if !destination.Address.Family().IsDomain() {
+		return destination, nil
+	}
+	domain := destination.Address.Domain()
+	if c.resolution(domain) != Resolution_Local {
+		return destination, nil
+	}
+	if c.dns == nil {
+		return destination, newError("no dns client to resolve ", domain, " locally")
+	}
+	ips, err := c.dns.LookupIP(domain)
+	if err != nil {
+		return destination, newError("failed to resolve ", domain, " locally").Base(err)
+	}
+	if len(ips) == 0 {
+		return destination, newError("no ip found for ", domain)
+	}
+	destination.Address = net.IPAddress(ips[0])
+	newError("resolved ", domain, " locally to ", destination.Address).AtDebug().WriteToLog(session.ExportIDToError(ctx))
+	return destination, nil
+}
@@ -0,0 +1,128 @@
+package ssh
+
+import (
+	"context"
+	"testing"
+
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/net"
+	"github.com/v2fly/v2ray-core/v5/common/session"
+	"github.com/v2fly/v2ray-core/v5/features/dns"
+	"github.com/v2fly/v2ray-core/v5/features/policy"
+	"github.com/v2fly/v2ray-core/v5/transport"
+	"github.com/v2fly/v2ray-core/v5/transport/pipe"
+	"golang.org/x/crypto/ssh"
+)
+
+type staticDNS map[string]net.IP
+
+func (staticDNS) Type() interface{} {
+	return dns.ClientType()
+}
+
+func (staticDNS) Start() error {
+	return nil
+}
+
+func (staticDNS) Close() error {
+	return nil
+}
+
+func (d staticDNS) LookupIP(domain string) ([]net.IP, error) {
+	if ip, found := d[domain]; found {
+		return []net.IP{ip}, nil
+	}
+	return nil, newError("domain not found: ", domain)
+}
+
+func TestResolveRules(t *testing.T) {
+	client := &Client{}
+	common.Must(client.Init(&Config{
+		Address: net.NewIPOrDomain(net.LocalHostIP),
+		Port:    22,
+		ResolveRules: []*ResolveRule{
+			{Domain: []string{"*.corp.example.com"}, Resolution: Resolution_Remote},
+			{Domain: []string{"*.example.com", "example.org"}, Resolution: Resolution_Local},
+		},
+	}, policy.DefaultManager{}, staticDNS{
+		"www.example.com": net.ParseIP("192.0.2.1"),
+	}))
+
+	cases := []struct {
+		domain   string
+		expected net.Address
+	}{
+		{"www.example.com", net.ParseAddress("192.0.2.1")},
+		{"git.corp.example.com", net.DomainAddress("git.corp.example.com")},
+		{"example.net", net.DomainAddress("example.net")},
+	}
+	for _, c := range cases {
+		dest, err := client.resolve(context.Background(), net.TCPDestination(net.DomainAddress(c.domain), 443))
+		if err != nil {
+			t.Error(c.domain, ": ", err)
+			continue
+		}
+		if dest.Address != c.expected || dest.Port != 443 {
+			t.Error(c.domain, ": expected ", c.expected, ", but actually ", dest)
+		}
+	}
+
+	if _, err := client.resolve(context.Background(), net.TCPDestination(net.DomainAddress("example.org"), 443)); err == nil {
+		t.Error("expected failed local resolution to be an error")
+	}
+}
+
+func TestForwardHostForm(t *testing.T) {
+	hosts := make(chan string, 1)
+	dialer := &pipeDialer{
+		config: newTestServerConfig(t),
+		handleChannel: func(newChannel ssh.NewChannel) {
+			var payload directTCPIPPayload
+			if err := ssh.Unmarshal(newChannel.ExtraData(), &payload); err != nil {
+				newChannel.Reject(ssh.ConnectionFailed, err.Error())
+				return
+			}
+			hosts <- payload.Raddr
+			newChannel.Reject(ssh.ConnectionFailed, "recorded")
+		},
+	}
+	resolver := staticDNS{
+		"www.example.com":   net.ParseIP("192.0.2.1"),
+		"other.example.net": net.ParseIP("192.0.2.2"),
+	}
+
+	cases := []struct {
+		form     ForwardHostForm
+		target   net.Address
+		expected string
+	}{
+		{ForwardHostForm_ForwardHostAuto, net.DomainAddress("www.example.com"), "192.0.2.1"},
+		{ForwardHostForm_ForwardHostAuto, net.DomainAddress("other.example.net"), "other.example.net"},
+		{ForwardHostForm_ForwardHostIP, net.DomainAddress("other.example.net"), "192.0.2.2"},
+		{ForwardHostForm_ForwardHostName, net.DomainAddress("www.example.com"), "www.example.com"},
+		{ForwardHostForm_ForwardHostName, net.ParseAddress("198.51.100.1"), "198.51.100.1"},
+	}
+	for _, c := range cases {
+		client := &Client{}
+		common.Must(client.Init(&Config{
+			Address:                  net.NewIPOrDomain(net.LocalHostIP),
+			Port:                     22,
+			InsecureSkipHostKeyCheck: true,
+			ForwardHostForm:          c.form,
+			ResolveRules: []*ResolveRule{
+				{Domain: []string{"www.example.com"}, Resolution: Resolution_Local},
+			},
+		}, policy.DefaultManager{}, resolver))
+		ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{
+			Target: net.TCPDestination(c.target, 443),
+		})
+		uplinkReader, _ := pipe.New()
+		_, downlinkWriter := pipe.New()
+		client.Process(ctx, &transport.Link{Reader: uplinkReader, Writer: downlinkWriter}, dialer)
+		client.Close()
+
+		if host := <-hosts; host != c.expected {
+			t.Error(c.form, " ", c.target, ": expected host ", c.expected, ", but actually ", host)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package ssh
+
+import (
+	"context"
+	"crypto/subtle"
+	"sync"
+	"time"
+
+	core "github.com/v2fly/v2ray-core/v5"
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/buf"
+	"github.com/v2fly/v2ray-core/v5/common/log"
+	"github.com/v2fly/v2ray-core/v5/common/net"
+	"github.com/v2fly/v2ray-core/v5/common/protocol"
+	"github.com/v2fly/v2ray-core/v5/common/session"
+	"github.com/v2fly/v2ray-core/v5/common/signal/done"
+	"github.com/v2fly/v2ray-core/v5/common/task"
+	"github.com/v2fly/v2ray-core/v5/features/policy"
+	"github.com/v2fly/v2ray-core/v5/features/routing"
+	"github.com/v2fly/v2ray-core/v5/transport/internet"
+	"golang.org/x/crypto/ssh"
+)
+
+// Server is an ssh inbound, forwarding the direct-tcpip channels of
+// authenticated users through routing.
+type Server struct {
+	config        *ServerConfig
+	policyManager policy.Manager
+	accounts      map[string]*serverAccount
+	sshConfig     *ssh.ServerConfig
+	done          *done.Instance
+}
+
+type serverAccount struct {
+	password string
+	keys     map[string]bool
+	keysDir  *keysDir
+	level    uint32
+	permit   *permitOpen
+}
+
+func (s *Server) Init(config *ServerConfig, policyManager policy.Manager) error {
+	if len(config.HostKeys) == 0 {
+		return newError("ssh inbound requires a host key")
+	}
+	s.config = config
+	s.policyManager = policyManager
+	s.accounts = make(map[string]*serverAccount, len(config.Accounts))
+	permit, err := newPermitOpen(config.PermitOpen)
+	if err != nil {
+		return err
+	}
+	var dirs []*keysDir
+	for i, account := range config.Accounts {
+		if account.User == "" {
+			return newError("ssh inbound account ", i, " has no user")
+		}
+		if _, found := s.accounts[account.User]; found {
+			return newError("duplicate ssh inbound account ", account.User)
+		}
+		parsed := &serverAccount{
+			password: account.Password,
+			keys:     make(map[string]bool),
+			level:    account.Level,
+			permit:   permit,
+		}
+		if len(account.PermitOpen) > 0 {
+			parsed.permit, err = newPermitOpen(account.PermitOpen)
+			if err != nil {
+				return newError("ssh inbound account ", account.User).Base(err)
+			}
+		}
+		for _, authorized := range account.AuthorizedKeys {
+			if err := parseAuthorizedKeys(authorized, parsed.keys); err != nil {
+				return newError("invalid authorized key of ssh inbound account ", account.User).Base(err)
+			}
+		}
+		if account.AuthorizedKeysDir != "" {
+			dir, err := newKeysDir(account.AuthorizedKeysDir)
+			if err != nil {
+				return newError("ssh inbound account ", account.User).Base(err)
+			}
+			parsed.keysDir = dir
+			dirs = append(dirs, dir)
+		}
+		if parsed.password == "" && len(parsed.keys) == 0 && parsed.keysDir == nil {
+			return newError("ssh inbound account ", account.User, " has neither password nor authorized keys")
+		}
+		s.accounts[account.User] = parsed
+	}
+
+	s.sshConfig = &ssh.ServerConfig{
+		ServerVersion:     config.ServerVersion,
+		PasswordCallback:  s.checkPassword,
+		PublicKeyCallback: s.checkPublicKey,
+		KeyboardInteractiveCallback: func(conn ssh.ConnMetadata, challenge ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error) {
+			answers, err := challenge("", "", []string{"Password: "}, []bool{false})
+			if err != nil {
+				return nil, err
+			}
+			if len(answers) != 1 {
+				return nil, newError("expected a single answer")
+			}
+			return s.checkPassword(conn, []byte(answers[0]))
+		},
+	}
+	if s.sshConfig.ServerVersion == "" {
+		s.sshConfig.ServerVersion = randomVersion()
+	}
+	for i, key := range config.HostKeys {
+		signer, err := ssh.ParsePrivateKey([]byte(key))
+		if err != nil {
+			return newError("parse host_keys[", i, "]").Base(err)
+		}
+		s.sshConfig.AddHostKey(signer)
+	}
+	s.done = done.New()
+	if len(dirs) > 0 {
+		go rescanKeysDirs(dirs, s.done.Wait())
+	}
+	return nil
+}
+
+// Close implements common.Closable. It stops reloading authorized keys.
+func (s *Server) Close() error {
+	if s.done != nil {
+		return s.done.Close()
+	}
+	return nil
+}
+
+func (s *Server) checkPassword(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+	account := s.accounts[conn.User()]
+	if account == nil || account.password == "" || subtle.ConstantTimeCompare([]byte(account.password), password) != 1 {
+		return nil, newError("invalid password for ", conn.User())
+	}
+	return nil, nil
+}
+
+func (s *Server) checkPublicKey(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+	account := s.accounts[conn.User()]
+	if account == nil || !account.keys[string(key.Marshal())] && (account.keysDir == nil || !account.keysDir.contains(key)) {
+		return nil, newError("key not authorized for ", conn.User())
+	}
+	return nil, nil
+}
+
+// Network implements proxy.Inbound.
+func (*Server) Network() []net.Network {
+	return []net.Network{net.Network_TCP, net.Network_UNIX}
+}
+
+// Process implements proxy.Inbound.
+func (s *Server) Process(ctx context.Context, network net.Network, conn internet.Connection, dispatcher routing.Dispatcher) error {
+	if err := conn.SetDeadline(time.Now().Add(s.policyManager.ForLevel(0).Timeouts.Handshake)); err != nil {
+		newError("failed to set handshake deadline").Base(err).WriteToLog(session.ExportIDToError(ctx))
+	}
+	sc, chans, reqs, err := ssh.NewServerConn(conn, s.sshConfig)
+	if err != nil {
+		if inbound := session.InboundFromContext(ctx); inbound != nil && inbound.Source.IsValid() {
+			log.Record(&log.AccessMessage{
+				From:   inbound.Source,
+				To:     "",
+				Status: log.AccessRejected,
+				Reason: err,
+			})
+		}
+		return newError("failed to accept ssh connection").Base(err)
+	}
+	defer sc.Close()
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		newError("failed to clear handshake deadline").Base(err).WriteToLog(session.ExportIDToError(ctx))
+	}
+	go ssh.DiscardRequests(reqs)
+
+	account := s.accounts[sc.User()]
+	if inbound := session.InboundFromContext(ctx); inbound != nil {
+		inbound.User = &protocol.MemoryUser{
+			Email: sc.User(),
+			Level: account.level,
+		}
+	}
+	sessionPolicy := s.policyManager.ForLevel(account.level)
+
+	var wg sync.WaitGroup
+	for newChannel := range chans {
+		if newChannel.ChannelType() != defaultChannelType {
+			newChannel.Reject(ssh.UnknownChannelType, "only direct-tcpip channels are supported")
+			continue
+		}
+		var payload directTCPIPPayload
+		if err := ssh.Unmarshal(newChannel.ExtraData(), &payload); err != nil || payload.Rport == 0 || payload.Rport > 65535 {
+			newChannel.Reject(ssh.ConnectionFailed, "invalid direct-tcpip request")
+			continue
+		}
+		if !account.permit.Allowed(payload.Raddr, payload.Rport) {
+			newError("rejected forwarding of ", sc.User(), " to ", payload.Raddr, ":", payload.Rport, " not in permit open").AtInfo().WriteToLog(session.ExportIDToError(ctx))
+			newChannel.Reject(ssh.Prohibited, "forwarding to this destination is not permitted")
+			continue
+		}
+		destination := net.TCPDestination(net.ParseAddress(payload.Raddr), net.Port(payload.Rport))
+		channel, channelReqs, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go ssh.DiscardRequests(channelReqs)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.forward(ctx, channel, destination, dispatcher, sessionPolicy); err != nil {
+				newError("failed to forward ssh channel to ", destination).Base(err).WriteToLog(session.ExportIDToError(ctx))
+			}
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// forward passes the data of channel to destination through routing.
+func (s *Server) forward(ctx context.Context, channel ssh.Channel, destination net.Destination, dispatcher routing.Dispatcher, sessionPolicy policy.Session) error {
+	defer channel.Close()
+	newError("tunneling request to ", destination).WriteToLog(session.ExportIDToError(ctx))
+	if inbound := session.InboundFromContext(ctx); inbound != nil && inbound.Source.IsValid() {
+		ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{
+			From:   inbound.Source,
+			To:     destination,
+			Status: log.AccessAccepted,
+			Reason: "",
+			Email:  inbound.User.Email,
+		})
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	timer := sessionPolicy.CancelAfterInactivity(ctx, cancel)
+	ctx = policy.ContextWithBufferPolicy(ctx, sessionPolicy.Buffer)
+	link, err := dispatcher.Dispatch(ctx, destination)
+	if err != nil {
+		return err
+	}
+
+	requestDone := func() error {
+		defer timer.SetTimeout(sessionPolicy.Timeouts.DownlinkOnly)
+		return buf.Copy(buf.NewReader(channel), link.Writer, buf.UpdateActivity(timer))
+	}
+	responseDone := func() error {
+		defer timer.SetTimeout(sessionPolicy.Timeouts.UplinkOnly)
+		if err := buf.Copy(link.Reader, buf.NewWriter(channel), buf.UpdateActivity(timer)); err != nil {
+			return err
+		}
+		return channel.CloseWrite()
+	}
+	if err := task.Run(ctx, task.OnSuccess(requestDone, task.Close(link.Writer)), responseDone); err != nil {
+		common.Interrupt(link.Reader)
+		common.Interrupt(link.Writer)
+		return newError("connection ends").Base(err)
+	}
+	return nil
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*ServerConfig)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
+		s := &Server{}
+		return s, core.RequireFeatures(ctx, func(policyManager policy.Manager) error {
+			return s.Init(config.(*ServerConfig), policyManager)
+		})
+	}))
+}
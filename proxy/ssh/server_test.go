@@ -0,0 +1,334 @@
+package ssh
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/buf"
+	"github.com/v2fly/v2ray-core/v5/common/net"
+	"github.com/v2fly/v2ray-core/v5/common/session"
+	"github.com/v2fly/v2ray-core/v5/features/policy"
+	"github.com/v2fly/v2ray-core/v5/features/routing"
+	"github.com/v2fly/v2ray-core/v5/transport"
+	"github.com/v2fly/v2ray-core/v5/transport/internet"
+	"github.com/v2fly/v2ray-core/v5/transport/pipe"
+	"golang.org/x/crypto/ssh"
+)
+
+// echoDispatcher echoes the data of each dispatched connection and reports
+// its destination and user.
+type echoDispatcher struct {
+	destinations chan net.Destination
+	users        chan string
+}
+
+func (*echoDispatcher) Type() interface{} {
+	return routing.DispatcherType()
+}
+
+func (*echoDispatcher) Start() error {
+	return nil
+}
+
+func (*echoDispatcher) Close() error {
+	return nil
+}
+
+func (d *echoDispatcher) Dispatch(ctx context.Context, dest net.Destination) (*transport.Link, error) {
+	d.destinations <- dest
+	if inbound := session.InboundFromContext(ctx); inbound != nil && inbound.User != nil {
+		d.users <- inbound.User.Email + "@" + string(rune('0'+inbound.User.Level))
+	}
+	uplinkReader, uplinkWriter := pipe.New()
+	downlinkReader, downlinkWriter := pipe.New()
+	go func() {
+		buf.Copy(uplinkReader, downlinkWriter)
+		downlinkWriter.Close()
+	}()
+	return &transport.Link{Reader: downlinkReader, Writer: uplinkWriter}, nil
+}
+
+func (d *echoDispatcher) DispatchLink(ctx context.Context, dest net.Destination, outbound *transport.Link) error {
+	return newError("not implemented")
+}
+
+func (d *echoDispatcher) DispatchConn(ctx context.Context, dest net.Destination, conn net.Conn, wait bool) error {
+	return newError("not implemented")
+}
+
+// serverDialer connects to server, processing the connection as accepted by
+// an inbound.
+type serverDialer struct {
+	server     *Server
+	dispatcher routing.Dispatcher
+	errs       chan error
+}
+
+func (d *serverDialer) Dial(ctx context.Context, destination net.Destination) (internet.Connection, error) {
+	clientConn, serverConn, err := connPair()
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		ctx := session.ContextWithInbound(context.Background(), &session.Inbound{
+			Source: net.DestinationFromAddr(serverConn.RemoteAddr()),
+		})
+		err := d.server.Process(ctx, net.Network_TCP, serverConn, d.dispatcher)
+		serverConn.Close()
+		d.errs <- err
+	}()
+	return clientConn, nil
+}
+
+func (d *serverDialer) Address() net.Address {
+	return nil
+}
+
+func newTestServer(t *testing.T, accounts ...*Account) (*Server, string) {
+	hostKey, _ := generatePrivateKey(t)
+	server := &Server{}
+	common.Must(server.Init(&ServerConfig{HostKeys: []string{hostKey}, Accounts: accounts}, policy.DefaultManager{}))
+	signer, err := ssh.ParsePrivateKey([]byte(hostKey))
+	common.Must(err)
+	return server, string(ssh.MarshalAuthorizedKey(signer.PublicKey()))
+}
+
+func TestServerForwardsChannels(t *testing.T) {
+	userKey, userPublic := generatePrivateKey(t)
+	server, hostKey := newTestServer(t,
+		&Account{User: "alice", Password: "secret", Level: 1},
+		&Account{User: "bob", AuthorizedKeys: []string{string(ssh.MarshalAuthorizedKey(userPublic))}, Level: 2},
+	)
+	dispatcher := &echoDispatcher{
+		destinations: make(chan net.Destination, 1),
+		users:        make(chan string, 1),
+	}
+	dialer := &serverDialer{server: server, dispatcher: dispatcher, errs: make(chan error, 1)}
+
+	cases := []struct {
+		config *Config
+		user   string
+	}{
+		{&Config{User: "alice", Password: "secret", PublicKey: hostKey}, "alice@1"},
+		{&Config{User: "bob", PrivateKey: userKey, PublicKey: hostKey}, "bob@2"},
+	}
+	for _, c := range cases {
+		client := newTestClient(t, c.config)
+		sc, err := client.sshClient(context.Background(), dialer)
+		if err != nil {
+			t.Fatal(c.user, ": failed to connect to the ssh inbound: ", err)
+		}
+		conn, err := client.openChannel(context.Background(), sc, net.TCPDestination(net.DomainAddress("example.com"), 443))
+		common.Must(err)
+		common.Must2(conn.Write([]byte("ping")))
+		conn.(*channelConn).CloseWrite()
+		received, err := io.ReadAll(conn)
+		common.Must(err)
+		if string(received) != "ping" {
+			t.Error(c.user, ": expected echo ping, but actually ", string(received))
+		}
+		if destination := <-dispatcher.destinations; destination != net.TCPDestination(net.DomainAddress("example.com"), 443) {
+			t.Error(c.user, ": unexpected destination ", destination)
+		}
+		if user := <-dispatcher.users; user != c.user {
+			t.Error("expected user and level ", c.user, ", but actually ", user)
+		}
+		conn.Close()
+		client.Close()
+		if err := <-dialer.errs; err != nil {
+			t.Error(c.user, ": ", err)
+		}
+	}
+
+	wrong := newTestClient(t, &Config{User: "alice", Password: "wrong", PublicKey: hostKey})
+	if _, err := wrong.sshClient(context.Background(), dialer); err == nil {
+		t.Error("expected a wrong password to be rejected")
+	}
+	if err := <-dialer.errs; err == nil {
+		t.Error("expected the inbound to report the failed authentication")
+	}
+}
+
+func TestServerPermitOpen(t *testing.T) {
+	hostKey, _ := generatePrivateKey(t)
+	server := &Server{}
+	common.Must(server.Init(&ServerConfig{
+		HostKeys:   []string{hostKey},
+		PermitOpen: []string{"*.example.com:443"},
+		Accounts: []*Account{
+			{User: "alice", Password: "secret"},
+			{User: "bob", Password: "secret", PermitOpen: []string{"none"}},
+		},
+	}, policy.DefaultManager{}))
+	signer, err := ssh.ParsePrivateKey([]byte(hostKey))
+	common.Must(err)
+	publicKey := string(ssh.MarshalAuthorizedKey(signer.PublicKey()))
+	dispatcher := &echoDispatcher{
+		destinations: make(chan net.Destination, 1),
+		users:        make(chan string, 1),
+	}
+	dialer := &serverDialer{server: server, dispatcher: dispatcher, errs: make(chan error, 1)}
+
+	open := func(sc *ssh.Client, destination net.Destination) error {
+		channel, reqs, err := sc.OpenChannel(defaultChannelType, ssh.Marshal(&directTCPIPPayload{
+			Raddr: destination.Address.String(),
+			Rport: uint32(destination.Port),
+		}))
+		if err != nil {
+			return err
+		}
+		go ssh.DiscardRequests(reqs)
+		common.Must2(channel.Write([]byte("ping")))
+		channel.CloseWrite()
+		received, err := io.ReadAll(channel)
+		channel.Close()
+		if err != nil {
+			return err
+		}
+		if string(received) != "ping" {
+			t.Error("expected echo ping, but actually ", string(received))
+		}
+		<-dispatcher.destinations
+		<-dispatcher.users
+		return nil
+	}
+	prohibited := func(err error) bool {
+		openErr, ok := err.(*ssh.OpenChannelError)
+		return ok && openErr.Reason == ssh.Prohibited
+	}
+
+	cases := []struct {
+		user    string
+		allowed []net.Destination
+		denied  []net.Destination
+	}{
+		{
+			user:    "alice",
+			allowed: []net.Destination{net.TCPDestination(net.DomainAddress("www.example.com"), 443)},
+			denied: []net.Destination{
+				net.TCPDestination(net.DomainAddress("www.example.com"), 80),
+				net.TCPDestination(net.DomainAddress("example.org"), 443),
+				net.TCPDestination(net.LocalHostIP, 443),
+			},
+		},
+		{
+			user:   "bob",
+			denied: []net.Destination{net.TCPDestination(net.DomainAddress("www.example.com"), 443)},
+		},
+	}
+	for _, c := range cases {
+		client := newTestClient(t, &Config{User: c.user, Password: "secret", PublicKey: publicKey})
+		sc, err := client.sshClient(context.Background(), dialer)
+		if err != nil {
+			t.Fatal(c.user, ": failed to connect to the ssh inbound: ", err)
+		}
+		for _, destination := range c.allowed {
+			if err := open(sc, destination); err != nil {
+				t.Error(c.user, ": expected forwarding to ", destination, " to be permitted, but actually ", err)
+			}
+		}
+		for _, destination := range c.denied {
+			if err := open(sc, destination); !prohibited(err) {
+				t.Error(c.user, ": expected forwarding to ", destination, " to be prohibited, but actually ", err)
+			}
+		}
+		client.Close()
+		if err := <-dialer.errs; err != nil {
+			t.Error(c.user, ": ", err)
+		}
+	}
+}
+
+func TestServerConfigValidation(t *testing.T) {
+	hostKey, _ := generatePrivateKey(t)
+	cases := []*ServerConfig{
+		{Accounts: []*Account{{User: "alice", Password: "secret"}}},
+		{HostKeys: []string{"not a key"}, Accounts: []*Account{{User: "alice", Password: "secret"}}},
+		{HostKeys: []string{hostKey}, Accounts: []*Account{{User: "alice"}}},
+		{HostKeys: []string{hostKey}, Accounts: []*Account{{User: "alice", AuthorizedKeys: []string{"ssh-ed25519 not-base64"}}}},
+		{HostKeys: []string{hostKey}, Accounts: []*Account{{User: "alice", Password: "a"}, {User: "alice", Password: "b"}}},
+		{HostKeys: []string{hostKey}, Accounts: []*Account{{User: "alice", Password: "secret"}}, PermitOpen: []string{"example.com"}},
+		{HostKeys: []string{hostKey}, Accounts: []*Account{{User: "alice", Password: "secret", PermitOpen: []string{"any", "example.com:443"}}}},
+	}
+	for i, config := range cases {
+		if err := (&Server{}).Init(config, policy.DefaultManager{}); err == nil {
+			t.Error("expected config ", i, " to be rejected")
+		}
+	}
+}
+
+func TestAuthorizedKeysDir(t *testing.T) {
+	rescan := authorizedKeysRescan
+	authorizedKeysRescan = 20 * time.Millisecond
+	defer func() { authorizedKeysRescan = rescan }()
+
+	dir := t.TempDir()
+	common.Must(os.WriteFile(filepath.Join(dir, "broken.pub"), []byte("not a key\n"), 0o600))
+	server, hostKey := newTestServer(t, &Account{User: "carol", AuthorizedKeysDir: dir})
+	defer server.Close()
+	dialer := &serverDialer{server: server, dispatcher: &echoDispatcher{}, errs: make(chan error, 1)}
+
+	userKey, userPublic := generatePrivateKey(t)
+	client := newTestClient(t, &Config{User: "carol", PrivateKey: userKey, PublicKey: hostKey})
+	if _, err := client.sshClient(context.Background(), dialer); err == nil {
+		t.Fatal("expected a key not in the directory to be rejected")
+	}
+	<-dialer.errs
+
+	keyFile := filepath.Join(dir, "carol.pub")
+	common.Must(os.WriteFile(keyFile, ssh.MarshalAuthorizedKey(userPublic), 0o600))
+	authenticate := func() bool {
+		sc, err := newTestClient(t, &Config{User: "carol", PrivateKey: userKey, PublicKey: hostKey}).sshClient(context.Background(), dialer)
+		if sc != nil {
+			sc.Close()
+		}
+		<-dialer.errs
+		return err == nil
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !authenticate() {
+		if time.Now().After(deadline) {
+			t.Fatal("key added to the directory not picked up")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	common.Must(os.Remove(keyFile))
+	for authenticate() {
+		if time.Now().After(deadline) {
+			t.Fatal("key removed from the directory still accepted")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if err := (&Server{}).Init(&ServerConfig{
+		HostKeys: []string{userKey},
+		Accounts: []*Account{{User: "carol", AuthorizedKeysDir: filepath.Join(dir, "missing")}},
+	}, policy.DefaultManager{}); err == nil {
+		t.Error("expected a missing authorized keys directory to be rejected")
+	}
+}
+
+func TestAuthorizedKeysDirSkipsBadFile(t *testing.T) {
+	_, before := generatePrivateKey(t)
+	_, after := generatePrivateKey(t)
+	_, other := generatePrivateKey(t)
+	dir := t.TempDir()
+	mixed := string(ssh.MarshalAuthorizedKey(before)) + "not a key\n" + string(ssh.MarshalAuthorizedKey(after))
+	common.Must(os.WriteFile(filepath.Join(dir, "mixed.pub"), []byte(mixed), 0o600))
+	common.Must(os.WriteFile(filepath.Join(dir, "other.pub"), ssh.MarshalAuthorizedKey(other), 0o600))
+
+	d, err := newKeysDir(dir)
+	common.Must(err)
+	if d.contains(before) || d.contains(after) {
+		t.Error("expected no key of a file with a bad line to be loaded")
+	}
+	if !d.contains(other) {
+		t.Error("expected the keys of the other file to be loaded")
+	}
+}
@@ -0,0 +1,60 @@
+package ssh
+
+import (
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common/net"
+	"golang.org/x/crypto/ssh"
+)
+
+// sshServer is a server the client connects to, with its own settings or the
+// ones of the config where it has none.
+type sshServer struct {
+	// offerPassphrase is accessed atomically, 1 once the server refused
+	// every method, see forgetPassphraseAssumption.
+	offerPassphrase int32
+
+	destination net.Destination
+	// keepAliveInterval is 0 without keepalives.
+	keepAliveInterval time.Duration
+	handshakeDeadline time.Duration
+	hostKeyCallback   ssh.HostKeyCallback
+}
+
+// newServers returns address:port of config followed by its fallback
+// servers. Servers with their own public_key verify host keys with it and
+// knownHostsCallback instead of with public_key of config.
+func (c *Client) newServers(config *Config, knownHostsCallback ssh.HostKeyCallback) ([]*sshServer, error) {
+	servers := []*sshServer{c.newServer(net.TCPDestination(config.Address.AsAddress(), net.Port(config.Port)))}
+	for _, endpoint := range config.Servers {
+		if endpoint.Address == nil || endpoint.Port == 0 {
+			return nil, newError("invalid ssh server, address and port are required")
+		}
+		server := c.newServer(net.TCPDestination(endpoint.Address.AsAddress(), net.Port(endpoint.Port)))
+		if endpoint.KeepAliveInterval > 0 {
+			server.keepAliveInterval = time.Duration(endpoint.KeepAliveInterval) * time.Second
+		}
+		if endpoint.HandshakeDeadline > 0 {
+			server.handshakeDeadline = time.Duration(endpoint.HandshakeDeadline) * time.Second
+		}
+		if endpoint.PublicKey != "" {
+			callback, err := newHostKeyCallback(config, endpoint.PublicKey, knownHostsCallback)
+			if err != nil {
+				return nil, newError("invalid public key of ssh server ", server.destination).Base(err)
+			}
+			server.hostKeyCallback = callback
+		}
+		servers = append(servers, server)
+	}
+	return servers, nil
+}
+
+// newServer returns destination with the settings of the config.
+func (c *Client) newServer(destination net.Destination) *sshServer {
+	return &sshServer{
+		destination:       destination,
+		keepAliveInterval: time.Duration(c.config.KeepAliveInterval) * time.Second,
+		handshakeDeadline: c.handshakeDeadline(),
+		hostKeyCallback:   c.hostKeyCallback,
+	}
+}
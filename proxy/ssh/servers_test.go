@@ -0,0 +1,113 @@
+package ssh
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/net"
+	"github.com/v2fly/v2ray-core/v5/common/session"
+	"github.com/v2fly/v2ray-core/v5/transport/internet"
+)
+
+// upDialer connects to the server on port up only, the others refuse.
+type upDialer struct {
+	pipeDialer
+	up net.Port
+}
+
+func (d *upDialer) Dial(ctx context.Context, destination net.Destination) (internet.Connection, error) {
+	if destination.Port != d.up {
+		return nil, newError("connection refused")
+	}
+	return d.pipeDialer.Dial(ctx, destination)
+}
+
+// slotDropped returns true if the connection of the first slot of client is
+// dropped within timeout.
+func slotDropped(client *Client, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		slot := client.slots[0]
+		slot.Lock()
+		current := slot.client
+		slot.Unlock()
+		if current == nil {
+			return true
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return false
+}
+
+func TestPerServerKeepAlive(t *testing.T) {
+	newConfig := func() *Config {
+		return &Config{
+			Port:              22,
+			KeepAliveInterval: 60,
+			Servers: []*Endpoint{
+				{Address: net.NewIPOrDomain(net.LocalHostIP), Port: 23, KeepAliveInterval: 1},
+			},
+		}
+	}
+	serverConfig := newTestServerConfig(t)
+	ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{
+		Target: net.TCPDestination(net.DomainAddress("example.com"), 443),
+	})
+
+	// The bastion on 22 is down, keepalives to 23 go unanswered.
+	backend := newTestClient(t, newConfig())
+	defer backend.Close()
+	if interval := backend.servers[0].keepAliveInterval; interval != time.Minute {
+		t.Error("expected the keepalive interval of the config, but actually ", interval)
+	}
+	if interval := backend.servers[1].keepAliveInterval; interval != time.Second {
+		t.Error("expected the keepalive interval of the server, but actually ", interval)
+	}
+	_, err := backend.sshClient(ctx, &upDialer{pipeDialer: pipeDialer{config: serverConfig, unresponsive: true}, up: 23})
+	common.Must(err)
+	if !slotDropped(backend, 5*time.Second) {
+		t.Error("connection with unanswered keepalives every second was not dropped")
+	}
+
+	// Connected to 22, the keepalive is not due before a minute.
+	bastion := newTestClient(t, newConfig())
+	defer bastion.Close()
+	_, err = bastion.sshClient(ctx, &upDialer{pipeDialer: pipeDialer{config: serverConfig, unresponsive: true}, up: 22})
+	common.Must(err)
+	if slotDropped(bastion, 2500*time.Millisecond) {
+		t.Error("connection dropped before its keepalive was due")
+	}
+}
+
+func TestPerServerHostKeys(t *testing.T) {
+	keys, pinned := generateHostKeys(t, 2)
+	client := newTestClient(t, &Config{
+		HandshakeDeadline: 30,
+		Servers: []*Endpoint{
+			{Address: net.NewIPOrDomain(net.LocalHostIP), Port: 2222, PublicKey: pinned, HandshakeDeadline: 5},
+		},
+	})
+
+	// The first server falls back to the insecure check of the config.
+	if err := client.servers[0].hostKeyCallback("localhost:22", nil, keys[0]); err != nil {
+		t.Error("key rejected without pins: ", err)
+	}
+	others, _ := generateHostKeys(t, 1)
+	for _, key := range keys {
+		if err := client.servers[1].hostKeyCallback("localhost:2222", nil, key); err != nil {
+			t.Error("pinned key rejected: ", err)
+		}
+	}
+	if err := client.servers[1].hostKeyCallback("localhost:2222", nil, others[0]); err == nil {
+		t.Error("expected unpinned key to be rejected")
+	}
+
+	if deadline := client.servers[0].handshakeDeadline; deadline != 30*time.Second {
+		t.Error("expected the handshake deadline of the config, but actually ", deadline)
+	}
+	if deadline := client.servers[1].handshakeDeadline; deadline != 5*time.Second {
+		t.Error("expected the handshake deadline of the server, but actually ", deadline)
+	}
+}
@@ -0,0 +1,28 @@
+package ssh
+
+import (
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// withoutTerrapinAffected returns the ciphers and MACs of config, or of the
+// library defaults, less those open to the Terrapin attack without strict
+// key exchange: chacha20-poly1305, and the encrypt-then-MAC MACs that make
+// CBC ciphers open to it. The ssh library does not implement strict key
+// exchange, so only leaving these out guards the connection.
+func withoutTerrapinAffected(config ssh.Config) ([]string, []string) {
+	config.SetDefaults()
+	var ciphers, macs []string
+	for _, cipher := range config.Ciphers {
+		if cipher != "chacha20-poly1305@openssh.com" {
+			ciphers = append(ciphers, cipher)
+		}
+	}
+	for _, mac := range config.MACs {
+		if !strings.HasSuffix(mac, "-etm@openssh.com") {
+			macs = append(macs, mac)
+		}
+	}
+	return ciphers, macs
+}
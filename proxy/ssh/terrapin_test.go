@@ -0,0 +1,38 @@
+package ssh
+
+import (
+	"context"
+	"testing"
+
+	"github.com/v2fly/v2ray-core/v5/common/net"
+	"github.com/v2fly/v2ray-core/v5/features/policy"
+)
+
+func TestAvoidTerrapinAlgorithms(t *testing.T) {
+	server := newTestServerConfig(t)
+	server.Ciphers = []string{"chacha20-poly1305@openssh.com", "aes128-ctr"}
+	client := newTestClient(t, &Config{AvoidTerrapinAlgorithms: true})
+	defer client.Close()
+	sc, err := client.sshClient(context.Background(), &pipeDialer{config: server})
+	if err != nil {
+		t.Fatal("expected the handshake with a server offering aes128-ctr to succeed, but got ", err)
+	}
+	sc.Close()
+
+	// Only the chacha20-poly1305 open to the Terrapin attack is in common.
+	affected := newTestServerConfig(t)
+	affected.Ciphers = []string{"chacha20-poly1305@openssh.com"}
+	if _, err := newTestClient(t, &Config{AvoidTerrapinAlgorithms: true}).sshClient(context.Background(), &pipeDialer{config: affected}); err == nil {
+		t.Error("expected chacha20-poly1305 not to be offered")
+	}
+
+	err = (&Client{}).Init(&Config{
+		Address:                 net.NewIPOrDomain(net.LocalHostIP),
+		Port:                    22,
+		AvoidTerrapinAlgorithms: true,
+		Ciphers:                 []string{"chacha20-poly1305@openssh.com"},
+	}, policy.DefaultManager{}, nil)
+	if err == nil {
+		t.Error("expected only ciphers open to the Terrapin attack to be rejected")
+	}
+}
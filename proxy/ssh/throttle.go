@@ -0,0 +1,83 @@
+package ssh
+
+import (
+	"io"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common/net"
+)
+
+// errThrottled is returned when the server closes the connection cleanly
+// before sending its version, which is how OpenSSH drops connections above
+// its MaxStartups limit.
+var errThrottled = newError("ssh server closed the connection before the handshake, it may be throttling new connections (MaxStartups)").AtWarning()
+
+const throttleRetries = 4
+
+// throttleBackoff is the mean delay before the first retry of a throttled
+// connection. It doubles with every further retry.
+var throttleBackoff = 500 * time.Millisecond
+
+// isThrottled returns true if the server closed conn cleanly before sending
+// anything. A reset or a failure of our side is not throttling. It must be
+// called before conn is closed, which fails the reads of the transport.
+func isThrottled(conn *countingConn) bool {
+	return conn.bytesRead() == 0 && conn.readError() == io.EOF
+}
+
+// throttleDelay returns the delay before retry attempt, starting at 0, picked
+// at random so clients throttled together do not come back together.
+func throttleDelay(attempt int) time.Duration {
+	mean := throttleBackoff << attempt
+	return mean/2 + time.Duration(rand.Int63n(int64(mean)))
+}
+
+// countingConn counts the bytes read from and written to the server, and
+// keeps the error the first failed read returned.
+type countingConn struct {
+	net.Conn
+	read    int64
+	written int64
+
+	access  sync.Mutex
+	readErr error
+}
+
+func (c *countingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	atomic.AddInt64(&c.read, int64(n))
+	if err != nil {
+		c.access.Lock()
+		if c.readErr == nil {
+			c.readErr = err
+		}
+		c.access.Unlock()
+	}
+	return n, err
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	atomic.AddInt64(&c.written, int64(n))
+	return n, err
+}
+
+// bytesRead returns the number of bytes read so far. The ssh transport keeps
+// reading in its own goroutine.
+func (c *countingConn) bytesRead() int64 {
+	return atomic.LoadInt64(&c.read)
+}
+
+func (c *countingConn) bytesWritten() int64 {
+	return atomic.LoadInt64(&c.written)
+}
+
+// readError returns the error the first failed read returned, if any.
+func (c *countingConn) readError() error {
+	c.access.Lock()
+	defer c.access.Unlock()
+	return c.readErr
+}
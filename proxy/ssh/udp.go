@@ -0,0 +1,110 @@
+package ssh
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+
+	"github.com/v2fly/v2ray-core/v5/common/buf"
+	"github.com/v2fly/v2ray-core/v5/common/net"
+	"github.com/v2fly/v2ray-core/v5/common/task"
+	"github.com/v2fly/v2ray-core/v5/transport"
+	"github.com/v2fly/v2ray-core/v5/transport/internet"
+)
+
+// errUDPNotSupported is returned for UDP connections unless enable_udp is
+// set. SSH can only forward TCP by itself.
+var errUDPNotSupported = newError("UDP is not supported by the ssh outbound without enable_udp")
+
+const maxDatagramSize = 65535
+
+// processUDP relays the datagrams of link over a channel to udp_relay. The
+// relay reads the destination from the first frame, then sends each
+// following frame as a datagram to it and frames the datagrams it receives
+// back. A frame is a 16-bit big-endian length and as many bytes.
+func (c *Client) processUDP(ctx context.Context, link *transport.Link, dialer internet.Dialer, destination net.Destination) error {
+	slot, release, err := c.acquireChannel(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	sc, err := c.slotClient(ctx, slot, dialer)
+	if err != nil {
+		return err
+	}
+	conn, err := c.openChannel(ctx, sc, c.udpRelay)
+	if err != nil {
+		return newError("failed to open ssh udp relay channel to ", c.udpRelay).Base(err)
+	}
+	defer conn.Close()
+	c.trackChannel(slot, 1)
+	defer c.trackChannel(slot, -1)
+
+	if err := writeFrame(conn, []byte(destination.NetAddr())); err != nil {
+		return newError("failed to send udp destination to relay").Base(err)
+	}
+
+	ctx, cancel := c.withMaxDuration(ctx)
+	defer cancel()
+	timer := c.sessionPolicy.CancelAfterInactivity(ctx, cancel)
+	err = task.Run(ctx, func() error {
+		defer timer.SetTimeout(c.sessionPolicy.Timeouts.DownlinkOnly)
+		return buf.Copy(link.Reader, &datagramWriter{conn}, buf.UpdateActivity(timer))
+	}, func() error {
+		defer timer.SetTimeout(c.sessionPolicy.Timeouts.UplinkOnly)
+		return buf.Copy(&datagramReader{Reader: conn, source: destination}, link.Writer, buf.UpdateActivity(timer))
+	})
+	if err != nil {
+		return c.endError(ctx, err)
+	}
+	return nil
+}
+
+func writeFrame(w io.Writer, payload []byte) error {
+	frame := make([]byte, 2+len(payload))
+	binary.BigEndian.PutUint16(frame, uint16(len(payload)))
+	copy(frame[2:], payload)
+	_, err := w.Write(frame)
+	return err
+}
+
+// datagramWriter frames each buffer as a datagram.
+type datagramWriter struct {
+	io.Writer
+}
+
+func (w *datagramWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	defer buf.ReleaseMulti(mb)
+	for _, b := range mb {
+		if b.Len() > maxDatagramSize {
+			newError("dropping udp datagram of ", b.Len(), " bytes").AtDebug().WriteToLog()
+			continue
+		}
+		if err := writeFrame(w.Writer, b.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// datagramReader reads framed datagrams, each into a buffer from source.
+type datagramReader struct {
+	io.Reader
+	source net.Destination
+}
+
+func (r *datagramReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(r.Reader, header[:]); err != nil {
+		return nil, err
+	}
+	size := int32(binary.BigEndian.Uint16(header[:]))
+	b := buf.NewSize(size)
+	if _, err := b.ReadFullFrom(r.Reader, size); err != nil {
+		b.Release()
+		return nil, err
+	}
+	source := r.source
+	b.Endpoint = &source
+	return buf.MultiBuffer{b}, nil
+}
@@ -0,0 +1,120 @@
+package ssh
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/v2fly/v2ray-core/v5/common"
+	"github.com/v2fly/v2ray-core/v5/common/buf"
+	"github.com/v2fly/v2ray-core/v5/common/net"
+	"github.com/v2fly/v2ray-core/v5/common/session"
+	"github.com/v2fly/v2ray-core/v5/features/policy"
+	"github.com/v2fly/v2ray-core/v5/transport"
+	"github.com/v2fly/v2ray-core/v5/transport/pipe"
+	"golang.org/x/crypto/ssh"
+)
+
+func readFrame(r io.Reader) ([]byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	payload := make([]byte, binary.BigEndian.Uint16(header[:]))
+	_, err := io.ReadFull(r, payload)
+	return payload, err
+}
+
+func TestUDPRelay(t *testing.T) {
+	relays := make(chan string, 1)
+	destinations := make(chan string, 1)
+	dialer := &pipeDialer{
+		config: newTestServerConfig(t),
+		handleChannel: func(newChannel ssh.NewChannel) {
+			var payload directTCPIPPayload
+			if err := ssh.Unmarshal(newChannel.ExtraData(), &payload); err != nil {
+				newChannel.Reject(ssh.ConnectionFailed, err.Error())
+				return
+			}
+			relays <- payload.Raddr + ":" + strconv.Itoa(int(payload.Rport))
+			channel, reqs, err := newChannel.Accept()
+			if err != nil {
+				return
+			}
+			go ssh.DiscardRequests(reqs)
+			defer channel.Close()
+			destination, err := readFrame(channel)
+			if err != nil {
+				return
+			}
+			destinations <- string(destination)
+			// Echo each datagram back as the relay agent would.
+			for {
+				datagram, err := readFrame(channel)
+				if err != nil {
+					return
+				}
+				if err := writeFrame(channel, datagram); err != nil {
+					return
+				}
+			}
+		},
+	}
+
+	client := newTestClient(t, &Config{EnableUdp: true, UdpRelay: "127.0.0.1:5300"})
+	ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{
+		Target: net.UDPDestination(net.DomainAddress("dns.example.com"), 53),
+	})
+	uplinkReader, uplinkWriter := pipe.New()
+	downlinkReader, downlinkWriter := pipe.New()
+	go client.Process(ctx, &transport.Link{Reader: uplinkReader, Writer: downlinkWriter}, dialer)
+	defer uplinkWriter.Close()
+
+	datagrams := []string{"first", "second"}
+	for _, datagram := range datagrams {
+		common.Must(uplinkWriter.WriteMultiBuffer(buf.MergeBytes(nil, []byte(datagram))))
+	}
+	var received buf.MultiBuffer
+	for len(received) < len(datagrams) {
+		mb, err := downlinkReader.ReadMultiBufferTimeout(5 * time.Second)
+		if err != nil {
+			t.Fatal("no datagram from the relay: ", err)
+		}
+		received = append(received, mb...)
+	}
+	for i, b := range received {
+		if b.String() != datagrams[i] {
+			t.Error("expected datagram ", datagrams[i], ", but actually ", b.String())
+		}
+		if b.Endpoint == nil || b.Endpoint.NetAddr() != "dns.example.com:53" {
+			t.Error("expected the datagram to come from dns.example.com:53, but actually ", b.Endpoint)
+		}
+	}
+	buf.ReleaseMulti(received)
+	if relay := <-relays; relay != "127.0.0.1:5300" {
+		t.Error("expected a channel to the relay 127.0.0.1:5300, but actually ", relay)
+	}
+	if destination := <-destinations; destination != "dns.example.com:53" {
+		t.Error("expected destination dns.example.com:53, but actually ", destination)
+	}
+}
+
+func TestUDPNotSupported(t *testing.T) {
+	client := newTestClient(t, &Config{})
+	ctx := session.ContextWithOutbound(context.Background(), &session.Outbound{
+		Target: net.UDPDestination(net.DomainAddress("dns.example.com"), 53),
+	})
+	uplinkReader, _ := pipe.New()
+	_, downlinkWriter := pipe.New()
+	err := client.Process(ctx, &transport.Link{Reader: uplinkReader, Writer: downlinkWriter}, &pipeDialer{config: newTestServerConfig(t)})
+	if err != errUDPNotSupported {
+		t.Error("expected errUDPNotSupported, but actually ", err)
+	}
+
+	if err := (&Client{}).Init(&Config{Address: net.NewIPOrDomain(net.LocalHostIP), Port: 22, EnableUdp: true, InsecureSkipHostKeyCheck: true}, policy.DefaultManager{}, nil); err == nil {
+		t.Error("expected enable_udp without udp_relay to be rejected")
+	}
+}
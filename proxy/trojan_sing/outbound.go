@@ -68,7 +68,7 @@ func (c *Client) ProcessConn(ctx context.Context, conn net.Conn, dialer internet
 	network := destination.Network
 
 	var outboundConn internet.Connection
-	err := retry.ExponentialBackoff(5, 100).On(func() error {
+	err := retry.WithObserver(retry.ExponentialBackoff(5, 100), retry.LogAttempts(ctx, "dial")).On(func() error {
 		rawConn, err := dialer.Dial(ctx, c.server)
 		if err != nil {
 			return err
@@ -172,7 +172,7 @@ func (c *Client) Process(ctx context.Context, link *transport.Link, dialer inter
 	network := destination.Network
 
 	var outboundConn internet.Connection
-	err := retry.ExponentialBackoff(5, 100).On(func() error {
+	err := retry.WithObserver(retry.ExponentialBackoff(5, 100), retry.LogAttempts(ctx, "dial")).On(func() error {
 		rawConn, err := dialer.Dial(ctx, c.server)
 		if err != nil {
 			return err
@@ -20,7 +20,6 @@ import (
 	"github.com/v2fly/v2ray-core/v5/common/retry"
 	"github.com/v2fly/v2ray-core/v5/common/serial"
 	"github.com/v2fly/v2ray-core/v5/common/session"
-	"github.com/v2fly/v2ray-core/v5/common/signal"
 	"github.com/v2fly/v2ray-core/v5/common/task"
 	"github.com/v2fly/v2ray-core/v5/features/dns"
 	feature_inbound "github.com/v2fly/v2ray-core/v5/features/inbound"
@@ -322,7 +321,7 @@ func (h *Handler) Process(ctx context.Context, network net.Network, connection i
 			}
 
 			ctx, cancel := context.WithCancel(ctx)
-			timer := signal.CancelAfterInactivity(ctx, cancel, sessionPolicy.Timeouts.ConnectionIdle)
+			timer := sessionPolicy.CancelAfterInactivity(ctx, cancel)
 			ctx = policy.ContextWithBufferPolicy(ctx, sessionPolicy.Buffer)
 
 			var conn net.Conn
@@ -495,7 +494,7 @@ func (h *Handler) Process(ctx context.Context, network net.Network, connection i
 
 	sessionPolicy = h.policyManager.ForLevel(request.User.Level)
 	ctx, cancel := context.WithCancel(ctx)
-	timer := signal.CancelAfterInactivity(ctx, cancel, sessionPolicy.Timeouts.ConnectionIdle)
+	timer := sessionPolicy.CancelAfterInactivity(ctx, cancel)
 	ctx = policy.ContextWithBufferPolicy(ctx, sessionPolicy.Buffer)
 
 	link, err := dispatcher.Dispatch(ctx, request.Destination())